@@ -2,9 +2,11 @@
 package main
 
 import (
+	"fmt"
 	App "github.com/axetroy/go-server"
 	"github.com/axetroy/go-server/core/helper/daemon"
 	"github.com/axetroy/go-server/core/server/admin_server"
+	"github.com/axetroy/go-server/core/service/reconcile"
 	"github.com/axetroy/go-server/core/util"
 	"github.com/urfave/cli"
 	"log"
@@ -41,6 +43,53 @@ func main() {
 				return daemon.Stop()
 			},
 		},
+		{
+			Name:  "reconcile",
+			Usage: "verify wallets against finance logs",
+			Flags: []cli.Flag{
+				cli.StringSliceFlag{
+					Name:  "currency, c",
+					Usage: "currency to reconcile, default all currencies",
+				},
+				cli.StringFlag{
+					Name:  "uid, u",
+					Usage: "only reconcile the user",
+				},
+				cli.BoolFlag{
+					Name:  "alert",
+					Usage: "publish alerts through the message queue",
+				},
+			},
+			Action: func(c *cli.Context) error {
+				reports, err := reconcile.Run(reconcile.Options{
+					Currencies: c.StringSlice("currency"),
+					Uid:        c.String("uid"),
+					Alert:      c.Bool("alert"),
+				})
+
+				if err != nil {
+					return err
+				}
+
+				ok := true
+
+				for _, report := range reports {
+					fmt.Printf("%s: %d wallets, %d logs, %d issues\n", report.Currency, report.Wallets, report.Logs, len(report.Issues))
+
+					for _, issue := range report.Issues {
+						fmt.Println("  " + issue.String())
+					}
+
+					ok = ok && report.OK()
+				}
+
+				if !ok {
+					return cli.NewExitError("reconcile failed", 1)
+				}
+
+				return nil
+			},
+		},
 		{
 			Name:  "env",
 			Usage: "print runtime environment",
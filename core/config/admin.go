@@ -3,13 +3,16 @@ package config
 
 import (
 	"github.com/axetroy/go-server/core/service/dotenv"
+	"time"
 )
 
 type admin struct {
-	Domain string `json:"domain"` // 管理员端 API 绑定的域名
-	Port   string `json:"port"`   // 管理员端 API 监听的端口
-	Secret string `json:"secret"` // 管理员端密钥，用于加密/解密 token
-	TLS    *TLS   `json:"tls"`
+	Domain               string        `json:"domain"`                 // 管理员端 API 绑定的域名
+	Port                 string        `json:"port"`                   // 管理员端 API 监听的端口
+	Secret               string        `json:"secret"`                 // 管理员端密钥，用于加密/解密 token
+	TokenDuration        time.Duration `json:"token_duration"`         // 管理员端 token 的有效期
+	RefreshTokenDuration time.Duration `json:"refresh_token_duration"` // 管理员端刷新令牌的有效期
+	TLS                  *TLS          `json:"tls"`
 }
 
 var Admin admin
@@ -18,6 +21,8 @@ func init() {
 	Admin.Port = dotenv.GetByDefault("ADMIN_HTTP_PORT", "8081")
 	Admin.Domain = dotenv.GetByDefault("ADMIN_HTTP_DOMAIN", "localhost")
 	Admin.Secret = dotenv.GetByDefault("ADMIN_TOKEN_SECRET_KEY", "admin")
+	Admin.TokenDuration = time.Second * time.Duration(dotenv.GetIntByDefault("ADMIN_TOKEN_DURATION", 60*60*6))                   // 默认 6 小时
+	Admin.RefreshTokenDuration = time.Second * time.Duration(dotenv.GetIntByDefault("ADMIN_REFRESH_TOKEN_DURATION", 60*60*24*7)) // 默认 7 天
 
 	TlsCert := dotenv.GetByDefault("ADMIN_TLS_CERT", "")
 	TlsKey := dotenv.GetByDefault("ADMIN_TLS_KEY", "")
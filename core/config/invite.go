@@ -0,0 +1,36 @@
+// Copyright 2019 Axetroy. All rights reserved. MIT license.
+package config
+
+import (
+	"github.com/axetroy/go-server/core/service/dotenv"
+	"log"
+	"strings"
+	"time"
+)
+
+type invite struct {
+	SettleInterval time.Duration     `json:"settle_interval"` // 发放邀请奖励的间隔
+	PayMin         map[string]string `json:"pay_min"`         // 被邀请人的转账至少达到这个数量才算完成支付, key 为币种
+}
+
+var Invite invite
+
+func init() {
+	Invite.SettleInterval = time.Second * time.Duration(dotenv.GetIntByDefault("INVITE_SETTLE_INTERVAL", 60)) // 默认 1 分钟
+	Invite.PayMin = map[string]string{}                                                                       // 默认所有币种都不算完成支付
+
+	// 格式为 币种:数量, 多个币种用逗号隔开, 例如 CNY:10,USD:1
+	for _, item := range dotenv.GetStrArrayByDefault("INVITE_PAY_MIN", []string{}) {
+		if item == "" {
+			continue
+		}
+
+		pair := strings.SplitN(item, ":", 2)
+
+		if len(pair) != 2 || strings.TrimSpace(pair[0]) == "" || strings.TrimSpace(pair[1]) == "" {
+			log.Fatalf("INVITE_PAY_MIN 的格式错误: %s", item)
+		}
+
+		Invite.PayMin[strings.ToUpper(strings.TrimSpace(pair[0]))] = strings.TrimSpace(pair[1])
+	}
+}
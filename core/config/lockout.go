@@ -0,0 +1,27 @@
+// Copyright 2019 Axetroy. All rights reserved. MIT license.
+package config
+
+import (
+	"github.com/axetroy/go-server/core/service/dotenv"
+	"time"
+)
+
+type lockout struct {
+	Window          time.Duration `json:"window"`           // 统计失败次数的滑动窗口
+	AccountLimit    int64         `json:"account_limit"`    // 窗口内同一个账号允许失败的次数
+	IpLimit         int64         `json:"ip_limit"`         // 窗口内同一个 IP 允许失败的次数
+	Duration        time.Duration `json:"duration"`         // 第一次锁定的时长, 之后每次锁定时长翻倍
+	MaxDuration     time.Duration `json:"max_duration"`     // 锁定时长的上限
+	LevelExpiration time.Duration `json:"level_expiration"` // 锁定等级的有效期, 超过这个时间没有再被锁定，则重新从第一级开始
+}
+
+var Lockout lockout
+
+func init() {
+	Lockout.Window = time.Second * time.Duration(dotenv.GetIntByDefault("LOCKOUT_WINDOW", 60*15))                       // 默认 15 分钟
+	Lockout.AccountLimit = int64(dotenv.GetIntByDefault("LOCKOUT_ACCOUNT_LIMIT", 5))                                    // 默认 5 次
+	Lockout.IpLimit = int64(dotenv.GetIntByDefault("LOCKOUT_IP_LIMIT", 20))                                             // 默认 20 次
+	Lockout.Duration = time.Second * time.Duration(dotenv.GetIntByDefault("LOCKOUT_DURATION", 60))                      // 默认 1 分钟
+	Lockout.MaxDuration = time.Second * time.Duration(dotenv.GetIntByDefault("LOCKOUT_MAX_DURATION", 60*60*24))         // 默认 1 天
+	Lockout.LevelExpiration = time.Second * time.Duration(dotenv.GetIntByDefault("LOCKOUT_LEVEL_EXPIRATION", 60*60*24)) // 默认 1 天
+}
@@ -0,0 +1,17 @@
+// Copyright 2019 Axetroy. All rights reserved. MIT license.
+package config
+
+import (
+	"github.com/axetroy/go-server/core/service/dotenv"
+	"time"
+)
+
+type notification struct {
+	ScheduleInterval time.Duration `json:"schedule_interval"` // 检查是否有通知需要发布或者过期的间隔
+}
+
+var Notification notification
+
+func init() {
+	Notification.ScheduleInterval = time.Second * time.Duration(dotenv.GetIntByDefault("NOTIFICATION_SCHEDULE_INTERVAL", 60)) // 默认 1 分钟
+}
@@ -0,0 +1,19 @@
+// Copyright 2019 Axetroy. All rights reserved. MIT license.
+package config
+
+import (
+	"github.com/axetroy/go-server/core/service/dotenv"
+	"time"
+)
+
+type statement struct {
+	Interval time.Duration `json:"interval"` // 检查是否需要发送月度对账单的间隔
+	Format   string        `json:"format"`   // 月度对账单的格式, csv 或者 pdf
+}
+
+var Statement statement
+
+func init() {
+	Statement.Interval = time.Second * time.Duration(dotenv.GetIntByDefault("STATEMENT_INTERVAL", 3600)) // 默认 1 小时
+	Statement.Format = dotenv.GetByDefault("STATEMENT_FORMAT", "pdf")
+}
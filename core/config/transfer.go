@@ -0,0 +1,19 @@
+// Copyright 2019 Axetroy. All rights reserved. MIT license.
+package config
+
+import (
+	"github.com/axetroy/go-server/core/service/dotenv"
+	"time"
+)
+
+type transfer struct {
+	PendingDuration time.Duration `json:"pending_duration"` // 等待收款方确认的时长, 超时自动退回汇款人
+	ExpireInterval  time.Duration `json:"expire_interval"`  // 检查超时转账的间隔
+}
+
+var Transfer transfer
+
+func init() {
+	Transfer.PendingDuration = time.Second * time.Duration(dotenv.GetIntByDefault("TRANSFER_PENDING_DURATION", 60*60*24)) // 默认 1 天
+	Transfer.ExpireInterval = time.Second * time.Duration(dotenv.GetIntByDefault("TRANSFER_EXPIRE_INTERVAL", 60))         // 默认 1 分钟
+}
@@ -3,6 +3,7 @@ package config
 
 import (
 	"github.com/axetroy/go-server/core/service/dotenv"
+	"time"
 )
 
 type TLS struct {
@@ -11,10 +12,12 @@ type TLS struct {
 }
 
 type user struct {
-	Domain string `json:"domain"` // 用户端 API 绑定的域名, 例如 https://example.com
-	Port   string `json:"port"`   // 用户端 API 监听的端口
-	Secret string `json:"secret"` // 用户端密钥，用于加密/解密 token
-	TLS    *TLS   `json:"tls"`
+	Domain               string        `json:"domain"`                 // 用户端 API 绑定的域名, 例如 https://example.com
+	Port                 string        `json:"port"`                   // 用户端 API 监听的端口
+	Secret               string        `json:"secret"`                 // 用户端密钥，用于加密/解密 token
+	TokenDuration        time.Duration `json:"token_duration"`         // 用户端 token 的有效期
+	RefreshTokenDuration time.Duration `json:"refresh_token_duration"` // 用户端刷新令牌的有效期
+	TLS                  *TLS          `json:"tls"`
 }
 
 var User user
@@ -23,6 +26,8 @@ func init() {
 	User.Port = dotenv.GetByDefault("USER_HTTP_PORT", "8080")
 	User.Domain = dotenv.GetByDefault("USER_HTTP_DOMAIN", "localhost")
 	User.Secret = dotenv.GetByDefault("USER_TOKEN_SECRET_KEY", "user")
+	User.TokenDuration = time.Second * time.Duration(dotenv.GetIntByDefault("USER_TOKEN_DURATION", 60*60*6))                   // 默认 6 小时
+	User.RefreshTokenDuration = time.Second * time.Duration(dotenv.GetIntByDefault("USER_REFRESH_TOKEN_DURATION", 60*60*24*7)) // 默认 7 天
 
 	TlsCert := dotenv.GetByDefault("USER_TLS_CERT", "")
 	TlsKey := dotenv.GetByDefault("USER_TLS_KEY", "")
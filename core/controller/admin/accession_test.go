@@ -2,7 +2,9 @@ package admin_test
 
 import (
 	"encoding/json"
+	"github.com/axetroy/go-server/core/controller"
 	"github.com/axetroy/go-server/core/controller/admin"
+	"github.com/axetroy/go-server/core/exception"
 	"github.com/axetroy/go-server/core/rbac/accession"
 	"github.com/axetroy/go-server/core/schema"
 	"github.com/axetroy/go-server/core/service/token"
@@ -44,3 +46,91 @@ func TestGetAccessionRouter(t *testing.T) {
 
 	assert.Equal(t, accession.AdminList, dataList)
 }
+
+func TestAccessionRequired(t *testing.T) {
+	superAdmin, err := tester.LoginAdmin()
+
+	assert.Nil(t, err)
+
+	// 创建一个没有任何权限的普通管理员
+	input := admin.CreateAdminParams{
+		Account:  "test-TestAccessionRequired",
+		Password: "123123",
+		Name:     "test-TestAccessionRequired",
+	}
+
+	r := admin.CreateAdmin(input, false)
+
+	assert.Equal(t, schema.StatusSuccess, r.Status)
+
+	defer admin.DeleteAdminByAccount(input.Account)
+
+	profile := schema.AdminProfile{}
+
+	assert.Nil(t, tester.Decode(r.Data, &profile))
+
+	r = admin.Login(controller.Context{}, admin.SignInParams{
+		Username: input.Account,
+		Password: input.Password,
+	})
+
+	assert.Equal(t, schema.StatusSuccess, r.Status)
+
+	adminInfo := schema.AdminProfileWithToken{}
+
+	assert.Nil(t, tester.Decode(r.Data, &adminInfo))
+
+	header := mocker.Header{
+		"Authorization": token.JoinPrefixToken(adminInfo.Token),
+	}
+
+	// 没有权限
+	{
+		r := tester.HttpAdmin.Get("/v1/admin/accession", nil, &header)
+
+		res := schema.Response{}
+
+		assert.Nil(t, json.Unmarshal(r.Body.Bytes(), &res))
+		assert.Equal(t, exception.NoPermission.Code(), res.Status)
+		assert.Equal(t, exception.NoPermission.Error(), res.Message)
+	}
+
+	// 个人信息不需要额外的权限
+	{
+		r := tester.HttpAdmin.Get("/v1/profile", nil, &header)
+
+		res := schema.Response{}
+
+		assert.Nil(t, json.Unmarshal(r.Body.Bytes(), &res))
+		assert.Equal(t, schema.StatusSuccess, res.Status)
+	}
+
+	// 分配权限之后可以访问
+	{
+		accessions := accession.Stringify(accession.AdminAdminGet)
+
+		r := admin.Update(controller.Context{Uid: superAdmin.Id}, profile.Id, admin.UpdateParams{
+			Accession: &accessions,
+		})
+
+		assert.Equal(t, schema.StatusSuccess, r.Status)
+
+		r2 := tester.HttpAdmin.Get("/v1/admin/accession", nil, &header)
+
+		res := schema.Response{}
+
+		assert.Nil(t, json.Unmarshal(r2.Body.Bytes(), &res))
+		assert.Equal(t, schema.StatusSuccess, res.Status)
+		assert.Equal(t, "", res.Message)
+	}
+
+	// 拥有的权限之外的接口依然无法访问
+	{
+		r := tester.HttpAdmin.Get("/v1/news", nil, &header)
+
+		res := schema.Response{}
+
+		assert.Nil(t, json.Unmarshal(r.Body.Bytes(), &res))
+		assert.Equal(t, exception.NoPermission.Code(), res.Status)
+	}
+}
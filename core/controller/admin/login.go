@@ -3,11 +3,13 @@ package admin
 
 import (
 	"errors"
+	"github.com/axetroy/go-server/core/controller"
 	"github.com/axetroy/go-server/core/exception"
 	"github.com/axetroy/go-server/core/helper"
 	"github.com/axetroy/go-server/core/model"
 	"github.com/axetroy/go-server/core/schema"
 	"github.com/axetroy/go-server/core/service/database"
+	"github.com/axetroy/go-server/core/service/lockout"
 	"github.com/axetroy/go-server/core/service/token"
 	"github.com/axetroy/go-server/core/util"
 	"github.com/gin-gonic/gin"
@@ -22,7 +24,27 @@ type SignInParams struct {
 	Password string
 }
 
-func Login(input SignInParams) (res schema.Response) {
+// 记录登陆失败，失败次数过多会锁定账号和 IP
+// 失败记录只是为了审计，写入失败也不影响接口的返回
+func loginFail(c controller.Context, account string, uid string, reason string) {
+	_, _ = lockout.Fail(lockout.ScopeAdmin, account, c.Ip)
+
+	// 不存在的账号没有对应的管理员，不写入登陆记录
+	if uid == "" {
+		return
+	}
+
+	_ = database.Db.Create(&model.LoginLog{
+		Uid:     uid,
+		Type:    model.LoginLogTypeAdmin,
+		Command: model.LoginLogCommandLoginFail,
+		Client:  c.UserAgent,
+		LastIp:  c.Ip,
+		Reason:  reason,
+	}).Error
+}
+
+func Login(c controller.Context, input SignInParams) (res schema.Response) {
 	var (
 		err  error
 		data = schema.AdminProfileWithToken{}
@@ -52,20 +74,44 @@ func Login(input SignInParams) (res schema.Response) {
 		helper.Response(&res, data, err)
 	}()
 
+	// 失败次数过多，账号或 IP 已被锁定
+	if err = lockout.Check(lockout.ScopeAdmin, input.Username, c.Ip); err != nil {
+		return
+	}
+
 	tx = database.Db.Begin()
 
 	adminInfo := model.Admin{
 		Username: input.Username,
-		Password: util.GeneratePassword(input.Password),
 	}
 
-	if err = database.Db.Where(&adminInfo).First(&adminInfo).Error; err != nil {
+	if err = tx.Where(&adminInfo).First(&adminInfo).Error; err != nil {
 		if err == gorm.ErrRecordNotFound {
+			loginFail(c, input.Username, "", "账号不存在")
 			err = exception.InvalidAccountOrPassword
 		}
 		return
 	}
 
+	if !util.VerifyPassword(input.Password, adminInfo.Password) {
+		loginFail(c, input.Username, adminInfo.Id, "密码错误")
+		err = exception.InvalidAccountOrPassword
+		return
+	}
+
+	if err = lockout.Success(lockout.ScopeAdmin, input.Username); err != nil {
+		return
+	}
+
+	// 旧的密码哈希, 登陆成功后自动升级
+	if util.PasswordNeedsRehash(adminInfo.Password) {
+		adminInfo.Password = util.GeneratePassword(input.Password)
+
+		if err = tx.Model(&adminInfo).Update("password", adminInfo.Password).Error; err != nil {
+			return
+		}
+	}
+
 	if err = mapstructure.Decode(adminInfo, &data.AdminProfilePure); err != nil {
 		return
 	}
@@ -74,11 +120,8 @@ func Login(input SignInParams) (res schema.Response) {
 	data.UpdatedAt = adminInfo.UpdatedAt.Format(time.RFC3339Nano)
 
 	// generate token
-	if t, er := token.Generate(adminInfo.Id, true); er != nil {
-		err = er
+	if data.Token, data.RefreshToken, err = token.GenerateSession(adminInfo.Id, true); err != nil {
 		return
-	} else {
-		data.Token = t
 	}
 
 	return
@@ -104,5 +147,5 @@ func LoginRouter(c *gin.Context) {
 		return
 	}
 
-	res = Login(input)
+	res = Login(controller.NewContext(c), input)
 }
@@ -4,9 +4,12 @@ package admin_test
 import (
 	"encoding/json"
 	"fmt"
+	"github.com/axetroy/go-server/core/controller"
 	"github.com/axetroy/go-server/core/controller/admin"
 	"github.com/axetroy/go-server/core/exception"
+	"github.com/axetroy/go-server/core/model"
 	"github.com/axetroy/go-server/core/schema"
+	"github.com/axetroy/go-server/core/service/database"
 	"github.com/axetroy/go-server/core/service/token"
 	"github.com/axetroy/go-server/tester"
 	"github.com/stretchr/testify/assert"
@@ -25,18 +28,29 @@ func init() {
 func TestLogin(t *testing.T) {
 	// 登陆超级管理员-失败
 	{
-		r := admin.Login(admin.SignInParams{
+		r := admin.Login(controller.Context{}, admin.SignInParams{
 			Username: "admin",
 			Password: "admin123",
 		})
 
 		assert.Equal(t, exception.InvalidAccountOrPassword.Code(), r.Status)
 		assert.Equal(t, exception.InvalidAccountOrPassword.Error(), r.Message)
+
+		// 写入了登陆失败的记录
+		adminInfo := model.Admin{}
+
+		assert.Nil(t, database.Db.Where("username = ?", "admin").First(&adminInfo).Error)
+
+		logInfo := model.LoginLog{}
+
+		assert.Nil(t, database.Db.Where("uid = ? AND type = ?", adminInfo.Id, model.LoginLogTypeAdmin).Last(&logInfo).Error)
+		assert.Equal(t, model.LoginLogCommandLoginFail, logInfo.Command)
+		assert.Equal(t, "密码错误", logInfo.Reason)
 	}
 
 	// 登陆超级管理员-成功
 	{
-		r := admin.Login(admin.SignInParams{
+		r := admin.Login(controller.Context{}, admin.SignInParams{
 			Username: "admin",
 			Password: "admin",
 		})
@@ -0,0 +1,99 @@
+// Copyright 2019 Axetroy. All rights reserved. MIT license.
+package admin
+
+import (
+	"errors"
+	"github.com/axetroy/go-server/core/exception"
+	"github.com/axetroy/go-server/core/helper"
+	"github.com/axetroy/go-server/core/model"
+	"github.com/axetroy/go-server/core/schema"
+	"github.com/axetroy/go-server/core/service/database"
+	"github.com/axetroy/go-server/core/service/token"
+	"github.com/axetroy/go-server/core/validator"
+	"github.com/gin-gonic/gin"
+	"github.com/jinzhu/gorm"
+	"net/http"
+)
+
+type RefreshTokenParams struct {
+	RefreshToken string `json:"refresh_token" valid:"required~请输入刷新令牌"` // 登陆或上一次刷新时返回的刷新令牌
+}
+
+// 管理员使用刷新令牌换取新的 token, 同时轮换刷新令牌
+func RefreshToken(input RefreshTokenParams) (res schema.Response) {
+	var (
+		err  error
+		data schema.TokenPair
+	)
+
+	defer func() {
+		if r := recover(); r != nil {
+			switch t := r.(type) {
+			case string:
+				err = errors.New(t)
+			case error:
+				err = t
+			default:
+				err = exception.Unknown
+			}
+		}
+
+		helper.Response(&res, data, err)
+	}()
+
+	// 参数校验
+	if err = validator.ValidateStruct(input); err != nil {
+		return
+	}
+
+	uid, family, refreshToken, err := token.RotateRefreshToken(input.RefreshToken, true)
+
+	if err != nil {
+		return
+	}
+
+	adminInfo := model.Admin{Id: uid}
+
+	if err = database.Db.Where(&adminInfo).First(&adminInfo).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			err = exception.AdminNotExist
+		}
+		return
+	}
+
+	// 被禁用或者未激活的管理员不能再刷新
+	if err = adminInfo.CheckStatusValid(); err != nil {
+		return
+	}
+
+	if data.Token, err = token.GenerateWithFamily(adminInfo.Id, true, family); err != nil {
+		return
+	}
+
+	data.RefreshToken = refreshToken
+
+	return
+}
+
+func RefreshTokenRouter(c *gin.Context) {
+	var (
+		input RefreshTokenParams
+		err   error
+		res   = schema.Response{}
+	)
+
+	defer func() {
+		if err != nil {
+			res.Data = nil
+			res.Message = err.Error()
+		}
+		c.JSON(http.StatusOK, res)
+	}()
+
+	if err = c.ShouldBindJSON(&input); err != nil {
+		err = exception.InvalidParams
+		return
+	}
+
+	res = RefreshToken(input)
+}
@@ -0,0 +1,88 @@
+// Copyright 2019 Axetroy. All rights reserved. MIT license.
+package admin_test
+
+import (
+	"github.com/axetroy/go-server/core/controller/admin"
+	"github.com/axetroy/go-server/core/exception"
+	"github.com/axetroy/go-server/core/model"
+	"github.com/axetroy/go-server/core/schema"
+	"github.com/axetroy/go-server/core/service/database"
+	"github.com/axetroy/go-server/core/service/token"
+	"github.com/axetroy/go-server/tester"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestRefreshToken(t *testing.T) {
+	adminInfo, _ := tester.LoginAdmin()
+
+	assert.NotEmpty(t, adminInfo.RefreshToken)
+
+	pair := schema.TokenPair{}
+
+	// 刷新成功
+	{
+		r := admin.RefreshToken(admin.RefreshTokenParams{
+			RefreshToken: adminInfo.RefreshToken,
+		})
+
+		assert.Equal(t, schema.StatusSuccess, r.Status)
+		assert.Equal(t, "", r.Message)
+		assert.Nil(t, tester.Decode(r.Data, &pair))
+		assert.NotEmpty(t, pair.Token)
+		assert.NotEqual(t, adminInfo.RefreshToken, pair.RefreshToken)
+
+		c, err := token.Parse(token.Prefix+" "+pair.Token, true)
+
+		assert.Nil(t, err)
+		assert.Equal(t, adminInfo.Id, c.Uid)
+	}
+
+	// 无效的刷新令牌
+	{
+		r := admin.RefreshToken(admin.RefreshTokenParams{
+			RefreshToken: "invalid refresh token",
+		})
+
+		assert.Equal(t, exception.InvalidRefreshToken.Code(), r.Status)
+	}
+
+	// 重复使用已经轮换掉的刷新令牌
+	{
+		r := admin.RefreshToken(admin.RefreshTokenParams{
+			RefreshToken: adminInfo.RefreshToken,
+		})
+
+		assert.Equal(t, exception.RefreshTokenReused.Code(), r.Status)
+		assert.Equal(t, exception.RefreshTokenReused.Error(), r.Message)
+	}
+}
+
+func TestRefreshTokenWithBannedAdmin(t *testing.T) {
+	r := admin.CreateAdmin(admin.CreateAdminParams{
+		Account:  "123123",
+		Password: "123123",
+		Name:     "123123",
+	}, false)
+
+	assert.Equal(t, schema.StatusSuccess, r.Status)
+
+	defer admin.DeleteAdminByAccount("123123")
+
+	testAdminInfo := model.Admin{}
+
+	assert.Nil(t, tester.Decode(r.Data, &testAdminInfo))
+
+	_, refreshToken, err := token.GenerateSession(testAdminInfo.Id, true)
+
+	assert.Nil(t, err)
+
+	assert.Nil(t, database.Db.Model(&model.Admin{Id: testAdminInfo.Id}).Update("status", model.AdminStatusBanned).Error)
+
+	// 被禁用的管理员不能再刷新
+	res := admin.RefreshToken(admin.RefreshTokenParams{
+		RefreshToken: refreshToken,
+	})
+
+	assert.Equal(t, exception.AdminHaveBeenBan.Error(), res.Message)
+}
@@ -81,7 +81,7 @@ func UpdatePassword(c controller.Context, input UpdatePasswordParams) (res schem
 	}
 
 	// 校验密码是否正确
-	if myInfo.Password != util.GeneratePassword(input.OldPassword) {
+	if !util.VerifyPassword(input.OldPassword, myInfo.Password) {
 		err = exception.InvalidOldPassword
 		return
 	}
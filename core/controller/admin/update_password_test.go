@@ -50,7 +50,7 @@ func TestUpdatePassword(t *testing.T) {
 	m := model.Admin{Id: testAdminInfo.Id}
 
 	assert.Nil(t, database.Db.First(&m).Error)
-	assert.Equal(t, util.GeneratePassword("321321"), m.Password)
+	assert.True(t, util.VerifyPassword("321321", m.Password))
 }
 
 func TestUpdatePasswordRouter(t *testing.T) {
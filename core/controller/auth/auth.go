@@ -6,26 +6,22 @@ import (
 	"github.com/axetroy/go-server/core/exception"
 	"github.com/axetroy/go-server/core/helper"
 	"github.com/axetroy/go-server/core/schema"
+	"github.com/axetroy/go-server/core/service/captcha"
 	"github.com/axetroy/go-server/core/service/email"
-	"github.com/axetroy/go-server/core/service/redis"
 	"github.com/axetroy/go-server/core/service/telephone"
-	"github.com/axetroy/go-server/core/util"
 	"github.com/axetroy/go-server/core/validator"
 	"github.com/gin-gonic/gin"
 	"net/http"
-	"time"
 )
 
 type SendEmailAuthCodeParams struct {
-	Email string `json:"email" valid:"required~请输入邮箱"`
+	Email   string `json:"email" valid:"required~请输入邮箱"`
+	Purpose string `json:"purpose" valid:"required~请输入验证码用途,in(signin|signup|binding)~无效的验证码用途"` // 验证码的用途
 }
 
 type SendPhoneAuthCodeParams struct {
-	Phone string `json:"phone" valid:"required~请输入手机号"`
-}
-
-func GenerateAuthCode() string {
-	return util.RandomString(6)
+	Phone   string `json:"phone" valid:"required~请输入手机号"`
+	Purpose string `json:"purpose" valid:"required~请输入验证码用途,in(signin|signup|binding)~无效的验证码用途"` // 验证码的用途
 }
 
 // 发送邮箱验证码 (不需要登陆)
@@ -54,20 +50,9 @@ func SendEmailAuthCode(c controller.Context, input SendEmailAuthCodeParams) (res
 		return
 	}
 
-	// 生成验证码
-	activationCode := GenerateAuthCode()
-
-	// 缓存验证码到 redis
-	if err = redis.ClientAuthEmailCode.Set(activationCode, input.Email, time.Minute*10).Err(); err != nil {
-		return
-	}
-
-	e := email.NewMailer()
-
-	// send email
-	if err = e.SendAuthEmail(input.Email, activationCode); err != nil {
-		// 邮件没发出去的话，删除redis的key
-		_ = redis.ClientAuthEmailCode.Del(activationCode).Err()
+	if err = captcha.Send(captcha.ChannelEmail, captcha.Purpose(input.Purpose), input.Email, func(code string) error {
+		return email.NewMailer().SendAuthEmail(input.Email, code)
+	}); err != nil {
 		return
 	}
 
@@ -100,17 +85,9 @@ func SendPhoneAuthCode(c controller.Context, input SendPhoneAuthCodeParams) (res
 		return
 	}
 
-	// 生成验证码
-	activationCode := GenerateAuthCode()
-
-	// 缓存验证码到 redis
-	if err = redis.ClientAuthPhoneCode.Set(activationCode, input.Phone, time.Minute*10).Err(); err != nil {
-		return
-	}
-
-	if err = telephone.GetClient().SendAuthCode(input.Phone, activationCode); err != nil {
-		// 如果发送失败，则删除
-		_ = redis.ClientAuthPhoneCode.Del(activationCode).Err()
+	if err = captcha.Send(captcha.ChannelPhone, captcha.Purpose(input.Purpose), input.Phone, func(code string) error {
+		return telephone.GetClient().SendAuthCode(input.Phone, code)
+	}); err != nil {
 		return
 	}
 
@@ -7,8 +7,8 @@ import (
 	"github.com/axetroy/go-server/core/helper"
 	"github.com/axetroy/go-server/core/model"
 	"github.com/axetroy/go-server/core/schema"
+	"github.com/axetroy/go-server/core/service/captcha"
 	"github.com/axetroy/go-server/core/service/database"
-	"github.com/axetroy/go-server/core/service/redis"
 	"github.com/axetroy/go-server/core/service/wechat"
 	"github.com/axetroy/go-server/core/validator"
 	"github.com/gin-gonic/gin"
@@ -102,15 +102,7 @@ func BindingEmail(c controller.Context, input BindingEmailParams) (res schema.Re
 	}
 
 	// 校验验证码正确不正确
-	email, err := redis.ClientAuthEmailCode.Get(input.Code).Result()
-
-	if err != nil {
-		return
-	}
-
-	// 验证是否正确
-	if email != input.Email {
-		err = exception.InvalidParams
+	if err = captcha.Verify(captcha.ChannelEmail, captcha.PurposeBinding, input.Email, input.Code); err != nil {
 		return
 	}
 
@@ -186,22 +178,14 @@ func BindingPhone(c controller.Context, input BindingPhoneParams) (res schema.Re
 		return
 	}
 
-	// 如果该用户已经绑定过邮箱了
-	if userInfo.Email != nil {
+	// 如果该用户已经绑定过手机了
+	if userInfo.Phone != nil {
 		err = exception.DuplicateBinding
 		return
 	}
 
 	// 校验验证码正确不正确
-	phone, err := redis.ClientAuthPhoneCode.Get(input.Code).Result()
-
-	if err != nil {
-		return
-	}
-
-	// 验证是否正确
-	if phone != input.Phone {
-		err = exception.InvalidParams
+	if err = captcha.Verify(captcha.ChannelPhone, captcha.PurposeBinding, input.Phone, input.Code); err != nil {
 		return
 	}
 
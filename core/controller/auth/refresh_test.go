@@ -0,0 +1,72 @@
+// Copyright 2019 Axetroy. All rights reserved. MIT license.
+package auth_test
+
+import (
+	"github.com/axetroy/go-server/core/controller"
+	"github.com/axetroy/go-server/core/controller/auth"
+	"github.com/axetroy/go-server/core/exception"
+	"github.com/axetroy/go-server/core/schema"
+	"github.com/axetroy/go-server/core/service/token"
+	"github.com/axetroy/go-server/tester"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestRefreshToken(t *testing.T) {
+	userInfo, _ := tester.CreateUser()
+
+	defer auth.DeleteUserByUserName(userInfo.Username)
+
+	assert.NotEmpty(t, userInfo.RefreshToken)
+
+	pair := schema.TokenPair{}
+
+	// 刷新成功
+	{
+		r := auth.RefreshToken(controller.Context{}, auth.RefreshTokenParams{
+			RefreshToken: userInfo.RefreshToken,
+		})
+
+		assert.Equal(t, schema.StatusSuccess, r.Status)
+		assert.Equal(t, "", r.Message)
+		assert.Nil(t, tester.Decode(r.Data, &pair))
+		assert.NotEmpty(t, pair.Token)
+		assert.NotEmpty(t, pair.RefreshToken)
+		assert.NotEqual(t, userInfo.RefreshToken, pair.RefreshToken)
+
+		c, err := token.Parse(token.Prefix+" "+pair.Token, false)
+
+		assert.Nil(t, err)
+		assert.Equal(t, userInfo.Id, c.Uid)
+	}
+
+	// 无效的刷新令牌
+	{
+		r := auth.RefreshToken(controller.Context{}, auth.RefreshTokenParams{
+			RefreshToken: "invalid refresh token",
+		})
+
+		assert.Equal(t, exception.InvalidRefreshToken.Code(), r.Status)
+		assert.Equal(t, exception.InvalidRefreshToken.Error(), r.Message)
+	}
+
+	// 重复使用已经轮换掉的刷新令牌
+	{
+		r := auth.RefreshToken(controller.Context{}, auth.RefreshTokenParams{
+			RefreshToken: userInfo.RefreshToken,
+		})
+
+		assert.Equal(t, exception.RefreshTokenReused.Code(), r.Status)
+		assert.Equal(t, exception.RefreshTokenReused.Error(), r.Message)
+	}
+
+	// 检测到重复使用后，整个令牌家族都被吊销
+	{
+		r := auth.RefreshToken(controller.Context{}, auth.RefreshTokenParams{
+			RefreshToken: pair.RefreshToken,
+		})
+
+		assert.Equal(t, exception.InvalidRefreshToken.Code(), r.Status)
+		assert.Equal(t, exception.InvalidRefreshToken.Error(), r.Message)
+	}
+}
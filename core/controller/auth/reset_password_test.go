@@ -136,5 +136,5 @@ func TestResetPasswordSuccess(t *testing.T) {
 	}
 
 	// 两次密码应该一致
-	assert.Equal(t, util.GeneratePassword(newPassword), userInfo.Password)
+	assert.True(t, util.VerifyPassword(newPassword, userInfo.Password))
 }
@@ -4,12 +4,14 @@ package auth
 import (
 	"errors"
 	"github.com/axetroy/go-server/core/controller"
-	"github.com/axetroy/go-server/core/controller/wallet"
 	"github.com/axetroy/go-server/core/exception"
 	"github.com/axetroy/go-server/core/helper"
 	"github.com/axetroy/go-server/core/model"
 	"github.com/axetroy/go-server/core/schema"
+	"github.com/axetroy/go-server/core/service/captcha"
+	"github.com/axetroy/go-server/core/service/currency"
 	"github.com/axetroy/go-server/core/service/database"
+	"github.com/axetroy/go-server/core/service/lockout"
 	"github.com/axetroy/go-server/core/service/redis"
 	"github.com/axetroy/go-server/core/service/token"
 	"github.com/axetroy/go-server/core/service/wechat"
@@ -52,12 +54,33 @@ type WechatCompleteParams struct {
 	Username *string `json:"username"`                        // 用户名
 }
 
+// 记录登陆失败，失败次数过多会锁定账号和 IP
+// 失败记录只是为了审计，写入失败也不影响接口的返回
+func signInFail(c controller.Context, account string, uid string, reason string) {
+	_, _ = lockout.Fail(lockout.ScopeUser, account, c.Ip)
+
+	// 不存在的账号没有对应的用户，不写入登陆记录
+	if uid == "" {
+		return
+	}
+
+	_ = database.Db.Create(&model.LoginLog{
+		Uid:     uid,
+		Type:    model.LoginLogTypeUserName,
+		Command: model.LoginLogCommandLoginFail,
+		Client:  c.UserAgent,
+		LastIp:  c.Ip,
+		Reason:  reason,
+	}).Error
+}
+
 // 普通帐号登陆
 func SignIn(c controller.Context, input SignInParams) (res schema.Response) {
 	var (
-		err  error
-		data = &schema.ProfileWithToken{}
-		tx   *gorm.DB
+		err       error
+		data      = &schema.ProfileWithToken{}
+		challenge *schema.SignInTOTPChallenge
+		tx        *gorm.DB
 	)
 
 	defer func() {
@@ -80,16 +103,18 @@ func SignIn(c controller.Context, input SignInParams) (res schema.Response) {
 			}
 		}
 
-		helper.Response(&res, data, err)
+		if challenge != nil {
+			helper.Response(&res, challenge, err)
+		} else {
+			helper.Response(&res, data, err)
+		}
 	}()
 
 	if err = validator.ValidateStruct(input); err != nil {
 		return
 	}
 
-	userInfo := model.User{
-		Password: util.GeneratePassword(input.Password),
-	}
+	userInfo := model.User{}
 
 	if validator.IsPhone(input.Account) {
 		// 用手机号登陆
@@ -102,19 +127,51 @@ func SignIn(c controller.Context, input SignInParams) (res schema.Response) {
 		userInfo.Username = input.Account
 	}
 
+	// 失败次数过多，账号或 IP 已被锁定
+	if err = lockout.Check(lockout.ScopeUser, input.Account, c.Ip); err != nil {
+		return
+	}
+
 	tx = database.Db.Begin()
 
 	if err = tx.Where(&userInfo).Preload("Wechat").Last(&userInfo).Error; err != nil {
 		if err == gorm.ErrRecordNotFound {
+			signInFail(c, input.Account, "", "账号不存在")
 			err = exception.InvalidAccountOrPassword
 		}
 		return
 	}
 
+	if !util.VerifyPassword(input.Password, userInfo.Password) {
+		signInFail(c, input.Account, userInfo.Id, "密码错误")
+		err = exception.InvalidAccountOrPassword
+		return
+	}
+
+	// 旧的密码哈希, 登陆成功后自动升级
+	if util.PasswordNeedsRehash(userInfo.Password) {
+		userInfo.Password = util.GeneratePassword(input.Password)
+
+		if err = tx.Model(&userInfo).Update("password", userInfo.Password).Error; err != nil {
+			return
+		}
+	}
+
 	if err = userInfo.CheckStatusValid(); err != nil {
 		return
 	}
 
+	// 开启了双重身份认证，需要再校验动态码才能登陆
+	// 失败次数等到动态码校验通过之后再清除
+	if userInfo.EnableTOTP {
+		challenge, err = createTOTPChallenge(userInfo.Id, input.Account)
+		return
+	}
+
+	if err = lockout.Success(lockout.ScopeUser, input.Account); err != nil {
+		return
+	}
+
 	if err = mapstructure.Decode(userInfo, &data.ProfilePure); err != nil {
 		return
 	}
@@ -130,11 +187,8 @@ func SignIn(c controller.Context, input SignInParams) (res schema.Response) {
 	data.UpdatedAt = userInfo.UpdatedAt.Format(time.RFC3339Nano)
 
 	// generate token
-	if t, er := token.Generate(userInfo.Id, false); er != nil {
-		err = er
+	if data.Token, data.RefreshToken, err = token.GenerateSession(userInfo.Id, false); err != nil {
 		return
-	} else {
-		data.Token = t
 	}
 
 	// 写入登陆记录
@@ -156,9 +210,10 @@ func SignIn(c controller.Context, input SignInParams) (res schema.Response) {
 // 邮箱 + 验证码登陆
 func SignInWithEmail(c controller.Context, input SignInWithEmailParams) (res schema.Response) {
 	var (
-		err  error
-		data = &schema.ProfileWithToken{}
-		tx   *gorm.DB
+		err       error
+		data      = &schema.ProfileWithToken{}
+		challenge *schema.SignInTOTPChallenge
+		tx        *gorm.DB
 	)
 
 	defer func() {
@@ -181,7 +236,11 @@ func SignInWithEmail(c controller.Context, input SignInWithEmailParams) (res sch
 			}
 		}
 
-		helper.Response(&res, data, err)
+		if challenge != nil {
+			helper.Response(&res, challenge, err)
+		} else {
+			helper.Response(&res, data, err)
+		}
 	}()
 
 	// 参数校验
@@ -189,11 +248,9 @@ func SignInWithEmail(c controller.Context, input SignInWithEmailParams) (res sch
 		return
 	}
 
-	email, err := redis.ClientAuthEmailCode.Get(input.Code).Result()
-
 	// 校验验证码是否正确
-	if err != nil || email != input.Email {
-		err = exception.InvalidParams
+	if err = captcha.Verify(captcha.ChannelEmail, captcha.PurposeSignIn, input.Email, input.Code); err != nil {
+		return
 	}
 
 	userInfo := model.User{
@@ -213,6 +270,12 @@ func SignInWithEmail(c controller.Context, input SignInWithEmailParams) (res sch
 		return
 	}
 
+	// 开启了双重身份认证，需要再校验动态码才能登陆
+	if userInfo.EnableTOTP {
+		challenge, err = createTOTPChallenge(userInfo.Id, input.Email)
+		return
+	}
+
 	if err = mapstructure.Decode(userInfo, &data.ProfilePure); err != nil {
 		return
 	}
@@ -228,11 +291,8 @@ func SignInWithEmail(c controller.Context, input SignInWithEmailParams) (res sch
 	data.UpdatedAt = userInfo.UpdatedAt.Format(time.RFC3339Nano)
 
 	// generate token
-	if t, er := token.Generate(userInfo.Id, false); er != nil {
-		err = er
+	if data.Token, data.RefreshToken, err = token.GenerateSession(userInfo.Id, false); err != nil {
 		return
-	} else {
-		data.Token = t
 	}
 
 	// 写入登陆记录
@@ -254,9 +314,10 @@ func SignInWithEmail(c controller.Context, input SignInWithEmailParams) (res sch
 // 手机 + 验证码登陆
 func SignInWithPhone(c controller.Context, input SignInWithPhoneParams) (res schema.Response) {
 	var (
-		err  error
-		data = &schema.ProfileWithToken{}
-		tx   *gorm.DB
+		err       error
+		data      = &schema.ProfileWithToken{}
+		challenge *schema.SignInTOTPChallenge
+		tx        *gorm.DB
 	)
 
 	defer func() {
@@ -279,7 +340,11 @@ func SignInWithPhone(c controller.Context, input SignInWithPhoneParams) (res sch
 			}
 		}
 
-		helper.Response(&res, data, err)
+		if challenge != nil {
+			helper.Response(&res, challenge, err)
+		} else {
+			helper.Response(&res, data, err)
+		}
 	}()
 
 	// 参数校验
@@ -287,15 +352,13 @@ func SignInWithPhone(c controller.Context, input SignInWithPhoneParams) (res sch
 		return
 	}
 
-	phone, err := redis.ClientAuthPhoneCode.Get(input.Code).Result()
-
 	// 校验验证码是否正确
-	if err != nil || phone != input.Phone {
-		err = exception.InvalidParams
+	if err = captcha.Verify(captcha.ChannelPhone, captcha.PurposeSignIn, input.Phone, input.Code); err != nil {
+		return
 	}
 
 	userInfo := model.User{
-		Email: &input.Phone,
+		Phone: &input.Phone,
 	}
 
 	tx = database.Db.Begin()
@@ -311,6 +374,12 @@ func SignInWithPhone(c controller.Context, input SignInWithPhoneParams) (res sch
 		return
 	}
 
+	// 开启了双重身份认证，需要再校验动态码才能登陆
+	if userInfo.EnableTOTP {
+		challenge, err = createTOTPChallenge(userInfo.Id, input.Phone)
+		return
+	}
+
 	if err = mapstructure.Decode(userInfo, &data.ProfilePure); err != nil {
 		return
 	}
@@ -326,11 +395,8 @@ func SignInWithPhone(c controller.Context, input SignInWithPhoneParams) (res sch
 	data.UpdatedAt = userInfo.UpdatedAt.Format(time.RFC3339Nano)
 
 	// generate token
-	if t, er := token.Generate(userInfo.Id, false); er != nil {
-		err = er
+	if data.Token, data.RefreshToken, err = token.GenerateSession(userInfo.Id, false); err != nil {
 		return
-	} else {
-		data.Token = t
 	}
 
 	// 写入登陆记录
@@ -352,9 +418,10 @@ func SignInWithPhone(c controller.Context, input SignInWithPhoneParams) (res sch
 // 使用微信小程序登陆
 func SignInWithWechat(c controller.Context, input SignInWithWechatParams) (res schema.Response) {
 	var (
-		err  error
-		data = &schema.ProfileWithToken{}
-		tx   *gorm.DB
+		err       error
+		data      = &schema.ProfileWithToken{}
+		challenge *schema.SignInTOTPChallenge
+		tx        *gorm.DB
 	)
 
 	defer func() {
@@ -377,7 +444,11 @@ func SignInWithWechat(c controller.Context, input SignInWithWechatParams) (res s
 			}
 		}
 
-		helper.Response(&res, data, err)
+		if challenge != nil {
+			helper.Response(&res, challenge, err)
+		} else {
+			helper.Response(&res, data, err)
+		}
 	}()
 
 	// 参数校验
@@ -432,12 +503,16 @@ func SignInWithWechat(c controller.Context, input SignInWithWechatParams) (res s
 		}
 
 		// 创建用户对应的钱包账号
-		for _, walletName := range model.Wallets {
-			if err = tx.Table(wallet.GetTableName(walletName)).Create(&model.Wallet{
+		var codes []string
+
+		if codes, err = currency.Codes(); err != nil {
+			return
+		}
+
+		for _, walletName := range codes {
+			if err = tx.Create(&model.Wallet{
 				Id:       userInfo.Id,
 				Currency: walletName,
-				Balance:  0,
-				Frozen:   0,
 			}).Error; err != nil {
 				return
 			}
@@ -452,6 +527,12 @@ func SignInWithWechat(c controller.Context, input SignInWithWechatParams) (res s
 		return
 	}
 
+	// 开启了双重身份认证，需要再校验动态码才能登陆
+	if userInfo.EnableTOTP {
+		challenge, err = createTOTPChallenge(userInfo.Id, userInfo.Username)
+		return
+	}
+
 	if err = mapstructure.Decode(userInfo, &data.ProfilePure); err != nil {
 		return
 	}
@@ -468,11 +549,8 @@ func SignInWithWechat(c controller.Context, input SignInWithWechatParams) (res s
 	data.UpdatedAt = userInfo.UpdatedAt.Format(time.RFC3339Nano)
 
 	// generate token
-	if t, er := token.Generate(userInfo.Id, false); er != nil {
-		err = er
+	if data.Token, data.RefreshToken, err = token.GenerateSession(userInfo.Id, false); err != nil {
 		return
-	} else {
-		data.Token = t
 	}
 
 	// 写入登陆记录
@@ -494,9 +572,10 @@ func SignInWithWechat(c controller.Context, input SignInWithWechatParams) (res s
 // 使用 oAuth 认证方式登陆
 func SignInWithOAuth(c controller.Context, input SignInWithOAuthParams) (res schema.Response) {
 	var (
-		err  error
-		data = &schema.ProfileWithToken{}
-		tx   *gorm.DB
+		err       error
+		data      = &schema.ProfileWithToken{}
+		challenge *schema.SignInTOTPChallenge
+		tx        *gorm.DB
 	)
 
 	defer func() {
@@ -519,7 +598,11 @@ func SignInWithOAuth(c controller.Context, input SignInWithOAuthParams) (res sch
 			}
 		}
 
-		helper.Response(&res, data, err)
+		if challenge != nil {
+			helper.Response(&res, challenge, err)
+		} else {
+			helper.Response(&res, data, err)
+		}
 	}()
 
 	// 参数校验
@@ -537,10 +620,18 @@ func SignInWithOAuth(c controller.Context, input SignInWithOAuthParams) (res sch
 		Id: uid,
 	}
 
+	tx = database.Db.Begin()
+
 	if err = tx.Where(&userInfo).Preload("Wechat").Find(&userInfo).Error; err != nil {
 		return
 	}
 
+	// 开启了双重身份认证，需要再校验动态码才能登陆
+	if userInfo.EnableTOTP {
+		challenge, err = createTOTPChallenge(userInfo.Id, userInfo.Username)
+		return
+	}
+
 	if err = mapstructure.Decode(userInfo, &data.ProfilePure); err != nil {
 		return
 	}
@@ -556,11 +647,8 @@ func SignInWithOAuth(c controller.Context, input SignInWithOAuthParams) (res sch
 	}
 
 	// generate token
-	if t, er := token.Generate(userInfo.Id, false); er != nil {
-		err = er
+	if data.Token, data.RefreshToken, err = token.GenerateSession(userInfo.Id, false); err != nil {
 		return
-	} else {
-		data.Token = t
 	}
 
 	// 写入登陆记录
@@ -6,8 +6,11 @@ import (
 	"github.com/axetroy/go-server/core/controller"
 	"github.com/axetroy/go-server/core/controller/auth"
 	"github.com/axetroy/go-server/core/exception"
+	"github.com/axetroy/go-server/core/model"
 	"github.com/axetroy/go-server/core/schema"
+	"github.com/axetroy/go-server/core/service/database"
 	"github.com/axetroy/go-server/core/service/token"
+	"github.com/axetroy/go-server/core/util"
 	"github.com/axetroy/go-server/tester"
 	"github.com/mitchellh/mapstructure"
 	"github.com/stretchr/testify/assert"
@@ -94,6 +97,37 @@ func TestSignInSuccess(t *testing.T) {
 	}
 }
 
+func TestSignInUpgradeLegacyPassword(t *testing.T) {
+	userInfo, _ := tester.CreateUser()
+
+	defer auth.DeleteUserByUserName(userInfo.Username)
+
+	// 模拟旧版本 MD5 生成的密码
+	legacyPassword := util.MD5("prefix" + "123123" + "suffix")
+
+	assert.Nil(t, database.Db.Model(&model.User{Id: userInfo.Id}).Update("password", legacyPassword).Error)
+
+	// 旧密码依旧可以登陆
+	r := auth.SignIn(controller.Context{
+		UserAgent: "test-user-agent",
+		Ip:        "0.0.0.0",
+	}, auth.SignInParams{
+		Account:  userInfo.Username,
+		Password: "123123",
+	})
+
+	assert.Equal(t, schema.StatusSuccess, r.Status)
+	assert.Equal(t, "", r.Message)
+
+	// 登陆后密码哈希自动升级
+	u := model.User{Id: userInfo.Id}
+
+	assert.Nil(t, database.Db.Where(&u).First(&u).Error)
+	assert.NotEqual(t, legacyPassword, u.Password)
+	assert.False(t, util.PasswordNeedsRehash(u.Password))
+	assert.True(t, util.VerifyPassword("123123", u.Password))
+}
+
 func TestSignInWithWechat(t *testing.T) {
 	res := auth.SignInWithWechat(controller.Context{}, auth.SignInWithWechatParams{
 		Code: "test_code",
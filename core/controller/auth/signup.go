@@ -4,14 +4,14 @@ package auth
 import (
 	"errors"
 	"fmt"
-	"github.com/axetroy/go-server/core/controller/wallet"
 	"github.com/axetroy/go-server/core/exception"
 	"github.com/axetroy/go-server/core/helper"
 	"github.com/axetroy/go-server/core/model"
 	"github.com/axetroy/go-server/core/schema"
+	"github.com/axetroy/go-server/core/service/captcha"
+	"github.com/axetroy/go-server/core/service/currency"
 	"github.com/axetroy/go-server/core/service/database"
 	"github.com/axetroy/go-server/core/service/email"
-	"github.com/axetroy/go-server/core/service/redis"
 	"github.com/axetroy/go-server/core/util"
 	"github.com/axetroy/go-server/core/validator"
 	"github.com/gin-gonic/gin"
@@ -100,12 +100,16 @@ func CreateUserTx(tx *gorm.DB, userInfo *model.User, inviterCode *string) (err e
 	}
 
 	// 创建用户对应的钱包账号
-	for _, walletName := range model.Wallets {
-		if err = tx.Table(wallet.GetTableName(walletName)).Create(&model.Wallet{
+	var codes []string
+
+	if codes, err = currency.Codes(); err != nil {
+		return err
+	}
+
+	for _, walletName := range codes {
+		if err = tx.Create(&model.Wallet{
 			Id:       userInfo.Id,
 			Currency: walletName,
-			Balance:  0,
-			Frozen:   0,
 		}).Error; err != nil {
 			return err
 		}
@@ -231,15 +235,8 @@ func SignUpWithEmail(input SignUpWithEmailParams) (res schema.Response) {
 		return
 	}
 
-	emailAddr, err := redis.ClientAuthEmailCode.Get(input.Code).Result()
-
-	if err != nil {
-		return
-	}
-
-	// 校验邮箱验证码是否一致
-	if emailAddr != input.Email {
-		err = exception.InvalidParams
+	// 校验邮箱验证码
+	if err = captcha.Verify(captcha.ChannelEmail, captcha.PurposeSignUp, input.Email, input.Code); err != nil {
 		return
 	}
 
@@ -328,25 +325,18 @@ func SignUpWithEmailAction(input SignUpWithEmailActionParams) (res schema.Respon
 
 	tx = database.Db.Begin()
 
-	if err = tx.Where("email = ?", input.Email).Find(&model.User{Email: &input.Email}).Error; err != nil {
-		if err != gorm.ErrRecordNotFound {
-			err = exception.UserExist
-		}
+	if err = tx.Where("email = ?", input.Email).First(&model.User{}).Error; err == nil {
+		err = exception.UserExist
 		return
-	}
-
-	code := GenerateAuthCode()
-
-	if err = redis.ClientAuthEmailCode.Set(code, input.Email, 10*time.Minute).Err(); err != nil {
+	} else if err != gorm.ErrRecordNotFound {
 		return
 	}
 
-	e := email.NewMailer()
-
-	link := fmt.Sprintf("%s?code=%s&email=%s", input.RedirectURL, code, input.Email)
-
 	// 发送邮件
-	if err = e.SendAuthEmail(input.Email, link); err != nil {
+	if err = captcha.Send(captcha.ChannelEmail, captcha.PurposeSignUp, input.Email, func(code string) error {
+		link := fmt.Sprintf("%s?code=%s&email=%s", input.RedirectURL, code, input.Email)
+		return email.NewMailer().SendAuthEmail(input.Email, link)
+	}); err != nil {
 		return
 	}
 
@@ -389,15 +379,8 @@ func SignUpWithPhone(input SignUpWithPhoneParams) (res schema.Response) {
 		return
 	}
 
-	phone, err := redis.ClientAuthPhoneCode.Get(input.Code).Result()
-
-	if err != nil {
-		return
-	}
-
-	// 校验短信验证码是否一致
-	if phone != input.Phone {
-		err = exception.InvalidParams
+	// 校验短信验证码
+	if err = captcha.Verify(captcha.ChannelPhone, captcha.PurposeSignUp, input.Phone, input.Code); err != nil {
 		return
 	}
 
@@ -0,0 +1,257 @@
+// Copyright 2019 Axetroy. All rights reserved. MIT license.
+package auth
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/axetroy/go-server/core/controller"
+	"github.com/axetroy/go-server/core/exception"
+	"github.com/axetroy/go-server/core/helper"
+	"github.com/axetroy/go-server/core/model"
+	"github.com/axetroy/go-server/core/schema"
+	"github.com/axetroy/go-server/core/service/captcha"
+	"github.com/axetroy/go-server/core/service/database"
+	"github.com/axetroy/go-server/core/service/lockout"
+	"github.com/axetroy/go-server/core/service/redis"
+	"github.com/axetroy/go-server/core/service/token"
+	"github.com/axetroy/go-server/core/util"
+	"github.com/axetroy/go-server/core/validator"
+	"github.com/gin-gonic/gin"
+	"github.com/jinzhu/gorm"
+	"github.com/mitchellh/mapstructure"
+	"net/http"
+	"time"
+)
+
+var (
+	TOTPChallengeDuration    = time.Minute * 5 // 登陆挑战码的有效期
+	TOTPChallengeMaxAttempts = int64(5)        // 每个挑战码允许输错动态码的次数, 超过则挑战码失效
+)
+
+type SignInWithTOTPParams struct {
+	Challenge string `json:"challenge" valid:"required~请输入挑战码"`                                        // 登陆接口返回的挑战码
+	Code      string `json:"code" valid:"required~请输入动态验证码,numeric~动态验证码为6位数字,length(6|6)~动态验证码为6位数字"` // 身份验证器 App 上的 6 位动态码
+}
+
+// 登陆挑战码对应的内容
+type totpChallenge struct {
+	Uid     string `json:"uid"`     // 用户 ID
+	Account string `json:"account"` // 第一步登陆使用的账号, 动态码输错时按这个账号计入失败次数
+}
+
+// 为开启了双重身份认证的用户生成登陆挑战
+func createTOTPChallenge(uid string, account string) (*schema.SignInTOTPChallenge, error) {
+	challenge, err := captcha.GenerateTOTPChallenge()
+
+	if err != nil {
+		return nil, err
+	}
+
+	b, err := json.Marshal(totpChallenge{Uid: uid, Account: account})
+
+	if err != nil {
+		return nil, err
+	}
+
+	if err = redis.ClientTOTPChallenge.Set(challenge, string(b), TOTPChallengeDuration).Err(); err != nil {
+		return nil, err
+	}
+
+	return &schema.SignInTOTPChallenge{
+		TOTPRequired: true,
+		Challenge:    challenge,
+		ExpiredAt:    time.Now().Add(TOTPChallengeDuration).Format(time.RFC3339Nano),
+	}, nil
+}
+
+// 记录挑战码输错的次数，超过次数则让挑战码失效
+func failTOTPChallenge(challenge string) {
+	key := challenge + ":fail"
+
+	times, err := redis.ClientTOTPChallenge.Incr(key).Result()
+
+	if err != nil {
+		return
+	}
+
+	_ = redis.ClientTOTPChallenge.Expire(key, TOTPChallengeDuration).Err()
+
+	if times >= TOTPChallengeMaxAttempts {
+		_ = redis.ClientTOTPChallenge.Del(challenge, key).Err()
+	}
+}
+
+// 标记动态码已经使用过, 同一个用户同一个周期的动态码只能使用一次
+func useTOTPCode(uid string, step int64) error {
+	ok, err := redis.ClientTOTPChallenge.SetNX(fmt.Sprintf("used:%s:%d", uid, step), 1, util.TOTPValidDuration()).Result()
+
+	if err != nil {
+		return err
+	}
+
+	if !ok {
+		return exception.InvalidTOTPCode
+	}
+
+	return nil
+}
+
+// 双重身份认证登陆的第二步，使用挑战码 + 动态码换取 token
+func SignInWithTOTP(c controller.Context, input SignInWithTOTPParams) (res schema.Response) {
+	var (
+		err  error
+		data = &schema.ProfileWithToken{}
+		tx   *gorm.DB
+	)
+
+	defer func() {
+		if r := recover(); r != nil {
+			switch t := r.(type) {
+			case string:
+				err = errors.New(t)
+			case error:
+				err = t
+			default:
+				err = exception.Unknown
+			}
+		}
+
+		if tx != nil {
+			if err != nil {
+				_ = tx.Rollback().Error
+			} else {
+				err = tx.Commit().Error
+			}
+		}
+
+		helper.Response(&res, data, err)
+	}()
+
+	// 参数校验
+	if err = validator.ValidateStruct(input); err != nil {
+		return
+	}
+
+	value, er := redis.ClientTOTPChallenge.Get(input.Challenge).Result()
+
+	if er != nil || value == "" {
+		err = exception.InvalidTOTPChallenge
+		return
+	}
+
+	payload := totpChallenge{}
+
+	if er := json.Unmarshal([]byte(value), &payload); er != nil || payload.Uid == "" {
+		err = exception.InvalidTOTPChallenge
+		return
+	}
+
+	// 动态码输错次数过多，账号或 IP 已被锁定
+	if err = lockout.Check(lockout.ScopeUser, payload.Account, c.Ip); err != nil {
+		return
+	}
+
+	userInfo := model.User{Id: payload.Uid}
+
+	tx = database.Db.Begin()
+
+	if err = tx.Where(&userInfo).Preload("Wechat").Last(&userInfo).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			err = exception.InvalidTOTPChallenge
+		}
+		return
+	}
+
+	if err = userInfo.CheckStatusValid(); err != nil {
+		return
+	}
+
+	if !userInfo.EnableTOTP {
+		err = exception.InvalidTOTPChallenge
+		return
+	}
+
+	step, ok := util.Verify2FAStep(userInfo.Secret, input.Code)
+
+	if !ok {
+		failTOTPChallenge(input.Challenge)
+		signInFail(c, payload.Account, userInfo.Id, "动态验证码错误")
+		err = exception.InvalidTOTPCode
+		return
+	}
+
+	// 挑战码只能使用一次, 并发请求中只有成功删除挑战码的请求可以继续
+	if n, er := redis.ClientTOTPChallenge.Del(input.Challenge).Result(); er != nil || n != 1 {
+		err = exception.InvalidTOTPChallenge
+		return
+	}
+
+	_ = redis.ClientTOTPChallenge.Del(input.Challenge + ":fail").Err()
+
+	// 动态码在有效期内不能重放
+	if err = useTOTPCode(userInfo.Id, step); err != nil {
+		return
+	}
+
+	if err = lockout.Success(lockout.ScopeUser, payload.Account); err != nil {
+		return
+	}
+
+	if err = mapstructure.Decode(userInfo, &data.ProfilePure); err != nil {
+		return
+	}
+
+	if userInfo.WechatOpenID != nil {
+		if err = mapstructure.Decode(userInfo.Wechat, &data.Wechat); err != nil {
+			return
+		}
+	}
+
+	data.PayPassword = userInfo.PayPassword != nil && len(*userInfo.PayPassword) != 0
+	data.CreatedAt = userInfo.CreatedAt.Format(time.RFC3339Nano)
+	data.UpdatedAt = userInfo.UpdatedAt.Format(time.RFC3339Nano)
+
+	// generate token
+	if data.Token, data.RefreshToken, err = token.GenerateSession(userInfo.Id, false); err != nil {
+		return
+	}
+
+	// 写入登陆记录
+	log := model.LoginLog{
+		Uid:     userInfo.Id,                       // 用户ID
+		Type:    model.LoginLogTypeUserName,        // 默认用户名登陆
+		Command: model.LoginLogCommandLoginSuccess, // 登陆成功
+		Client:  c.UserAgent,                       // 用户的 userAgent
+		LastIp:  c.Ip,                              // 用户的IP
+	}
+
+	if err = tx.Create(&log).Error; err != nil {
+		return
+	}
+
+	return
+}
+
+func SignInWithTOTPRouter(c *gin.Context) {
+	var (
+		input SignInWithTOTPParams
+		err   error
+		res   = schema.Response{}
+	)
+
+	defer func() {
+		if err != nil {
+			res.Data = nil
+			res.Message = err.Error()
+		}
+		c.JSON(http.StatusOK, res)
+	}()
+
+	if err = c.ShouldBindJSON(&input); err != nil {
+		err = exception.InvalidParams
+		return
+	}
+
+	res = SignInWithTOTP(controller.NewContext(c), input)
+}
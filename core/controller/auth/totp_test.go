@@ -0,0 +1,104 @@
+// Copyright 2019 Axetroy. All rights reserved. MIT license.
+package auth_test
+
+import (
+	"github.com/axetroy/go-server/core/controller"
+	"github.com/axetroy/go-server/core/controller/auth"
+	"github.com/axetroy/go-server/core/controller/user"
+	"github.com/axetroy/go-server/core/exception"
+	"github.com/axetroy/go-server/core/schema"
+	"github.com/axetroy/go-server/core/service/token"
+	"github.com/axetroy/go-server/core/util"
+	"github.com/axetroy/go-server/tester"
+	"github.com/stretchr/testify/assert"
+	"testing"
+	"time"
+)
+
+func TestSignInWithTOTP(t *testing.T) {
+	userInfo, _ := tester.CreateUser()
+
+	defer auth.DeleteUserByUserName(userInfo.Username)
+
+	enrollment := schema.TOTPEnrollment{}
+
+	// 开启双重身份认证
+	{
+		r := user.EnrollTOTP(controller.Context{Uid: userInfo.Id})
+
+		assert.Equal(t, schema.StatusSuccess, r.Status)
+		assert.Nil(t, tester.Decode(r.Data, &enrollment))
+
+		code, _ := util.Generate2FACode(enrollment.Secret, time.Now())
+
+		r = user.ConfirmTOTP(controller.Context{Uid: userInfo.Id}, user.TOTPCodeParams{Code: code})
+
+		assert.Equal(t, schema.StatusSuccess, r.Status)
+	}
+
+	challenge := schema.SignInTOTPChallenge{}
+
+	// 登陆后不会返回 token，而是返回挑战码
+	{
+		r := auth.SignIn(controller.Context{
+			UserAgent: "test-user-agent",
+			Ip:        "0.0.0.0",
+		}, auth.SignInParams{
+			Account:  userInfo.Username,
+			Password: "123123",
+		})
+
+		assert.Equal(t, schema.StatusSuccess, r.Status)
+		assert.Equal(t, "", r.Message)
+		assert.Nil(t, tester.Decode(r.Data, &challenge))
+		assert.True(t, challenge.TOTPRequired)
+		assert.NotEmpty(t, challenge.Challenge)
+	}
+
+	// 错误的动态码
+	{
+		r := auth.SignInWithTOTP(controller.Context{}, auth.SignInWithTOTPParams{
+			Challenge: challenge.Challenge,
+			Code:      "000000",
+		})
+
+		assert.Equal(t, exception.InvalidTOTPCode.Code(), r.Status)
+		assert.Equal(t, exception.InvalidTOTPCode.Error(), r.Message)
+	}
+
+	// 正确的动态码换取 token
+	{
+		code, _ := util.Generate2FACode(enrollment.Secret, time.Now())
+
+		r := auth.SignInWithTOTP(controller.Context{}, auth.SignInWithTOTPParams{
+			Challenge: challenge.Challenge,
+			Code:      code,
+		})
+
+		assert.Equal(t, schema.StatusSuccess, r.Status)
+		assert.Equal(t, "", r.Message)
+
+		profile := schema.ProfileWithToken{}
+
+		assert.Nil(t, tester.Decode(r.Data, &profile))
+		assert.NotEmpty(t, profile.Token)
+
+		c, err := token.Parse(token.Prefix+" "+profile.Token, false)
+
+		assert.Nil(t, err)
+		assert.Equal(t, userInfo.Id, c.Uid)
+	}
+
+	// 挑战码只能使用一次
+	{
+		code, _ := util.Generate2FACode(enrollment.Secret, time.Now())
+
+		r := auth.SignInWithTOTP(controller.Context{}, auth.SignInWithTOTPParams{
+			Challenge: challenge.Challenge,
+			Code:      code,
+		})
+
+		assert.Equal(t, exception.InvalidTOTPChallenge.Code(), r.Status)
+		assert.Equal(t, exception.InvalidTOTPChallenge.Error(), r.Message)
+	}
+}
@@ -7,8 +7,8 @@ import (
 	"github.com/axetroy/go-server/core/helper"
 	"github.com/axetroy/go-server/core/model"
 	"github.com/axetroy/go-server/core/schema"
+	"github.com/axetroy/go-server/core/service/captcha"
 	"github.com/axetroy/go-server/core/service/database"
-	"github.com/axetroy/go-server/core/service/redis"
 	"github.com/axetroy/go-server/core/service/wechat"
 	"github.com/axetroy/go-server/core/validator"
 	"github.com/gin-gonic/gin"
@@ -66,9 +66,12 @@ func UnbindingEmail(c controller.Context, input UnbindingEmailParams) (res schem
 
 	tx = database.Db.Begin()
 
-	userInfo := model.User{}
+	userInfo := model.User{Id: c.Uid}
 
-	if err = tx.Where(&userInfo).Find(&userInfo).Error; err != nil {
+	if err = tx.Where(&userInfo).Last(&userInfo).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			err = exception.UserNotExist
+		}
 		return
 	}
 
@@ -79,15 +82,7 @@ func UnbindingEmail(c controller.Context, input UnbindingEmailParams) (res schem
 	}
 
 	// 校验验证码正确不正确
-	email, err := redis.ClientAuthEmailCode.Get(input.Code).Result()
-
-	if err != nil {
-		return
-	}
-
-	// 如果邮箱不匹配，则校验失败
-	if email != *userInfo.Email {
-		err = exception.InvalidParams
+	if err = captcha.Verify(captcha.ChannelEmail, captcha.PurposeUnbinding, *userInfo.Email, input.Code); err != nil {
 		return
 	}
 
@@ -136,9 +131,12 @@ func UnbindingPhone(c controller.Context, input UnbindingPhoneParams) (res schem
 
 	tx = database.Db.Begin()
 
-	userInfo := model.User{}
+	userInfo := model.User{Id: c.Uid}
 
-	if err = tx.Where(&userInfo).Find(&userInfo).Error; err != nil {
+	if err = tx.Where(&userInfo).Last(&userInfo).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			err = exception.UserNotExist
+		}
 		return
 	}
 
@@ -149,15 +147,7 @@ func UnbindingPhone(c controller.Context, input UnbindingPhoneParams) (res schem
 	}
 
 	// 校验验证码正确不正确
-	phone, err := redis.ClientAuthPhoneCode.Get(input.Code).Result()
-
-	if err != nil {
-		return
-	}
-
-	// 如果邮箱不匹配，则校验失败
-	if phone != *userInfo.Phone {
-		err = exception.InvalidParams
+	if err = captcha.Verify(captcha.ChannelPhone, captcha.PurposeUnbinding, *userInfo.Phone, input.Code); err != nil {
 		return
 	}
 
@@ -206,9 +196,12 @@ func UnbindingWechat(c controller.Context, input UnbindingWechatParams) (res sch
 
 	tx = database.Db.Begin()
 
-	userInfo := model.User{}
+	userInfo := model.User{Id: c.Uid}
 
-	if err = tx.Where(&userInfo).Find(&userInfo).Error; err != nil {
+	if err = tx.Where(&userInfo).Last(&userInfo).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			err = exception.UserNotExist
+		}
 		return
 	}
 
@@ -217,30 +210,14 @@ func UnbindingWechat(c controller.Context, input UnbindingWechatParams) (res sch
 		// 如果用户已有手机号，则用手机号作为验证码
 
 		// 校验验证码正确不正确
-		phone, err := redis.ClientAuthPhoneCode.Get(input.Code).Result()
-
-		if err != nil {
-			return
-		}
-
-		// 如果不匹配，则校验失败
-		if phone != *userInfo.Phone {
-			err = exception.InvalidParams
+		if err = captcha.Verify(captcha.ChannelPhone, captcha.PurposeUnbinding, *userInfo.Phone, input.Code); err != nil {
 			return
 		}
 	} else if userInfo.Email != nil {
 		// 	如果用户已有邮箱，则用邮箱作为验证码
 
 		// 校验验证码正确不正确
-		email, err := redis.ClientAuthEmailCode.Get(input.Code).Result()
-
-		if err != nil {
-			return
-		}
-
-		// 如果邮箱不匹配，则校验失败
-		if email != *userInfo.Email {
-			err = exception.InvalidParams
+		if err = captcha.Verify(captcha.ChannelEmail, captcha.PurposeUnbinding, *userInfo.Email, input.Code); err != nil {
 			return
 		}
 	} else {
@@ -0,0 +1,154 @@
+// Copyright 2019 Axetroy. All rights reserved. MIT license.
+package currency
+
+import (
+	"errors"
+	"github.com/axetroy/go-server/core/controller"
+	"github.com/axetroy/go-server/core/exception"
+	"github.com/axetroy/go-server/core/helper"
+	"github.com/axetroy/go-server/core/middleware"
+	"github.com/axetroy/go-server/core/model"
+	"github.com/axetroy/go-server/core/schema"
+	"github.com/axetroy/go-server/core/service/currency"
+	"github.com/axetroy/go-server/core/service/database"
+	"github.com/axetroy/go-server/core/validator"
+	"github.com/gin-gonic/gin"
+	"github.com/jinzhu/gorm"
+	"log"
+	"net/http"
+	"strings"
+)
+
+type CreateParams struct {
+	Code        string  `json:"code" valid:"required~请输入币种代码,matches(^[A-Za-z][A-Za-z0-9]*$)~币种代码只能包含字母和数字,length(2|16)~币种代码为2-16个字符"` // 币种代码
+	Name        string  `json:"name" valid:"required~请输入币种名称,length(1|32)~币种名称不能超过32个字符"`                                              // 币种名称
+	Precision   int     `json:"precision"`                                                                                             // 允许的小数位数, 0-8
+	Enabled     *bool   `json:"enabled"`                                                                                               // 是否启用, 默认启用
+	TransferMin *string `json:"transfer_min"`                                                                                          // 单笔转账的最小金额
+	TransferMax *string `json:"transfer_max"`                                                                                          // 单笔转账的最大金额
+}
+
+// 添加币种, 钱包, 转账记录, 财务日志都保存在共用的表中, 不需要创建新的表
+func Create(c controller.Context, input CreateParams) (res schema.Response) {
+	var (
+		err  error
+		data schema.Currency
+		tx   *gorm.DB
+	)
+
+	defer func() {
+		if r := recover(); r != nil {
+			switch t := r.(type) {
+			case string:
+				err = errors.New(t)
+			case error:
+				err = t
+			default:
+				err = exception.Unknown
+			}
+		}
+
+		if tx != nil {
+			if err != nil {
+				_ = tx.Rollback().Error
+			} else {
+				err = tx.Commit().Error
+			}
+		}
+
+		if err == nil {
+			if er := currency.Invalidate(); er != nil {
+				log.Printf("通知币种缓存失效失败: %s\n", er)
+			}
+		}
+
+		helper.Response(&res, data, err)
+	}()
+
+	// 参数校验
+	if err = validator.ValidateStruct(input); err != nil {
+		return
+	}
+
+	currencyInfo := model.Currency{
+		Code:      strings.ToUpper(input.Code),
+		Name:      input.Name,
+		Precision: input.Precision,
+		Enabled:   true,
+	}
+
+	if input.Enabled != nil {
+		currencyInfo.Enabled = *input.Enabled
+	}
+
+	if input.TransferMin != nil {
+		if currencyInfo.TransferMin, err = parseLimit(*input.TransferMin, input.Precision); err != nil {
+			return
+		}
+	}
+
+	if input.TransferMax != nil {
+		if currencyInfo.TransferMax, err = parseLimit(*input.TransferMax, input.Precision); err != nil {
+			return
+		}
+	}
+
+	if err = validate(currencyInfo); err != nil {
+		return
+	}
+
+	tx = database.Db.Begin()
+
+	adminInfo := model.Admin{Id: c.Uid}
+
+	if err = tx.First(&adminInfo).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			err = exception.AdminNotExist
+		}
+		return
+	}
+
+	var count int
+
+	if err = tx.Model(&model.Currency{}).Where("code = ?", currencyInfo.Code).Count(&count).Error; err != nil {
+		return
+	}
+
+	if count > 0 {
+		err = exception.CurrencyExist
+		return
+	}
+
+	if err = tx.Create(&currencyInfo).Error; err != nil {
+		return
+	}
+
+	data = mapToSchema(currencyInfo)
+
+	return
+}
+
+func CreateRouter(c *gin.Context) {
+	var (
+		input CreateParams
+		err   error
+		res   = schema.Response{}
+	)
+
+	defer func() {
+		if err != nil {
+			res.Data = nil
+			res.Message = err.Error()
+		}
+		c.JSON(http.StatusOK, res)
+	}()
+
+	if err = c.ShouldBindJSON(&input); err != nil {
+		err = exception.InvalidParams
+		return
+	}
+
+	res = Create(controller.Context{
+		Uid: c.GetString(middleware.ContextUidField),
+	}, input)
+}
@@ -0,0 +1,85 @@
+// Copyright 2019 Axetroy. All rights reserved. MIT license.
+package currency_test
+
+import (
+	"github.com/axetroy/go-server/core/controller"
+	"github.com/axetroy/go-server/core/controller/currency"
+	"github.com/axetroy/go-server/core/exception"
+	"github.com/axetroy/go-server/core/model"
+	"github.com/axetroy/go-server/core/schema"
+	currencyService "github.com/axetroy/go-server/core/service/currency"
+	"github.com/axetroy/go-server/tester"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestCreate(t *testing.T) {
+	adminInfo, _ := tester.LoginAdmin()
+
+	c := controller.Context{Uid: adminInfo.Id}
+
+	defer currency.DeleteCurrencyByCode("TEST")
+
+	min := "1.5"
+
+	// 添加币种
+	{
+		r := currency.Create(c, currency.CreateParams{
+			Code:        "test",
+			Name:        "测试币",
+			Precision:   4,
+			TransferMin: &min,
+		})
+
+		assert.Equal(t, "", r.Message)
+		assert.Equal(t, schema.StatusSuccess, r.Status)
+
+		data := schema.Currency{}
+
+		assert.Nil(t, tester.Decode(r.Data, &data))
+		assert.Equal(t, "TEST", data.Code)
+		assert.Equal(t, "测试币", data.Name)
+		assert.Equal(t, 4, data.Precision)
+		assert.True(t, data.Enabled)
+		assert.Equal(t, "1.5000", data.TransferMin)
+		assert.Equal(t, "0.0000", data.TransferMax)
+
+		// 缓存已经刷新
+		info, err := currencyService.Get("TEST")
+
+		assert.Nil(t, err)
+		assert.Equal(t, 4, info.Precision)
+	}
+
+	// 重复添加
+	{
+		r := currency.Create(c, currency.CreateParams{
+			Code:      "TEST",
+			Name:      "测试币",
+			Precision: 4,
+		})
+
+		assert.Equal(t, exception.CurrencyExist.Error(), r.Message)
+	}
+
+	// 无效的小数位数
+	{
+		r := currency.Create(c, currency.CreateParams{
+			Code:      "TESTB",
+			Name:      "测试币",
+			Precision: model.MaxCurrencyPrecision + 1,
+		})
+
+		assert.Equal(t, exception.InvalidParams.Error(), r.Message)
+	}
+
+	// 无效的币种代码
+	{
+		r := currency.Create(c, currency.CreateParams{
+			Code: "1-A",
+			Name: "测试币",
+		})
+
+		assert.Equal(t, exception.InvalidParams.Code(), r.Status)
+	}
+}
@@ -0,0 +1,117 @@
+// Copyright 2019 Axetroy. All rights reserved. MIT license.
+package currency
+
+import (
+	"errors"
+	"github.com/axetroy/go-server/core/controller"
+	"github.com/axetroy/go-server/core/exception"
+	"github.com/axetroy/go-server/core/helper"
+	"github.com/axetroy/go-server/core/middleware"
+	"github.com/axetroy/go-server/core/model"
+	"github.com/axetroy/go-server/core/schema"
+	"github.com/axetroy/go-server/core/service/currency"
+	"github.com/axetroy/go-server/core/service/database"
+	"github.com/gin-gonic/gin"
+	"github.com/jinzhu/gorm"
+	"log"
+	"net/http"
+	"strings"
+)
+
+// 删除币种
+// 已经产生过余额变动的币种不能删除, 只能停用
+func Delete(c controller.Context, code string) (res schema.Response) {
+	var (
+		err  error
+		data schema.Currency
+		tx   *gorm.DB
+	)
+
+	defer func() {
+		if r := recover(); r != nil {
+			switch t := r.(type) {
+			case string:
+				err = errors.New(t)
+			case error:
+				err = t
+			default:
+				err = exception.Unknown
+			}
+		}
+
+		if tx != nil {
+			if err != nil {
+				_ = tx.Rollback().Error
+			} else {
+				err = tx.Commit().Error
+			}
+		}
+
+		if err == nil {
+			if er := currency.Invalidate(); er != nil {
+				log.Printf("通知币种缓存失效失败: %s\n", er)
+			}
+		}
+
+		helper.Response(&res, data, err)
+	}()
+
+	tx = database.Db.Begin()
+
+	adminInfo := model.Admin{Id: c.Uid}
+
+	if err = tx.First(&adminInfo).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			err = exception.AdminNotExist
+		}
+		return
+	}
+
+	currencyInfo := model.Currency{}
+
+	if err = tx.Where("code = ?", strings.ToUpper(code)).First(&currencyInfo).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			err = exception.CurrencyNotExist
+		}
+		return
+	}
+
+	// 每一次余额变动都会生成财务日志, 有财务日志即说明已经被使用
+	var count int
+
+	if err = model.FinanceLogOf(tx.Unscoped(), currencyInfo.Code).Count(&count).Error; err != nil {
+		return
+	}
+
+	if count > 0 {
+		err = exception.CurrencyHadBeenUsed
+		return
+	}
+
+	if err = tx.Delete(&currencyInfo).Error; err != nil {
+		return
+	}
+
+	data = mapToSchema(currencyInfo)
+
+	return
+}
+
+func DeleteRouter(c *gin.Context) {
+	var (
+		err error
+		res = schema.Response{}
+	)
+
+	defer func() {
+		if err != nil {
+			res.Data = nil
+			res.Message = err.Error()
+		}
+		c.JSON(http.StatusOK, res)
+	}()
+
+	res = Delete(controller.Context{
+		Uid: c.GetString(middleware.ContextUidField),
+	}, c.Param("code"))
+}
@@ -0,0 +1,41 @@
+// Copyright 2019 Axetroy. All rights reserved. MIT license.
+package currency_test
+
+import (
+	"github.com/axetroy/go-server/core/controller"
+	"github.com/axetroy/go-server/core/controller/currency"
+	"github.com/axetroy/go-server/core/exception"
+	"github.com/axetroy/go-server/core/model"
+	"github.com/axetroy/go-server/tester"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestDelete(t *testing.T) {
+	adminInfo, _ := tester.LoginAdmin()
+
+	c := controller.Context{Uid: adminInfo.Id}
+
+	defer currency.DeleteCurrencyByCode("TEST")
+
+	assert.Equal(t, "", currency.Create(c, currency.CreateParams{
+		Code:      "TEST",
+		Name:      "测试币",
+		Precision: 2,
+	}).Message)
+
+	// 未使用的币种可以删除
+	{
+		r := currency.Delete(c, "TEST")
+
+		assert.Equal(t, "", r.Message)
+		assert.Equal(t, exception.CurrencyNotExist.Error(), currency.GetByAdmin(c, "TEST").Message)
+	}
+
+	// 已经使用过的币种不能删除
+	{
+		r := currency.Delete(c, model.WalletCNY)
+
+		assert.Equal(t, exception.CurrencyHadBeenUsed.Error(), r.Message)
+	}
+}
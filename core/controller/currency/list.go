@@ -0,0 +1,184 @@
+// Copyright 2019 Axetroy. All rights reserved. MIT license.
+package currency
+
+import (
+	"errors"
+	"github.com/axetroy/go-server/core/controller"
+	"github.com/axetroy/go-server/core/exception"
+	"github.com/axetroy/go-server/core/helper"
+	"github.com/axetroy/go-server/core/middleware"
+	"github.com/axetroy/go-server/core/model"
+	"github.com/axetroy/go-server/core/schema"
+	"github.com/axetroy/go-server/core/service/currency"
+	"github.com/axetroy/go-server/core/service/database"
+	"github.com/gin-gonic/gin"
+	"github.com/jinzhu/gorm"
+	"net/http"
+	"strings"
+)
+
+// 获取可用的币种
+func GetList(c controller.Context) (res schema.Response) {
+	var (
+		err  error
+		data = make([]schema.Currency, 0)
+	)
+
+	defer func() {
+		if r := recover(); r != nil {
+			switch t := r.(type) {
+			case string:
+				err = errors.New(t)
+			case error:
+				err = t
+			default:
+				err = exception.Unknown
+			}
+		}
+
+		helper.Response(&res, data, err)
+	}()
+
+	var list []model.Currency
+
+	if list, err = currency.List(); err != nil {
+		return
+	}
+
+	for _, v := range list {
+		if v.Enabled {
+			data = append(data, mapToSchema(v))
+		}
+	}
+
+	return
+}
+
+// 管理员获取所有币种, 包括停用的币种
+func GetListByAdmin(c controller.Context) (res schema.Response) {
+	var (
+		err  error
+		data = make([]schema.Currency, 0)
+	)
+
+	defer func() {
+		if r := recover(); r != nil {
+			switch t := r.(type) {
+			case string:
+				err = errors.New(t)
+			case error:
+				err = t
+			default:
+				err = exception.Unknown
+			}
+		}
+
+		helper.Response(&res, data, err)
+	}()
+
+	list := make([]model.Currency, 0)
+
+	// 直接读取数据库, 不使用缓存
+	if err = database.Db.Order("code ASC").Find(&list).Error; err != nil {
+		return
+	}
+
+	for _, v := range list {
+		data = append(data, mapToSchema(v))
+	}
+
+	return
+}
+
+// 管理员获取单个币种
+func GetByAdmin(c controller.Context, code string) (res schema.Response) {
+	var (
+		err  error
+		data schema.Currency
+	)
+
+	defer func() {
+		if r := recover(); r != nil {
+			switch t := r.(type) {
+			case string:
+				err = errors.New(t)
+			case error:
+				err = t
+			default:
+				err = exception.Unknown
+			}
+		}
+
+		helper.Response(&res, data, err)
+	}()
+
+	currencyInfo := model.Currency{}
+
+	if err = database.Db.Where("code = ?", strings.ToUpper(code)).First(&currencyInfo).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			err = exception.CurrencyNotExist
+		}
+		return
+	}
+
+	data = mapToSchema(currencyInfo)
+
+	return
+}
+
+func GetListRouter(c *gin.Context) {
+	var (
+		err error
+		res = schema.Response{}
+	)
+
+	defer func() {
+		if err != nil {
+			res.Data = nil
+			res.Message = err.Error()
+		}
+		c.JSON(http.StatusOK, res)
+	}()
+
+	res = GetList(controller.Context{
+		Uid: c.GetString(middleware.ContextUidField),
+	})
+}
+
+func GetListByAdminRouter(c *gin.Context) {
+	var (
+		err error
+		res = schema.Response{}
+	)
+
+	defer func() {
+		if err != nil {
+			res.Data = nil
+			res.Message = err.Error()
+		}
+		c.JSON(http.StatusOK, res)
+	}()
+
+	res = GetListByAdmin(controller.Context{
+		Uid: c.GetString(middleware.ContextUidField),
+	})
+}
+
+func GetByAdminRouter(c *gin.Context) {
+	var (
+		err error
+		res = schema.Response{}
+	)
+
+	defer func() {
+		if err != nil {
+			res.Data = nil
+			res.Message = err.Error()
+		}
+		c.JSON(http.StatusOK, res)
+	}()
+
+	res = GetByAdmin(controller.Context{
+		Uid: c.GetString(middleware.ContextUidField),
+	}, c.Param("code"))
+}
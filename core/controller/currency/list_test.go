@@ -0,0 +1,58 @@
+// Copyright 2019 Axetroy. All rights reserved. MIT license.
+package currency_test
+
+import (
+	"github.com/axetroy/go-server/core/controller"
+	"github.com/axetroy/go-server/core/controller/currency"
+	"github.com/axetroy/go-server/core/schema"
+	"github.com/axetroy/go-server/tester"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestGetList(t *testing.T) {
+	adminInfo, _ := tester.LoginAdmin()
+
+	c := controller.Context{Uid: adminInfo.Id}
+
+	defer currency.DeleteCurrencyByCode("TEST")
+
+	enabled := false
+
+	assert.Equal(t, "", currency.Create(c, currency.CreateParams{
+		Code:      "TEST",
+		Name:      "测试币",
+		Precision: 2,
+		Enabled:   &enabled,
+	}).Message)
+
+	contains := func(list []schema.Currency, code string) bool {
+		for _, v := range list {
+			if v.Code == code {
+				return true
+			}
+		}
+		return false
+	}
+
+	// 用户只能看到启用的币种
+	{
+		r := currency.GetList(controller.Context{})
+		list := make([]schema.Currency, 0)
+
+		assert.Equal(t, "", r.Message)
+		assert.Nil(t, tester.Decode(r.Data, &list))
+		assert.False(t, contains(list, "TEST"))
+		assert.True(t, contains(list, "CNY"))
+	}
+
+	// 管理员可以看到所有币种
+	{
+		r := currency.GetListByAdmin(c)
+		list := make([]schema.Currency, 0)
+
+		assert.Equal(t, "", r.Message)
+		assert.Nil(t, tester.Decode(r.Data, &list))
+		assert.True(t, contains(list, "TEST"))
+	}
+}
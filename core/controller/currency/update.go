@@ -0,0 +1,166 @@
+// Copyright 2019 Axetroy. All rights reserved. MIT license.
+package currency
+
+import (
+	"errors"
+	"github.com/axetroy/go-server/core/controller"
+	"github.com/axetroy/go-server/core/exception"
+	"github.com/axetroy/go-server/core/helper"
+	"github.com/axetroy/go-server/core/middleware"
+	"github.com/axetroy/go-server/core/model"
+	"github.com/axetroy/go-server/core/schema"
+	"github.com/axetroy/go-server/core/service/currency"
+	"github.com/axetroy/go-server/core/service/database"
+	"github.com/axetroy/go-server/core/validator"
+	"github.com/gin-gonic/gin"
+	"github.com/jinzhu/gorm"
+	"log"
+	"net/http"
+	"strings"
+)
+
+type UpdateParams struct {
+	Name        *string `json:"name" valid:"length(1|32)~币种名称不能超过32个字符"` // 币种名称
+	Precision   *int    `json:"precision"`                               // 允许的小数位数, 0-8
+	Enabled     *bool   `json:"enabled"`                                 // 是否启用
+	TransferMin *string `json:"transfer_min"`                            // 单笔转账的最小金额, 0 则不限制
+	TransferMax *string `json:"transfer_max"`                            // 单笔转账的最大金额, 0 则不限制
+}
+
+// 修改币种
+// 修改小数位数只影响之后的金额校验, 不会修改已有的余额
+func Update(c controller.Context, code string, input UpdateParams) (res schema.Response) {
+	var (
+		err          error
+		data         schema.Currency
+		tx           *gorm.DB
+		shouldUpdate bool
+	)
+
+	defer func() {
+		if r := recover(); r != nil {
+			switch t := r.(type) {
+			case string:
+				err = errors.New(t)
+			case error:
+				err = t
+			default:
+				err = exception.Unknown
+			}
+		}
+
+		if tx != nil {
+			if err != nil {
+				_ = tx.Rollback().Error
+			} else {
+				err = tx.Commit().Error
+			}
+		}
+
+		if err == nil && shouldUpdate {
+			if er := currency.Invalidate(); er != nil {
+				log.Printf("通知币种缓存失效失败: %s\n", er)
+			}
+		}
+
+		helper.Response(&res, data, err)
+	}()
+
+	// 参数校验
+	if err = validator.ValidateStruct(input); err != nil {
+		return
+	}
+
+	tx = database.Db.Begin()
+
+	adminInfo := model.Admin{Id: c.Uid}
+
+	if err = tx.First(&adminInfo).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			err = exception.AdminNotExist
+		}
+		return
+	}
+
+	currencyInfo := model.Currency{}
+
+	if err = tx.Where("code = ?", strings.ToUpper(code)).First(&currencyInfo).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			err = exception.CurrencyNotExist
+		}
+		return
+	}
+
+	// 使用 map 更新, 否则 false 和 0 会被忽略
+	updated := map[string]interface{}{}
+
+	if input.Name != nil {
+		currencyInfo.Name = *input.Name
+		updated["name"] = currencyInfo.Name
+	}
+
+	if input.Precision != nil {
+		currencyInfo.Precision = *input.Precision
+		updated["precision"] = currencyInfo.Precision
+	}
+
+	if input.Enabled != nil {
+		currencyInfo.Enabled = *input.Enabled
+		updated["enabled"] = currencyInfo.Enabled
+	}
+
+	if input.TransferMin != nil {
+		if currencyInfo.TransferMin, err = parseLimit(*input.TransferMin, currencyInfo.Precision); err != nil {
+			return
+		}
+		updated["transfer_min"] = currencyInfo.TransferMin
+	}
+
+	if input.TransferMax != nil {
+		if currencyInfo.TransferMax, err = parseLimit(*input.TransferMax, currencyInfo.Precision); err != nil {
+			return
+		}
+		updated["transfer_max"] = currencyInfo.TransferMax
+	}
+
+	if err = validate(currencyInfo); err != nil {
+		return
+	}
+
+	if len(updated) > 0 {
+		shouldUpdate = true
+
+		if err = tx.Model(&currencyInfo).Updates(updated).Error; err != nil {
+			return
+		}
+	}
+
+	data = mapToSchema(currencyInfo)
+
+	return
+}
+
+func UpdateRouter(c *gin.Context) {
+	var (
+		input UpdateParams
+		err   error
+		res   = schema.Response{}
+	)
+
+	defer func() {
+		if err != nil {
+			res.Data = nil
+			res.Message = err.Error()
+		}
+		c.JSON(http.StatusOK, res)
+	}()
+
+	if err = c.ShouldBindJSON(&input); err != nil {
+		err = exception.InvalidParams
+		return
+	}
+
+	res = Update(controller.Context{
+		Uid: c.GetString(middleware.ContextUidField),
+	}, c.Param("code"), input)
+}
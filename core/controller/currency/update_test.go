@@ -0,0 +1,72 @@
+// Copyright 2019 Axetroy. All rights reserved. MIT license.
+package currency_test
+
+import (
+	"github.com/axetroy/go-server/core/controller"
+	"github.com/axetroy/go-server/core/controller/currency"
+	"github.com/axetroy/go-server/core/exception"
+	"github.com/axetroy/go-server/core/schema"
+	currencyService "github.com/axetroy/go-server/core/service/currency"
+	"github.com/axetroy/go-server/tester"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestUpdate(t *testing.T) {
+	adminInfo, _ := tester.LoginAdmin()
+
+	c := controller.Context{Uid: adminInfo.Id}
+
+	defer currency.DeleteCurrencyByCode("TEST")
+
+	assert.Equal(t, "", currency.Create(c, currency.CreateParams{
+		Code:      "TEST",
+		Name:      "测试币",
+		Precision: 2,
+	}).Message)
+
+	// 停用币种
+	{
+		enabled := false
+		max := "100"
+
+		r := currency.Update(c, "test", currency.UpdateParams{
+			Enabled:     &enabled,
+			TransferMax: &max,
+		})
+
+		assert.Equal(t, "", r.Message)
+
+		data := schema.Currency{}
+
+		assert.Nil(t, tester.Decode(r.Data, &data))
+		assert.False(t, data.Enabled)
+		assert.Equal(t, "100.00", data.TransferMax)
+
+		_, err := currencyService.GetEnabled("TEST")
+
+		assert.Equal(t, exception.CurrencyDisabled, err)
+	}
+
+	// 最小金额大于最大金额
+	{
+		min := "1000"
+
+		r := currency.Update(c, "TEST", currency.UpdateParams{
+			TransferMin: &min,
+		})
+
+		assert.Equal(t, exception.InvalidParams.Error(), r.Message)
+	}
+
+	// 不存在的币种
+	{
+		name := "不存在"
+
+		r := currency.Update(c, "NOTEXIST", currency.UpdateParams{
+			Name: &name,
+		})
+
+		assert.Equal(t, exception.CurrencyNotExist.Error(), r.Message)
+	}
+}
@@ -0,0 +1,64 @@
+// Copyright 2019 Axetroy. All rights reserved. MIT license.
+package currency
+
+import (
+	"github.com/axetroy/go-server/core/exception"
+	"github.com/axetroy/go-server/core/model"
+	"github.com/axetroy/go-server/core/schema"
+	"github.com/axetroy/go-server/core/service/currency"
+	"github.com/axetroy/go-server/core/service/database"
+	"github.com/axetroy/go-server/core/util"
+	"strings"
+	"time"
+)
+
+func DeleteCurrencyByCode(code string) {
+	b := model.Currency{}
+	database.DeleteRowByTable(b.TableName(), "code", strings.ToUpper(code))
+	_ = currency.Invalidate()
+}
+
+func mapToSchema(c model.Currency) (d schema.Currency) {
+	d.Code = c.Code
+	d.Name = c.Name
+	d.Precision = c.Precision
+	d.Enabled = c.Enabled
+	d.TransferMin = c.TransferMin.StringFixed(c.Precision)
+	d.TransferMax = c.TransferMax.StringFixed(c.Precision)
+	d.CreatedAt = c.CreatedAt.Format(time.RFC3339Nano)
+	d.UpdatedAt = c.UpdatedAt.Format(time.RFC3339Nano)
+	return
+}
+
+// 解析转账限额, 不能为负数, 并且不能超过币种的小数位数
+func parseLimit(s string, precision int) (d util.Decimal, err error) {
+	if d, err = util.ParseDecimal(s); err != nil {
+		err = exception.InvalidParams
+		return
+	}
+
+	if d.Sign() < 0 || d.Places() > precision {
+		err = exception.InvalidParams
+		return
+	}
+
+	return
+}
+
+// 检验币种的配置是否合法
+func validate(c model.Currency) error {
+	if c.Precision < 0 || c.Precision > model.MaxCurrencyPrecision {
+		return exception.InvalidParams
+	}
+
+	if c.TransferMin.Places() > c.Precision || c.TransferMax.Places() > c.Precision {
+		return exception.InvalidParams
+	}
+
+	// 同时设置了最小和最大限额时, 最小限额不能大于最大限额
+	if !c.TransferMin.IsZero() && !c.TransferMax.IsZero() && c.TransferMin.Cmp(c.TransferMax) > 0 {
+		return exception.InvalidParams
+	}
+
+	return nil
+}
@@ -0,0 +1,161 @@
+// Copyright 2019 Axetroy. All rights reserved. MIT license.
+package finance
+
+import (
+	"database/sql"
+	"encoding/csv"
+	"fmt"
+	"github.com/axetroy/go-server/core/controller"
+	"github.com/axetroy/go-server/core/exception"
+	"github.com/axetroy/go-server/core/middleware"
+	"github.com/axetroy/go-server/core/model"
+	"github.com/axetroy/go-server/core/schema"
+	"github.com/axetroy/go-server/core/service/currency"
+	"github.com/axetroy/go-server/core/service/database"
+	"github.com/axetroy/go-server/core/util"
+	"github.com/axetroy/go-server/core/validator"
+	"github.com/gin-gonic/gin"
+	"github.com/jinzhu/gorm"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+)
+
+var (
+	// 导出的 CSV 的表头
+	csvHeader = []string{
+		"id",
+		"currency",
+		"order_id",
+		"uid",
+		"type",
+		"before_balance",
+		"balance_mutation",
+		"after_balance",
+		"before_frozen",
+		"frozen_mutation",
+		"after_frozen",
+		"note",
+		"created_at",
+	}
+)
+
+// 导出财务日志为 CSV, 用于对账
+// 导出所有符合条件的数据, 忽略分页, 按照时间正序排列
+func ExportHistoryByAdmin(c controller.Context, input QueryByAdmin, w io.Writer) (err error) {
+	var (
+		db   *gorm.DB
+		uid  string
+		rows *sql.Rows
+	)
+
+	// 参数校验
+	if err = validator.ValidateStruct(input); err != nil {
+		return
+	}
+
+	if input.Uid != nil {
+		uid = *input.Uid
+	}
+
+	if db, err = filter(database.Db, uid, input.Query); err != nil {
+		return
+	}
+
+	if rows, err = db.Order("created_at ASC").Order("id ASC").Rows(); err != nil {
+		return
+	}
+
+	defer func() {
+		_ = rows.Close()
+	}()
+
+	writer := csv.NewWriter(w)
+
+	if err = writer.Write(csvHeader); err != nil {
+		return
+	}
+
+	for rows.Next() {
+		log := model.FinanceLog{}
+
+		if err = db.ScanRows(rows, &log); err != nil {
+			return
+		}
+
+		d := mapToSchema(log)
+
+		note := ""
+
+		// 备注是用户输入的内容, 需要防止 CSV 注入
+		if d.Note != nil {
+			note = util.EscapeCSVCell(*d.Note)
+		}
+
+		if err = writer.Write([]string{
+			d.Id,
+			d.Currency,
+			d.OrderId,
+			d.Uid,
+			string(d.Type),
+			d.BeforeBalance,
+			d.BalanceMutation,
+			d.AfterBalance,
+			d.BeforeFrozen,
+			d.FrozenMutation,
+			d.AfterFrozen,
+			note,
+			d.CreatedAt,
+		}); err != nil {
+			return
+		}
+	}
+
+	writer.Flush()
+
+	return writer.Error()
+}
+
+func ExportHistoryByAdminRouter(c *gin.Context) {
+	var (
+		err   error
+		input QueryByAdmin
+	)
+
+	if err = c.ShouldBindQuery(&input); err != nil {
+		c.JSON(http.StatusOK, schema.Response{
+			Status:  exception.InvalidParams.Code(),
+			Message: exception.InvalidParams.Error(),
+		})
+		return
+	}
+
+	defer func() {
+		// 还没有开始写入数据的话, 返回错误信息
+		if err != nil && !c.Writer.Written() {
+			c.Header("Content-Type", "")
+			c.Header("Content-Disposition", "")
+			c.JSON(http.StatusOK, schema.Response{
+				Status:  exception.GetCodeFromError(err),
+				Message: err.Error(),
+			})
+		}
+	}()
+
+	var currencyInfo model.Currency
+
+	// 文件名使用校验过的币种代码, 不直接使用用户的输入
+	if currencyInfo, err = currency.Get(input.Currency); err != nil {
+		return
+	}
+
+	filename := fmt.Sprintf("finance_log_%s_%s.csv", strings.ToLower(currencyInfo.Code), time.Now().Format("20060102150405"))
+
+	c.Header("Content-Type", "text/csv; charset=utf-8")
+	c.Header("Content-Disposition", "attachment; filename="+filename)
+
+	err = ExportHistoryByAdmin(controller.Context{
+		Uid: c.GetString(middleware.ContextUidField),
+	}, input, c.Writer)
+}
@@ -2,168 +2,192 @@
 package finance
 
 import (
+	"errors"
+	"github.com/axetroy/go-server/core/controller"
+	"github.com/axetroy/go-server/core/exception"
+	"github.com/axetroy/go-server/core/helper"
+	"github.com/axetroy/go-server/core/middleware"
+	"github.com/axetroy/go-server/core/model"
+	"github.com/axetroy/go-server/core/schema"
+	"github.com/axetroy/go-server/core/service/database"
+	"github.com/axetroy/go-server/core/validator"
 	"github.com/gin-gonic/gin"
+	"github.com/jinzhu/gorm"
+	"net/http"
 )
 
-func GetHistory(c *gin.Context) {
-	//var (
-	//	err     error
-	//	session *xorm.Session
-	//	tx      bool
-	//	data    []Log
-	//	meta    = response.Meta{}
-	//	query   = request.Query{}
-	//)
-	//
-	//defer func() {
-	//	if r := recover(); r != nil {
-	//		switch t := r.(type) {
-	//		case string:
-	//			err = errors.New(t)
-	//		case error:
-	//			err = t
-	//		default:
-	//			err = exception.Unknown
-	//		}
-	//	}
-	//
-	//	if tx {
-	//		if err != nil {
-	//			_ = session.Rollback()
-	//		} else {
-	//			err = session.Commit()
-	//		}
-	//	}
-	//
-	//	if session != nil {
-	//		session.Close()
-	//	}
-	//
-	//	if err != nil {
-	//		c.JSON(http.StatusOK, response.Response{
-	//			Status:  response.StatusFail,
-	//			Message: err.Error(),
-	//			Data:    nil,
-	//		})
-	//	} else {
-	//		c.JSON(http.StatusOK, response.List{
-	//			Response: response.Response{
-	//				Status:  response.StatusSuccess,
-	//				Message: "",
-	//				Data:    data,
-	//			},
-	//			Meta: &meta,
-	//		})
-	//	}
-	//}()
-	//
-	//uid := context.GetInt64("uid")
-	//
-	//if err = c.ShouldBindQuery(&query); err != nil {
-	//	return
-	//}
-	//
-	//query.Normalize()
-	//
-	//session = orm.Db.NewSession()
-	//
-	//if err = session.Begin(); err != nil {
-	//	return
-	//}
-	//
-	//tx = true
-	//
-	//keys := mapStr(structs.Names(&model.FinanceLog{}), func(s string) string {
-	//	return strcase.ToSnake(s)
-	//})
-	//
-	//seleted := strings.Join(keys[:], ",")
-	//
-	//sql := GenerateSql(uid, seleted)
-	//
-	//session = session.SQL(sql + fmt.Sprintf(" LIMIT %v", query.Limit))
-	//
-	//if res, er := session.QueryInterface(); er != nil {
-	//	err = er
-	//	return
-	//} else {
-	//	var (
-	//		length = len(res)
-	//		total  int64
-	//	)
-	//
-	//	meta.Num = length
-	//	meta.Page = query.Page
-	//	meta.Limit = query.Limit
-	//	meta.Sort = query.Sort
-	//	meta.Platform = query.Platform
-	//
-	//	// 如果查出来的总数
-	//	if length >= query.Limit {
-	//		// 统计总数
-	//		countSql := GenerateSql(uid, "COUNT(*)")
-	//
-	//		if total, err = session.SQL(countSql).Count(); err != nil {
-	//			return
-	//		}
-	//
-	//		meta.Total = total
-	//	} else {
-	//		meta.Total = int64(length)
-	//	}
-	//
-	//	data = make([]Log, 0)
-	//
-	//	for _, v := range res {
-	//		log := Log{}
-	//		if err = mapstructure.Decode(v, &log); err != nil {
-	//			return
-	//		}
-	//
-	//		createdAt := v["created_at"].(time.Time)
-	//		updatedAt := v["updated_at"].(time.Time)
-	//
-	//		// balance
-	//		log.BeforeBalance = v["before_balance"].(float64)
-	//		log.BalanceMutation = v["balance_mutation"].(float64)
-	//		log.AfterBalance = v["after_balance"].(float64)
-	//
-	//		// frozen
-	//		log.BeforeFrozen = v["before_frozen"].(float64)
-	//		log.FrozenMutation = v["frozen_mutation"].(float64)
-	//		log.AfterFrozen = v["after_frozen"].(float64)
-	//
-	//		log.CreatedAt = createdAt.Format(time.RFC3339Nano)
-	//		log.UpdatedAt = updatedAt.Format(time.RFC3339Nano)
-	//		data = append(data, log)
-	//	}
-	//}
+type Query struct {
+	schema.Query
+	Currency  string             `json:"currency" form:"currency" valid:"required~请选择币种"` // 币种
+	Type      *model.FinanceType `json:"type" form:"type"`                                // 流水类型
+	OrderId   *string            `json:"order_id" form:"order_id"`                        // 对应的订单ID
+	StartAt   *string            `json:"start_at" form:"start_at"`                        // 开始时间, RFC3339 格式
+	EndAt     *string            `json:"end_at" form:"end_at"`                            // 结束时间, RFC3339 格式
+	MinAmount *string            `json:"min_amount" form:"min_amount"`                    // 最小变动金额
+	MaxAmount *string            `json:"max_amount" form:"max_amount"`                    // 最大变动金额
 }
 
-//func GenerateSql(UidField int64, selected string) string {
-//	suffix := `("deleted_at" IS NULL OR "deleted_at"='0001-01-01 00:00:00')`
-//
-//	tables := []string{"finance_log_cny", "finance_log_usd", "finance_log_coin"}
-//
-//	sqlList := make([]string, 0)
-//
-//	if selected == "*" {
-//		selected = "uid"
-//	}
-//
-//	for _, tableName := range tables {
-//		sql := fmt.Sprintf(`SELECT %v FROM "%v" WHERE "uid"=%v AND %v`, selected, tableName, UidField, suffix)
-//		sqlList = append(sqlList, sql)
-//	}
-//
-//	sql := strings.Join(sqlList[:], " UNION ")
-//	return sql
-//}
-//
-//func mapStr(vs []string, f func(string) string) []string {
-//	vsm := make([]string, len(vs))
-//	for i, v := range vs {
-//		vsm[i] = f(v)
-//	}
-//	return vsm
-//}
+type QueryByAdmin struct {
+	Query
+	Uid *string `json:"uid" form:"uid"` // 要查询的用户, 不填则查询所有用户
+}
+
+func getHistory(uid string, input Query) (data []schema.FinanceLog, meta *schema.Meta, err error) {
+	var (
+		db    *gorm.DB
+		list  = make([]model.FinanceLog, 0)
+		total int64
+	)
+
+	data = make([]schema.FinanceLog, 0)
+	meta = &schema.Meta{}
+
+	// 参数校验
+	if err = validator.ValidateStruct(input); err != nil {
+		return
+	}
+
+	query := input.Query
+
+	query.Normalize()
+
+	if !validSort(query) {
+		err = exception.InvalidParams
+		return
+	}
+
+	if db, err = filter(database.Db, uid, input); err != nil {
+		return
+	}
+
+	if err = db.Count(&total).Error; err != nil {
+		return
+	}
+
+	if err = query.Order(db.Limit(query.Limit).Offset(query.Limit * query.Page)).Find(&list).Error; err != nil {
+		return
+	}
+
+	for _, v := range list {
+		data = append(data, mapToSchema(v))
+	}
+
+	meta.Total = total
+	meta.Num = len(data)
+	meta.Page = query.Page
+	meta.Limit = query.Limit
+	meta.Sort = query.Sort
+
+	return
+}
+
+// 获取我的财务日志
+func GetHistory(c controller.Context, input Query) (res schema.List) {
+	var (
+		err  error
+		data = make([]schema.FinanceLog, 0)
+		meta = &schema.Meta{}
+	)
+
+	defer func() {
+		if r := recover(); r != nil {
+			switch t := r.(type) {
+			case string:
+				err = errors.New(t)
+			case error:
+				err = t
+			default:
+				err = exception.Unknown
+			}
+		}
+
+		helper.ResponseList(&res, data, meta, err)
+	}()
+
+	data, meta, err = getHistory(c.Uid, input)
+
+	return
+}
+
+// 管理员获取财务日志, 可以查询任意用户
+func GetHistoryByAdmin(c controller.Context, input QueryByAdmin) (res schema.List) {
+	var (
+		err  error
+		data = make([]schema.FinanceLog, 0)
+		meta = &schema.Meta{}
+		uid  string
+	)
+
+	defer func() {
+		if r := recover(); r != nil {
+			switch t := r.(type) {
+			case string:
+				err = errors.New(t)
+			case error:
+				err = t
+			default:
+				err = exception.Unknown
+			}
+		}
+
+		helper.ResponseList(&res, data, meta, err)
+	}()
+
+	if input.Uid != nil {
+		uid = *input.Uid
+	}
+
+	data, meta, err = getHistory(uid, input.Query)
+
+	return
+}
+
+func GetHistoryRouter(c *gin.Context) {
+	var (
+		err   error
+		res   = schema.List{}
+		input Query
+	)
+
+	defer func() {
+		if err != nil {
+			res.Data = nil
+			res.Message = err.Error()
+		}
+		c.JSON(http.StatusOK, res)
+	}()
+
+	if err = c.ShouldBindQuery(&input); err != nil {
+		err = exception.InvalidParams
+		return
+	}
+
+	res = GetHistory(controller.Context{
+		Uid: c.GetString(middleware.ContextUidField),
+	}, input)
+}
+
+func GetHistoryByAdminRouter(c *gin.Context) {
+	var (
+		err   error
+		res   = schema.List{}
+		input QueryByAdmin
+	)
+
+	defer func() {
+		if err != nil {
+			res.Data = nil
+			res.Message = err.Error()
+		}
+		c.JSON(http.StatusOK, res)
+	}()
+
+	if err = c.ShouldBindQuery(&input); err != nil {
+		err = exception.InvalidParams
+		return
+	}
+
+	res = GetHistoryByAdmin(controller.Context{
+		Uid: c.GetString(middleware.ContextUidField),
+	}, input)
+}
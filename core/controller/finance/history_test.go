@@ -0,0 +1,165 @@
+// Copyright 2019 Axetroy. All rights reserved. MIT license.
+package finance_test
+
+import (
+	"bytes"
+	"encoding/csv"
+	"github.com/axetroy/go-server/core/controller"
+	"github.com/axetroy/go-server/core/controller/auth"
+	"github.com/axetroy/go-server/core/controller/finance"
+	"github.com/axetroy/go-server/core/exception"
+	"github.com/axetroy/go-server/core/model"
+	"github.com/axetroy/go-server/core/schema"
+	"github.com/axetroy/go-server/core/service/database"
+	"github.com/axetroy/go-server/core/service/wallet"
+	"github.com/axetroy/go-server/core/util"
+	"github.com/axetroy/go-server/tester"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+// 给用户生成几条财务日志
+func prepare(t *testing.T, uid string, amounts ...string) {
+	for _, amount := range amounts {
+		tx := database.Db.Begin()
+
+		_, err := wallet.Apply(tx, model.WalletCNY, "order-"+amount, wallet.Mutation{
+			Uid:     uid,
+			Balance: util.MustParseDecimal(amount),
+			Type:    model.FinanceTypeTransferIn,
+		})
+
+		assert.Nil(t, err)
+		assert.Nil(t, tx.Commit().Error)
+	}
+}
+
+func TestGetHistory(t *testing.T) {
+	userInfo, _ := tester.CreateUser()
+
+	defer auth.DeleteUserByUserName(userInfo.Username)
+
+	prepare(t, userInfo.Id, "10", "20", "30")
+
+	context := controller.Context{Uid: userInfo.Id}
+
+	// 获取全部
+	{
+		r := finance.GetHistory(context, finance.Query{Currency: "cny"})
+
+		assert.Equal(t, "", r.Message)
+		assert.Equal(t, schema.StatusSuccess, r.Status)
+		assert.Equal(t, int64(3), r.Meta.Total)
+
+		list := make([]schema.FinanceLog, 0)
+
+		assert.Nil(t, tester.Decode(r.Data, &list))
+		assert.Len(t, list, 3)
+
+		// 默认按照时间倒序
+		assert.Equal(t, "30.00000000", list[0].BalanceMutation)
+		assert.Equal(t, "30.00000000", list[0].BeforeBalance)
+		assert.Equal(t, "60.00000000", list[0].AfterBalance)
+	}
+
+	// 按照金额筛选
+	{
+		min := "15"
+		max := "25"
+
+		r := finance.GetHistory(context, finance.Query{Currency: "CNY", MinAmount: &min, MaxAmount: &max})
+
+		list := make([]schema.FinanceLog, 0)
+
+		assert.Nil(t, tester.Decode(r.Data, &list))
+		assert.Len(t, list, 1)
+		assert.Equal(t, "20.00000000", list[0].BalanceMutation)
+	}
+
+	// 按照订单筛选
+	{
+		orderId := "order-10"
+
+		r := finance.GetHistory(context, finance.Query{Currency: "CNY", OrderId: &orderId})
+
+		assert.Equal(t, int64(1), r.Meta.Total)
+	}
+
+	// 按照类型筛选
+	{
+		financeType := model.FinanceTypeTransferOut
+
+		r := finance.GetHistory(context, finance.Query{Currency: "CNY", Type: &financeType})
+
+		assert.Equal(t, int64(0), r.Meta.Total)
+	}
+
+	// 按照时间筛选
+	{
+		startAt := "2000-01-01T00:00:00Z"
+		endAt := "2001-01-01T00:00:00Z"
+
+		r := finance.GetHistory(context, finance.Query{Currency: "CNY", StartAt: &startAt, EndAt: &endAt})
+
+		assert.Equal(t, int64(0), r.Meta.Total)
+	}
+
+	// 无效的币种
+	{
+		r := finance.GetHistory(context, finance.Query{Currency: "BTC"})
+
+		assert.Equal(t, exception.InvalidWallet.Error(), r.Message)
+	}
+
+	// 不允许的排序字段
+	{
+		q := finance.Query{Currency: "CNY"}
+		q.Sort = "uid; DROP TABLE finance_log"
+
+		r := finance.GetHistory(context, q)
+
+		assert.Equal(t, exception.InvalidParams.Error(), r.Message)
+	}
+}
+
+func TestGetHistoryByAdmin(t *testing.T) {
+	adminInfo, _ := tester.LoginAdmin()
+	userInfo, _ := tester.CreateUser()
+
+	defer auth.DeleteUserByUserName(userInfo.Username)
+
+	prepare(t, userInfo.Id, "10", "20")
+
+	r := finance.GetHistoryByAdmin(controller.Context{Uid: adminInfo.Id}, finance.QueryByAdmin{
+		Query: finance.Query{Currency: "CNY"},
+		Uid:   &userInfo.Id,
+	})
+
+	assert.Equal(t, "", r.Message)
+	assert.Equal(t, int64(2), r.Meta.Total)
+}
+
+func TestExportHistoryByAdmin(t *testing.T) {
+	adminInfo, _ := tester.LoginAdmin()
+	userInfo, _ := tester.CreateUser()
+
+	defer auth.DeleteUserByUserName(userInfo.Username)
+
+	prepare(t, userInfo.Id, "10", "20")
+
+	buf := &bytes.Buffer{}
+
+	assert.Nil(t, finance.ExportHistoryByAdmin(controller.Context{Uid: adminInfo.Id}, finance.QueryByAdmin{
+		Query: finance.Query{Currency: "CNY"},
+		Uid:   &userInfo.Id,
+	}, buf))
+
+	records, err := csv.NewReader(buf).ReadAll()
+
+	assert.Nil(t, err)
+	assert.Len(t, records, 3)
+	assert.Equal(t, "id", records[0][0])
+	assert.Equal(t, userInfo.Id, records[1][3])
+	assert.Equal(t, "10.00000000", records[1][6])
+	assert.Equal(t, "20.00000000", records[2][6])
+}
@@ -0,0 +1,96 @@
+// Copyright 2019 Axetroy. All rights reserved. MIT license.
+package finance
+
+import (
+	"errors"
+	"github.com/axetroy/go-server/core/controller"
+	"github.com/axetroy/go-server/core/exception"
+	"github.com/axetroy/go-server/core/helper"
+	"github.com/axetroy/go-server/core/middleware"
+	"github.com/axetroy/go-server/core/model"
+	"github.com/axetroy/go-server/core/schema"
+	"github.com/axetroy/go-server/core/service/database"
+	"github.com/axetroy/go-server/core/service/reconcile"
+	"github.com/gin-gonic/gin"
+	"github.com/jinzhu/gorm"
+	"net/http"
+)
+
+type ReconcileParams struct {
+	Currency *string `json:"currency"` // 要对账的币种, 不填则对账所有币种
+	Uid      *string `json:"uid"`      // 只对账指定的用户, 不填则对账所有用户
+	Alert    bool    `json:"alert"`    // 发现问题时是否通过消息队列发送告警
+}
+
+// 管理员发起对账, 检查钱包的余额与财务日志是否一致
+func ReconcileByAdmin(c controller.Context, input ReconcileParams) (res schema.Response) {
+	var (
+		err  error
+		data = make([]reconcile.Report, 0)
+	)
+
+	defer func() {
+		if r := recover(); r != nil {
+			switch t := r.(type) {
+			case string:
+				err = errors.New(t)
+			case error:
+				err = t
+			default:
+				err = exception.Unknown
+			}
+		}
+
+		helper.Response(&res, data, err)
+	}()
+
+	adminInfo := model.Admin{Id: c.Uid}
+
+	if err = database.Db.First(&adminInfo).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			err = exception.AdminNotExist
+		}
+		return
+	}
+
+	options := reconcile.Options{
+		Alert: input.Alert,
+	}
+
+	if input.Currency != nil {
+		options.Currencies = []string{*input.Currency}
+	}
+
+	if input.Uid != nil {
+		options.Uid = *input.Uid
+	}
+
+	data, err = reconcile.Run(options)
+
+	return
+}
+
+func ReconcileByAdminRouter(c *gin.Context) {
+	var (
+		err   error
+		res   = schema.Response{}
+		input ReconcileParams
+	)
+
+	defer func() {
+		if err != nil {
+			res.Data = nil
+			res.Message = err.Error()
+		}
+		c.JSON(http.StatusOK, res)
+	}()
+
+	if err = c.ShouldBindJSON(&input); err != nil {
+		err = exception.InvalidParams
+		return
+	}
+
+	res = ReconcileByAdmin(controller.Context{
+		Uid: c.GetString(middleware.ContextUidField),
+	}, input)
+}
@@ -0,0 +1,375 @@
+// Copyright 2019 Axetroy. All rights reserved. MIT license.
+package finance
+
+import (
+	"errors"
+	"fmt"
+	"github.com/axetroy/go-server/core/config"
+	"github.com/axetroy/go-server/core/controller"
+	"github.com/axetroy/go-server/core/exception"
+	"github.com/axetroy/go-server/core/helper"
+	"github.com/axetroy/go-server/core/logger"
+	"github.com/axetroy/go-server/core/message_queue"
+	"github.com/axetroy/go-server/core/model"
+	"github.com/axetroy/go-server/core/schema"
+	"github.com/axetroy/go-server/core/service/currency"
+	"github.com/axetroy/go-server/core/service/database"
+	"github.com/axetroy/go-server/core/service/redis"
+	"github.com/axetroy/go-server/core/service/statement"
+	"github.com/axetroy/go-server/core/validator"
+	"github.com/gin-gonic/gin"
+	"github.com/jinzhu/gorm"
+	"net/http"
+	"time"
+)
+
+type StatementQuery struct {
+	Period string           `json:"period" form:"period" valid:"required~请选择月份"` // 对账单的月份, 例如 2019-08
+	Format statement.Format `json:"format" form:"format"`                        // 对账单的格式, csv 或者 pdf, 预览时不需要
+}
+
+var (
+	statementBatchSize    = 100              // 每次查询的用户数量
+	StatementSendCooldown = time.Minute * 10 // 同一个用户同一个月份的对账单重新发送的间隔
+)
+
+// 校验对账单的月份, 不能是还没开始的月份
+func validPeriod(period string) error {
+	start, _, err := statement.ParsePeriod(period)
+
+	if err != nil {
+		return err
+	}
+
+	if start.After(time.Now()) {
+		return exception.StatementInvalidPeriod
+	}
+
+	return nil
+}
+
+// 预览我的对账单
+func GetStatement(c controller.Context, input StatementQuery) (res schema.Response) {
+	var (
+		err  error
+		data = make([]statement.Statement, 0)
+	)
+
+	defer func() {
+		if r := recover(); r != nil {
+			switch t := r.(type) {
+			case string:
+				err = errors.New(t)
+			case error:
+				err = t
+			default:
+				err = exception.Unknown
+			}
+		}
+
+		helper.Response(&res, data, err)
+	}()
+
+	// 参数校验
+	if err = validator.ValidateStruct(input); err != nil {
+		return
+	}
+
+	if err = validPeriod(input.Period); err != nil {
+		return
+	}
+
+	data, err = statement.Build(database.Db, c.Uid, input.Period)
+
+	return
+}
+
+// 下载我的对账单
+func DownloadStatement(c controller.Context, input StatementQuery) (attachment *statement.Attachment, err error) {
+	var list []statement.Statement
+
+	// 参数校验
+	if err = validator.ValidateStruct(input); err != nil {
+		return
+	}
+
+	if err = input.Format.Validate(); err != nil {
+		return
+	}
+
+	if err = validPeriod(input.Period); err != nil {
+		return
+	}
+
+	if list, err = statement.Build(database.Db, c.Uid, input.Period); err != nil {
+		return
+	}
+
+	return statement.Render(input.Format, input.Period, list)
+}
+
+// 把我的对账单发送到绑定的邮箱
+// 对账单在消息队列中生成并发送
+func SendStatement(c controller.Context, input StatementQuery) (res schema.Response) {
+	var (
+		err error
+	)
+
+	defer func() {
+		if r := recover(); r != nil {
+			switch t := r.(type) {
+			case string:
+				err = errors.New(t)
+			case error:
+				err = t
+			default:
+				err = exception.Unknown
+			}
+		}
+
+		helper.Response(&res, nil, err)
+	}()
+
+	// 参数校验
+	if err = validator.ValidateStruct(input); err != nil {
+		return
+	}
+
+	if err = input.Format.Validate(); err != nil {
+		return
+	}
+
+	if err = validPeriod(input.Period); err != nil {
+		return
+	}
+
+	user := model.User{Id: c.Uid}
+
+	if err = database.Db.First(&user).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			err = exception.UserNotExist
+		}
+		return
+	}
+
+	if user.Email == nil || *user.Email == "" {
+		err = exception.StatementRequireEmail
+		return
+	}
+
+	// 每次发送都要生成对账单并发送邮件, 限制发送的频率
+	cooldownKey := fmt.Sprintf("statement:%s:%s", c.Uid, input.Period)
+
+	var ok bool
+
+	if ok, err = redis.Client.SetNX(cooldownKey, 1, StatementSendCooldown).Result(); err != nil {
+		return
+	}
+
+	if !ok {
+		err = exception.StatementTooFrequent
+		return
+	}
+
+	if err = message_queue.PublishSendStatement(message_queue.SendStatementBody{
+		Uid:    c.Uid,
+		Period: input.Period,
+		Format: input.Format,
+	}); err != nil {
+		// 没有加入队列的话, 允许马上重新发送
+		_ = redis.Client.Del(cooldownKey).Err()
+		return
+	}
+
+	return
+}
+
+// 发送某个月的月度对账单
+// 发送给在这个月结束之前有过财务日志, 并且绑定了邮箱的用户. 每个用户每个月只会发送一次
+func SendMonthlyStatements(period string, format statement.Format) (count int, err error) {
+	var (
+		end   time.Time
+		codes []string
+	)
+
+	if _, end, err = statement.ParsePeriod(period); err != nil {
+		return
+	}
+
+	if codes, err = currency.Codes(); err != nil {
+		return
+	}
+
+	uidMap := map[string]bool{}
+
+	for _, code := range codes {
+		uids := make([]string, 0)
+
+		if err = model.FinanceLogOf(database.Db, code).Where("created_at < ?", end).Pluck("DISTINCT uid", &uids).Error; err != nil {
+			return
+		}
+
+		for _, uid := range uids {
+			uidMap[uid] = true
+		}
+	}
+
+	uids := make([]string, 0, len(uidMap))
+
+	for uid := range uidMap {
+		uids = append(uids, uid)
+	}
+
+	for i := 0; i < len(uids); i += statementBatchSize {
+		batch := uids[i:]
+
+		if len(batch) > statementBatchSize {
+			batch = batch[:statementBatchSize]
+		}
+
+		users := make([]string, 0)
+
+		if err = database.Db.Model(&model.User{}).
+			Where("id IN (?) AND email IS NOT NULL AND email != ''", batch).
+			Where("id NOT IN (?)", database.Db.Model(&model.Statement{}).Select("uid").Where("period = ?", period).SubQuery()).
+			Pluck("id", &users).Error; err != nil {
+			return
+		}
+
+		for _, uid := range users {
+			record := model.Statement{
+				Uid:    uid,
+				Period: period,
+				Format: string(format),
+				Status: model.StatementStatusPending,
+			}
+
+			if err = database.Db.Create(&record).Error; err != nil {
+				// 唯一索引冲突说明已经被其他进程发送了
+				if database.IsUniqueViolation(err) {
+					err = nil
+					continue
+				}
+				return
+			}
+
+			if err = message_queue.PublishSendStatement(message_queue.SendStatementBody{
+				Id:     record.Id,
+				Uid:    uid,
+				Period: period,
+				Format: format,
+			}); err != nil {
+				// 没有加入队列的话, 删除记录, 下次再发送
+				_ = database.Db.Delete(&record).Error
+				return
+			}
+
+			count = count + 1
+		}
+	}
+
+	return
+}
+
+// 定时发送上个月的月度对账单
+func RunStatementWorker(interval time.Duration) {
+	format := statement.Format(config.Statement.Format)
+
+	if err := format.Validate(); err != nil {
+		logger.Errorf("invalid statement format: %s", format)
+		return
+	}
+
+	ticker := time.NewTicker(interval)
+
+	defer ticker.Stop()
+
+	for range ticker.C {
+		if config.Common.Exiting {
+			return
+		}
+
+		period := statement.LastPeriod(time.Now())
+
+		count, err := SendMonthlyStatements(period, format)
+
+		if err != nil {
+			logger.Errorf("queue statements of %s failed: %s", period, err)
+		}
+
+		if count > 0 {
+			logger.Infof("%d statements of %s queued", count, period)
+		}
+	}
+}
+
+func GetStatementRouter(c *gin.Context) {
+	var (
+		err   error
+		res   = schema.Response{}
+		input StatementQuery
+	)
+
+	defer func() {
+		if err != nil {
+			res.Data = nil
+			res.Message = err.Error()
+		}
+		c.JSON(http.StatusOK, res)
+	}()
+
+	if err = c.ShouldBindQuery(&input); err != nil {
+		err = exception.InvalidParams
+		return
+	}
+
+	res = GetStatement(controller.NewContext(c), input)
+}
+
+func DownloadStatementRouter(c *gin.Context) {
+	var (
+		err        error
+		input      StatementQuery
+		attachment *statement.Attachment
+	)
+
+	if err = c.ShouldBindQuery(&input); err != nil {
+		err = exception.InvalidParams
+	} else {
+		attachment, err = DownloadStatement(controller.NewContext(c), input)
+	}
+
+	if err != nil {
+		c.JSON(http.StatusOK, schema.Response{
+			Status:  exception.GetCodeFromError(err),
+			Message: err.Error(),
+		})
+		return
+	}
+
+	c.Header("Content-Disposition", fmt.Sprintf("attachment; filename=%s", attachment.Filename))
+	c.Data(http.StatusOK, attachment.ContentType, attachment.Content)
+}
+
+func SendStatementRouter(c *gin.Context) {
+	var (
+		err   error
+		res   = schema.Response{}
+		input StatementQuery
+	)
+
+	defer func() {
+		if err != nil {
+			res.Data = nil
+			res.Message = err.Error()
+		}
+		c.JSON(http.StatusOK, res)
+	}()
+
+	if err = c.ShouldBind(&input); err != nil {
+		err = exception.InvalidParams
+		return
+	}
+
+	res = SendStatement(controller.NewContext(c), input)
+}
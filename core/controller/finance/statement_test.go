@@ -0,0 +1,74 @@
+// Copyright 2019 Axetroy. All rights reserved. MIT license.
+package finance_test
+
+import (
+	"github.com/axetroy/go-server/core/controller"
+	"github.com/axetroy/go-server/core/controller/auth"
+	"github.com/axetroy/go-server/core/controller/finance"
+	"github.com/axetroy/go-server/core/exception"
+	"github.com/axetroy/go-server/core/model"
+	"github.com/axetroy/go-server/core/service/statement"
+	"github.com/axetroy/go-server/tester"
+	"github.com/stretchr/testify/assert"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestGetStatement(t *testing.T) {
+	userInfo, _ := tester.CreateUser()
+
+	defer auth.DeleteUserByUserName(userInfo.Username)
+
+	prepare(t, userInfo.Id, "10", "20")
+
+	context := controller.Context{Uid: userInfo.Id}
+
+	period := time.Now().Format(statement.PeriodLayout)
+
+	// 预览本月的对账单
+	{
+		r := finance.GetStatement(context, finance.StatementQuery{Period: period})
+
+		assert.Equal(t, "", r.Message)
+
+		list := make([]statement.Statement, 0)
+
+		assert.Nil(t, tester.Decode(r.Data, &list))
+		assert.Len(t, list, 1)
+		assert.Equal(t, model.WalletCNY, list[0].Currency)
+		assert.Equal(t, "0", list[0].OpeningBalance.String())
+		assert.Equal(t, "30", list[0].ClosingBalance.String())
+		assert.Equal(t, "30", list[0].Income.String())
+		assert.Len(t, list[0].Items, 2)
+	}
+
+	// 下个月的对账单还不能生成
+	{
+		r := finance.GetStatement(context, finance.StatementQuery{Period: time.Now().AddDate(0, 1, 0).Format(statement.PeriodLayout)})
+
+		assert.Equal(t, exception.StatementInvalidPeriod.Error(), r.Message)
+	}
+
+	// 下载 CSV 格式的对账单
+	{
+		attachment, err := finance.DownloadStatement(context, finance.StatementQuery{Period: period, Format: statement.FormatCSV})
+
+		assert.Nil(t, err)
+		assert.Len(t, strings.Split(strings.TrimSpace(string(attachment.Content)), "\n"), 5)
+	}
+
+	// 无效的格式
+	{
+		_, err := finance.DownloadStatement(context, finance.StatementQuery{Period: period, Format: "xls"})
+
+		assert.Equal(t, exception.StatementInvalidFormat, err)
+	}
+
+	// 没有绑定邮箱不能发送
+	{
+		r := finance.SendStatement(context, finance.StatementQuery{Period: period, Format: statement.FormatPDF})
+
+		assert.Equal(t, exception.StatementRequireEmail.Error(), r.Message)
+	}
+}
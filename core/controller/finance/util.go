@@ -1,8 +1,117 @@
 // Copyright 2019 Axetroy. All rights reserved. MIT license.
 package finance
 
-import "strings"
+import (
+	"time"
 
-func GetTableName(currency string) string {
-	return "finance_log_" + strings.ToLower(currency)
+	"github.com/axetroy/go-server/core/exception"
+	"github.com/axetroy/go-server/core/model"
+	"github.com/axetroy/go-server/core/schema"
+	"github.com/axetroy/go-server/core/service/currency"
+	"github.com/axetroy/go-server/core/util"
+	"github.com/jinzhu/gorm"
+)
+
+var (
+	// 允许排序的字段
+	sortFields = map[string]bool{
+		"created_at":       true,
+		"balance_mutation": true,
+		"frozen_mutation":  true,
+		"type":             true,
+	}
+)
+
+func mapToSchema(log model.FinanceLog) (d schema.FinanceLog) {
+	d.Id = log.Id
+	d.Currency = log.Currency
+	d.OrderId = log.OrderId
+	d.Uid = log.Uid
+	d.BeforeBalance = log.BeforeBalance.StringFixed(8)
+	d.BalanceMutation = log.BalanceMutation.StringFixed(8)
+	d.AfterBalance = log.AfterBalance.StringFixed(8)
+	d.BeforeFrozen = log.BeforeFrozen.StringFixed(8)
+	d.FrozenMutation = log.FrozenMutation.StringFixed(8)
+	d.AfterFrozen = log.AfterFrozen.StringFixed(8)
+	d.Type = log.Type
+	d.Note = log.Note
+	d.CreatedAt = log.CreatedAt.Format(time.RFC3339Nano)
+	d.UpdatedAt = log.UpdatedAt.Format(time.RFC3339Nano)
+	return
+}
+
+// 根据筛选条件生成查询, uid 为空则查询所有用户
+func filter(db *gorm.DB, uid string, input Query) (*gorm.DB, error) {
+	c, err := currency.Get(input.Currency)
+
+	if err != nil {
+		return nil, err
+	}
+
+	db = model.FinanceLogOf(db, c.Code)
+
+	if uid != "" {
+		db = db.Where("uid = ?", uid)
+	}
+
+	if input.Type != nil {
+		db = db.Where("type = ?", *input.Type)
+	}
+
+	if input.OrderId != nil {
+		db = db.Where("order_id = ?", *input.OrderId)
+	}
+
+	if input.StartAt != nil {
+		t, err := time.Parse(time.RFC3339Nano, *input.StartAt)
+
+		if err != nil {
+			return nil, exception.InvalidParams
+		}
+
+		db = db.Where("created_at >= ?", t)
+	}
+
+	if input.EndAt != nil {
+		t, err := time.Parse(time.RFC3339Nano, *input.EndAt)
+
+		if err != nil {
+			return nil, exception.InvalidParams
+		}
+
+		db = db.Where("created_at < ?", t)
+	}
+
+	// 金额的范围按照可用余额变动的绝对值筛选, 转入和转出都适用
+	if input.MinAmount != nil {
+		amount, err := util.ParseDecimal(*input.MinAmount)
+
+		if err != nil {
+			return nil, exception.InvalidParams
+		}
+
+		db = db.Where("ABS(balance_mutation) >= ?", amount)
+	}
+
+	if input.MaxAmount != nil {
+		amount, err := util.ParseDecimal(*input.MaxAmount)
+
+		if err != nil {
+			return nil, exception.InvalidParams
+		}
+
+		db = db.Where("ABS(balance_mutation) <= ?", amount)
+	}
+
+	return db, nil
+}
+
+// 排序的字段会直接拼接到 SQL 中, 所以只允许白名单中的字段
+func validSort(query schema.Query) bool {
+	for _, s := range query.FormatSort() {
+		if !sortFields[s.Field] {
+			return false
+		}
+	}
+	return true
 }
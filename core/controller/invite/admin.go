@@ -0,0 +1,505 @@
+// Copyright 2019 Axetroy. All rights reserved. MIT license.
+package invite
+
+import (
+	"errors"
+	"github.com/axetroy/go-server/core/controller"
+	"github.com/axetroy/go-server/core/exception"
+	"github.com/axetroy/go-server/core/helper"
+	"github.com/axetroy/go-server/core/middleware"
+	"github.com/axetroy/go-server/core/model"
+	"github.com/axetroy/go-server/core/schema"
+	"github.com/axetroy/go-server/core/service/database"
+	"github.com/gin-gonic/gin"
+	"github.com/jinzhu/gorm"
+	"github.com/mitchellh/mapstructure"
+	"net/http"
+	"strings"
+	"time"
+)
+
+type QueryByAdmin struct {
+	schema.Query
+	Inviter       *string             `json:"inviter" form:"inviter"`               // 邀请人
+	Invitee       *string             `json:"invitee" form:"invitee"`               // 被邀请人
+	Status        *model.InviteStatus `json:"status" form:"status"`                 // 被邀请人的状态
+	RewardSettled *bool               `json:"reward_settled" form:"reward_settled"` // 奖励是否已经发放完毕
+}
+
+type RewardQuery struct {
+	schema.Query
+	InviteId *string `json:"invite_id" form:"invite_id"` // 邀请记录
+	Uid      *string `json:"uid" form:"uid"`             // 邀请人或者被邀请人
+	Currency *string `json:"currency" form:"currency"`   // 币种
+}
+
+type UpdateStatusParams struct {
+	Status model.InviteStatus `json:"status"` // 被邀请人的状态
+}
+
+func inviteToSchema(invite model.InviteHistory) (d schema.Invite, err error) {
+	if err = mapstructure.Decode(invite, &d.InvitePure); err != nil {
+		return
+	}
+
+	d.CreatedAt = invite.CreatedAt.Format(time.RFC3339Nano)
+	d.UpdatedAt = invite.UpdatedAt.Format(time.RFC3339Nano)
+	return
+}
+
+func rewardToSchema(reward model.InviteReward) (d schema.InviteReward) {
+	d.Id = reward.Id
+	d.InviteId = reward.InviteId
+	d.Status = int(reward.Status)
+	d.Currency = reward.Currency
+	d.Inviter = reward.Inviter
+	d.Invitee = reward.Invitee
+	d.InviterAmount = reward.InviterAmount.StringFixed(8)
+	d.InviteeAmount = reward.InviteeAmount.StringFixed(8)
+	d.CreatedAt = reward.CreatedAt.Format(time.RFC3339Nano)
+	d.UpdatedAt = reward.UpdatedAt.Format(time.RFC3339Nano)
+	return
+}
+
+// 管理员获取邀请记录
+func GetInvitesByAdmin(c controller.Context, input QueryByAdmin) (res schema.List) {
+	var (
+		err  error
+		data = make([]schema.Invite, 0)
+		meta = &schema.Meta{}
+	)
+
+	defer func() {
+		if r := recover(); r != nil {
+			switch t := r.(type) {
+			case string:
+				err = errors.New(t)
+			case error:
+				err = t
+			default:
+				err = exception.Unknown
+			}
+		}
+
+		helper.ResponseList(&res, data, meta, err)
+	}()
+
+	query := input.Query
+
+	query.Normalize()
+
+	list := make([]model.InviteHistory, 0)
+
+	filter := map[string]interface{}{}
+
+	if input.Inviter != nil {
+		filter["inviter"] = *input.Inviter
+	}
+
+	if input.Invitee != nil {
+		filter["invitee"] = *input.Invitee
+	}
+
+	if input.Status != nil {
+		filter["status"] = *input.Status
+	}
+
+	if input.RewardSettled != nil {
+		filter["reward_settled"] = *input.RewardSettled
+	}
+
+	if err = query.Order(database.Db.Limit(query.Limit).Offset(query.Limit * query.Page)).Where(filter).Find(&list).Error; err != nil {
+		return
+	}
+
+	var total int64
+
+	if err = database.Db.Model(&model.InviteHistory{}).Where(filter).Count(&total).Error; err != nil {
+		return
+	}
+
+	for _, v := range list {
+		d, er := inviteToSchema(v)
+
+		if er != nil {
+			err = er
+			return
+		}
+
+		data = append(data, d)
+	}
+
+	meta.Total = total
+	meta.Num = len(list)
+	meta.Page = query.Page
+	meta.Limit = query.Limit
+	meta.Sort = query.Sort
+
+	return
+}
+
+// 管理员获取单条邀请记录
+func GetInviteByAdmin(c controller.Context, inviteId string) (res schema.Response) {
+	var (
+		err  error
+		data schema.Invite
+	)
+
+	defer func() {
+		if r := recover(); r != nil {
+			switch t := r.(type) {
+			case string:
+				err = errors.New(t)
+			case error:
+				err = t
+			default:
+				err = exception.Unknown
+			}
+		}
+
+		helper.Response(&res, data, err)
+	}()
+
+	invite := model.InviteHistory{}
+
+	if err = database.Db.Where("id = ?", inviteId).First(&invite).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			err = exception.InviteNotExist
+		}
+		return
+	}
+
+	data, err = inviteToSchema(invite)
+
+	return
+}
+
+// 管理员修改被邀请人的状态, 例如线下完成了实名认证
+// 状态只能往前推进, 奖励由发放任务异步发放
+func UpdateStatusByAdmin(c controller.Context, inviteId string, input UpdateStatusParams) (res schema.Response) {
+	var (
+		err  error
+		data schema.Invite
+		tx   *gorm.DB
+	)
+
+	defer func() {
+		if r := recover(); r != nil {
+			switch t := r.(type) {
+			case string:
+				err = errors.New(t)
+			case error:
+				err = t
+			default:
+				err = exception.Unknown
+			}
+		}
+
+		if tx != nil {
+			if err != nil {
+				_ = tx.Rollback().Error
+			} else {
+				err = tx.Commit().Error
+			}
+		}
+
+		helper.Response(&res, data, err)
+	}()
+
+	tx = database.Db.Begin()
+
+	adminInfo := model.Admin{Id: c.Uid}
+
+	if err = tx.First(&adminInfo).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			err = exception.AdminNotExist
+		}
+		return
+	}
+
+	invite := model.InviteHistory{}
+
+	if err = tx.Where("id = ?", inviteId).First(&invite).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			err = exception.InviteNotExist
+		}
+		return
+	}
+
+	if input.Status < invite.Status {
+		err = exception.InviteInvalidStatus
+		return
+	}
+
+	if err = Advance(tx, invite.Invitee, input.Status); err != nil {
+		return
+	}
+
+	if err = tx.Where("id = ?", inviteId).First(&invite).Error; err != nil {
+		return
+	}
+
+	data, err = inviteToSchema(invite)
+
+	return
+}
+
+// 管理员立即发放一条邀请记录的奖励, 返回本次发放的奖励
+func SettleByAdmin(c controller.Context, inviteId string) (res schema.Response) {
+	var (
+		err  error
+		data = make([]schema.InviteReward, 0)
+	)
+
+	defer func() {
+		if r := recover(); r != nil {
+			switch t := r.(type) {
+			case string:
+				err = errors.New(t)
+			case error:
+				err = t
+			default:
+				err = exception.Unknown
+			}
+		}
+
+		helper.Response(&res, data, err)
+	}()
+
+	var rewards []model.InviteReward
+
+	if rewards, err = Settle(inviteId); err != nil {
+		return
+	}
+
+	for _, v := range rewards {
+		data = append(data, rewardToSchema(v))
+	}
+
+	return
+}
+
+// 管理员立即发放所有未发放的邀请奖励, 返回发放的奖励数量
+func SettlePendingByAdmin(c controller.Context) (res schema.Response) {
+	var (
+		err   error
+		count int
+	)
+
+	defer func() {
+		if r := recover(); r != nil {
+			switch t := r.(type) {
+			case string:
+				err = errors.New(t)
+			case error:
+				err = t
+			default:
+				err = exception.Unknown
+			}
+		}
+
+		helper.Response(&res, count, err)
+	}()
+
+	count, err = SettlePending()
+
+	return
+}
+
+// 管理员获取已经发放的邀请奖励
+func GetRewardsByAdmin(c controller.Context, input RewardQuery) (res schema.List) {
+	var (
+		err  error
+		data = make([]schema.InviteReward, 0)
+		meta = &schema.Meta{}
+	)
+
+	defer func() {
+		if r := recover(); r != nil {
+			switch t := r.(type) {
+			case string:
+				err = errors.New(t)
+			case error:
+				err = t
+			default:
+				err = exception.Unknown
+			}
+		}
+
+		helper.ResponseList(&res, data, meta, err)
+	}()
+
+	query := input.Query
+
+	query.Normalize()
+
+	list := make([]model.InviteReward, 0)
+
+	db := database.Db.Model(&model.InviteReward{})
+
+	if input.InviteId != nil {
+		db = db.Where("invite_id = ?", *input.InviteId)
+	}
+
+	if input.Uid != nil {
+		db = db.Where("inviter = ? OR invitee = ?", *input.Uid, *input.Uid)
+	}
+
+	if input.Currency != nil {
+		db = db.Where("currency = ?", strings.ToUpper(*input.Currency))
+	}
+
+	var total int64
+
+	if err = db.Count(&total).Error; err != nil {
+		return
+	}
+
+	if err = query.Order(db.Limit(query.Limit).Offset(query.Limit * query.Page)).Find(&list).Error; err != nil {
+		return
+	}
+
+	for _, v := range list {
+		data = append(data, rewardToSchema(v))
+	}
+
+	meta.Total = total
+	meta.Num = len(list)
+	meta.Page = query.Page
+	meta.Limit = query.Limit
+	meta.Sort = query.Sort
+
+	return
+}
+
+func GetInvitesByAdminRouter(c *gin.Context) {
+	var (
+		err   error
+		res   = schema.List{}
+		input QueryByAdmin
+	)
+
+	defer func() {
+		if err != nil {
+			res.Data = nil
+			res.Message = err.Error()
+		}
+		c.JSON(http.StatusOK, res)
+	}()
+
+	if err = c.ShouldBindQuery(&input); err != nil {
+		err = exception.InvalidParams
+		return
+	}
+
+	res = GetInvitesByAdmin(controller.Context{
+		Uid: c.GetString(middleware.ContextUidField),
+	}, input)
+}
+
+func GetInviteByAdminRouter(c *gin.Context) {
+	var (
+		err error
+		res = schema.Response{}
+	)
+
+	defer func() {
+		if err != nil {
+			res.Data = nil
+			res.Message = err.Error()
+		}
+		c.JSON(http.StatusOK, res)
+	}()
+
+	res = GetInviteByAdmin(controller.Context{
+		Uid: c.GetString(middleware.ContextUidField),
+	}, c.Param("invite_id"))
+}
+
+func UpdateStatusByAdminRouter(c *gin.Context) {
+	var (
+		err   error
+		res   = schema.Response{}
+		input UpdateStatusParams
+	)
+
+	defer func() {
+		if err != nil {
+			res.Data = nil
+			res.Message = err.Error()
+		}
+		c.JSON(http.StatusOK, res)
+	}()
+
+	if err = c.ShouldBindJSON(&input); err != nil {
+		err = exception.InvalidParams
+		return
+	}
+
+	res = UpdateStatusByAdmin(controller.Context{
+		Uid: c.GetString(middleware.ContextUidField),
+	}, c.Param("invite_id"), input)
+}
+
+func SettleByAdminRouter(c *gin.Context) {
+	var (
+		err error
+		res = schema.Response{}
+	)
+
+	defer func() {
+		if err != nil {
+			res.Data = nil
+			res.Message = err.Error()
+		}
+		c.JSON(http.StatusOK, res)
+	}()
+
+	res = SettleByAdmin(controller.Context{
+		Uid: c.GetString(middleware.ContextUidField),
+	}, c.Param("invite_id"))
+}
+
+func SettlePendingByAdminRouter(c *gin.Context) {
+	var (
+		err error
+		res = schema.Response{}
+	)
+
+	defer func() {
+		if err != nil {
+			res.Data = nil
+			res.Message = err.Error()
+		}
+		c.JSON(http.StatusOK, res)
+	}()
+
+	res = SettlePendingByAdmin(controller.Context{
+		Uid: c.GetString(middleware.ContextUidField),
+	})
+}
+
+func GetRewardsByAdminRouter(c *gin.Context) {
+	var (
+		err   error
+		res   = schema.List{}
+		input RewardQuery
+	)
+
+	defer func() {
+		if err != nil {
+			res.Data = nil
+			res.Message = err.Error()
+		}
+		c.JSON(http.StatusOK, res)
+	}()
+
+	if err = c.ShouldBindQuery(&input); err != nil {
+		err = exception.InvalidParams
+		return
+	}
+
+	res = GetRewardsByAdmin(controller.Context{
+		Uid: c.GetString(middleware.ContextUidField),
+	}, input)
+}
@@ -0,0 +1,425 @@
+// Copyright 2019 Axetroy. All rights reserved. MIT license.
+package invite
+
+import (
+	"errors"
+	"github.com/axetroy/go-server/core/controller"
+	"github.com/axetroy/go-server/core/exception"
+	"github.com/axetroy/go-server/core/helper"
+	"github.com/axetroy/go-server/core/middleware"
+	"github.com/axetroy/go-server/core/model"
+	"github.com/axetroy/go-server/core/schema"
+	"github.com/axetroy/go-server/core/service/currency"
+	"github.com/axetroy/go-server/core/service/database"
+	"github.com/axetroy/go-server/core/util"
+	"github.com/axetroy/go-server/core/validator"
+	"github.com/gin-gonic/gin"
+	"github.com/jinzhu/gorm"
+	"net/http"
+	"time"
+)
+
+type CreateRuleParams struct {
+	Status        model.InviteStatus `json:"status"`                          // 被邀请人达到的状态
+	Currency      string             `json:"currency" valid:"required~请选择币种"` // 奖励的币种
+	InviterAmount *string            `json:"inviter_amount"`                  // 邀请人的奖励
+	InviteeAmount *string            `json:"invitee_amount"`                  // 被邀请人的奖励
+}
+
+type UpdateRuleParams struct {
+	InviterAmount *string `json:"inviter_amount"` // 邀请人的奖励
+	InviteeAmount *string `json:"invitee_amount"` // 被邀请人的奖励
+}
+
+func DeleteRuleById(id string) {
+	database.DeleteRowByTable("invite_reward_rule", "id", id)
+}
+
+func ruleToSchema(rule model.InviteRewardRule) (d schema.InviteRewardRule) {
+	d.Id = rule.Id
+	d.Status = int(rule.Status)
+	d.Currency = rule.Currency
+	d.InviterAmount = rule.InviterAmount.StringFixed(8)
+	d.InviteeAmount = rule.InviteeAmount.StringFixed(8)
+	d.CreatedAt = rule.CreatedAt.Format(time.RFC3339Nano)
+	d.UpdatedAt = rule.UpdatedAt.Format(time.RFC3339Nano)
+	return
+}
+
+// 解析奖励的金额, 不能为负数, 并且不能超过币种的小数位数
+func parseRewardAmount(s string, precision int) (d util.Decimal, err error) {
+	if d, err = util.ParseDecimal(s); err != nil {
+		err = exception.InvalidParams
+		return
+	}
+
+	if d.Sign() < 0 || d.Places() > precision {
+		err = exception.InvalidParams
+		return
+	}
+
+	return
+}
+
+// 添加邀请奖励规则
+func CreateRuleByAdmin(c controller.Context, input CreateRuleParams) (res schema.Response) {
+	var (
+		err  error
+		data schema.InviteRewardRule
+		tx   *gorm.DB
+	)
+
+	defer func() {
+		if r := recover(); r != nil {
+			switch t := r.(type) {
+			case string:
+				err = errors.New(t)
+			case error:
+				err = t
+			default:
+				err = exception.Unknown
+			}
+		}
+
+		if tx != nil {
+			if err != nil {
+				_ = tx.Rollback().Error
+			} else {
+				err = tx.Commit().Error
+			}
+		}
+
+		helper.Response(&res, data, err)
+	}()
+
+	// 参数校验
+	if err = validator.ValidateStruct(input); err != nil {
+		return
+	}
+
+	if !inviteStatuses[input.Status] {
+		err = exception.InviteInvalidStatus
+		return
+	}
+
+	var currencyInfo model.Currency
+
+	if currencyInfo, err = currency.Get(input.Currency); err != nil {
+		return
+	}
+
+	rule := model.InviteRewardRule{
+		Status:   input.Status,
+		Currency: currencyInfo.Code,
+	}
+
+	if input.InviterAmount != nil {
+		if rule.InviterAmount, err = parseRewardAmount(*input.InviterAmount, currencyInfo.Precision); err != nil {
+			return
+		}
+	}
+
+	if input.InviteeAmount != nil {
+		if rule.InviteeAmount, err = parseRewardAmount(*input.InviteeAmount, currencyInfo.Precision); err != nil {
+			return
+		}
+	}
+
+	tx = database.Db.Begin()
+
+	adminInfo := model.Admin{Id: c.Uid}
+
+	if err = tx.First(&adminInfo).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			err = exception.AdminNotExist
+		}
+		return
+	}
+
+	var count int
+
+	if err = tx.Model(&model.InviteRewardRule{}).Where("status = ? AND currency = ?", rule.Status, rule.Currency).Count(&count).Error; err != nil {
+		return
+	}
+
+	if count > 0 {
+		err = exception.InviteRewardRuleExist
+		return
+	}
+
+	if err = tx.Create(&rule).Error; err != nil {
+		return
+	}
+
+	data = ruleToSchema(rule)
+
+	return
+}
+
+// 修改邀请奖励规则, 只对之后发放的奖励生效
+func UpdateRuleByAdmin(c controller.Context, ruleId string, input UpdateRuleParams) (res schema.Response) {
+	var (
+		err  error
+		data schema.InviteRewardRule
+		tx   *gorm.DB
+	)
+
+	defer func() {
+		if r := recover(); r != nil {
+			switch t := r.(type) {
+			case string:
+				err = errors.New(t)
+			case error:
+				err = t
+			default:
+				err = exception.Unknown
+			}
+		}
+
+		if tx != nil {
+			if err != nil {
+				_ = tx.Rollback().Error
+			} else {
+				err = tx.Commit().Error
+			}
+		}
+
+		helper.Response(&res, data, err)
+	}()
+
+	tx = database.Db.Begin()
+
+	adminInfo := model.Admin{Id: c.Uid}
+
+	if err = tx.First(&adminInfo).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			err = exception.AdminNotExist
+		}
+		return
+	}
+
+	rule := model.InviteRewardRule{}
+
+	if err = tx.Where("id = ?", ruleId).First(&rule).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			err = exception.InviteRewardRuleNotExist
+		}
+		return
+	}
+
+	var currencyInfo model.Currency
+
+	if currencyInfo, err = currency.Get(rule.Currency); err != nil {
+		return
+	}
+
+	// 使用 map 更新, 否则 0 会被忽略
+	updated := map[string]interface{}{}
+
+	if input.InviterAmount != nil {
+		if rule.InviterAmount, err = parseRewardAmount(*input.InviterAmount, currencyInfo.Precision); err != nil {
+			return
+		}
+		updated["inviter_amount"] = rule.InviterAmount
+	}
+
+	if input.InviteeAmount != nil {
+		if rule.InviteeAmount, err = parseRewardAmount(*input.InviteeAmount, currencyInfo.Precision); err != nil {
+			return
+		}
+		updated["invitee_amount"] = rule.InviteeAmount
+	}
+
+	if len(updated) > 0 {
+		if err = tx.Model(&rule).Updates(updated).Error; err != nil {
+			return
+		}
+	}
+
+	data = ruleToSchema(rule)
+
+	return
+}
+
+// 删除邀请奖励规则, 已经发放的奖励不受影响
+func DeleteRuleByAdmin(c controller.Context, ruleId string) (res schema.Response) {
+	var (
+		err  error
+		data schema.InviteRewardRule
+		tx   *gorm.DB
+	)
+
+	defer func() {
+		if r := recover(); r != nil {
+			switch t := r.(type) {
+			case string:
+				err = errors.New(t)
+			case error:
+				err = t
+			default:
+				err = exception.Unknown
+			}
+		}
+
+		if tx != nil {
+			if err != nil {
+				_ = tx.Rollback().Error
+			} else {
+				err = tx.Commit().Error
+			}
+		}
+
+		helper.Response(&res, data, err)
+	}()
+
+	tx = database.Db.Begin()
+
+	adminInfo := model.Admin{Id: c.Uid}
+
+	if err = tx.First(&adminInfo).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			err = exception.AdminNotExist
+		}
+		return
+	}
+
+	rule := model.InviteRewardRule{}
+
+	if err = tx.Where("id = ?", ruleId).First(&rule).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			err = exception.InviteRewardRuleNotExist
+		}
+		return
+	}
+
+	if err = tx.Delete(&rule).Error; err != nil {
+		return
+	}
+
+	data = ruleToSchema(rule)
+
+	return
+}
+
+// 获取所有的邀请奖励规则
+func GetRulesByAdmin(c controller.Context) (res schema.Response) {
+	var (
+		err  error
+		data = make([]schema.InviteRewardRule, 0)
+	)
+
+	defer func() {
+		if r := recover(); r != nil {
+			switch t := r.(type) {
+			case string:
+				err = errors.New(t)
+			case error:
+				err = t
+			default:
+				err = exception.Unknown
+			}
+		}
+
+		helper.Response(&res, data, err)
+	}()
+
+	list := make([]model.InviteRewardRule, 0)
+
+	if err = database.Db.Order("status ASC").Order("currency ASC").Find(&list).Error; err != nil {
+		return
+	}
+
+	for _, v := range list {
+		data = append(data, ruleToSchema(v))
+	}
+
+	return
+}
+
+func CreateRuleByAdminRouter(c *gin.Context) {
+	var (
+		input CreateRuleParams
+		err   error
+		res   = schema.Response{}
+	)
+
+	defer func() {
+		if err != nil {
+			res.Data = nil
+			res.Message = err.Error()
+		}
+		c.JSON(http.StatusOK, res)
+	}()
+
+	if err = c.ShouldBindJSON(&input); err != nil {
+		err = exception.InvalidParams
+		return
+	}
+
+	res = CreateRuleByAdmin(controller.Context{
+		Uid: c.GetString(middleware.ContextUidField),
+	}, input)
+}
+
+func UpdateRuleByAdminRouter(c *gin.Context) {
+	var (
+		input UpdateRuleParams
+		err   error
+		res   = schema.Response{}
+	)
+
+	defer func() {
+		if err != nil {
+			res.Data = nil
+			res.Message = err.Error()
+		}
+		c.JSON(http.StatusOK, res)
+	}()
+
+	if err = c.ShouldBindJSON(&input); err != nil {
+		err = exception.InvalidParams
+		return
+	}
+
+	res = UpdateRuleByAdmin(controller.Context{
+		Uid: c.GetString(middleware.ContextUidField),
+	}, c.Param("rule_id"), input)
+}
+
+func DeleteRuleByAdminRouter(c *gin.Context) {
+	var (
+		err error
+		res = schema.Response{}
+	)
+
+	defer func() {
+		if err != nil {
+			res.Data = nil
+			res.Message = err.Error()
+		}
+		c.JSON(http.StatusOK, res)
+	}()
+
+	res = DeleteRuleByAdmin(controller.Context{
+		Uid: c.GetString(middleware.ContextUidField),
+	}, c.Param("rule_id"))
+}
+
+func GetRulesByAdminRouter(c *gin.Context) {
+	var (
+		err error
+		res = schema.Response{}
+	)
+
+	defer func() {
+		if err != nil {
+			res.Data = nil
+			res.Message = err.Error()
+		}
+		c.JSON(http.StatusOK, res)
+	}()
+
+	res = GetRulesByAdmin(controller.Context{
+		Uid: c.GetString(middleware.ContextUidField),
+	})
+}
@@ -0,0 +1,211 @@
+// Copyright 2019 Axetroy. All rights reserved. MIT license.
+package invite
+
+import (
+	"github.com/axetroy/go-server/core/config"
+	"github.com/axetroy/go-server/core/exception"
+	"github.com/axetroy/go-server/core/logger"
+	"github.com/axetroy/go-server/core/model"
+	"github.com/axetroy/go-server/core/service/database"
+	"github.com/axetroy/go-server/core/service/wallet"
+	"github.com/jinzhu/gorm"
+	"log"
+	"time"
+)
+
+// 邀请奖励的发放
+// 被邀请人每达到一个状态, 按照该状态的奖励规则给邀请人和被邀请人发放奖励
+// 每一条邀请记录的每一个状态, 每一个币种的奖励只发放一次
+// 所有配置了奖励的状态都已经发放完毕后, 邀请记录标记为已发放 (reward_settled), 之后不再处理
+
+var (
+	// 每次最多处理的邀请记录数量
+	settleBatchSize = 100
+
+	// 有效的邀请状态
+	inviteStatuses = map[model.InviteStatus]bool{
+		model.StatusInviteRegistered: true,
+		model.StatusInviteAuth:       true,
+		model.StatusInvitePay:        true,
+	}
+)
+
+// 推进被邀请人的状态, 必须在事务中调用
+// 状态只会往前推进, 不是被邀请人或者已经达到该状态则什么也不做
+// 奖励由发放任务异步发放
+func Advance(tx *gorm.DB, invitee string, status model.InviteStatus) error {
+	if !inviteStatuses[status] {
+		return exception.InviteInvalidStatus
+	}
+
+	return tx.Model(&model.InviteHistory{}).Where("invitee = ? AND status < ?", invitee, status).Update("status", status).Error
+}
+
+// 发放一条邀请记录的奖励, 返回本次发放的奖励, 必须在事务中调用
+func settle(tx *gorm.DB, inviteId string) (rewards []model.InviteReward, err error) {
+	invite := model.InviteHistory{}
+
+	// 锁定邀请记录, 同一条邀请记录的发放排队进行, 不会重复发放
+	if err = tx.Set("gorm:query_option", "FOR UPDATE").Where("id = ?", inviteId).First(&invite).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			err = exception.InviteNotExist
+		}
+		return
+	}
+
+	if invite.RewardSettled {
+		return
+	}
+
+	rules := make([]model.InviteRewardRule, 0)
+
+	if err = tx.Order("status ASC").Order("currency ASC").Find(&rules).Error; err != nil {
+		return
+	}
+
+	// 还没有配置奖励规则
+	if len(rules) == 0 {
+		return
+	}
+
+	finished := true
+
+	for _, rule := range rules {
+		// 还没有达到这个状态
+		if rule.Status > invite.Status {
+			finished = false
+			continue
+		}
+
+		var count int
+
+		if err = tx.Model(&model.InviteReward{}).Where("invite_id = ? AND status = ? AND currency = ?", invite.Id, rule.Status, rule.Currency).Count(&count).Error; err != nil {
+			return
+		}
+
+		// 已经发放过了
+		if count > 0 {
+			continue
+		}
+
+		reward := model.InviteReward{
+			InviteId:      invite.Id,
+			Status:        rule.Status,
+			Currency:      rule.Currency,
+			Inviter:       invite.Inviter,
+			Invitee:       invite.Invitee,
+			InviterAmount: rule.InviterAmount,
+			InviteeAmount: rule.InviteeAmount,
+		}
+
+		if err = tx.Create(&reward).Error; err != nil {
+			return
+		}
+
+		mutations := make([]wallet.Mutation, 0)
+
+		if reward.InviterAmount.Sign() > 0 {
+			mutations = append(mutations, wallet.Mutation{
+				Uid:     reward.Inviter,
+				Balance: reward.InviterAmount,
+				Type:    model.FinanceTypeInviteReward,
+			})
+		}
+
+		if reward.InviteeAmount.Sign() > 0 {
+			mutations = append(mutations, wallet.Mutation{
+				Uid:     reward.Invitee,
+				Balance: reward.InviteeAmount,
+				Type:    model.FinanceTypeInviteReward,
+			})
+		}
+
+		if len(mutations) > 0 {
+			if _, err = wallet.Apply(tx, reward.Currency, reward.Id, mutations...); err != nil {
+				return
+			}
+		}
+
+		rewards = append(rewards, reward)
+	}
+
+	// 所有的奖励都发放完毕, 之后修改的规则不会再对这条邀请记录生效
+	if finished {
+		if err = tx.Model(&invite).Update("reward_settled", true).Error; err != nil {
+			return
+		}
+	}
+
+	return
+}
+
+// 在单独的事务中发放一条邀请记录的奖励
+func Settle(inviteId string) (rewards []model.InviteReward, err error) {
+	tx := database.Db.Begin()
+
+	defer func() {
+		if err != nil {
+			_ = tx.Rollback().Error
+		} else {
+			err = tx.Commit().Error
+		}
+	}()
+
+	return settle(tx, inviteId)
+}
+
+// 发放所有未发放完毕的邀请奖励, 返回发放的奖励数量
+// 单条邀请记录发放失败不会影响其他的邀请记录
+func SettlePending() (count int, err error) {
+	lastId := ""
+
+	for {
+		var ids []string
+
+		if err = database.Db.Model(&model.InviteHistory{}).Where("reward_settled = ? AND id > ?", false, lastId).Order("id ASC").Limit(settleBatchSize).Pluck("id", &ids).Error; err != nil {
+			return
+		}
+
+		for _, id := range ids {
+			rewards, er := Settle(id)
+
+			if er != nil {
+				log.Println("发放邀请奖励失败:", id, er)
+				continue
+			}
+
+			count += len(rewards)
+		}
+
+		if len(ids) < settleBatchSize {
+			break
+		}
+
+		lastId = ids[len(ids)-1]
+	}
+
+	return
+}
+
+// 定时发放邀请奖励, 进程退出时停止
+func RunSettleWorker(interval time.Duration) {
+	ticker := time.NewTicker(interval)
+
+	defer ticker.Stop()
+
+	for range ticker.C {
+		if config.Common.Exiting {
+			return
+		}
+
+		count, err := SettlePending()
+
+		if err != nil {
+			log.Println("发放邀请奖励失败:", err)
+		}
+
+		if count > 0 {
+			logger.Infof("%d invite rewards settled", count)
+		}
+	}
+}
@@ -0,0 +1,139 @@
+// Copyright 2019 Axetroy. All rights reserved. MIT license.
+package invite_test
+
+import (
+	"github.com/axetroy/go-server/core/controller"
+	"github.com/axetroy/go-server/core/controller/auth"
+	"github.com/axetroy/go-server/core/controller/invite"
+	"github.com/axetroy/go-server/core/controller/wallet"
+	"github.com/axetroy/go-server/core/exception"
+	"github.com/axetroy/go-server/core/model"
+	"github.com/axetroy/go-server/core/schema"
+	"github.com/axetroy/go-server/core/service/database"
+	"github.com/axetroy/go-server/tester"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestSettle(t *testing.T) {
+	adminInfo, _ := tester.LoginAdmin()
+	inviter, _ := tester.CreateUser()
+	invitee, _ := tester.CreateUser()
+
+	defer auth.DeleteUserByUserName(inviter.Username)
+	defer auth.DeleteUserByUserName(invitee.Username)
+
+	admin := controller.Context{Uid: adminInfo.Id}
+
+	createRule := func(status model.InviteStatus, inviterAmount string, inviteeAmount string) string {
+		r := invite.CreateRuleByAdmin(admin, invite.CreateRuleParams{
+			Status:        status,
+			Currency:      model.WalletCNY,
+			InviterAmount: &inviterAmount,
+			InviteeAmount: &inviteeAmount,
+		})
+
+		assert.Equal(t, "", r.Message)
+
+		rule := schema.InviteRewardRule{}
+
+		assert.Nil(t, tester.Decode(r.Data, &rule))
+
+		return rule.Id
+	}
+
+	defer invite.DeleteRuleById(createRule(model.StatusInviteRegistered, "10", "5"))
+	defer invite.DeleteRuleById(createRule(model.StatusInvitePay, "20", "0"))
+
+	// 同一个状态和币种只能有一条规则
+	{
+		amount := "1"
+
+		r := invite.CreateRuleByAdmin(admin, invite.CreateRuleParams{
+			Status:        model.StatusInvitePay,
+			Currency:      model.WalletCNY,
+			InviterAmount: &amount,
+		})
+
+		assert.Equal(t, exception.InviteRewardRuleExist.Error(), r.Message)
+	}
+
+	history := model.InviteHistory{
+		Inviter: inviter.Id,
+		Invitee: invitee.Id,
+		Status:  model.StatusInviteRegistered,
+	}
+
+	assert.Nil(t, database.Db.Create(&history).Error)
+
+	defer database.DeleteRowByTable(history.TableName(), "id", history.Id)
+	defer database.DeleteRowByTable("invite_reward", "invite_id", history.Id)
+
+	balance := func(uid string) string {
+		w := schema.Wallet{}
+		assert.Nil(t, tester.Decode(wallet.GetWallet(controller.Context{Uid: uid}, model.WalletCNY).Data, &w))
+		return w.Balance
+	}
+
+	// 发放注册的奖励
+	{
+		rewards, err := invite.Settle(history.Id)
+
+		assert.Nil(t, err)
+		assert.Len(t, rewards, 1)
+		assert.Equal(t, "10.00000000", balance(inviter.Id))
+		assert.Equal(t, "5.00000000", balance(invitee.Id))
+	}
+
+	// 重复发放不会生效
+	{
+		rewards, err := invite.Settle(history.Id)
+
+		assert.Nil(t, err)
+		assert.Len(t, rewards, 0)
+		assert.Equal(t, "10.00000000", balance(inviter.Id))
+	}
+
+	// 还有未达到的状态, 没有标记为已发放
+	assert.Nil(t, database.Db.First(&history).Error)
+	assert.False(t, history.RewardSettled)
+
+	// 被邀请人完成支付
+	{
+		r := invite.UpdateStatusByAdmin(admin, history.Id, invite.UpdateStatusParams{
+			Status: model.StatusInvitePay,
+		})
+
+		assert.Equal(t, "", r.Message)
+
+		r = invite.SettleByAdmin(admin, history.Id)
+
+		rewards := make([]schema.InviteReward, 0)
+
+		assert.Equal(t, "", r.Message)
+		assert.Nil(t, tester.Decode(r.Data, &rewards))
+		assert.Len(t, rewards, 1)
+		assert.Equal(t, int(model.StatusInvitePay), rewards[0].Status)
+		assert.Equal(t, "30.00000000", balance(inviter.Id))
+		assert.Equal(t, "5.00000000", balance(invitee.Id))
+
+		// 生成了邀请奖励的财务日志
+		log := model.FinanceLog{}
+
+		assert.Nil(t, model.FinanceLogOf(database.Db, model.WalletCNY).Where("order_id = ?", rewards[0].Id).First(&log).Error)
+		assert.Equal(t, model.FinanceTypeInviteReward, log.Type)
+	}
+
+	// 所有的奖励都已经发放
+	assert.Nil(t, database.Db.First(&history).Error)
+	assert.True(t, history.RewardSettled)
+
+	// 状态不能回退
+	{
+		r := invite.UpdateStatusByAdmin(admin, history.Id, invite.UpdateStatusParams{
+			Status: model.StatusInviteRegistered,
+		})
+
+		assert.Equal(t, exception.InviteInvalidStatus.Error(), r.Message)
+	}
+}
@@ -0,0 +1,496 @@
+// Copyright 2019 Axetroy. All rights reserved. MIT license.
+package invite
+
+import (
+	"errors"
+	"github.com/axetroy/go-server/core/controller"
+	"github.com/axetroy/go-server/core/exception"
+	"github.com/axetroy/go-server/core/helper"
+	"github.com/axetroy/go-server/core/middleware"
+	"github.com/axetroy/go-server/core/model"
+	"github.com/axetroy/go-server/core/schema"
+	"github.com/axetroy/go-server/core/service/database"
+	"github.com/gin-gonic/gin"
+	"net/http"
+	"sort"
+	"time"
+)
+
+var (
+	DefaultTreeDepth = 3  // 默认查询 3 级邀请关系
+	MaxTreeDepth     = 10 // 最多查询 10 级邀请关系
+
+	// 转化漏斗的各个状态, 按照先后顺序排列
+	funnelStatuses = []model.InviteStatus{
+		model.StatusInviteRegistered,
+		model.StatusInviteAuth,
+		model.StatusInvitePay,
+	}
+)
+
+type TreeQuery struct {
+	schema.Query
+	Depth int  `json:"depth" form:"depth"` // 查询的层级深度, 默认 3, 最大 10
+	Level *int `json:"level" form:"level"` // 只查询某一级的邀请
+}
+
+type StatsQuery struct {
+	Depth int `json:"depth" form:"depth"` // 统计的层级深度, 默认 3, 最大 10
+}
+
+type TopQuery struct {
+	schema.Query
+	StartAt *string `json:"start_at" form:"start_at"` // 开始时间, RFC3339 格式
+	EndAt   *string `json:"end_at" form:"end_at"`     // 结束时间, RFC3339 格式
+}
+
+type inviteNode struct {
+	Id            string
+	Inviter       string
+	Invitee       string
+	Status        model.InviteStatus
+	RewardSettled bool
+	Level         int
+	CreatedAt     time.Time
+	UpdatedAt     time.Time
+}
+
+type levelStatusCount struct {
+	Level  int
+	Status model.InviteStatus
+	Count  int
+}
+
+// 递归查询邀请关系树的 CTE, 从 uid 直接邀请的用户开始, 最多查询 depth 级
+// 被邀请人是唯一的, 并且只能在注册时被邀请, 所以邀请关系不会成环
+const treeCTE = `WITH RECURSIVE tree AS (
+	SELECT id, inviter, invitee, status, reward_settled, created_at, updated_at, 1 AS level
+	FROM invite_history
+	WHERE inviter = ? AND deleted_at IS NULL
+	UNION ALL
+	SELECT h.id, h.inviter, h.invitee, h.status, h.reward_settled, h.created_at, h.updated_at, tree.level + 1
+	FROM invite_history h
+	INNER JOIN tree ON h.inviter = tree.invitee
+	WHERE tree.level < ? AND h.deleted_at IS NULL
+) `
+
+func normalizeDepth(depth int) int {
+	if depth <= 0 {
+		return DefaultTreeDepth
+	}
+
+	if depth > MaxTreeDepth {
+		return MaxTreeDepth
+	}
+
+	return depth
+}
+
+func getTree(uid string, input TreeQuery) (data []schema.InviteNode, meta *schema.Meta, err error) {
+	data = make([]schema.InviteNode, 0)
+	meta = &schema.Meta{}
+
+	query := input.Query
+
+	query.Normalize()
+
+	depth := normalizeDepth(input.Depth)
+
+	where := "WHERE 1 = 1"
+	args := []interface{}{uid, depth}
+
+	if input.Level != nil {
+		where = "WHERE level = ?"
+		args = append(args, *input.Level)
+	}
+
+	var total int64
+
+	if err = database.Db.Raw(treeCTE+"SELECT COUNT(*) FROM tree "+where, args...).Row().Scan(&total); err != nil {
+		return
+	}
+
+	list := make([]inviteNode, 0)
+
+	if err = database.Db.Raw(treeCTE+"SELECT * FROM tree "+where+" ORDER BY level ASC, created_at ASC, id ASC LIMIT ? OFFSET ?", append(args, query.Limit, query.Limit*query.Page)...).Scan(&list).Error; err != nil {
+		return
+	}
+
+	for _, v := range list {
+		d := schema.InviteNode{Level: v.Level}
+
+		d.Id = v.Id
+		d.Inviter = v.Inviter
+		d.Invitee = v.Invitee
+		d.Status = int32(v.Status)
+		d.RewardSettled = v.RewardSettled
+		d.CreatedAt = v.CreatedAt.Format(time.RFC3339Nano)
+		d.UpdatedAt = v.UpdatedAt.Format(time.RFC3339Nano)
+
+		data = append(data, d)
+	}
+
+	meta.Total = total
+	meta.Num = len(data)
+	meta.Page = query.Page
+	meta.Limit = query.Limit
+	meta.Sort = query.Sort
+
+	return
+}
+
+func getStats(uid string, input StatsQuery) (data schema.InviteStats, err error) {
+	depth := normalizeDepth(input.Depth)
+
+	rows := make([]levelStatusCount, 0)
+
+	if err = database.Db.Raw(treeCTE+"SELECT level, status, COUNT(*) AS count FROM tree GROUP BY level, status", uid, depth).Scan(&rows).Error; err != nil {
+		return
+	}
+
+	levels := map[int]int{}
+
+	data.Depth = depth
+	data.Levels = make([]schema.InviteLevelCount, 0)
+	data.Funnel = make([]schema.InviteFunnel, 0)
+
+	for level := 1; level <= depth; level++ {
+		levels[level] = 0
+	}
+
+	for _, row := range rows {
+		levels[row.Level] += row.Count
+		data.Total += row.Count
+	}
+
+	for level, count := range levels {
+		data.Levels = append(data.Levels, schema.InviteLevelCount{Level: level, Count: count})
+	}
+
+	sort.Slice(data.Levels, func(i, j int) bool {
+		return data.Levels[i].Level < data.Levels[j].Level
+	})
+
+	// 漏斗中的每一个状态, 包括已经达到更高状态的人
+	for _, status := range funnelStatuses {
+		count := 0
+
+		for _, row := range rows {
+			if row.Status >= status {
+				count += row.Count
+			}
+		}
+
+		data.Funnel = append(data.Funnel, schema.InviteFunnel{Status: int(status), Count: count})
+	}
+
+	return
+}
+
+// 获取我的邀请关系树, 包括间接邀请的用户
+func GetTree(c controller.Context, input TreeQuery) (res schema.List) {
+	var (
+		err  error
+		data = make([]schema.InviteNode, 0)
+		meta = &schema.Meta{}
+	)
+
+	defer func() {
+		if r := recover(); r != nil {
+			switch t := r.(type) {
+			case string:
+				err = errors.New(t)
+			case error:
+				err = t
+			default:
+				err = exception.Unknown
+			}
+		}
+
+		helper.ResponseList(&res, data, meta, err)
+	}()
+
+	data, meta, err = getTree(c.Uid, input)
+
+	return
+}
+
+// 获取我的邀请统计, 包括每一级的人数和转化漏斗
+func GetStats(c controller.Context, input StatsQuery) (res schema.Response) {
+	var (
+		err  error
+		data schema.InviteStats
+	)
+
+	defer func() {
+		if r := recover(); r != nil {
+			switch t := r.(type) {
+			case string:
+				err = errors.New(t)
+			case error:
+				err = t
+			default:
+				err = exception.Unknown
+			}
+		}
+
+		helper.Response(&res, data, err)
+	}()
+
+	data, err = getStats(c.Uid, input)
+
+	return
+}
+
+// 管理员获取某个用户的邀请关系树
+func GetTreeByAdmin(c controller.Context, userId string, input TreeQuery) (res schema.List) {
+	var (
+		err  error
+		data = make([]schema.InviteNode, 0)
+		meta = &schema.Meta{}
+	)
+
+	defer func() {
+		if r := recover(); r != nil {
+			switch t := r.(type) {
+			case string:
+				err = errors.New(t)
+			case error:
+				err = t
+			default:
+				err = exception.Unknown
+			}
+		}
+
+		helper.ResponseList(&res, data, meta, err)
+	}()
+
+	data, meta, err = getTree(userId, input)
+
+	return
+}
+
+// 管理员获取某个用户的邀请统计
+func GetStatsByAdmin(c controller.Context, userId string, input StatsQuery) (res schema.Response) {
+	var (
+		err  error
+		data schema.InviteStats
+	)
+
+	defer func() {
+		if r := recover(); r != nil {
+			switch t := r.(type) {
+			case string:
+				err = errors.New(t)
+			case error:
+				err = t
+			default:
+				err = exception.Unknown
+			}
+		}
+
+		helper.Response(&res, data, err)
+	}()
+
+	data, err = getStats(userId, input)
+
+	return
+}
+
+// 管理员获取邀请排行, 按照时间范围内直接邀请的人数排序
+func GetTopInvitersByAdmin(c controller.Context, input TopQuery) (res schema.List) {
+	var (
+		err  error
+		data = make([]schema.TopInviter, 0)
+		meta = &schema.Meta{}
+	)
+
+	defer func() {
+		if r := recover(); r != nil {
+			switch t := r.(type) {
+			case string:
+				err = errors.New(t)
+			case error:
+				err = t
+			default:
+				err = exception.Unknown
+			}
+		}
+
+		helper.ResponseList(&res, data, meta, err)
+	}()
+
+	query := input.Query
+
+	query.Normalize()
+
+	db := database.Db.Model(&model.InviteHistory{})
+
+	if input.StartAt != nil {
+		t, er := time.Parse(time.RFC3339Nano, *input.StartAt)
+
+		if er != nil {
+			err = exception.InvalidParams
+			return
+		}
+
+		db = db.Where("created_at >= ?", t)
+	}
+
+	if input.EndAt != nil {
+		t, er := time.Parse(time.RFC3339Nano, *input.EndAt)
+
+		if er != nil {
+			err = exception.InvalidParams
+			return
+		}
+
+		db = db.Where("created_at < ?", t)
+	}
+
+	var total int64
+
+	if err = db.Select("COUNT(DISTINCT inviter)").Row().Scan(&total); err != nil {
+		return
+	}
+
+	if err = db.
+		Select("inviter, COUNT(*) AS total, COUNT(CASE WHEN status >= ? THEN 1 END) AS auth, COUNT(CASE WHEN status >= ? THEN 1 END) AS pay", model.StatusInviteAuth, model.StatusInvitePay).
+		Group("inviter").
+		Order("total DESC").
+		Order("inviter ASC").
+		Limit(query.Limit).
+		Offset(query.Limit * query.Page).
+		Scan(&data).Error; err != nil {
+		return
+	}
+
+	meta.Total = total
+	meta.Num = len(data)
+	meta.Page = query.Page
+	meta.Limit = query.Limit
+	meta.Sort = query.Sort
+
+	return
+}
+
+func GetTreeRouter(c *gin.Context) {
+	var (
+		err   error
+		res   = schema.List{}
+		input TreeQuery
+	)
+
+	defer func() {
+		if err != nil {
+			res.Data = nil
+			res.Message = err.Error()
+		}
+		c.JSON(http.StatusOK, res)
+	}()
+
+	if err = c.ShouldBindQuery(&input); err != nil {
+		err = exception.InvalidParams
+		return
+	}
+
+	res = GetTree(controller.NewContext(c), input)
+}
+
+func GetStatsRouter(c *gin.Context) {
+	var (
+		err   error
+		res   = schema.Response{}
+		input StatsQuery
+	)
+
+	defer func() {
+		if err != nil {
+			res.Data = nil
+			res.Message = err.Error()
+		}
+		c.JSON(http.StatusOK, res)
+	}()
+
+	if err = c.ShouldBindQuery(&input); err != nil {
+		err = exception.InvalidParams
+		return
+	}
+
+	res = GetStats(controller.NewContext(c), input)
+}
+
+func GetTreeByAdminRouter(c *gin.Context) {
+	var (
+		err   error
+		res   = schema.List{}
+		input TreeQuery
+	)
+
+	defer func() {
+		if err != nil {
+			res.Data = nil
+			res.Message = err.Error()
+		}
+		c.JSON(http.StatusOK, res)
+	}()
+
+	if err = c.ShouldBindQuery(&input); err != nil {
+		err = exception.InvalidParams
+		return
+	}
+
+	res = GetTreeByAdmin(controller.Context{
+		Uid: c.GetString(middleware.ContextUidField),
+	}, c.Param("user_id"), input)
+}
+
+func GetStatsByAdminRouter(c *gin.Context) {
+	var (
+		err   error
+		res   = schema.Response{}
+		input StatsQuery
+	)
+
+	defer func() {
+		if err != nil {
+			res.Data = nil
+			res.Message = err.Error()
+		}
+		c.JSON(http.StatusOK, res)
+	}()
+
+	if err = c.ShouldBindQuery(&input); err != nil {
+		err = exception.InvalidParams
+		return
+	}
+
+	res = GetStatsByAdmin(controller.Context{
+		Uid: c.GetString(middleware.ContextUidField),
+	}, c.Param("user_id"), input)
+}
+
+func GetTopInvitersByAdminRouter(c *gin.Context) {
+	var (
+		err   error
+		res   = schema.List{}
+		input TopQuery
+	)
+
+	defer func() {
+		if err != nil {
+			res.Data = nil
+			res.Message = err.Error()
+		}
+		c.JSON(http.StatusOK, res)
+	}()
+
+	if err = c.ShouldBindQuery(&input); err != nil {
+		err = exception.InvalidParams
+		return
+	}
+
+	res = GetTopInvitersByAdmin(controller.Context{
+		Uid: c.GetString(middleware.ContextUidField),
+	}, input)
+}
@@ -0,0 +1,129 @@
+// Copyright 2019 Axetroy. All rights reserved. MIT license.
+package invite_test
+
+import (
+	"github.com/axetroy/go-server/core/controller"
+	"github.com/axetroy/go-server/core/controller/auth"
+	"github.com/axetroy/go-server/core/controller/invite"
+	"github.com/axetroy/go-server/core/model"
+	"github.com/axetroy/go-server/core/schema"
+	"github.com/axetroy/go-server/core/service/database"
+	"github.com/axetroy/go-server/tester"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestGetTree(t *testing.T) {
+	adminInfo, _ := tester.LoginAdmin()
+
+	users := make([]schema.ProfileWithToken, 0)
+
+	for i := 0; i < 4; i++ {
+		u, _ := tester.CreateUser()
+		users = append(users, u)
+		defer auth.DeleteUserByUserName(u.Username)
+	}
+
+	// 0 -> 1 -> 2
+	//        -> 3
+	relations := []struct {
+		inviter int
+		invitee int
+		status  model.InviteStatus
+	}{
+		{0, 1, model.StatusInvitePay},
+		{1, 2, model.StatusInviteAuth},
+		{1, 3, model.StatusInviteRegistered},
+	}
+
+	for _, r := range relations {
+		history := model.InviteHistory{
+			Inviter: users[r.inviter].Id,
+			Invitee: users[r.invitee].Id,
+			Status:  r.status,
+		}
+
+		assert.Nil(t, database.Db.Create(&history).Error)
+
+		defer database.DeleteRowByTable(history.TableName(), "id", history.Id)
+	}
+
+	// 获取邀请关系树
+	{
+		r := invite.GetTree(controller.Context{Uid: users[0].Id}, invite.TreeQuery{})
+
+		assert.Equal(t, "", r.Message)
+
+		list := make([]schema.InviteNode, 0)
+
+		assert.Nil(t, tester.Decode(r.Data, &list))
+
+		assert.Equal(t, int64(3), r.Meta.Total)
+		assert.Len(t, list, 3)
+		assert.Equal(t, 1, list[0].Level)
+		assert.Equal(t, users[1].Id, list[0].Invitee)
+		assert.Equal(t, 2, list[1].Level)
+		assert.Equal(t, 2, list[2].Level)
+	}
+
+	// 只获取第二级
+	{
+		level := 2
+
+		r := invite.GetTree(controller.Context{Uid: users[0].Id}, invite.TreeQuery{Level: &level})
+
+		assert.Equal(t, "", r.Message)
+		assert.Equal(t, int64(2), r.Meta.Total)
+	}
+
+	// 限制层级深度
+	{
+		r := invite.GetTree(controller.Context{Uid: users[0].Id}, invite.TreeQuery{Depth: 1})
+
+		assert.Equal(t, "", r.Message)
+		assert.Equal(t, int64(1), r.Meta.Total)
+	}
+
+	// 邀请统计
+	{
+		r := invite.GetStatsByAdmin(controller.Context{Uid: adminInfo.Id}, users[0].Id, invite.StatsQuery{Depth: 2})
+
+		assert.Equal(t, "", r.Message)
+
+		stats := schema.InviteStats{}
+
+		assert.Nil(t, tester.Decode(r.Data, &stats))
+
+		assert.Equal(t, 3, stats.Total)
+		assert.Equal(t, []schema.InviteLevelCount{{Level: 1, Count: 1}, {Level: 2, Count: 2}}, stats.Levels)
+		assert.Equal(t, []schema.InviteFunnel{
+			{Status: int(model.StatusInviteRegistered), Count: 3},
+			{Status: int(model.StatusInviteAuth), Count: 2},
+			{Status: int(model.StatusInvitePay), Count: 1},
+		}, stats.Funnel)
+	}
+
+	// 邀请排行
+	{
+		r := invite.GetTopInvitersByAdmin(controller.Context{Uid: adminInfo.Id}, invite.TopQuery{})
+
+		assert.Equal(t, "", r.Message)
+
+		list := make([]schema.TopInviter, 0)
+
+		assert.Nil(t, tester.Decode(r.Data, &list))
+
+		found := false
+
+		for _, v := range list {
+			if v.Inviter == users[1].Id {
+				found = true
+				assert.Equal(t, 2, v.Total)
+				assert.Equal(t, 1, v.Auth)
+				assert.Equal(t, 0, v.Pay)
+			}
+		}
+
+		assert.True(t, found)
+	}
+}
@@ -0,0 +1,72 @@
+// Copyright 2019 Axetroy. All rights reserved. MIT license.
+package lock
+
+import (
+	"errors"
+	"github.com/axetroy/go-server/core/controller"
+	"github.com/axetroy/go-server/core/exception"
+	"github.com/axetroy/go-server/core/helper"
+	"github.com/axetroy/go-server/core/middleware"
+	"github.com/axetroy/go-server/core/schema"
+	"github.com/axetroy/go-server/core/service/lockout"
+	"github.com/gin-gonic/gin"
+	"net/http"
+)
+
+// 解除锁定
+func DeleteLockByAdmin(c controller.Context, lockId string) (res schema.Response) {
+	var (
+		err  error
+		data schema.Lock
+	)
+
+	defer func() {
+		if r := recover(); r != nil {
+			switch t := r.(type) {
+			case string:
+				err = errors.New(t)
+			case error:
+				err = t
+			default:
+				err = exception.Unknown
+			}
+		}
+
+		helper.Response(&res, data, err)
+	}()
+
+	l, err := lockout.Get(lockId)
+
+	if err != nil {
+		return
+	}
+
+	if err = lockout.Clear(lockId); err != nil {
+		return
+	}
+
+	data = toSchema(l)
+
+	return
+}
+
+func DeleteLockByAdminRouter(c *gin.Context) {
+	var (
+		err error
+		res = schema.Response{}
+	)
+
+	defer func() {
+		if err != nil {
+			res.Data = nil
+			res.Message = err.Error()
+		}
+		c.JSON(http.StatusOK, res)
+	}()
+
+	id := c.Param("lock_id")
+
+	res = DeleteLockByAdmin(controller.Context{
+		Uid: c.GetString(middleware.ContextUidField),
+	}, id)
+}
@@ -0,0 +1,51 @@
+// Copyright 2019 Axetroy. All rights reserved. MIT license.
+package lock_test
+
+import (
+	"github.com/axetroy/go-server/core/controller"
+	"github.com/axetroy/go-server/core/controller/auth"
+	"github.com/axetroy/go-server/core/controller/lock"
+	"github.com/axetroy/go-server/core/exception"
+	"github.com/axetroy/go-server/core/schema"
+	"github.com/axetroy/go-server/core/service/lockout"
+	"github.com/axetroy/go-server/tester"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestDeleteLockByAdmin(t *testing.T) {
+	adminInfo, _ := tester.LoginAdmin()
+	userInfo, _ := tester.CreateUser()
+
+	defer auth.DeleteUserByUserName(userInfo.Username)
+
+	lockUser(t, userInfo.Username)
+
+	id := string(lockout.ScopeUser) + ":" + string(lockout.KindAccount) + ":" + userInfo.Username
+
+	// 解除锁定
+	{
+		r := lock.DeleteLockByAdmin(controller.Context{Uid: adminInfo.Id}, id)
+
+		assert.Equal(t, schema.StatusSuccess, r.Status)
+		assert.Equal(t, "", r.Message)
+	}
+
+	// 解除后可以正常登陆
+	{
+		r := auth.SignIn(controller.Context{}, auth.SignInParams{
+			Account:  userInfo.Username,
+			Password: "123123",
+		})
+
+		assert.Equal(t, schema.StatusSuccess, r.Status)
+	}
+
+	// 不存在的锁定
+	{
+		r := lock.DeleteLockByAdmin(controller.Context{Uid: adminInfo.Id}, id)
+
+		assert.Equal(t, exception.LockNotExist.Code(), r.Status)
+		assert.Equal(t, exception.LockNotExist.Error(), r.Message)
+	}
+}
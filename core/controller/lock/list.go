@@ -0,0 +1,90 @@
+// Copyright 2019 Axetroy. All rights reserved. MIT license.
+package lock
+
+import (
+	"errors"
+	"github.com/axetroy/go-server/core/controller"
+	"github.com/axetroy/go-server/core/exception"
+	"github.com/axetroy/go-server/core/helper"
+	"github.com/axetroy/go-server/core/middleware"
+	"github.com/axetroy/go-server/core/schema"
+	"github.com/axetroy/go-server/core/service/lockout"
+	"github.com/gin-gonic/gin"
+	"net/http"
+	"time"
+)
+
+type Query struct {
+	Scope string `json:"scope" form:"scope"` // 根据锁定的场景筛选
+}
+
+func toSchema(l lockout.Lock) schema.Lock {
+	return schema.Lock{
+		Id:        l.Id,
+		Scope:     string(l.Scope),
+		Kind:      string(l.Kind),
+		Value:     l.Value,
+		Level:     l.Level,
+		ExpiredAt: l.ExpiredAt.Format(time.RFC3339Nano),
+	}
+}
+
+// 获取当前被锁定的账号和 IP
+func GetLockListByAdmin(c controller.Context, q Query) (res schema.Response) {
+	var (
+		err  error
+		data = make([]schema.Lock, 0)
+	)
+
+	defer func() {
+		if r := recover(); r != nil {
+			switch t := r.(type) {
+			case string:
+				err = errors.New(t)
+			case error:
+				err = t
+			default:
+				err = exception.Unknown
+			}
+		}
+
+		helper.Response(&res, data, err)
+	}()
+
+	list, err := lockout.List(lockout.Scope(q.Scope))
+
+	if err != nil {
+		return
+	}
+
+	for _, l := range list {
+		data = append(data, toSchema(l))
+	}
+
+	return
+}
+
+func GetLockListByAdminRouter(c *gin.Context) {
+	var (
+		err   error
+		res   = schema.Response{}
+		input Query
+	)
+
+	defer func() {
+		if err != nil {
+			res.Data = nil
+			res.Message = err.Error()
+		}
+		c.JSON(http.StatusOK, res)
+	}()
+
+	if err = c.ShouldBindQuery(&input); err != nil {
+		err = exception.InvalidParams
+		return
+	}
+
+	res = GetLockListByAdmin(controller.Context{
+		Uid: c.GetString(middleware.ContextUidField),
+	}, input)
+}
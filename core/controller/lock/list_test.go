@@ -0,0 +1,73 @@
+// Copyright 2019 Axetroy. All rights reserved. MIT license.
+package lock_test
+
+import (
+	"github.com/axetroy/go-server/core/config"
+	"github.com/axetroy/go-server/core/controller"
+	"github.com/axetroy/go-server/core/controller/auth"
+	"github.com/axetroy/go-server/core/controller/lock"
+	"github.com/axetroy/go-server/core/exception"
+	"github.com/axetroy/go-server/core/schema"
+	"github.com/axetroy/go-server/core/service/lockout"
+	"github.com/axetroy/go-server/tester"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+// 连续输错密码直到账号被锁定
+func lockUser(t *testing.T, username string) {
+	for i := int64(0); i < config.Lockout.AccountLimit; i++ {
+		r := auth.SignIn(controller.Context{}, auth.SignInParams{
+			Account:  username,
+			Password: "invalid password",
+		})
+
+		assert.Equal(t, exception.InvalidAccountOrPassword.Code(), r.Status)
+	}
+
+	// 被锁定后，即使密码正确也无法登陆
+	r := auth.SignIn(controller.Context{}, auth.SignInParams{
+		Account:  username,
+		Password: "123123",
+	})
+
+	assert.Equal(t, exception.AccountLocked.Code(), r.Status)
+	assert.Equal(t, exception.AccountLocked.Error(), r.Message)
+}
+
+func TestGetLockListByAdmin(t *testing.T) {
+	adminInfo, _ := tester.LoginAdmin()
+	userInfo, _ := tester.CreateUser()
+
+	defer auth.DeleteUserByUserName(userInfo.Username)
+
+	lockUser(t, userInfo.Username)
+
+	id := string(lockout.ScopeUser) + ":" + string(lockout.KindAccount) + ":" + userInfo.Username
+
+	defer lock.DeleteLockByAdmin(controller.Context{Uid: adminInfo.Id}, id)
+
+	r := lock.GetLockListByAdmin(controller.Context{Uid: adminInfo.Id}, lock.Query{
+		Scope: string(lockout.ScopeUser),
+	})
+
+	assert.Equal(t, schema.StatusSuccess, r.Status)
+
+	list := make([]schema.Lock, 0)
+
+	assert.Nil(t, tester.Decode(r.Data, &list))
+
+	found := false
+
+	for _, l := range list {
+		assert.Equal(t, string(lockout.ScopeUser), l.Scope)
+
+		if l.Id == id {
+			found = true
+			assert.Equal(t, userInfo.Username, l.Value)
+			assert.Equal(t, int64(1), l.Level)
+		}
+	}
+
+	assert.True(t, found)
+}
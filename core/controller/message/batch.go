@@ -0,0 +1,268 @@
+// Copyright 2019 Axetroy. All rights reserved. MIT license.
+package message
+
+import (
+	"errors"
+	"github.com/axetroy/go-server/core/controller"
+	"github.com/axetroy/go-server/core/exception"
+	"github.com/axetroy/go-server/core/helper"
+	"github.com/axetroy/go-server/core/middleware"
+	"github.com/axetroy/go-server/core/model"
+	"github.com/axetroy/go-server/core/schema"
+	"github.com/axetroy/go-server/core/service/database"
+	"github.com/gin-gonic/gin"
+	"github.com/jinzhu/gorm"
+	"net/http"
+	"time"
+)
+
+// 批量操作一次最多处理的数量
+var MaxBatchSize = 100
+
+type BatchParams struct {
+	Ids []string `json:"ids"` // 消息 ID 列表
+}
+
+func (p BatchParams) validate() error {
+	if len(p.Ids) == 0 || len(p.Ids) > MaxBatchSize {
+		return exception.InvalidParams
+	}
+
+	return nil
+}
+
+// 获取我的未读消息数量
+func GetUnreadCount(c controller.Context) (res schema.Response) {
+	var (
+		err  error
+		data int64
+	)
+
+	defer func() {
+		if r := recover(); r != nil {
+			switch t := r.(type) {
+			case string:
+				err = errors.New(t)
+			case error:
+				err = t
+			default:
+				err = exception.Unknown
+			}
+		}
+
+		helper.Response(&res, data, err)
+	}()
+
+	data, err = CountUnread(c.Uid)
+
+	return
+}
+
+// 标记消息为已读, ids 为空则标记所有消息, 返回标记的数量
+func markRead(uid string, ids []string) (res schema.Response) {
+	var (
+		err  error
+		data int64
+	)
+
+	defer func() {
+		if r := recover(); r != nil {
+			switch t := r.(type) {
+			case string:
+				err = errors.New(t)
+			case error:
+				err = t
+			default:
+				err = exception.Unknown
+			}
+		}
+
+		if err == nil {
+			unreadChanged(uid, -data)
+		}
+
+		helper.Response(&res, data, err)
+	}()
+
+	db := database.Db.Model(&model.Message{}).Where("uid = ? AND read = ?", uid, false)
+
+	if ids != nil {
+		db = db.Where("id IN (?)", ids)
+	}
+
+	result := db.UpdateColumns(map[string]interface{}{
+		"read":    true,
+		"read_at": time.Now(),
+	})
+
+	if err = result.Error; err != nil {
+		return
+	}
+
+	data = result.RowsAffected
+
+	return
+}
+
+// 标记我的所有消息为已读
+func ReadAll(c controller.Context) (res schema.Response) {
+	return markRead(c.Uid, nil)
+}
+
+// 批量标记消息为已读
+func ReadBatch(c controller.Context, input BatchParams) (res schema.Response) {
+	if err := input.validate(); err != nil {
+		helper.Response(&res, nil, err)
+		return
+	}
+
+	return markRead(c.Uid, input.Ids)
+}
+
+// 批量删除我的消息, 返回删除的数量
+func DeleteBatchByUser(c controller.Context, input BatchParams) (res schema.Response) {
+	var (
+		err   error
+		data  int64
+		tx    *gorm.DB
+		delta int64
+	)
+
+	defer func() {
+		if r := recover(); r != nil {
+			switch t := r.(type) {
+			case string:
+				err = errors.New(t)
+			case error:
+				err = t
+			default:
+				err = exception.Unknown
+			}
+		}
+
+		if tx != nil {
+			if err != nil {
+				_ = tx.Rollback().Error
+			} else {
+				err = tx.Commit().Error
+			}
+		}
+
+		if err == nil {
+			unreadChanged(c.Uid, delta)
+		}
+
+		helper.Response(&res, data, err)
+	}()
+
+	if err = input.validate(); err != nil {
+		return
+	}
+
+	tx = database.Db.Begin()
+
+	var unreadCount int64
+
+	if err = tx.Model(&model.Message{}).Where("uid = ? AND read = ? AND id IN (?)", c.Uid, false, input.Ids).Count(&unreadCount).Error; err != nil {
+		return
+	}
+
+	result := tx.Where("uid = ? AND id IN (?)", c.Uid, input.Ids).Delete(&model.Message{})
+
+	if err = result.Error; err != nil {
+		return
+	}
+
+	data = result.RowsAffected
+	delta = -unreadCount
+
+	return
+}
+
+func GetUnreadCountRouter(c *gin.Context) {
+	var (
+		err error
+		res = schema.Response{}
+	)
+
+	defer func() {
+		if err != nil {
+			res.Data = nil
+			res.Message = err.Error()
+		}
+		c.JSON(http.StatusOK, res)
+	}()
+
+	res = GetUnreadCount(controller.Context{
+		Uid: c.GetString(middleware.ContextUidField),
+	})
+}
+
+func ReadAllRouter(c *gin.Context) {
+	var (
+		err error
+		res = schema.Response{}
+	)
+
+	defer func() {
+		if err != nil {
+			res.Data = nil
+			res.Message = err.Error()
+		}
+		c.JSON(http.StatusOK, res)
+	}()
+
+	res = ReadAll(controller.Context{
+		Uid: c.GetString(middleware.ContextUidField),
+	})
+}
+
+func ReadBatchRouter(c *gin.Context) {
+	var (
+		input BatchParams
+		err   error
+		res   = schema.Response{}
+	)
+
+	defer func() {
+		if err != nil {
+			res.Data = nil
+			res.Message = err.Error()
+		}
+		c.JSON(http.StatusOK, res)
+	}()
+
+	if err = c.ShouldBindJSON(&input); err != nil {
+		err = exception.InvalidParams
+		return
+	}
+
+	res = ReadBatch(controller.Context{
+		Uid: c.GetString(middleware.ContextUidField),
+	}, input)
+}
+
+func DeleteBatchByUserRouter(c *gin.Context) {
+	var (
+		input BatchParams
+		err   error
+		res   = schema.Response{}
+	)
+
+	defer func() {
+		if err != nil {
+			res.Data = nil
+			res.Message = err.Error()
+		}
+		c.JSON(http.StatusOK, res)
+	}()
+
+	if err = c.ShouldBindJSON(&input); err != nil {
+		err = exception.InvalidParams
+		return
+	}
+
+	res = DeleteBatchByUser(controller.Context{
+		Uid: c.GetString(middleware.ContextUidField),
+	}, input)
+}
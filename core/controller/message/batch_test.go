@@ -0,0 +1,106 @@
+// Copyright 2019 Axetroy. All rights reserved. MIT license.
+package message_test
+
+import (
+	"github.com/axetroy/go-server/core/controller"
+	"github.com/axetroy/go-server/core/controller/auth"
+	"github.com/axetroy/go-server/core/controller/message"
+	"github.com/axetroy/go-server/core/exception"
+	"github.com/axetroy/go-server/core/schema"
+	"github.com/axetroy/go-server/tester"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestBatch(t *testing.T) {
+	adminInfo, _ := tester.LoginAdmin()
+	userInfo, _ := tester.CreateUser()
+
+	defer auth.DeleteUserByUserName(userInfo.Username)
+
+	context := controller.Context{Uid: userInfo.Id}
+
+	unreadCount := func() int64 {
+		r := message.GetUnreadCount(context)
+
+		assert.Equal(t, "", r.Message)
+
+		var count int64
+
+		assert.Nil(t, tester.Decode(r.Data, &count))
+
+		return count
+	}
+
+	assert.Equal(t, int64(0), unreadCount())
+
+	ids := make([]string, 0)
+
+	for i := 0; i < 5; i++ {
+		r := message.Create(controller.Context{Uid: adminInfo.Id}, message.CreateMessageParams{
+			Uid:     userInfo.Id,
+			Title:   "test",
+			Content: "test",
+		})
+
+		n := schema.Message{}
+
+		assert.Nil(t, tester.Decode(r.Data, &n))
+
+		defer message.DeleteMessageById(n.Id)
+
+		ids = append(ids, n.Id)
+	}
+
+	assert.Equal(t, int64(5), unreadCount())
+
+	// 单条标记已读, 重复标记不会重复扣减
+	{
+		message.MarkRead(context, ids[0])
+		message.MarkRead(context, ids[0])
+
+		assert.Equal(t, int64(4), unreadCount())
+	}
+
+	// 批量标记已读, 已读的不计入
+	{
+		r := message.ReadBatch(context, message.BatchParams{Ids: ids[:2]})
+
+		assert.Equal(t, "", r.Message)
+		assert.Equal(t, int64(1), r.Data)
+		assert.Equal(t, int64(3), unreadCount())
+	}
+
+	// 批量删除, 包括一条已读的
+	{
+		r := message.DeleteBatchByUser(context, message.BatchParams{Ids: ids[1:3]})
+
+		assert.Equal(t, "", r.Message)
+		assert.Equal(t, int64(2), r.Data)
+		assert.Equal(t, int64(2), unreadCount())
+	}
+
+	// 不能删除别人的消息
+	{
+		r := message.DeleteBatchByUser(controller.Context{Uid: adminInfo.Id}, message.BatchParams{Ids: ids[3:]})
+
+		assert.Equal(t, "", r.Message)
+		assert.Equal(t, int64(0), r.Data)
+	}
+
+	// 全部标记已读
+	{
+		r := message.ReadAll(context)
+
+		assert.Equal(t, "", r.Message)
+		assert.Equal(t, int64(2), r.Data)
+		assert.Equal(t, int64(0), unreadCount())
+	}
+
+	// 数量不正确
+	{
+		r := message.ReadBatch(context, message.BatchParams{})
+
+		assert.Equal(t, exception.InvalidParams.Error(), r.Message)
+	}
+}
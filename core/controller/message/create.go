@@ -10,6 +10,9 @@ import (
 	"github.com/axetroy/go-server/core/model"
 	"github.com/axetroy/go-server/core/schema"
 	"github.com/axetroy/go-server/core/service/database"
+	"github.com/axetroy/go-server/core/service/message_template"
+	"github.com/axetroy/go-server/core/service/preference"
+	"github.com/axetroy/go-server/core/service/push"
 	"github.com/axetroy/go-server/core/validator"
 	"github.com/gin-gonic/gin"
 	"github.com/jinzhu/gorm"
@@ -24,7 +27,33 @@ type CreateMessageParams struct {
 	Content string `json:"content" valid:"required~请填写消息内容"`
 }
 
+// 管理员直接创建的消息属于系统消息, 只有超级管理员可以直接创建
 func Create(c controller.Context, input CreateMessageParams) (res schema.Response) {
+	adminInfo := model.Admin{
+		Id: c.Uid,
+	}
+
+	if err := database.Db.First(&adminInfo).Error; err != nil {
+		// 没有找到管理员
+		if err == gorm.ErrRecordNotFound {
+			err = exception.AdminNotExist
+		}
+		helper.Response(&res, nil, err)
+		return
+	}
+
+	if !adminInfo.IsSuper {
+		helper.Response(&res, nil, exception.AdminNotSuper)
+		return
+	}
+
+	return CreateWithCategory(c, input, preference.CategorySystem)
+}
+
+// 创建某个分类的消息, 分类由消息的来源决定, 例如模版的分类
+// 用户关闭了该分类的站内消息时创建失败
+// 这里不校验管理员的权限, 由调用方自行校验, 例如模版发送由 RBAC 授权
+func CreateWithCategory(c controller.Context, input CreateMessageParams, category preference.Category) (res schema.Response) {
 	var (
 		err  error
 		data schema.Message
@@ -51,6 +80,12 @@ func Create(c controller.Context, input CreateMessageParams) (res schema.Respons
 			}
 		}
 
+		// 推送给用户, 推送失败不影响创建
+		if err == nil {
+			_ = unreadCounter.Add(input.Uid, 1)
+			_ = push.Publish(push.EventMessage, input.Uid, data)
+		}
+
 		helper.Response(&res, data, err)
 	}()
 
@@ -73,11 +108,6 @@ func Create(c controller.Context, input CreateMessageParams) (res schema.Respons
 		return
 	}
 
-	if !adminInfo.IsSuper {
-		err = exception.AdminNotSuper
-		return
-	}
-
 	userInfo := model.User{
 		Id: input.Uid,
 	}
@@ -90,6 +120,11 @@ func Create(c controller.Context, input CreateMessageParams) (res schema.Respons
 		return
 	}
 
+	// 用户关闭了该分类的站内消息
+	if _, err = preference.Check(tx, input.Uid, category, message_template.ChannelMessage); err != nil {
+		return
+	}
+
 	MessageInfo := model.Message{
 		Uid:     input.Uid,
 		Title:   input.Title,
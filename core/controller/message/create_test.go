@@ -9,6 +9,9 @@ import (
 	"github.com/axetroy/go-server/core/exception"
 	"github.com/axetroy/go-server/core/model"
 	"github.com/axetroy/go-server/core/schema"
+	"github.com/axetroy/go-server/core/service/database"
+	"github.com/axetroy/go-server/core/service/message_template"
+	"github.com/axetroy/go-server/core/service/preference"
 	"github.com/axetroy/go-server/core/service/token"
 	"github.com/axetroy/go-server/tester"
 	"github.com/axetroy/mocker"
@@ -70,6 +73,39 @@ func TestCreate(t *testing.T) {
 		assert.Equal(t, schema.StatusFail, r.Status)
 		assert.Equal(t, exception.AdminNotExist.Error(), r.Message)
 	}
+
+	// 用户关闭了营销类的站内消息
+	{
+		p := preference.Default(userInfo.Id)
+		p.SetEnabled(preference.CategoryMarketing, message_template.ChannelMessage, false)
+		m := p.ToModel()
+
+		assert.Nil(t, database.Db.Create(&m).Error)
+
+		defer database.DeleteRowByTable("notification_preference", "uid", userInfo.Id)
+
+		r := message.CreateWithCategory(controller.Context{
+			Uid: adminInfo.Id,
+		}, message.CreateMessageParams{
+			Uid:     userInfo.Id,
+			Title:   "test",
+			Content: "test",
+		}, preference.CategoryMarketing)
+
+		assert.Equal(t, exception.NotificationPreferenceDisabled.Code(), r.Status)
+		assert.Equal(t, exception.NotificationPreferenceDisabled.Error(), r.Message)
+
+		// 管理员直接创建的系统消息不受影响
+		r = message.Create(controller.Context{
+			Uid: adminInfo.Id,
+		}, message.CreateMessageParams{
+			Uid:     userInfo.Id,
+			Title:   "test",
+			Content: "test",
+		})
+
+		assert.Equal(t, "", r.Message)
+	}
 }
 
 func TestCreateRouter(t *testing.T) {
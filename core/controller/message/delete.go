@@ -23,9 +23,11 @@ func DeleteMessageById(id string) {
 
 func DeleteByAdmin(c controller.Context, messageId string) (res schema.Response) {
 	var (
-		err  error
-		data schema.Message
-		tx   *gorm.DB
+		err   error
+		data  schema.Message
+		tx    *gorm.DB
+		uid   string
+		delta int64
 	)
 
 	defer func() {
@@ -48,6 +50,10 @@ func DeleteByAdmin(c controller.Context, messageId string) (res schema.Response)
 			}
 		}
 
+		if err == nil {
+			unreadChanged(uid, delta)
+		}
+
 		helper.Response(&res, data, err)
 	}()
 
@@ -74,6 +80,12 @@ func DeleteByAdmin(c controller.Context, messageId string) (res schema.Response)
 		return
 	}
 
+	uid = messageInfo.Uid
+
+	if !messageInfo.Read {
+		delta = -1
+	}
+
 	if err = tx.Delete(model.Message{Id: messageInfo.Id}).Error; err != nil {
 		return
 	}
@@ -111,9 +123,10 @@ func DeleteByAdminRouter(c *gin.Context) {
 
 func DeleteByUser(c controller.Context, messageId string) (res schema.Response) {
 	var (
-		err  error
-		data schema.Message
-		tx   *gorm.DB
+		err   error
+		data  schema.Message
+		tx    *gorm.DB
+		delta int64
 	)
 
 	defer func() {
@@ -136,6 +149,10 @@ func DeleteByUser(c controller.Context, messageId string) (res schema.Response)
 			}
 		}
 
+		if err == nil {
+			unreadChanged(c.Uid, delta)
+		}
+
 		helper.Response(&res, data, err)
 	}()
 
@@ -154,6 +171,10 @@ func DeleteByUser(c controller.Context, messageId string) (res schema.Response)
 		return
 	}
 
+	if !messageInfo.Read {
+		delta = -1
+	}
+
 	if err = tx.Delete(model.Message{Id: messageInfo.Id}).Error; err != nil {
 		return
 	}
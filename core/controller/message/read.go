@@ -18,9 +18,10 @@ import (
 
 func MarkRead(c controller.Context, id string) (res schema.Response) {
 	var (
-		err  error
-		data schema.Message
-		tx   *gorm.DB
+		err   error
+		data  schema.Message
+		tx    *gorm.DB
+		delta int64
 	)
 
 	defer func() {
@@ -43,6 +44,10 @@ func MarkRead(c controller.Context, id string) (res schema.Response) {
 			}
 		}
 
+		if err == nil {
+			unreadChanged(c.Uid, delta)
+		}
+
 		helper.Response(&res, data, err)
 	}()
 
@@ -68,6 +73,10 @@ func MarkRead(c controller.Context, id string) (res schema.Response) {
 	data.CreatedAt = MessageInfo.CreatedAt.Format(time.RFC3339Nano)
 	data.UpdatedAt = MessageInfo.UpdatedAt.Format(time.RFC3339Nano)
 
+	if !MessageInfo.Read {
+		delta = -1
+	}
+
 	now := time.Now()
 
 	if err = tx.Model(&MessageInfo).UpdateColumn(model.Message{
@@ -0,0 +1,36 @@
+// Copyright 2019 Axetroy. All rights reserved. MIT license.
+package message
+
+import (
+	"github.com/axetroy/go-server/core/model"
+	"github.com/axetroy/go-server/core/service/database"
+	"github.com/axetroy/go-server/core/service/push"
+	"github.com/axetroy/go-server/core/service/unread"
+	"time"
+)
+
+var unreadCounter = unread.New("message", time.Hour, countUnreadFromDB)
+
+func countUnreadFromDB(uid string) (count int64, err error) {
+	err = database.Db.Model(&model.Message{}).Where("uid = ? AND read = ?", uid, false).Count(&count).Error
+
+	return
+}
+
+// 获取用户未读的个人消息数量
+func CountUnread(uid string) (int64, error) {
+	return unreadCounter.Get(uid)
+}
+
+// 未读数量发生变化, 更新缓存并通知用户
+func unreadChanged(uid string, delta int64) {
+	if delta == 0 {
+		return
+	}
+
+	if err := unreadCounter.Add(uid, delta); err != nil {
+		_ = unreadCounter.Invalidate(uid)
+	}
+
+	_ = push.Publish(push.EventUnread, uid, nil)
+}
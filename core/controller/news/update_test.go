@@ -24,7 +24,7 @@ func TestUpdate(t *testing.T) {
 		newsId   string
 	)
 	{
-		r := admin.Login(admin.SignInParams{
+		r := admin.Login(controller.Context{}, admin.SignInParams{
 			Username: "admin",
 			Password: "admin",
 		})
@@ -0,0 +1,159 @@
+// Copyright 2019 Axetroy. All rights reserved. MIT license.
+package notification
+
+import (
+	"github.com/axetroy/go-server/core/exception"
+	"github.com/axetroy/go-server/core/model"
+	"github.com/axetroy/go-server/core/service/message_template"
+	"github.com/axetroy/go-server/core/service/preference"
+	"github.com/jinzhu/gorm"
+	"github.com/lib/pq"
+	"time"
+)
+
+// 筛选出 now 时刻对该用户可见的通知
+// 通知必须处于启用状态, 在发布时间窗口内, 并且用户属于通知的受众
+func visibleTo(db *gorm.DB, userInfo model.User, now time.Time) *gorm.DB {
+	roles := userInfo.Role
+
+	if roles == nil {
+		roles = pq.StringArray{}
+	}
+
+	return db.
+		Where("notification.status = ?", model.NotificationStatusActive).
+		Where("notification.publish_at IS NULL OR notification.publish_at <= ?", now).
+		Where("notification.expire_at IS NULL OR notification.expire_at > ?", now).
+		Where(`notification.audience = ?
+			OR (notification.audience = ? AND notification.roles && ?::varchar[])
+			OR (notification.audience = ? AND ? = ANY(notification.levels))
+			OR (notification.audience = ? AND ? = ANY(notification.uids))`,
+			model.NotificationAudienceAll,
+			model.NotificationAudienceRole, roles,
+			model.NotificationAudienceLevel, userInfo.Level,
+			model.NotificationAudienceUser, userInfo.Id,
+		)
+}
+
+// 获取用户信息并筛选出对他可见的通知
+func visibleToUser(db *gorm.DB, uid string) (*gorm.DB, error) {
+	userInfo := model.User{}
+
+	if err := db.Where("id = ?", uid).First(&userInfo).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, exception.UserNotExist
+		}
+		return nil, err
+	}
+
+	return visibleTo(db.Model(&model.Notification{}), userInfo, time.Now()), nil
+}
+
+// 用户是否关闭了系统通知的站内消息, 关闭后不再展示和推送系统通知
+func Muted(db *gorm.DB, uid string) (bool, error) {
+	p, err := preference.Load(db, uid)
+
+	if err != nil {
+		return false, err
+	}
+
+	return !p.Enabled(preference.CategorySystem, message_template.ChannelMessage), nil
+}
+
+// 受众的参数
+type AudienceParams struct {
+	Audience  *model.NotificationAudience `json:"audience"`   // 通知的受众, all/role/level/user, 默认为 all
+	Roles     []string                    `json:"roles"`      // 受众为 role 时的角色列表
+	Levels    []int64                     `json:"levels"`     // 受众为 level 时的等级列表
+	Uids      []string                    `json:"uids"`       // 受众为 user 时的用户 ID 列表
+	PublishAt *string                     `json:"publish_at"` // 定时发布的时间, RFC3339 格式, 为空则立即发布
+	ExpireAt  *string                     `json:"expire_at"`  // 过期的时间, RFC3339 格式, 为空则永不过期
+}
+
+// 校验受众参数, 并且把它应用到通知上
+func (p AudienceParams) apply(n *model.Notification) error {
+	if p.Audience != nil {
+		n.Audience = *p.Audience
+	}
+
+	if n.Audience == "" {
+		n.Audience = model.NotificationAudienceAll
+	}
+
+	if p.Roles != nil {
+		n.Roles = p.Roles
+	}
+
+	if p.Levels != nil {
+		n.Levels = p.Levels
+	}
+
+	if p.Uids != nil {
+		n.Uids = p.Uids
+	}
+
+	valid := false
+
+	for _, a := range model.NotificationAudiences {
+		if a == n.Audience {
+			valid = true
+			break
+		}
+	}
+
+	if !valid {
+		return exception.NotificationInvalidAudience
+	}
+
+	switch n.Audience {
+	case model.NotificationAudienceRole:
+		if len(n.Roles) == 0 {
+			return exception.NotificationInvalidAudience
+		}
+	case model.NotificationAudienceLevel:
+		if len(n.Levels) == 0 {
+			return exception.NotificationInvalidAudience
+		}
+	case model.NotificationAudienceUser:
+		if len(n.Uids) == 0 {
+			return exception.NotificationInvalidAudience
+		}
+	}
+
+	if p.PublishAt != nil {
+		t, err := parseScheduleTime(*p.PublishAt)
+		if err != nil {
+			return err
+		}
+		n.PublishAt = t
+	}
+
+	if p.ExpireAt != nil {
+		t, err := parseScheduleTime(*p.ExpireAt)
+		if err != nil {
+			return err
+		}
+		n.ExpireAt = t
+	}
+
+	if n.PublishAt != nil && n.ExpireAt != nil && !n.ExpireAt.After(*n.PublishAt) {
+		return exception.NotificationInvalidSchedule
+	}
+
+	return nil
+}
+
+// 空字符串表示清除时间
+func parseScheduleTime(value string) (*time.Time, error) {
+	if value == "" {
+		return nil, nil
+	}
+
+	t, err := time.Parse(time.RFC3339, value)
+
+	if err != nil {
+		return nil, exception.NotificationInvalidSchedule
+	}
+
+	return &t, nil
+}
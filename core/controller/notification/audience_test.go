@@ -0,0 +1,204 @@
+// Copyright 2019 Axetroy. All rights reserved. MIT license.
+package notification_test
+
+import (
+	"github.com/axetroy/go-server/core/controller"
+	"github.com/axetroy/go-server/core/controller/auth"
+	"github.com/axetroy/go-server/core/controller/notification"
+	"github.com/axetroy/go-server/core/exception"
+	"github.com/axetroy/go-server/core/model"
+	"github.com/axetroy/go-server/core/schema"
+	"github.com/axetroy/go-server/core/service/database"
+	"github.com/axetroy/go-server/tester"
+	"github.com/stretchr/testify/assert"
+	"testing"
+	"time"
+)
+
+func TestAudience(t *testing.T) {
+	adminInfo, _ := tester.LoginAdmin()
+	user1, _ := tester.CreateUser()
+	user2, _ := tester.CreateUser()
+
+	defer auth.DeleteUserByUserName(user1.Username)
+	defer auth.DeleteUserByUserName(user2.Username)
+
+	admin := controller.Context{Uid: adminInfo.Id}
+
+	visible := func(uid string, id string) bool {
+		r := notification.GetByUser(controller.Context{Uid: uid}, id)
+		return r.Status == schema.StatusSuccess
+	}
+
+	create := func(input notification.CreateParams) string {
+		input.Title = "TestAudience"
+		input.Content = "TestAudience"
+
+		r := notification.Create(admin, input)
+
+		assert.Equal(t, "", r.Message)
+
+		n := schema.Notification{}
+
+		assert.Nil(t, tester.Decode(r.Data, &n))
+
+		return n.Id
+	}
+
+	// 指定用户
+	{
+		audience := model.NotificationAudienceUser
+
+		id := create(notification.CreateParams{AudienceParams: notification.AudienceParams{
+			Audience: &audience,
+			Uids:     []string{user1.Id},
+		}})
+
+		defer notification.DeleteNotificationById(id)
+
+		assert.True(t, visible(user1.Id, id))
+		assert.False(t, visible(user2.Id, id))
+	}
+
+	// 指定角色
+	{
+		audience := model.NotificationAudienceRole
+
+		id := create(notification.CreateParams{AudienceParams: notification.AudienceParams{
+			Audience: &audience,
+			Roles:    []string{"not-exist-role"},
+		}})
+
+		defer notification.DeleteNotificationById(id)
+
+		assert.False(t, visible(user1.Id, id))
+
+		roleId := create(notification.CreateParams{AudienceParams: notification.AudienceParams{
+			Audience: &audience,
+			Roles:    []string{model.DefaultUser.Name},
+		}})
+
+		defer notification.DeleteNotificationById(roleId)
+
+		assert.True(t, visible(user1.Id, roleId))
+		assert.True(t, visible(user2.Id, roleId))
+	}
+
+	// 指定等级
+	{
+		audience := model.NotificationAudienceLevel
+
+		id := create(notification.CreateParams{AudienceParams: notification.AudienceParams{
+			Audience: &audience,
+			Levels:   []int64{int64(user1.Level) + 1},
+		}})
+
+		defer notification.DeleteNotificationById(id)
+
+		assert.False(t, visible(user1.Id, id))
+	}
+
+	// 无效的受众
+	{
+		audience := model.NotificationAudience("unknown")
+
+		r := notification.Create(admin, notification.CreateParams{
+			Title:          "TestAudience",
+			Content:        "TestAudience",
+			AudienceParams: notification.AudienceParams{Audience: &audience},
+		})
+
+		assert.Equal(t, exception.NotificationInvalidAudience.Error(), r.Message)
+
+		audience = model.NotificationAudienceUser
+
+		r = notification.Create(admin, notification.CreateParams{
+			Title:          "TestAudience",
+			Content:        "TestAudience",
+			AudienceParams: notification.AudienceParams{Audience: &audience},
+		})
+
+		assert.Equal(t, exception.NotificationInvalidAudience.Error(), r.Message)
+	}
+}
+
+func TestSchedule(t *testing.T) {
+	adminInfo, _ := tester.LoginAdmin()
+	userInfo, _ := tester.CreateUser()
+
+	defer auth.DeleteUserByUserName(userInfo.Username)
+
+	admin := controller.Context{Uid: adminInfo.Id}
+	user := controller.Context{Uid: userInfo.Id}
+
+	publishAt := time.Now().Add(time.Hour).Format(time.RFC3339)
+	expireAt := time.Now().Add(time.Hour * 2).Format(time.RFC3339)
+
+	r := notification.Create(admin, notification.CreateParams{
+		Title:   "TestSchedule",
+		Content: "TestSchedule",
+		AudienceParams: notification.AudienceParams{
+			PublishAt: &publishAt,
+			ExpireAt:  &expireAt,
+		},
+	})
+
+	assert.Equal(t, "", r.Message)
+
+	n := schema.Notification{}
+
+	assert.Nil(t, tester.Decode(r.Data, &n))
+
+	defer notification.DeleteNotificationById(n.Id)
+
+	notificationInfo := model.Notification{}
+
+	assert.Nil(t, database.Db.Where("id = ?", n.Id).First(&notificationInfo).Error)
+	assert.Equal(t, model.NotificationStatusPending, notificationInfo.Status)
+
+	// 还没到发布时间, 用户看不到
+	assert.Equal(t, exception.NoData.Error(), notification.GetByUser(user, n.Id).Message)
+
+	// 到了发布时间, 调度之后用户可见
+	{
+		activated, _, err := notification.RunSchedule(time.Now().Add(time.Hour + time.Minute))
+
+		assert.Nil(t, err)
+		assert.True(t, activated >= 1)
+
+		assert.Nil(t, database.Db.Where("id = ?", n.Id).First(&notificationInfo).Error)
+		assert.Equal(t, model.NotificationStatusActive, notificationInfo.Status)
+
+		// 把发布时间调整到现在, 让它在当前时间窗口内
+		assert.Nil(t, database.Db.Model(&notificationInfo).Update("publish_at", time.Now().Add(-time.Minute)).Error)
+
+		assert.Equal(t, "", notification.GetByUser(user, n.Id).Message)
+	}
+
+	// 过期之后用户不可见
+	{
+		_, expired, err := notification.RunSchedule(time.Now().Add(time.Hour * 3))
+
+		assert.Nil(t, err)
+		assert.True(t, expired >= 1)
+
+		assert.Nil(t, database.Db.Where("id = ?", n.Id).First(&notificationInfo).Error)
+		assert.Equal(t, model.NotificationStatusExpired, notificationInfo.Status)
+
+		assert.Equal(t, exception.NoData.Error(), notification.GetByUser(user, n.Id).Message)
+	}
+
+	// 过期时间早于发布时间
+	{
+		r := notification.Create(admin, notification.CreateParams{
+			Title:   "TestSchedule",
+			Content: "TestSchedule",
+			AudienceParams: notification.AudienceParams{
+				PublishAt: &expireAt,
+				ExpireAt:  &publishAt,
+			},
+		})
+
+		assert.Equal(t, exception.NotificationInvalidSchedule.Error(), r.Message)
+	}
+}
@@ -0,0 +1,277 @@
+// Copyright 2019 Axetroy. All rights reserved. MIT license.
+package notification
+
+import (
+	"errors"
+	"github.com/axetroy/go-server/core/controller"
+	"github.com/axetroy/go-server/core/exception"
+	"github.com/axetroy/go-server/core/helper"
+	"github.com/axetroy/go-server/core/middleware"
+	"github.com/axetroy/go-server/core/model"
+	"github.com/axetroy/go-server/core/schema"
+	"github.com/axetroy/go-server/core/service/database"
+	"github.com/axetroy/go-server/core/service/push"
+	"github.com/gin-gonic/gin"
+	"github.com/jinzhu/gorm"
+	"net/http"
+)
+
+// 批量操作一次最多处理的数量
+var MaxBatchSize = 100
+
+type BatchParams struct {
+	Ids []string `json:"ids"` // 通知 ID 列表
+}
+
+func (p BatchParams) validate() error {
+	if len(p.Ids) == 0 || len(p.Ids) > MaxBatchSize {
+		return exception.InvalidParams
+	}
+
+	return nil
+}
+
+// 获取我的未读通知数量
+func GetUnreadCount(c controller.Context) (res schema.Response) {
+	var (
+		err  error
+		data int64
+	)
+
+	defer func() {
+		if r := recover(); r != nil {
+			switch t := r.(type) {
+			case string:
+				err = errors.New(t)
+			case error:
+				err = t
+			default:
+				err = exception.Unknown
+			}
+		}
+
+		helper.Response(&res, data, err)
+	}()
+
+	data, err = CountUnread(c.Uid)
+
+	return
+}
+
+// 标记通知为已读, ids 为空则标记所有通知, 返回标记的数量
+// 为还没有已读记录的通知批量插入已读记录
+func markRead(uid string, ids []string) (res schema.Response) {
+	var (
+		err  error
+		data int64
+	)
+
+	defer func() {
+		if r := recover(); r != nil {
+			switch t := r.(type) {
+			case string:
+				err = errors.New(t)
+			case error:
+				err = t
+			default:
+				err = exception.Unknown
+			}
+		}
+
+		if err == nil {
+			unreadChanged(uid, -data)
+		}
+
+		helper.Response(&res, data, err)
+	}()
+
+	visible, err := visibleToUser(database.Db, uid)
+
+	if err != nil {
+		return
+	}
+
+	// 只标记对用户可见的通知
+	unread := visible.
+		Select("notification.id, ?, TRUE, NOW(), NOW()", uid).
+		Where("notification.id NOT IN (?)", database.Db.Model(&model.NotificationMark{}).Select("id").Where("uid = ?", uid).SubQuery())
+
+	if ids != nil {
+		unread = unread.Where("notification.id IN (?)", ids)
+	}
+
+	result := database.Db.Exec("INSERT INTO notification_mark (id, uid, read, created_at, updated_at) ? ON CONFLICT DO NOTHING", unread.SubQuery())
+
+	if err = result.Error; err != nil {
+		return
+	}
+
+	data = result.RowsAffected
+
+	return
+}
+
+// 标记所有通知为已读
+func ReadAll(c controller.Context) (res schema.Response) {
+	return markRead(c.Uid, nil)
+}
+
+// 批量标记通知为已读
+func ReadBatch(c controller.Context, input BatchParams) (res schema.Response) {
+	if err := input.validate(); err != nil {
+		helper.Response(&res, nil, err)
+		return
+	}
+
+	return markRead(c.Uid, input.Ids)
+}
+
+// 管理员批量删除通知, 返回删除的数量
+func DeleteBatch(c controller.Context, input BatchParams) (res schema.Response) {
+	var (
+		err  error
+		data int64
+		tx   *gorm.DB
+	)
+
+	defer func() {
+		if r := recover(); r != nil {
+			switch t := r.(type) {
+			case string:
+				err = errors.New(t)
+			case error:
+				err = t
+			default:
+				err = exception.Unknown
+			}
+		}
+
+		if tx != nil {
+			if err != nil {
+				_ = tx.Rollback().Error
+			} else {
+				err = tx.Commit().Error
+			}
+		}
+
+		if err == nil && data > 0 {
+			unreadChangedAll()
+			_ = push.Publish(push.EventUnread, "", nil)
+		}
+
+		helper.Response(&res, data, err)
+	}()
+
+	if err = input.validate(); err != nil {
+		return
+	}
+
+	tx = database.Db.Begin()
+
+	adminInfo := model.Admin{Id: c.Uid}
+
+	if err = tx.First(&adminInfo).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			err = exception.AdminNotExist
+		}
+		return
+	}
+
+	result := tx.Where("id IN (?)", input.Ids).Delete(&model.Notification{})
+
+	if err = result.Error; err != nil {
+		return
+	}
+
+	data = result.RowsAffected
+
+	return
+}
+
+func GetUnreadCountRouter(c *gin.Context) {
+	var (
+		err error
+		res = schema.Response{}
+	)
+
+	defer func() {
+		if err != nil {
+			res.Data = nil
+			res.Message = err.Error()
+		}
+		c.JSON(http.StatusOK, res)
+	}()
+
+	res = GetUnreadCount(controller.Context{
+		Uid: c.GetString(middleware.ContextUidField),
+	})
+}
+
+func ReadAllRouter(c *gin.Context) {
+	var (
+		err error
+		res = schema.Response{}
+	)
+
+	defer func() {
+		if err != nil {
+			res.Data = nil
+			res.Message = err.Error()
+		}
+		c.JSON(http.StatusOK, res)
+	}()
+
+	res = ReadAll(controller.Context{
+		Uid: c.GetString(middleware.ContextUidField),
+	})
+}
+
+func ReadBatchRouter(c *gin.Context) {
+	var (
+		input BatchParams
+		err   error
+		res   = schema.Response{}
+	)
+
+	defer func() {
+		if err != nil {
+			res.Data = nil
+			res.Message = err.Error()
+		}
+		c.JSON(http.StatusOK, res)
+	}()
+
+	if err = c.ShouldBindJSON(&input); err != nil {
+		err = exception.InvalidParams
+		return
+	}
+
+	res = ReadBatch(controller.Context{
+		Uid: c.GetString(middleware.ContextUidField),
+	}, input)
+}
+
+func DeleteBatchRouter(c *gin.Context) {
+	var (
+		input BatchParams
+		err   error
+		res   = schema.Response{}
+	)
+
+	defer func() {
+		if err != nil {
+			res.Data = nil
+			res.Message = err.Error()
+		}
+		c.JSON(http.StatusOK, res)
+	}()
+
+	if err = c.ShouldBindJSON(&input); err != nil {
+		err = exception.InvalidParams
+		return
+	}
+
+	res = DeleteBatch(controller.Context{
+		Uid: c.GetString(middleware.ContextUidField),
+	}, input)
+}
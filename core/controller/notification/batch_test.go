@@ -0,0 +1,107 @@
+// Copyright 2019 Axetroy. All rights reserved. MIT license.
+package notification_test
+
+import (
+	"github.com/axetroy/go-server/core/controller"
+	"github.com/axetroy/go-server/core/controller/auth"
+	"github.com/axetroy/go-server/core/controller/notification"
+	"github.com/axetroy/go-server/core/exception"
+	"github.com/axetroy/go-server/core/schema"
+	"github.com/axetroy/go-server/tester"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestBatch(t *testing.T) {
+	adminInfo, _ := tester.LoginAdmin()
+	user1, _ := tester.CreateUser()
+	user2, _ := tester.CreateUser()
+
+	defer auth.DeleteUserByUserName(user1.Username)
+	defer auth.DeleteUserByUserName(user2.Username)
+
+	admin := controller.Context{Uid: adminInfo.Id}
+
+	unreadCount := func(uid string) int64 {
+		r := notification.GetUnreadCount(controller.Context{Uid: uid})
+
+		assert.Equal(t, "", r.Message)
+
+		var count int64
+
+		assert.Nil(t, tester.Decode(r.Data, &count))
+
+		return count
+	}
+
+	// 先把之前的通知都标记为已读
+	notification.ReadAll(controller.Context{Uid: user1.Id})
+	notification.ReadAll(controller.Context{Uid: user2.Id})
+
+	assert.Equal(t, int64(0), unreadCount(user1.Id))
+
+	ids := make([]string, 0)
+
+	for i := 0; i < 3; i++ {
+		r := notification.Create(admin, notification.CreateParams{
+			Title:   "test",
+			Content: "test",
+		})
+
+		n := schema.Notification{}
+
+		assert.Nil(t, tester.Decode(r.Data, &n))
+
+		defer notification.DeleteNotificationById(n.Id)
+		defer notification.DeleteNotificationMarkById(n.Id)
+
+		ids = append(ids, n.Id)
+	}
+
+	assert.Equal(t, int64(3), unreadCount(user1.Id))
+	assert.Equal(t, int64(3), unreadCount(user2.Id))
+
+	// 不同的用户可以标记同一条通知
+	{
+		notification.MarkRead(controller.Context{Uid: user1.Id}, ids[0])
+		notification.MarkRead(controller.Context{Uid: user2.Id}, ids[0])
+
+		assert.Equal(t, int64(2), unreadCount(user1.Id))
+		assert.Equal(t, int64(2), unreadCount(user2.Id))
+	}
+
+	// 批量标记已读, 已读的不计入
+	{
+		r := notification.ReadBatch(controller.Context{Uid: user1.Id}, notification.BatchParams{Ids: ids[:2]})
+
+		assert.Equal(t, "", r.Message)
+		assert.Equal(t, int64(1), r.Data)
+		assert.Equal(t, int64(1), unreadCount(user1.Id))
+		assert.Equal(t, int64(2), unreadCount(user2.Id))
+	}
+
+	// 全部标记已读
+	{
+		r := notification.ReadAll(controller.Context{Uid: user2.Id})
+
+		assert.Equal(t, "", r.Message)
+		assert.Equal(t, int64(2), r.Data)
+		assert.Equal(t, int64(0), unreadCount(user2.Id))
+	}
+
+	// 管理员批量删除
+	{
+		r := notification.DeleteBatch(admin, notification.BatchParams{Ids: ids[2:]})
+
+		assert.Equal(t, "", r.Message)
+		assert.Equal(t, int64(1), r.Data)
+		assert.Equal(t, int64(0), unreadCount(user1.Id))
+	}
+
+	// 数量不正确
+	{
+		r := notification.ReadBatch(controller.Context{Uid: user1.Id}, notification.BatchParams{})
+
+		assert.Equal(t, exception.InvalidParams.Error(), r.Message)
+	}
+}
@@ -22,13 +22,15 @@ type CreateParams struct {
 	Title   string  `json:"title" valid:"required~请输入公告标题"`   // 公告标题
 	Content string  `json:"content" valid:"required~请输入公告内容"` // 公告内容
 	Note    *string `json:"note"`                             // 备注
+	AudienceParams
 }
 
 func Create(c controller.Context, input CreateParams) (res schema.Response) {
 	var (
-		err  error
-		data schema.Notification
-		tx   *gorm.DB
+		err              error
+		data             schema.Notification
+		tx               *gorm.DB
+		notificationInfo model.Notification
 	)
 
 	defer func() {
@@ -51,6 +53,11 @@ func Create(c controller.Context, input CreateParams) (res schema.Response) {
 			}
 		}
 
+		// 定时发布的通知会在发布时再推送
+		if err == nil && notificationInfo.Status == model.NotificationStatusActive {
+			published(notificationInfo)
+		}
+
 		helper.Response(&res, data, err)
 	}()
 
@@ -73,13 +80,17 @@ func Create(c controller.Context, input CreateParams) (res schema.Response) {
 		return
 	}
 
-	notificationInfo := model.Notification{
+	notificationInfo = model.Notification{
 		Author:  adminInfo.Id,
 		Title:   input.Title,
 		Content: input.Content,
 		Note:    input.Note,
 	}
 
+	if err = input.AudienceParams.apply(&notificationInfo); err != nil {
+		return
+	}
+
 	if err = tx.Create(&notificationInfo).Error; err != nil {
 		return
 	}
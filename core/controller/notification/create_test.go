@@ -20,7 +20,7 @@ func TestCreate(t *testing.T) {
 	{
 		// 登陆超级管理员-成功
 
-		r := admin.Login(admin.SignInParams{
+		r := admin.Login(controller.Context{}, admin.SignInParams{
 			Username: "admin",
 			Password: "admin",
 		})
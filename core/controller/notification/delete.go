@@ -10,6 +10,7 @@ import (
 	"github.com/axetroy/go-server/core/model"
 	"github.com/axetroy/go-server/core/schema"
 	"github.com/axetroy/go-server/core/service/database"
+	"github.com/axetroy/go-server/core/service/push"
 	"github.com/gin-gonic/gin"
 	"github.com/jinzhu/gorm"
 	"github.com/mitchellh/mapstructure"
@@ -52,6 +53,12 @@ func Delete(c controller.Context, notificationId string) (res schema.Response) {
 			}
 		}
 
+		// 通知所有用户未读数量发生变化
+		if err == nil {
+			unreadChangedAll()
+			_ = push.Publish(push.EventUnread, "", nil)
+		}
+
 		helper.Response(&res, data, err)
 	}()
 
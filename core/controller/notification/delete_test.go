@@ -26,7 +26,7 @@ func TestDelete(t *testing.T) {
 	{
 		// 登陆超级管理员-成功
 
-		r := admin.Login(admin.SignInParams{
+		r := admin.Login(controller.Context{}, admin.SignInParams{
 			Username: "admin",
 			Password: "admin",
 		})
@@ -122,7 +122,7 @@ func TestDeleteRouter(t *testing.T) {
 	{
 		// 登陆超级管理员-成功
 
-		r := admin.Login(admin.SignInParams{
+		r := admin.Login(controller.Context{}, admin.SignInParams{
 			Username: "admin",
 			Password: "admin",
 		})
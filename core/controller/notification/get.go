@@ -3,7 +3,6 @@ package notification
 
 import (
 	"errors"
-	"fmt"
 	"github.com/axetroy/go-server/core/controller"
 	"github.com/axetroy/go-server/core/exception"
 	"github.com/axetroy/go-server/core/helper"
@@ -51,12 +50,10 @@ func Get(c controller.Context, id string) (res schema.Response) {
 
 	tx = database.Db.Begin()
 
-	notificationInfo := model.Notification{}
-	NotificationMark := model.NotificationMark{Id: id}
+	notificationInfo := model.Notification{Id: id}
+	NotificationMark := model.NotificationMark{Id: id, Uid: c.Uid}
 
-	sql := fmt.Sprintf("LEFT JOIN notification_mark ON notification_mark.id = notification.id AND notification.id = '%s'", notificationInfo.Id)
-
-	if err = tx.Table(notificationInfo.TableName()).Joins(sql).Last(&notificationInfo).Error; err != nil {
+	if err = tx.Where(&notificationInfo).Last(&notificationInfo).Error; err != nil {
 		if err == gorm.ErrRecordNotFound {
 			err = exception.NoData
 		}
@@ -86,6 +83,50 @@ func Get(c controller.Context, id string) (res schema.Response) {
 	return
 }
 
+// 用户获取通知详情, 只能获取对他可见的通知
+func GetByUser(c controller.Context, id string) (res schema.Response) {
+	var (
+		err   error
+		count int64
+	)
+
+	defer func() {
+		if r := recover(); r != nil {
+			switch t := r.(type) {
+			case string:
+				err = errors.New(t)
+			case error:
+				err = t
+			default:
+				err = exception.Unknown
+			}
+		}
+
+		if err != nil {
+			helper.Response(&res, nil, err)
+		}
+	}()
+
+	visible, err := visibleToUser(database.Db, c.Uid)
+
+	if err != nil {
+		return
+	}
+
+	if err = visible.Where("notification.id = ?", id).Count(&count).Error; err != nil {
+		return
+	}
+
+	if count == 0 {
+		err = exception.NoData
+		return
+	}
+
+	res = Get(c, id)
+
+	return
+}
+
 // GetRouter get notification detail router
 func GetRouter(c *gin.Context) {
 	var (
@@ -107,3 +148,24 @@ func GetRouter(c *gin.Context) {
 		Uid: c.GetString(middleware.ContextUidField),
 	}, id)
 }
+
+func GetByUserRouter(c *gin.Context) {
+	var (
+		err error
+		res = schema.Response{}
+	)
+
+	defer func() {
+		if err != nil {
+			res.Data = nil
+			res.Message = err.Error()
+		}
+		c.JSON(http.StatusOK, res)
+	}()
+
+	id := c.Param("id")
+
+	res = GetByUser(controller.Context{
+		Uid: c.GetString(middleware.ContextUidField),
+	}, id)
+}
@@ -60,17 +60,33 @@ func GetNotificationListByUser(c controller.Context, input Query) (res schema.Li
 
 	tx = database.Db.Begin()
 
+	meta.Page = query.Page
+	meta.Limit = query.Limit
+	meta.Sort = query.Sort
+
+	var muted bool
+
+	// 用户关闭了系统通知的站内消息
+	if muted, err = Muted(tx, c.Uid); err != nil || muted {
+		return
+	}
+
 	list := make([]model.Notification, 0)
 
-	filter := map[string]interface{}{}
+	var visible *gorm.DB
 
-	if err = query.Order(tx.Limit(query.Limit).Offset(query.Limit * query.Page).Where(filter)).Find(&list).Error; err != nil {
+	// 只返回用户是受众, 并且在发布时间窗口内的通知
+	if visible, err = visibleToUser(tx, c.Uid); err != nil {
+		return
+	}
+
+	if err = query.Order(visible.Limit(query.Limit).Offset(query.Limit * query.Page)).Find(&list).Error; err != nil {
 		return
 	}
 
 	var total int64
 
-	if err = tx.Model(&model.Notification{}).Count(&total).Error; err != nil {
+	if err = visible.Count(&total).Error; err != nil {
 		return
 	}
 
@@ -107,9 +123,6 @@ func GetNotificationListByUser(c controller.Context, input Query) (res schema.Li
 
 	meta.Total = total
 	meta.Num = len(data)
-	meta.Page = query.Page
-	meta.Limit = query.Limit
-	meta.Sort = query.Sort
 
 	return
 }
@@ -172,6 +185,14 @@ func GetNotificationListByAdmin(c controller.Context, input Query) (res schema.L
 			err = er
 			return
 		}
+		if v.PublishAt != nil {
+			publishAt := v.PublishAt.Format(time.RFC3339Nano)
+			d.PublishAt = &publishAt
+		}
+		if v.ExpireAt != nil {
+			expireAt := v.ExpireAt.Format(time.RFC3339Nano)
+			d.ExpireAt = &expireAt
+		}
 		d.CreatedAt = v.CreatedAt.Format(time.RFC3339Nano)
 		d.UpdatedAt = v.UpdatedAt.Format(time.RFC3339Nano)
 		data = append(data, d)
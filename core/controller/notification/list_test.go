@@ -8,6 +8,9 @@ import (
 	"github.com/axetroy/go-server/core/controller/auth"
 	"github.com/axetroy/go-server/core/controller/notification"
 	"github.com/axetroy/go-server/core/schema"
+	"github.com/axetroy/go-server/core/service/database"
+	"github.com/axetroy/go-server/core/service/message_template"
+	"github.com/axetroy/go-server/core/service/preference"
 	"github.com/axetroy/go-server/core/service/token"
 	"github.com/axetroy/go-server/tester"
 	"github.com/axetroy/mocker"
@@ -16,13 +19,17 @@ import (
 )
 
 func TestGetNotificationListByUser(t *testing.T) {
+	userInfo, _ := tester.CreateUser()
+
+	defer auth.DeleteUserByUserName(userInfo.Username)
+
 	{
 		var (
 			adminUid string
 		)
 		// 1. 先登陆获取管理员的Token
 		{
-			r := admin.Login(admin.SignInParams{
+			r := admin.Login(controller.Context{}, admin.SignInParams{
 				Username: "admin",
 				Password: "admin",
 			})
@@ -73,7 +80,9 @@ func TestGetNotificationListByUser(t *testing.T) {
 			query := schema.Query{
 				Limit: 20,
 			}
-			r := notification.GetNotificationListByUser(controller.Context{}, notification.Query{
+			r := notification.GetNotificationListByUser(controller.Context{
+				Uid: userInfo.Id,
+			}, notification.Query{
 				Query: query,
 			})
 
@@ -88,6 +97,33 @@ func TestGetNotificationListByUser(t *testing.T) {
 
 			assert.True(t, len(data) > 0)
 		}
+
+		// 4. 用户关闭了系统通知的站内消息, 不再展示系统通知
+		{
+			p := preference.Default(userInfo.Id)
+			p.SetEnabled(preference.CategorySystem, message_template.ChannelMessage, false)
+			m := p.ToModel()
+
+			assert.Nil(t, database.Db.Create(&m).Error)
+
+			defer database.DeleteRowByTable("notification_preference", "uid", userInfo.Id)
+
+			data := make([]schema.Notification, 0)
+
+			r := notification.GetNotificationListByUser(controller.Context{
+				Uid: userInfo.Id,
+			}, notification.Query{})
+
+			assert.Equal(t, "", r.Message)
+			assert.Nil(t, tester.Decode(r.Data, &data))
+			assert.Len(t, data, 0)
+			assert.Equal(t, int64(0), r.Meta.Total)
+
+			count, err := notification.CountUnread(userInfo.Id)
+
+			assert.Nil(t, err)
+			assert.Equal(t, int64(0), count)
+		}
 	}
 }
 
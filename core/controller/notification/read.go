@@ -13,13 +13,15 @@ import (
 	"github.com/gin-gonic/gin"
 	"github.com/jinzhu/gorm"
 	"net/http"
+	"time"
 )
 
 // MarkRead mark notification as read
 func MarkRead(c controller.Context, notificationID string) (res schema.Response) {
 	var (
-		err error
-		tx  *gorm.DB
+		err   error
+		tx    *gorm.DB
+		delta int64
 	)
 
 	defer func() {
@@ -42,6 +44,10 @@ func MarkRead(c controller.Context, notificationID string) (res schema.Response)
 			}
 		}
 
+		if err == nil {
+			unreadChanged(c.Uid, delta)
+		}
+
 		helper.Response(&res, nil, err)
 	}()
 
@@ -59,12 +65,10 @@ func MarkRead(c controller.Context, notificationID string) (res schema.Response)
 		return
 	}
 
-	notificationInfo := model.Notification{
-		Id: notificationID,
-	}
+	notificationInfo := model.Notification{}
 
-	// 先获取通知
-	if err = tx.Where(&notificationInfo).Last(&notificationInfo).Error; err != nil {
+	// 先获取对用户可见的通知
+	if err = visibleTo(tx.Model(&model.Notification{}), userInfo, time.Now()).Where("notification.id = ?", notificationID).Last(&notificationInfo).Error; err != nil {
 		if err == gorm.ErrRecordNotFound {
 			err = exception.NoData
 		}
@@ -94,6 +98,8 @@ func MarkRead(c controller.Context, notificationID string) (res schema.Response)
 		return
 	}
 
+	delta = -1
+
 	return
 }
 
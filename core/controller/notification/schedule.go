@@ -0,0 +1,113 @@
+// Copyright 2019 Axetroy. All rights reserved. MIT license.
+package notification
+
+import (
+	"github.com/axetroy/go-server/core/config"
+	"github.com/axetroy/go-server/core/logger"
+	"github.com/axetroy/go-server/core/model"
+	"github.com/axetroy/go-server/core/schema"
+	"github.com/axetroy/go-server/core/service/database"
+	"github.com/axetroy/go-server/core/service/push"
+	"github.com/mitchellh/mapstructure"
+	"log"
+	"time"
+)
+
+// 通知已经发布, 推送给它的受众, 推送失败不影响发布
+func published(notificationInfo model.Notification) {
+	unreadChangedAll()
+
+	data := schema.Notification{}
+
+	if err := mapstructure.Decode(notificationInfo, &data.NotificationPure); err != nil {
+		return
+	}
+
+	data.CreatedAt = notificationInfo.CreatedAt.Format(time.RFC3339Nano)
+	data.UpdatedAt = notificationInfo.UpdatedAt.Format(time.RFC3339Nano)
+
+	switch notificationInfo.Audience {
+	case model.NotificationAudienceUser:
+		for _, uid := range notificationInfo.Uids {
+			_ = push.Publish(push.EventNotification, uid, data)
+		}
+	case model.NotificationAudienceRole, model.NotificationAudienceLevel:
+		// 推送服务并不知道用户的角色和等级, 只通知所有用户刷新未读数量
+		_ = push.Publish(push.EventUnread, "", nil)
+	default:
+		_ = push.Publish(push.EventNotification, "", data)
+	}
+}
+
+// 发布到达发布时间的通知, 过期到达过期时间的通知
+// 返回发布和过期的数量
+func RunSchedule(now time.Time) (activated int, expired int, err error) {
+	list := make([]model.Notification, 0)
+
+	if err = database.Db.Where("status = ? AND publish_at <= ?", model.NotificationStatusPending, now).Find(&list).Error; err != nil {
+		return
+	}
+
+	for _, n := range list {
+		status := n.Schedule(now)
+
+		result := database.Db.Model(&model.Notification{}).Where("id = ? AND status = ?", n.Id, model.NotificationStatusPending).Update("status", status)
+
+		if err = result.Error; err != nil {
+			return
+		}
+
+		// 已经被其他进程处理
+		if result.RowsAffected == 0 {
+			continue
+		}
+
+		if status == model.NotificationStatusActive {
+			n.Status = status
+			published(n)
+			activated++
+		} else {
+			expired++
+		}
+	}
+
+	result := database.Db.Model(&model.Notification{}).
+		Where("status IN (?) AND expire_at <= ?", []model.NotificationStatus{model.NotificationStatusActive, model.NotificationStatusPending}, now).
+		Update("status", model.NotificationStatusExpired)
+
+	if err = result.Error; err != nil {
+		return
+	}
+
+	expired += int(result.RowsAffected)
+
+	if expired > 0 {
+		unreadChangedAll()
+		_ = push.Publish(push.EventUnread, "", nil)
+	}
+
+	return
+}
+
+// 定时发布和过期通知
+func RunScheduleWorker(interval time.Duration) {
+	ticker := time.NewTicker(interval)
+
+	defer ticker.Stop()
+
+	for range ticker.C {
+		if config.Common.Exiting {
+			return
+		}
+
+		activated, expired, err := RunSchedule(time.Now())
+
+		if err != nil {
+			log.Println("定时发布系统通知失败:", err)
+		}
+
+		if activated > 0 || expired > 0 {
+			logger.Infof("%d notifications published, %d notifications expired", activated, expired)
+		}
+	}
+}
@@ -0,0 +1,56 @@
+// Copyright 2019 Axetroy. All rights reserved. MIT license.
+package notification
+
+import (
+	"github.com/axetroy/go-server/core/model"
+	"github.com/axetroy/go-server/core/service/database"
+	"github.com/axetroy/go-server/core/service/push"
+	"github.com/axetroy/go-server/core/service/unread"
+	"time"
+)
+
+var unreadCounter = unread.New("notification", time.Hour, countUnreadFromDB)
+
+// 对用户可见并且没有已读记录的通知即为未读
+func countUnreadFromDB(uid string) (count int64, err error) {
+	visible, err := visibleToUser(database.Db, uid)
+
+	if err != nil {
+		return
+	}
+
+	err = visible.
+		Where("notification.id NOT IN (?)", database.Db.Model(&model.NotificationMark{}).Select("id").Where("uid = ?", uid).SubQuery()).
+		Count(&count).Error
+
+	return
+}
+
+// 获取用户未读的系统通知数量, 关闭了系统通知的用户没有未读的通知
+func CountUnread(uid string) (int64, error) {
+	muted, err := Muted(database.Db, uid)
+
+	if err != nil || muted {
+		return 0, err
+	}
+
+	return unreadCounter.Get(uid)
+}
+
+// 用户的未读数量发生变化, 更新缓存并通知用户
+func unreadChanged(uid string, delta int64) {
+	if delta == 0 {
+		return
+	}
+
+	if err := unreadCounter.Add(uid, delta); err != nil {
+		_ = unreadCounter.Invalidate(uid)
+	}
+
+	_ = push.Publish(push.EventUnread, uid, nil)
+}
+
+// 所有用户的未读数量都发生了变化, 例如新增或者删除了通知
+func unreadChangedAll() {
+	_ = unreadCounter.InvalidateAll()
+}
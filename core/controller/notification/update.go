@@ -22,13 +22,16 @@ type UpdateParams struct {
 	Title   *string `json:"title"`   // 公告标题
 	Content *string `json:"content"` // 公告内容
 	Note    *string `json:"note"`    // 备注
+	AudienceParams
 }
 
 func Update(c controller.Context, notificationId string, input UpdateParams) (res schema.Response) {
 	var (
-		err  error
-		data schema.Notification
-		tx   *gorm.DB
+		err              error
+		data             schema.Notification
+		tx               *gorm.DB
+		notificationInfo model.Notification
+		publishing       bool // 定时发布的通知被修改为立即发布
 	)
 
 	defer func() {
@@ -51,6 +54,14 @@ func Update(c controller.Context, notificationId string, input UpdateParams) (re
 			}
 		}
 
+		if err == nil {
+			if publishing {
+				published(notificationInfo)
+			} else {
+				unreadChangedAll()
+			}
+		}
+
 		helper.Response(&res, data, err)
 	}()
 
@@ -73,7 +84,7 @@ func Update(c controller.Context, notificationId string, input UpdateParams) (re
 		return
 	}
 
-	notificationInfo := model.Notification{
+	notificationInfo = model.Notification{
 		Id: notificationId,
 	}
 
@@ -102,6 +113,31 @@ func Update(c controller.Context, notificationId string, input UpdateParams) (re
 		return
 	}
 
+	previousStatus := notificationInfo.Status
+
+	if err = input.AudienceParams.apply(&notificationInfo); err != nil {
+		return
+	}
+
+	// 未启用的通知保持未启用, 其他的根据发布时间重新计算状态
+	if notificationInfo.Status != model.NotificationStatusInActive {
+		notificationInfo.Status = notificationInfo.Schedule(time.Now())
+	}
+
+	if err = tx.Model(&notificationInfo).Updates(map[string]interface{}{
+		"audience":   notificationInfo.Audience,
+		"roles":      notificationInfo.Roles,
+		"levels":     notificationInfo.Levels,
+		"uids":       notificationInfo.Uids,
+		"publish_at": notificationInfo.PublishAt,
+		"expire_at":  notificationInfo.ExpireAt,
+		"status":     notificationInfo.Status,
+	}).Error; err != nil {
+		return
+	}
+
+	publishing = previousStatus == model.NotificationStatusPending && notificationInfo.Status == model.NotificationStatusActive
+
 	if err = mapstructure.Decode(notificationInfo, &data.NotificationPure); err != nil {
 		return
 	}
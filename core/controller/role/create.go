@@ -21,7 +21,8 @@ import (
 type CreateParams struct {
 	Name        string   `json:"name" valid:"required~请输入角色名"`       // 角色名
 	Description string   `json:"description" valid:"required~请输入描述"` // 描述
-	Accession   []string `json:"accession" valid:"required~请输入权限"`   // 权限列表
+	Accession   []string `json:"accession" valid:"required~请输入权限"`   // 权限列表, 支持通配符, 例如 news::*
+	Parent      []string `json:"parent"`                             // 继承的父级角色
 	Note        *string  `json:"note"`                               // 备注
 }
 
@@ -84,10 +85,15 @@ func Create(c controller.Context, input CreateParams) (res schema.Response) {
 		return
 	}
 
+	if err = validateParent(tx, input.Name, input.Parent); err != nil {
+		return
+	}
+
 	roleInfo := model.Role{
 		Name:        input.Name,
 		Description: input.Description,
 		Accession:   input.Accession,
+		Parent:      input.Parent,
 	}
 
 	if err = tx.Create(&roleInfo).Error; err != nil {
@@ -8,11 +8,13 @@ import (
 	"github.com/axetroy/go-server/core/exception"
 	"github.com/axetroy/go-server/core/helper"
 	"github.com/axetroy/go-server/core/model"
+	"github.com/axetroy/go-server/core/rbac"
 	"github.com/axetroy/go-server/core/schema"
 	"github.com/axetroy/go-server/core/service/database"
 	"github.com/gin-gonic/gin"
 	"github.com/jinzhu/gorm"
 	"github.com/mitchellh/mapstructure"
+	"log"
 	"net/http"
 	"time"
 )
@@ -49,6 +51,13 @@ func Delete(c controller.Context, roleName string) (res schema.Response) {
 			}
 		}
 
+		// 权限发生变化, 清除所有服务实例的权限缓存
+		if err == nil {
+			if er := rbac.InvalidateAll(); er != nil {
+				log.Printf("通知权限缓存失效失败: %s\n", er)
+			}
+		}
+
 		helper.Response(&res, data, err)
 	}()
 
@@ -87,6 +96,18 @@ func Delete(c controller.Context, roleName string) (res schema.Response) {
 		return
 	}
 
+	// 被其他角色继承的角色也不允许删除
+	var childRoleNum int64
+
+	if err = tx.Model(&model.Role{}).Where("? = ANY(parent)", roleInfo.Name).Count(&childRoleNum).Error; err != nil {
+		return
+	}
+
+	if childRoleNum > 0 {
+		err = exception.RoleHadBeenUsed
+		return
+	}
+
 	now := time.Now()
 	timestamp := fmt.Sprintf("%v", now.UnixNano())
 
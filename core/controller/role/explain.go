@@ -0,0 +1,95 @@
+// Copyright 2019 Axetroy. All rights reserved. MIT license.
+package role
+
+import (
+	"errors"
+	"github.com/axetroy/go-server/core/controller"
+	"github.com/axetroy/go-server/core/exception"
+	"github.com/axetroy/go-server/core/helper"
+	"github.com/axetroy/go-server/core/middleware"
+	"github.com/axetroy/go-server/core/rbac"
+	"github.com/axetroy/go-server/core/rbac/accession"
+	"github.com/axetroy/go-server/core/schema"
+	"github.com/axetroy/go-server/core/validator"
+	"github.com/gin-gonic/gin"
+	"github.com/jinzhu/gorm"
+	"net/http"
+)
+
+type ExplainQuery struct {
+	Accession string `json:"accession" form:"accession" valid:"required~请输入权限"` // 要查询的权限
+}
+
+// 查询用户的某个权限是由哪些角色授予的
+func ExplainByAdmin(c controller.Context, userId string, input ExplainQuery) (res schema.Response) {
+	var (
+		err  error
+		data = make([]schema.AccessionGrant, 0)
+		cc   *rbac.Controller
+	)
+
+	defer func() {
+		if r := recover(); r != nil {
+			switch t := r.(type) {
+			case string:
+				err = errors.New(t)
+			case error:
+				err = t
+			default:
+				err = exception.Unknown
+			}
+		}
+
+		helper.Response(&res, data, err)
+	}()
+
+	// 参数校验
+	if err = validator.ValidateStruct(input); err != nil {
+		return
+	}
+
+	if _, ok := accession.Map[input.Accession]; !ok {
+		err = exception.InvalidParams
+		return
+	}
+
+	// 审计需要最新的数据, 所以不读取缓存
+	if cc, err = rbac.New(userId); err != nil {
+		if err == gorm.ErrRecordNotFound {
+			err = exception.UserNotExist
+		} else if err == exception.NoPermission {
+			// 用户没有任何角色
+			err = nil
+		}
+		return
+	}
+
+	data = cc.Explain(*accession.Map[input.Accession])
+
+	return
+}
+
+func ExplainByAdminRouter(c *gin.Context) {
+	var (
+		err   error
+		res   = schema.Response{}
+		input ExplainQuery
+	)
+
+	defer func() {
+		if err != nil {
+			res.Data = nil
+			res.Message = err.Error()
+		}
+		c.JSON(http.StatusOK, res)
+	}()
+
+	if err = c.ShouldBindQuery(&input); err != nil {
+		err = exception.InvalidParams
+		return
+	}
+
+	res = ExplainByAdmin(controller.Context{
+		Uid: c.GetString(middleware.ContextUidField),
+	}, c.Param("user_id"), input)
+}
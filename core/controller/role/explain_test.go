@@ -0,0 +1,115 @@
+// Copyright 2019 Axetroy. All rights reserved. MIT license.
+package role_test
+
+import (
+	"github.com/axetroy/go-server/core/controller"
+	"github.com/axetroy/go-server/core/controller/auth"
+	"github.com/axetroy/go-server/core/controller/role"
+	"github.com/axetroy/go-server/core/exception"
+	"github.com/axetroy/go-server/core/rbac/accession"
+	"github.com/axetroy/go-server/core/schema"
+	"github.com/axetroy/go-server/tester"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestExplainByAdmin(t *testing.T) {
+	adminInfo, _ := tester.LoginAdmin()
+
+	context := controller.Context{
+		Uid: adminInfo.Id,
+	}
+
+	userInfo, _ := tester.CreateUser()
+
+	defer auth.DeleteUserByUserName(userInfo.Username)
+
+	// 父级角色通过通配符拥有所有的修改权限
+	r := role.Create(context, role.CreateParams{
+		Name:        "test-explain-parent",
+		Description: "parent",
+		Accession:   []string{"*::update"},
+	})
+
+	assert.Equal(t, schema.StatusSuccess, r.Status)
+
+	defer role.DeleteRoleByName("test-explain-parent")
+
+	r = role.Create(context, role.CreateParams{
+		Name:        "test-explain-child",
+		Description: "child",
+		Accession:   accession.Stringify(accession.ProfileUpdate),
+		Parent:      []string{"test-explain-parent"},
+	})
+
+	assert.Equal(t, schema.StatusSuccess, r.Status)
+
+	defer role.DeleteRoleByName("test-explain-child")
+
+	r = role.UpdateUserRole(context, userInfo.Id, role.UpdateUserRoleParams{
+		Roles: []string{"test-explain-child"},
+	})
+
+	assert.Equal(t, schema.StatusSuccess, r.Status)
+
+	// 继承而来的权限
+	{
+		r := role.ExplainByAdmin(context, userInfo.Id, role.ExplainQuery{
+			Accession: accession.PasswordUpdate.Name,
+		})
+
+		assert.Equal(t, schema.StatusSuccess, r.Status)
+
+		grants := make([]schema.AccessionGrant, 0)
+
+		assert.Nil(t, tester.Decode(r.Data, &grants))
+		assert.Len(t, grants, 1)
+		assert.Equal(t, "test-explain-parent", grants[0].Role)
+		assert.Equal(t, "*::update", grants[0].Accession)
+		assert.Equal(t, []string{"test-explain-child", "test-explain-parent"}, grants[0].Path)
+	}
+
+	// 自身和父级角色都授予了这个权限
+	{
+		r := role.ExplainByAdmin(context, userInfo.Id, role.ExplainQuery{
+			Accession: accession.ProfileUpdate.Name,
+		})
+
+		grants := make([]schema.AccessionGrant, 0)
+
+		assert.Nil(t, tester.Decode(r.Data, &grants))
+		assert.Len(t, grants, 2)
+		assert.Equal(t, "test-explain-child", grants[0].Role)
+		assert.Equal(t, "test-explain-parent", grants[1].Role)
+	}
+
+	// 没有被授予的权限
+	{
+		r := role.ExplainByAdmin(context, userInfo.Id, role.ExplainQuery{
+			Accession: accession.DoTransfer.Name,
+		})
+
+		grants := make([]schema.AccessionGrant, 0)
+
+		assert.Nil(t, tester.Decode(r.Data, &grants))
+		assert.Len(t, grants, 0)
+	}
+
+	// 循环继承
+	{
+		parent := []string{"test-explain-child"}
+
+		r := role.Update(context, "test-explain-parent", role.UpdateParams{
+			Parent: &parent,
+		})
+
+		assert.Equal(t, exception.RoleInheritCycle.Error(), r.Message)
+	}
+
+	// 被继承的角色不能删除
+	{
+		r := role.Delete(context, "test-explain-parent")
+
+		assert.Equal(t, exception.RoleHadBeenUsed.Error(), r.Message)
+	}
+}
@@ -0,0 +1,66 @@
+// Copyright 2019 Axetroy. All rights reserved. MIT license.
+package role
+
+import (
+	"github.com/axetroy/go-server/core/exception"
+	"github.com/axetroy/go-server/core/model"
+	"github.com/jinzhu/gorm"
+)
+
+// 校验角色的父级角色
+// 父级角色必须存在, 并且沿着父级角色一直往上找, 不能再回到这个角色本身
+func validateParent(tx *gorm.DB, roleName string, parents []string) (err error) {
+	visited := map[string]bool{}
+	queue := make([]string, 0)
+
+	for _, name := range parents {
+		if name == roleName {
+			return exception.RoleInheritCycle
+		}
+
+		roleInfo := model.Role{
+			Name: name,
+		}
+
+		if err = tx.First(&roleInfo).Error; err != nil {
+			if err == gorm.ErrRecordNotFound {
+				err = exception.RoleNotExist
+			}
+			return
+		}
+
+		queue = append(queue, roleInfo.Parent...)
+	}
+
+	for len(queue) > 0 {
+		name := queue[0]
+		queue = queue[1:]
+
+		if name == roleName {
+			return exception.RoleInheritCycle
+		}
+
+		if visited[name] {
+			continue
+		}
+
+		visited[name] = true
+
+		roleInfo := model.Role{
+			Name: name,
+		}
+
+		if err = tx.First(&roleInfo).Error; err != nil {
+			// 已经被删除的父级角色忽略
+			if err == gorm.ErrRecordNotFound {
+				err = nil
+				continue
+			}
+			return
+		}
+
+		queue = append(queue, roleInfo.Parent...)
+	}
+
+	return
+}
@@ -7,20 +7,24 @@ import (
 	"github.com/axetroy/go-server/core/exception"
 	"github.com/axetroy/go-server/core/helper"
 	"github.com/axetroy/go-server/core/model"
+	"github.com/axetroy/go-server/core/rbac"
 	"github.com/axetroy/go-server/core/rbac/accession"
 	"github.com/axetroy/go-server/core/schema"
 	"github.com/axetroy/go-server/core/service/database"
 	"github.com/axetroy/go-server/core/validator"
 	"github.com/gin-gonic/gin"
 	"github.com/jinzhu/gorm"
+	"github.com/lib/pq"
 	"github.com/mitchellh/mapstructure"
+	"log"
 	"net/http"
 	"time"
 )
 
 type UpdateParams struct {
 	Description *string   `json:"description"`
-	Accession   *[]string `json:"accession"`
+	Accession   *[]string `json:"accession"` // 权限列表, 支持通配符, 例如 news::*
+	Parent      *[]string `json:"parent"`    // 继承的父级角色
 	Note        *string   `json:"note"`
 }
 
@@ -56,6 +60,13 @@ func Update(c controller.Context, roleName string, input UpdateParams) (res sche
 			}
 		}
 
+		// 权限发生变化, 清除所有服务实例的权限缓存
+		if err == nil && shouldUpdate {
+			if er := rbac.InvalidateAll(); er != nil {
+				log.Printf("通知权限缓存失效失败: %s\n", er)
+			}
+		}
+
 		helper.Response(&res, data, err)
 	}()
 
@@ -114,7 +125,22 @@ func Update(c controller.Context, roleName string, input UpdateParams) (res sche
 		updateModel.Note = input.Note
 	}
 
+	if input.Parent != nil {
+		if err = validateParent(tx, roleInfo.Name, *input.Parent); err != nil {
+			return
+		}
+
+		shouldUpdate = true
+	}
+
 	if shouldUpdate {
+		// 清空父级角色时 Updates 会忽略空值, 所以单独更新
+		if input.Parent != nil {
+			if err = tx.Model(&roleInfo).Update("parent", pq.StringArray(*input.Parent)).Error; err != nil {
+				return
+			}
+		}
+
 		if err = tx.Model(&roleInfo).Updates(&updateModel).Error; err != nil {
 			if err == gorm.ErrRecordNotFound {
 				err = exception.RoleNotExist
@@ -192,6 +218,13 @@ func UpdateUserRole(c controller.Context, userId string, input UpdateUserRolePar
 			}
 		}
 
+		// 用户的角色发生变化, 清除所有服务实例中该用户的权限缓存
+		if err == nil {
+			if er := rbac.Invalidate(userId); er != nil {
+				log.Printf("通知用户 %s 的权限缓存失效失败: %s\n", userId, er)
+			}
+		}
+
 		helper.Response(&res, data, err)
 	}()
 
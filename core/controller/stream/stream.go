@@ -0,0 +1,96 @@
+// Copyright 2019 Axetroy. All rights reserved. MIT license.
+package stream
+
+import (
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/axetroy/go-server/core/controller/message"
+	"github.com/axetroy/go-server/core/controller/notification"
+	"github.com/axetroy/go-server/core/middleware"
+	"github.com/axetroy/go-server/core/schema"
+	"github.com/axetroy/go-server/core/service/database"
+	"github.com/axetroy/go-server/core/service/push"
+	"github.com/gin-gonic/gin"
+)
+
+var (
+	HeartbeatInterval = time.Second * 15 // 心跳的间隔, 防止连接被代理服务器断开
+	MaxDuration       = time.Second * 50 // 单个连接的最长时间, 需要小于服务器的写超时, 到期后客户端会自动重连
+	RetryInterval     = 3000             // 客户端断线后重连的间隔, 单位毫秒
+)
+
+// 获取用户的未读数量
+func GetUnread(uid string) (data schema.Unread, err error) {
+	if data.Message, err = message.CountUnread(uid); err != nil {
+		return
+	}
+
+	if data.Notification, err = notification.CountUnread(uid); err != nil {
+		return
+	}
+
+	return
+}
+
+// 通过 Server-Sent Events 推送新的个人消息, 系统通知, 以及未读数量的变化
+// 个人消息和系统通知之后都会推送一次最新的未读数量
+func StreamRouter(c *gin.Context) {
+	uid := c.GetString(middleware.ContextUidField)
+
+	subscriber := push.Subscribe(uid)
+
+	defer push.Unsubscribe(subscriber)
+
+	heartbeat := time.NewTicker(HeartbeatInterval)
+
+	defer heartbeat.Stop()
+
+	timeout := time.After(MaxDuration)
+
+	c.Header("Content-Type", "text/event-stream")
+	c.Header("Cache-Control", "no-cache")
+	c.Header("Connection", "keep-alive")
+	c.Header("X-Accel-Buffering", "no") // 禁止 nginx 缓存响应
+
+	sendUnread := func() {
+		if unread, err := GetUnread(uid); err == nil {
+			c.SSEvent(string(push.EventUnread), unread)
+		}
+	}
+
+	// 连接之后先推送一次未读数量
+	_, _ = fmt.Fprintf(c.Writer, "retry: %d\n\n", RetryInterval)
+	sendUnread()
+
+	c.Stream(func(w io.Writer) bool {
+		select {
+		case <-c.Request.Context().Done():
+			return false
+		case <-timeout:
+			return false
+		case <-heartbeat.C:
+			c.SSEvent("ping", time.Now().Unix())
+		case event, ok := <-subscriber.C:
+			if !ok {
+				return false
+			}
+
+			switch event.Type {
+			case push.EventUnread:
+			case push.EventNotification:
+				// 用户关闭了系统通知的站内消息
+				if muted, err := notification.Muted(database.Db, uid); err == nil && !muted {
+					c.SSEvent(string(event.Type), event.Data)
+				}
+			default:
+				c.SSEvent(string(event.Type), event.Data)
+			}
+
+			sendUnread()
+		}
+
+		return true
+	})
+}
@@ -0,0 +1,80 @@
+// Copyright 2019 Axetroy. All rights reserved. MIT license.
+package stream_test
+
+import (
+	"github.com/axetroy/go-server/core/controller"
+	"github.com/axetroy/go-server/core/controller/auth"
+	"github.com/axetroy/go-server/core/controller/message"
+	"github.com/axetroy/go-server/core/controller/stream"
+	"github.com/axetroy/go-server/core/middleware"
+	"github.com/axetroy/go-server/core/schema"
+	"github.com/axetroy/go-server/tester"
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestStream(t *testing.T) {
+	adminInfo, _ := tester.LoginAdmin()
+	userInfo, _ := tester.CreateUser()
+
+	defer auth.DeleteUserByUserName(userInfo.Username)
+
+	stream.MaxDuration = time.Second * 2
+
+	router := gin.New()
+
+	router.GET("/stream", func(c *gin.Context) {
+		c.Set(middleware.ContextUidField, userInfo.Id)
+	}, stream.StreamRouter)
+
+	server := httptest.NewServer(router)
+
+	defer server.Close()
+
+	messageId := make(chan string, 1)
+
+	// 连接之后再创建消息
+	go func() {
+		time.Sleep(time.Millisecond * 500)
+
+		r := message.Create(controller.Context{Uid: adminInfo.Id}, message.CreateMessageParams{
+			Uid:     userInfo.Id,
+			Title:   "test",
+			Content: "test",
+		})
+
+		n := schema.Message{}
+
+		assert.Nil(t, tester.Decode(r.Data, &n))
+
+		messageId <- n.Id
+	}()
+
+	res, err := http.Get(server.URL + "/stream")
+
+	assert.Nil(t, err)
+
+	defer res.Body.Close()
+
+	assert.Equal(t, "text/event-stream", res.Header.Get("Content-Type"))
+
+	body, err := ioutil.ReadAll(res.Body)
+
+	assert.Nil(t, err)
+
+	defer message.DeleteMessageById(<-messageId)
+
+	events := string(body)
+
+	// 连接时的未读数量, 新消息, 以及新消息之后的未读数量
+	assert.True(t, strings.HasPrefix(events, "retry:"))
+	assert.Contains(t, events, "event:unread\ndata:{\"message\":0,\"notification\":")
+	assert.Contains(t, events, "event:message\n")
+	assert.Contains(t, events, "event:unread\ndata:{\"message\":1,\"notification\":")
+}
@@ -0,0 +1,162 @@
+// Copyright 2019 Axetroy. All rights reserved. MIT license.
+package template
+
+import (
+	"errors"
+	"github.com/axetroy/go-server/core/controller"
+	"github.com/axetroy/go-server/core/exception"
+	"github.com/axetroy/go-server/core/helper"
+	"github.com/axetroy/go-server/core/middleware"
+	"github.com/axetroy/go-server/core/model"
+	"github.com/axetroy/go-server/core/schema"
+	"github.com/axetroy/go-server/core/service/database"
+	"github.com/axetroy/go-server/core/service/message_template"
+	"github.com/axetroy/go-server/core/validator"
+	"github.com/gin-gonic/gin"
+	"github.com/jinzhu/gorm"
+	"net/http"
+)
+
+type CreateParams struct {
+	Name          string                      `json:"name" valid:"required~请输入模版名称,matches(^[A-Za-z0-9_.-]+$)~模版名称只能包含字母数字下划线点和横线,length(1|64)~模版名称不能超过64个字符"` // 模版名称
+	Locale        string                      `json:"locale" valid:"length(0|16)~语言不能超过16个字符"`                                                                 // 模版的语言, 默认为 zh-CN
+	Subject       string                      `json:"subject" valid:"required~请输入模版标题,length(1|255)~模版标题不能超过255个字符"`                                           // 标题, 使用 text/template 语法
+	Format        string                      `json:"format"`                                                                                                  // 内容的格式, text 或者 html, 默认为 text
+	Body          string                      `json:"body" valid:"required~请输入模版内容"`                                                                           // 模版内容
+	Variables     []message_template.Variable `json:"variables"`                                                                                               // 模版变量的声明
+	Category      string                      `json:"category"`                                                                                                // 通知的分类, 默认为 system
+	SmsTemplateId *string                     `json:"sms_template_id"`                                                                                         // 短信服务商的模版ID
+	Note          *string                     `json:"note"`                                                                                                    // 备注
+}
+
+// 创建消息模版
+func Create(c controller.Context, input CreateParams) (res schema.Response) {
+	var (
+		err  error
+		data schema.MessageTemplate
+		tx   *gorm.DB
+	)
+
+	defer func() {
+		if r := recover(); r != nil {
+			switch t := r.(type) {
+			case string:
+				err = errors.New(t)
+			case error:
+				err = t
+			default:
+				err = exception.Unknown
+			}
+		}
+
+		if tx != nil {
+			if err != nil {
+				_ = tx.Rollback().Error
+			} else {
+				err = tx.Commit().Error
+			}
+		}
+
+		helper.Response(&res, data, err)
+	}()
+
+	// 参数校验
+	if err = validator.ValidateStruct(input); err != nil {
+		return
+	}
+
+	tpl := message_template.Template{
+		Name:      input.Name,
+		Locale:    input.Locale,
+		Subject:   input.Subject,
+		Format:    message_template.Format(input.Format),
+		Body:      input.Body,
+		Variables: input.Variables,
+	}
+
+	if tpl.Locale == "" {
+		tpl.Locale = message_template.DefaultLocale
+	}
+
+	if tpl.Format == "" {
+		tpl.Format = message_template.FormatText
+	}
+
+	if err = tpl.Validate(); err != nil {
+		return
+	}
+
+	if tpl.Category, err = parseCategory(tpl.Name, input.Category); err != nil {
+		return
+	}
+
+	templateInfo := model.MessageTemplate{
+		Name:          tpl.Name,
+		Locale:        tpl.Locale,
+		Subject:       tpl.Subject,
+		Format:        string(tpl.Format),
+		Body:          tpl.Body,
+		Category:      tpl.Category,
+		SmsTemplateId: input.SmsTemplateId,
+		Note:          input.Note,
+	}
+
+	if templateInfo.Variables, err = message_template.MarshalVariables(tpl.Variables); err != nil {
+		return
+	}
+
+	tx = database.Db.Begin()
+
+	adminInfo := model.Admin{Id: c.Uid}
+
+	if err = tx.First(&adminInfo).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			err = exception.AdminNotExist
+		}
+		return
+	}
+
+	var count int
+
+	if err = tx.Model(&model.MessageTemplate{}).Where("name = ? AND locale = ?", templateInfo.Name, templateInfo.Locale).Count(&count).Error; err != nil {
+		return
+	}
+
+	if count > 0 {
+		err = exception.MessageTemplateExist
+		return
+	}
+
+	if err = tx.Create(&templateInfo).Error; err != nil {
+		return
+	}
+
+	data, err = mapToSchema(templateInfo)
+
+	return
+}
+
+func CreateRouter(c *gin.Context) {
+	var (
+		input CreateParams
+		err   error
+		res   = schema.Response{}
+	)
+
+	defer func() {
+		if err != nil {
+			res.Data = nil
+			res.Message = err.Error()
+		}
+		c.JSON(http.StatusOK, res)
+	}()
+
+	if err = c.ShouldBindJSON(&input); err != nil {
+		err = exception.InvalidParams
+		return
+	}
+
+	res = Create(controller.Context{
+		Uid: c.GetString(middleware.ContextUidField),
+	}, input)
+}
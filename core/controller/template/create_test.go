@@ -0,0 +1,134 @@
+// Copyright 2019 Axetroy. All rights reserved. MIT license.
+package template_test
+
+import (
+	"github.com/axetroy/go-server/core/controller"
+	"github.com/axetroy/go-server/core/controller/template"
+	"github.com/axetroy/go-server/core/exception"
+	"github.com/axetroy/go-server/core/schema"
+	"github.com/axetroy/go-server/core/service/message_template"
+	"github.com/axetroy/go-server/tester"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func createTemplate(t *testing.T, c controller.Context, name string) schema.MessageTemplate {
+	r := template.Create(c, template.CreateParams{
+		Name:    name,
+		Subject: "{{.name}} 的订单",
+		Format:  "html",
+		Body:    "<p>{{.name}} 的订单已发货</p>",
+		Variables: []message_template.Variable{
+			{Name: "name", Type: message_template.VariableTypeString, Required: true},
+		},
+	})
+
+	assert.Equal(t, "", r.Message)
+
+	data := schema.MessageTemplate{}
+
+	assert.Nil(t, tester.Decode(r.Data, &data))
+
+	return data
+}
+
+func TestCreate(t *testing.T) {
+	adminInfo, _ := tester.LoginAdmin()
+
+	c := controller.Context{Uid: adminInfo.Id}
+
+	data := createTemplate(t, c, "test_create")
+
+	defer template.DeleteTemplateById(data.Id)
+
+	assert.Equal(t, "test_create", data.Name)
+	assert.Equal(t, message_template.DefaultLocale, data.Locale)
+	assert.Equal(t, "html", data.Format)
+	assert.Equal(t, message_template.CategorySystem, data.Category)
+	assert.Equal(t, []schema.MessageTemplateVariable{{Name: "name", Type: "string", Required: true}}, data.Variables)
+
+	// 名称和语言重复
+	{
+		r := template.Create(c, template.CreateParams{
+			Name:    "test_create",
+			Subject: "test",
+			Body:    "test",
+		})
+
+		assert.Equal(t, exception.MessageTemplateExist.Error(), r.Message)
+	}
+
+	// 同名的其他语言
+	{
+		r := template.Create(c, template.CreateParams{
+			Name:    "test_create",
+			Locale:  "en-US",
+			Subject: "test",
+			Body:    "test",
+		})
+
+		assert.Equal(t, "", r.Message)
+
+		other := schema.MessageTemplate{}
+
+		assert.Nil(t, tester.Decode(r.Data, &other))
+
+		defer template.DeleteTemplateById(other.Id)
+
+		assert.Equal(t, "text", other.Format)
+	}
+
+	// 语法错误
+	{
+		r := template.Create(c, template.CreateParams{
+			Name:    "test_create_invalid",
+			Subject: "test",
+			Body:    "{{.name",
+		})
+
+		assert.Equal(t, exception.MessageTemplateInvalid.Error(), r.Message)
+	}
+
+	// 自定义模版不能使用安全类的分类
+	{
+		r := template.Create(c, template.CreateParams{
+			Name:     "test_create_security",
+			Subject:  "test",
+			Body:     "test",
+			Category: "security",
+		})
+
+		assert.Equal(t, exception.MessageTemplateInvalidCategory.Error(), r.Message)
+	}
+
+	// 覆盖内置模版时不能改变分类
+	{
+		r := template.Create(c, template.CreateParams{
+			Name:     message_template.NameStatement,
+			Locale:   "en-US",
+			Subject:  "Statement",
+			Body:     "{{.period}}",
+			Category: "marketing",
+			Variables: []message_template.Variable{
+				{Name: "period", Type: message_template.VariableTypeString, Required: true},
+			},
+		})
+
+		assert.Equal(t, exception.MessageTemplateInvalidCategory.Error(), r.Message)
+	}
+
+	// 覆盖内置模版时没有声明内置模版的变量
+	{
+		r := template.Create(c, template.CreateParams{
+			Name:    message_template.NameAuth,
+			Locale:  "en-US",
+			Subject: "Verify",
+			Body:    "Your name is {{.name}}",
+			Variables: []message_template.Variable{
+				{Name: "name", Type: message_template.VariableTypeString, Required: true},
+			},
+		})
+
+		assert.Equal(t, exception.MessageTemplateIncompatible.Error(), r.Message)
+	}
+}
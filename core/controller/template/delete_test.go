@@ -0,0 +1,26 @@
+// Copyright 2019 Axetroy. All rights reserved. MIT license.
+package template_test
+
+import (
+	"github.com/axetroy/go-server/core/controller"
+	"github.com/axetroy/go-server/core/controller/template"
+	"github.com/axetroy/go-server/core/exception"
+	"github.com/axetroy/go-server/tester"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestDelete(t *testing.T) {
+	adminInfo, _ := tester.LoginAdmin()
+
+	c := controller.Context{Uid: adminInfo.Id}
+
+	data := createTemplate(t, c, "test_delete")
+
+	defer template.DeleteTemplateById(data.Id)
+
+	assert.Equal(t, "", template.Delete(c, data.Id).Message)
+
+	assert.Equal(t, exception.MessageTemplateNotExist.Error(), template.Get(c, data.Id).Message)
+	assert.Equal(t, exception.MessageTemplateNotExist.Error(), template.Delete(c, data.Id).Message)
+}
@@ -0,0 +1,170 @@
+// Copyright 2019 Axetroy. All rights reserved. MIT license.
+package template
+
+import (
+	"errors"
+	"github.com/axetroy/go-server/core/controller"
+	"github.com/axetroy/go-server/core/exception"
+	"github.com/axetroy/go-server/core/helper"
+	"github.com/axetroy/go-server/core/middleware"
+	"github.com/axetroy/go-server/core/model"
+	"github.com/axetroy/go-server/core/schema"
+	"github.com/axetroy/go-server/core/service/database"
+	"github.com/gin-gonic/gin"
+	"github.com/jinzhu/gorm"
+	"net/http"
+)
+
+type Query struct {
+	schema.Query
+	Name   *string `json:"name" form:"name"`     // 根据模版名称筛选
+	Locale *string `json:"locale" form:"locale"` // 根据语言筛选
+}
+
+// 获取消息模版列表, 不包含内置的模版
+func GetList(c controller.Context, input Query) (res schema.List) {
+	var (
+		err  error
+		data = make([]schema.MessageTemplate, 0)
+		meta = &schema.Meta{}
+	)
+
+	defer func() {
+		if r := recover(); r != nil {
+			switch t := r.(type) {
+			case string:
+				err = errors.New(t)
+			case error:
+				err = t
+			default:
+				err = exception.Unknown
+			}
+		}
+
+		helper.ResponseList(&res, data, meta, err)
+	}()
+
+	query := input.Query
+
+	query.Normalize()
+
+	list := make([]model.MessageTemplate, 0)
+
+	filter := map[string]interface{}{}
+
+	if input.Name != nil {
+		filter["name"] = *input.Name
+	}
+
+	if input.Locale != nil {
+		filter["locale"] = *input.Locale
+	}
+
+	if err = query.Order(database.Db.Limit(query.Limit).Offset(query.Limit * query.Page)).Where(filter).Find(&list).Error; err != nil {
+		return
+	}
+
+	var total int64
+
+	if err = database.Db.Model(&model.MessageTemplate{}).Where(filter).Count(&total).Error; err != nil {
+		return
+	}
+
+	for _, v := range list {
+		var d schema.MessageTemplate
+
+		if d, err = mapToSchema(v); err != nil {
+			return
+		}
+
+		data = append(data, d)
+	}
+
+	meta.Total = total
+	meta.Num = len(data)
+	meta.Page = query.Page
+	meta.Limit = query.Limit
+	meta.Sort = query.Sort
+
+	return
+}
+
+// 获取单个消息模版
+func Get(c controller.Context, id string) (res schema.Response) {
+	var (
+		err  error
+		data schema.MessageTemplate
+	)
+
+	defer func() {
+		if r := recover(); r != nil {
+			switch t := r.(type) {
+			case string:
+				err = errors.New(t)
+			case error:
+				err = t
+			default:
+				err = exception.Unknown
+			}
+		}
+
+		helper.Response(&res, data, err)
+	}()
+
+	templateInfo := model.MessageTemplate{}
+
+	if err = database.Db.Where("id = ?", id).First(&templateInfo).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			err = exception.MessageTemplateNotExist
+		}
+		return
+	}
+
+	data, err = mapToSchema(templateInfo)
+
+	return
+}
+
+func GetListRouter(c *gin.Context) {
+	var (
+		err   error
+		res   = schema.List{}
+		input Query
+	)
+
+	defer func() {
+		if err != nil {
+			res.Data = nil
+			res.Message = err.Error()
+		}
+		c.JSON(http.StatusOK, res)
+	}()
+
+	if err = c.ShouldBindQuery(&input); err != nil {
+		err = exception.InvalidParams
+		return
+	}
+
+	res = GetList(controller.Context{
+		Uid: c.GetString(middleware.ContextUidField),
+	}, input)
+}
+
+func GetRouter(c *gin.Context) {
+	var (
+		err error
+		res = schema.Response{}
+	)
+
+	defer func() {
+		if err != nil {
+			res.Data = nil
+			res.Message = err.Error()
+		}
+		c.JSON(http.StatusOK, res)
+	}()
+
+	res = Get(controller.Context{
+		Uid: c.GetString(middleware.ContextUidField),
+	}, c.Param("id"))
+}
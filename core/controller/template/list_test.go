@@ -0,0 +1,46 @@
+// Copyright 2019 Axetroy. All rights reserved. MIT license.
+package template_test
+
+import (
+	"github.com/axetroy/go-server/core/controller"
+	"github.com/axetroy/go-server/core/controller/template"
+	"github.com/axetroy/go-server/core/schema"
+	"github.com/axetroy/go-server/tester"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestGetList(t *testing.T) {
+	adminInfo, _ := tester.LoginAdmin()
+
+	c := controller.Context{Uid: adminInfo.Id}
+
+	data := createTemplate(t, c, "test_list")
+
+	defer template.DeleteTemplateById(data.Id)
+
+	name := "test_list"
+
+	r := template.GetList(c, template.Query{Name: &name})
+
+	assert.Equal(t, "", r.Message)
+
+	list := make([]schema.MessageTemplate, 0)
+
+	assert.Nil(t, tester.Decode(r.Data, &list))
+	assert.Len(t, list, 1)
+	assert.Equal(t, data.Id, list[0].Id)
+	assert.Equal(t, int64(1), r.Meta.Total)
+
+	// 详情
+	{
+		r := template.Get(c, data.Id)
+
+		assert.Equal(t, "", r.Message)
+
+		detail := schema.MessageTemplate{}
+
+		assert.Nil(t, tester.Decode(r.Data, &detail))
+		assert.Equal(t, data.Body, detail.Body)
+	}
+}
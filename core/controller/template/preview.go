@@ -0,0 +1,158 @@
+// Copyright 2019 Axetroy. All rights reserved. MIT license.
+package template
+
+import (
+	"errors"
+	"github.com/axetroy/go-server/core/controller"
+	"github.com/axetroy/go-server/core/exception"
+	"github.com/axetroy/go-server/core/helper"
+	"github.com/axetroy/go-server/core/middleware"
+	"github.com/axetroy/go-server/core/model"
+	"github.com/axetroy/go-server/core/schema"
+	"github.com/axetroy/go-server/core/service/database"
+	"github.com/axetroy/go-server/core/service/message_template"
+	"github.com/axetroy/go-server/core/validator"
+	"github.com/gin-gonic/gin"
+	"github.com/jinzhu/gorm"
+	"net/http"
+)
+
+type PreviewParams struct {
+	Name   string                 `json:"name" valid:"required~请输入模版名称"` // 模版名称, 可以是内置的模版
+	Locale string                 `json:"locale"`                        // 模版的语言, 找不到时回退到默认语言
+	Values map[string]interface{} `json:"values"`                        // 模版变量
+}
+
+type PreviewByIdParams struct {
+	Values map[string]interface{} `json:"values"` // 模版变量
+}
+
+// 根据名称和语言预览模版, 与实际发送时查找模版的规则一致
+func Preview(c controller.Context, input PreviewParams) (res schema.Response) {
+	var (
+		err  error
+		data schema.MessageTemplatePreview
+	)
+
+	defer func() {
+		if r := recover(); r != nil {
+			switch t := r.(type) {
+			case string:
+				err = errors.New(t)
+			case error:
+				err = t
+			default:
+				err = exception.Unknown
+			}
+		}
+
+		helper.Response(&res, data, err)
+	}()
+
+	// 参数校验
+	if err = validator.ValidateStruct(input); err != nil {
+		return
+	}
+
+	tpl, err := message_template.Find(database.Db, input.Name, input.Locale)
+
+	if err != nil {
+		return
+	}
+
+	data, err = preview(tpl, input.Values)
+
+	return
+}
+
+// 预览某个模版
+func PreviewById(c controller.Context, id string, input PreviewByIdParams) (res schema.Response) {
+	var (
+		err  error
+		data schema.MessageTemplatePreview
+	)
+
+	defer func() {
+		if r := recover(); r != nil {
+			switch t := r.(type) {
+			case string:
+				err = errors.New(t)
+			case error:
+				err = t
+			default:
+				err = exception.Unknown
+			}
+		}
+
+		helper.Response(&res, data, err)
+	}()
+
+	templateInfo := model.MessageTemplate{}
+
+	if err = database.Db.Where("id = ?", id).First(&templateInfo).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			err = exception.MessageTemplateNotExist
+		}
+		return
+	}
+
+	tpl, err := message_template.FromModel(templateInfo)
+
+	if err != nil {
+		return
+	}
+
+	data, err = preview(tpl, input.Values)
+
+	return
+}
+
+func PreviewRouter(c *gin.Context) {
+	var (
+		input PreviewParams
+		err   error
+		res   = schema.Response{}
+	)
+
+	defer func() {
+		if err != nil {
+			res.Data = nil
+			res.Message = err.Error()
+		}
+		c.JSON(http.StatusOK, res)
+	}()
+
+	if err = c.ShouldBindJSON(&input); err != nil {
+		err = exception.InvalidParams
+		return
+	}
+
+	res = Preview(controller.Context{
+		Uid: c.GetString(middleware.ContextUidField),
+	}, input)
+}
+
+func PreviewByIdRouter(c *gin.Context) {
+	var (
+		input PreviewByIdParams
+		err   error
+		res   = schema.Response{}
+	)
+
+	defer func() {
+		if err != nil {
+			res.Data = nil
+			res.Message = err.Error()
+		}
+		c.JSON(http.StatusOK, res)
+	}()
+
+	if err = c.ShouldBindJSON(&input); err != nil {
+		err = exception.InvalidParams
+		return
+	}
+
+	res = PreviewById(controller.Context{
+		Uid: c.GetString(middleware.ContextUidField),
+	}, c.Param("id"), input)
+}
@@ -0,0 +1,80 @@
+// Copyright 2019 Axetroy. All rights reserved. MIT license.
+package template_test
+
+import (
+	"github.com/axetroy/go-server/core/controller"
+	"github.com/axetroy/go-server/core/controller/template"
+	"github.com/axetroy/go-server/core/exception"
+	"github.com/axetroy/go-server/core/schema"
+	"github.com/axetroy/go-server/core/service/message_template"
+	"github.com/axetroy/go-server/tester"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestPreview(t *testing.T) {
+	adminInfo, _ := tester.LoginAdmin()
+
+	c := controller.Context{Uid: adminInfo.Id}
+
+	data := createTemplate(t, c, "test_preview")
+
+	defer template.DeleteTemplateById(data.Id)
+
+	// 预览指定的模版
+	{
+		r := template.PreviewById(c, data.Id, template.PreviewByIdParams{
+			Values: map[string]interface{}{"name": "<axetroy>"},
+		})
+
+		assert.Equal(t, "", r.Message)
+
+		preview := schema.MessageTemplatePreview{}
+
+		assert.Nil(t, tester.Decode(r.Data, &preview))
+		assert.Equal(t, "<axetroy> 的订单", preview.Subject)
+		assert.Equal(t, "<p>&lt;axetroy&gt; 的订单已发货</p>", preview.Body)
+		assert.True(t, preview.HTML)
+		assert.Equal(t, map[string]string{"name": "<axetroy>"}, preview.Sms)
+	}
+
+	// 找不到对应语言时回退到默认语言
+	{
+		r := template.Preview(c, template.PreviewParams{
+			Name:   "test_preview",
+			Locale: "en-US",
+			Values: map[string]interface{}{"name": "axetroy"},
+		})
+
+		assert.Equal(t, "", r.Message)
+	}
+
+	// 内置的模版
+	{
+		r := template.Preview(c, template.PreviewParams{
+			Name:   message_template.NameActivation,
+			Values: map[string]interface{}{"code": "123456"},
+		})
+
+		assert.Equal(t, "", r.Message)
+
+		preview := schema.MessageTemplatePreview{}
+
+		assert.Nil(t, tester.Decode(r.Data, &preview))
+		assert.Contains(t, preview.Body, "123456")
+	}
+
+	// 缺少变量
+	{
+		r := template.PreviewById(c, data.Id, template.PreviewByIdParams{})
+
+		assert.Equal(t, exception.MessageTemplateInvalidVariable.Error(), r.Message)
+	}
+
+	// 不存在的模版
+	{
+		r := template.Preview(c, template.PreviewParams{Name: "not_exist"})
+
+		assert.Equal(t, exception.MessageTemplateNotExist.Error(), r.Message)
+	}
+}
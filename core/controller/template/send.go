@@ -0,0 +1,237 @@
+// Copyright 2019 Axetroy. All rights reserved. MIT license.
+package template
+
+import (
+	"errors"
+	"github.com/axetroy/go-server/core/controller"
+	"github.com/axetroy/go-server/core/controller/message"
+	"github.com/axetroy/go-server/core/exception"
+	"github.com/axetroy/go-server/core/helper"
+	"github.com/axetroy/go-server/core/message_queue"
+	"github.com/axetroy/go-server/core/middleware"
+	"github.com/axetroy/go-server/core/model"
+	"github.com/axetroy/go-server/core/schema"
+	"github.com/axetroy/go-server/core/service/database"
+	"github.com/axetroy/go-server/core/service/email"
+	"github.com/axetroy/go-server/core/service/message_template"
+	"github.com/axetroy/go-server/core/service/preference"
+	"github.com/axetroy/go-server/core/service/telephone"
+	"github.com/axetroy/go-server/core/validator"
+	"github.com/gin-gonic/gin"
+	"github.com/jinzhu/gorm"
+	"net/http"
+	"time"
+)
+
+// 一次最多发送给多少个用户
+var MaxBatchSize = 100
+
+type SendParams struct {
+	Name     string                 `json:"name" valid:"required~请输入模版名称"` // 模版名称, 可以是内置的模版
+	Locale   string                 `json:"locale"`                        // 模版的语言, 找不到时回退到默认语言
+	Uids     []string               `json:"uids"`                          // 接收的用户ID列表
+	Channels []string               `json:"channels"`                      // 发送渠道, message/email/sms
+	Values   map[string]interface{} `json:"values"`                        // 模版变量
+}
+
+// 使用模版给用户发送消息
+// 模版只渲染一次, 每个用户每个渠道单独发送, 返回每一次发送的结果
+func Send(c controller.Context, input SendParams) (res schema.Response) {
+	var (
+		err  error
+		data = make([]schema.MessageTemplateDelivery, 0)
+	)
+
+	defer func() {
+		if r := recover(); r != nil {
+			switch t := r.(type) {
+			case string:
+				err = errors.New(t)
+			case error:
+				err = t
+			default:
+				err = exception.Unknown
+			}
+		}
+
+		helper.Response(&res, data, err)
+	}()
+
+	// 参数校验
+	if err = validator.ValidateStruct(input); err != nil {
+		return
+	}
+
+	if len(input.Uids) == 0 || len(input.Uids) > MaxBatchSize {
+		err = exception.InvalidParams
+		return
+	}
+
+	channels, err := message_template.ParseChannels(input.Channels)
+
+	if err != nil {
+		return
+	}
+
+	adminInfo := model.Admin{Id: c.Uid}
+
+	if err = database.Db.First(&adminInfo).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			err = exception.AdminNotExist
+		}
+		return
+	}
+
+	tpl, err := message_template.Find(database.Db, input.Name, input.Locale)
+
+	if err != nil {
+		return
+	}
+
+	for _, channel := range channels {
+		if channel == message_template.ChannelSms && tpl.SmsTemplateId == "" {
+			err = exception.MessageTemplateRequireSms
+			return
+		}
+	}
+
+	// 通知的分类由模版决定, 发送时按照这个分类检查用户的通知偏好
+	category, err := preference.ParseCategory(tpl.Category)
+
+	if err != nil {
+		return
+	}
+
+	rendered, err := tpl.Render(input.Values)
+
+	if err != nil {
+		return
+	}
+
+	for _, uid := range input.Uids {
+		userInfo := model.User{}
+
+		er := database.Db.Where("id = ?", uid).First(&userInfo).Error
+
+		if er == gorm.ErrRecordNotFound {
+			er = exception.UserNotExist
+		}
+
+		for _, channel := range channels {
+			deferred := false
+
+			if er == nil {
+				deferred, er = deliver(c, userInfo, tpl, rendered, category, channel)
+			}
+
+			d := schema.MessageTemplateDelivery{
+				Uid:      uid,
+				Channel:  string(channel),
+				Success:  er == nil,
+				Deferred: deferred,
+			}
+
+			if er != nil {
+				d.Error = er.Error()
+			}
+
+			data = append(data, d)
+
+			// 用户不存在时, 所有渠道都失败, 其他错误只影响当前渠道
+			if er != exception.UserNotExist {
+				er = nil
+			}
+		}
+	}
+
+	return
+}
+
+// 通过某个渠道把渲染好的消息发送给用户
+// 用户关闭了该分类的通知时, 不发送并返回对应的错误
+// 邮件和短信处于用户的免打扰时段时, 加入消息队列等到免打扰时段结束后再发送
+func deliver(c controller.Context, userInfo model.User, tpl message_template.Template, rendered message_template.Rendered, category preference.Category, channel message_template.Channel) (deferred bool, err error) {
+	switch channel {
+	case message_template.ChannelMessage:
+		// 站内消息在 message.CreateWithCategory 中检查通知偏好
+		r := message.CreateWithCategory(c, message.CreateMessageParams{
+			Uid:     userInfo.Id,
+			Title:   rendered.Subject,
+			Content: rendered.Body,
+		}, category)
+
+		if r.Status != schema.StatusSuccess {
+			err = errors.New(r.Message)
+		}
+
+		return
+	case message_template.ChannelEmail:
+		if userInfo.Email == nil {
+			err = exception.MessageTemplateRequireEmail
+			return
+		}
+	case message_template.ChannelSms:
+		if userInfo.Phone == nil {
+			err = exception.MessageTemplateRequirePhone
+			return
+		}
+	default:
+		return
+	}
+
+	var until time.Time
+
+	if until, err = preference.Check(database.Db, userInfo.Id, category, channel); err != nil {
+		if err != exception.NotificationPreferenceQuiet {
+			return
+		}
+
+		err = message_queue.PublishSendTemplate(message_queue.SendTemplateBody{
+			Uid:           userInfo.Id,
+			Category:      category,
+			Channel:       channel,
+			Subject:       rendered.Subject,
+			Body:          rendered.Body,
+			HTML:          rendered.HTML,
+			SmsTemplateId: tpl.SmsTemplateId,
+			SmsParams:     message_template.Strings(rendered.Values),
+		}, time.Until(until))
+
+		deferred = err == nil
+
+		return
+	}
+
+	if channel == message_template.ChannelEmail {
+		err = email.NewMailer().SendRendered(*userInfo.Email, rendered)
+	} else {
+		err = telephone.GetClient().SendTemplate(*userInfo.Phone, tpl.SmsTemplateId, message_template.Strings(rendered.Values))
+	}
+
+	return
+}
+
+func SendRouter(c *gin.Context) {
+	var (
+		input SendParams
+		err   error
+		res   = schema.Response{}
+	)
+
+	defer func() {
+		if err != nil {
+			res.Data = nil
+			res.Message = err.Error()
+		}
+		c.JSON(http.StatusOK, res)
+	}()
+
+	if err = c.ShouldBindJSON(&input); err != nil {
+		err = exception.InvalidParams
+		return
+	}
+
+	res = Send(controller.Context{
+		Uid: c.GetString(middleware.ContextUidField),
+	}, input)
+}
@@ -0,0 +1,112 @@
+// Copyright 2019 Axetroy. All rights reserved. MIT license.
+package template_test
+
+import (
+	"github.com/axetroy/go-server/core/controller"
+	"github.com/axetroy/go-server/core/controller/auth"
+	"github.com/axetroy/go-server/core/controller/message"
+	"github.com/axetroy/go-server/core/controller/template"
+	"github.com/axetroy/go-server/core/exception"
+	"github.com/axetroy/go-server/core/model"
+	"github.com/axetroy/go-server/core/schema"
+	"github.com/axetroy/go-server/core/service/database"
+	"github.com/axetroy/go-server/core/service/message_template"
+	"github.com/axetroy/go-server/core/service/preference"
+	"github.com/axetroy/go-server/tester"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestSend(t *testing.T) {
+	adminInfo, _ := tester.LoginAdmin()
+	userInfo, _ := tester.CreateUser()
+
+	defer auth.DeleteUserByUserName(userInfo.Username)
+
+	c := controller.Context{Uid: adminInfo.Id}
+
+	data := createTemplate(t, c, "test_send")
+
+	defer template.DeleteTemplateById(data.Id)
+
+	// 站内消息发送成功, 用户没有绑定邮箱则邮件发送失败
+	{
+		r := template.Send(c, template.SendParams{
+			Name:     "test_send",
+			Uids:     []string{userInfo.Id, "not_exist"},
+			Channels: []string{"message", "email"},
+			Values:   map[string]interface{}{"name": "axetroy"},
+		})
+
+		assert.Equal(t, "", r.Message)
+
+		list := make([]schema.MessageTemplateDelivery, 0)
+
+		assert.Nil(t, tester.Decode(r.Data, &list))
+
+		assert.Equal(t, []schema.MessageTemplateDelivery{
+			{Uid: userInfo.Id, Channel: "message", Success: true},
+			{Uid: userInfo.Id, Channel: "email", Error: exception.MessageTemplateRequireEmail.Error()},
+			{Uid: "not_exist", Channel: "message", Error: exception.UserNotExist.Error()},
+			{Uid: "not_exist", Channel: "email", Error: exception.UserNotExist.Error()},
+		}, list)
+
+		messageInfo := model.Message{}
+
+		assert.Nil(t, database.Db.Where("uid = ?", userInfo.Id).Last(&messageInfo).Error)
+		assert.Equal(t, "axetroy 的订单", messageInfo.Title)
+
+		defer message.DeleteMessageById(messageInfo.Id)
+	}
+
+	// 用户关闭了系统消息的站内消息, 模版的分类为 system
+	{
+		p := preference.Default(userInfo.Id)
+		p.SetEnabled(preference.CategorySystem, message_template.ChannelMessage, false)
+		m := p.ToModel()
+
+		assert.Nil(t, database.Db.Create(&m).Error)
+
+		r := template.Send(c, template.SendParams{
+			Name:     "test_send",
+			Uids:     []string{userInfo.Id},
+			Channels: []string{"message"},
+			Values:   map[string]interface{}{"name": "axetroy"},
+		})
+
+		database.DeleteRowByTable("notification_preference", "uid", userInfo.Id)
+
+		assert.Equal(t, "", r.Message)
+
+		list := make([]schema.MessageTemplateDelivery, 0)
+
+		assert.Nil(t, tester.Decode(r.Data, &list))
+
+		assert.Equal(t, []schema.MessageTemplateDelivery{
+			{Uid: userInfo.Id, Channel: "message", Error: exception.NotificationPreferenceDisabled.Error()},
+		}, list)
+	}
+
+	// 没有设置短信模版ID
+	{
+		r := template.Send(c, template.SendParams{
+			Name:     "test_send",
+			Uids:     []string{userInfo.Id},
+			Channels: []string{"sms"},
+			Values:   map[string]interface{}{"name": "axetroy"},
+		})
+
+		assert.Equal(t, exception.MessageTemplateRequireSms.Error(), r.Message)
+	}
+
+	// 无效的渠道
+	{
+		r := template.Send(c, template.SendParams{
+			Name:     "test_send",
+			Uids:     []string{userInfo.Id},
+			Channels: []string{"wechat"},
+		})
+
+		assert.Equal(t, exception.MessageTemplateInvalidChannel.Error(), r.Message)
+	}
+}
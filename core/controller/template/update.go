@@ -0,0 +1,176 @@
+// Copyright 2019 Axetroy. All rights reserved. MIT license.
+package template
+
+import (
+	"errors"
+	"github.com/axetroy/go-server/core/controller"
+	"github.com/axetroy/go-server/core/exception"
+	"github.com/axetroy/go-server/core/helper"
+	"github.com/axetroy/go-server/core/middleware"
+	"github.com/axetroy/go-server/core/model"
+	"github.com/axetroy/go-server/core/schema"
+	"github.com/axetroy/go-server/core/service/database"
+	"github.com/axetroy/go-server/core/service/message_template"
+	"github.com/axetroy/go-server/core/validator"
+	"github.com/gin-gonic/gin"
+	"github.com/jinzhu/gorm"
+	"net/http"
+)
+
+type UpdateParams struct {
+	Subject       *string                      `json:"subject" valid:"length(1|255)~模版标题不能超过255个字符"` // 标题
+	Format        *string                      `json:"format"`                                       // 内容的格式, text 或者 html
+	Body          *string                      `json:"body"`                                         // 模版内容
+	Variables     *[]message_template.Variable `json:"variables"`                                    // 模版变量的声明
+	Category      *string                      `json:"category"`                                     // 通知的分类
+	SmsTemplateId *string                      `json:"sms_template_id"`                              // 短信服务商的模版ID, 空字符串则清除
+	Note          *string                      `json:"note"`                                         // 备注
+}
+
+// 修改消息模版, 模版的名称和语言不能修改
+func Update(c controller.Context, id string, input UpdateParams) (res schema.Response) {
+	var (
+		err  error
+		data schema.MessageTemplate
+		tx   *gorm.DB
+	)
+
+	defer func() {
+		if r := recover(); r != nil {
+			switch t := r.(type) {
+			case string:
+				err = errors.New(t)
+			case error:
+				err = t
+			default:
+				err = exception.Unknown
+			}
+		}
+
+		if tx != nil {
+			if err != nil {
+				_ = tx.Rollback().Error
+			} else {
+				err = tx.Commit().Error
+			}
+		}
+
+		helper.Response(&res, data, err)
+	}()
+
+	// 参数校验
+	if err = validator.ValidateStruct(input); err != nil {
+		return
+	}
+
+	tx = database.Db.Begin()
+
+	adminInfo := model.Admin{Id: c.Uid}
+
+	if err = tx.First(&adminInfo).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			err = exception.AdminNotExist
+		}
+		return
+	}
+
+	templateInfo := model.MessageTemplate{}
+
+	if err = tx.Where("id = ?", id).First(&templateInfo).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			err = exception.MessageTemplateNotExist
+		}
+		return
+	}
+
+	// 使用 map 更新, 否则空值会被忽略
+	updated := map[string]interface{}{}
+
+	if input.Subject != nil {
+		templateInfo.Subject = *input.Subject
+		updated["subject"] = templateInfo.Subject
+	}
+
+	if input.Format != nil {
+		templateInfo.Format = *input.Format
+		updated["format"] = templateInfo.Format
+	}
+
+	if input.Body != nil {
+		templateInfo.Body = *input.Body
+		updated["body"] = templateInfo.Body
+	}
+
+	if input.Variables != nil {
+		if templateInfo.Variables, err = message_template.MarshalVariables(*input.Variables); err != nil {
+			return
+		}
+		updated["variables"] = templateInfo.Variables
+	}
+
+	if input.Category != nil {
+		if templateInfo.Category, err = parseCategory(templateInfo.Name, *input.Category); err != nil {
+			return
+		}
+		updated["category"] = templateInfo.Category
+	}
+
+	if input.SmsTemplateId != nil {
+		if *input.SmsTemplateId == "" {
+			templateInfo.SmsTemplateId = nil
+		} else {
+			templateInfo.SmsTemplateId = input.SmsTemplateId
+		}
+		updated["sms_template_id"] = templateInfo.SmsTemplateId
+	}
+
+	if input.Note != nil {
+		templateInfo.Note = input.Note
+		updated["note"] = templateInfo.Note
+	}
+
+	tpl, err := message_template.FromModel(templateInfo)
+
+	if err != nil {
+		return
+	}
+
+	if err = tpl.Validate(); err != nil {
+		return
+	}
+
+	if len(updated) > 0 {
+		if err = tx.Model(&templateInfo).Updates(updated).Error; err != nil {
+			return
+		}
+	}
+
+	data, err = mapToSchema(templateInfo)
+
+	return
+}
+
+func UpdateRouter(c *gin.Context) {
+	var (
+		input UpdateParams
+		err   error
+		res   = schema.Response{}
+	)
+
+	defer func() {
+		if err != nil {
+			res.Data = nil
+			res.Message = err.Error()
+		}
+		c.JSON(http.StatusOK, res)
+	}()
+
+	if err = c.ShouldBindJSON(&input); err != nil {
+		err = exception.InvalidParams
+		return
+	}
+
+	res = Update(controller.Context{
+		Uid: c.GetString(middleware.ContextUidField),
+	}, c.Param("id"), input)
+}
@@ -0,0 +1,66 @@
+// Copyright 2019 Axetroy. All rights reserved. MIT license.
+package template_test
+
+import (
+	"github.com/axetroy/go-server/core/controller"
+	"github.com/axetroy/go-server/core/controller/template"
+	"github.com/axetroy/go-server/core/exception"
+	"github.com/axetroy/go-server/core/schema"
+	"github.com/axetroy/go-server/core/service/message_template"
+	"github.com/axetroy/go-server/tester"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestUpdate(t *testing.T) {
+	adminInfo, _ := tester.LoginAdmin()
+
+	c := controller.Context{Uid: adminInfo.Id}
+
+	data := createTemplate(t, c, "test_update")
+
+	defer template.DeleteTemplateById(data.Id)
+
+	// 修改内容和变量
+	{
+		body := "{{.name}} 共 {{.count}} 件"
+		variables := []message_template.Variable{
+			{Name: "name", Type: message_template.VariableTypeString, Required: true},
+			{Name: "count", Type: message_template.VariableTypeInt},
+		}
+		smsTemplateId := "SMS_001"
+
+		r := template.Update(c, data.Id, template.UpdateParams{
+			Body:          &body,
+			Variables:     &variables,
+			SmsTemplateId: &smsTemplateId,
+		})
+
+		assert.Equal(t, "", r.Message)
+
+		updated := schema.MessageTemplate{}
+
+		assert.Nil(t, tester.Decode(r.Data, &updated))
+		assert.Equal(t, body, updated.Body)
+		assert.Len(t, updated.Variables, 2)
+		assert.Equal(t, smsTemplateId, *updated.SmsTemplateId)
+	}
+
+	// 无效的格式
+	{
+		format := "markdown"
+
+		r := template.Update(c, data.Id, template.UpdateParams{Format: &format})
+
+		assert.Equal(t, exception.MessageTemplateInvalid.Error(), r.Message)
+	}
+
+	// 不存在的模版
+	{
+		body := "test"
+
+		r := template.Update(c, "123123", template.UpdateParams{Body: &body})
+
+		assert.Equal(t, exception.MessageTemplateNotExist.Error(), r.Message)
+	}
+}
@@ -0,0 +1,86 @@
+// Copyright 2019 Axetroy. All rights reserved. MIT license.
+package template
+
+import (
+	"github.com/axetroy/go-server/core/exception"
+	"github.com/axetroy/go-server/core/model"
+	"github.com/axetroy/go-server/core/schema"
+	"github.com/axetroy/go-server/core/service/database"
+	"github.com/axetroy/go-server/core/service/message_template"
+	"github.com/axetroy/go-server/core/service/preference"
+	"time"
+)
+
+func DeleteTemplateById(id string) {
+	database.DeleteRowByTable("message_template", "id", id)
+}
+
+func mapToSchema(m model.MessageTemplate) (d schema.MessageTemplate, err error) {
+	tpl, err := message_template.FromModel(m)
+
+	if err != nil {
+		return
+	}
+
+	d.Id = m.Id
+	d.Name = m.Name
+	d.Locale = m.Locale
+	d.Subject = m.Subject
+	d.Format = m.Format
+	d.Body = m.Body
+	d.Variables = make([]schema.MessageTemplateVariable, 0)
+	d.Category = tpl.Category
+	d.SmsTemplateId = m.SmsTemplateId
+	d.Note = m.Note
+	d.CreatedAt = m.CreatedAt.Format(time.RFC3339Nano)
+	d.UpdatedAt = m.UpdatedAt.Format(time.RFC3339Nano)
+
+	for _, v := range tpl.Variables {
+		d.Variables = append(d.Variables, schema.MessageTemplateVariable{
+			Name:     v.Name,
+			Type:     string(v.Type),
+			Required: v.Required,
+		})
+	}
+
+	return
+}
+
+// 解析模版的分类, 为空则使用默认的分类
+// 覆盖内置模版时只能使用内置模版的分类, 安全类只能由内置模版使用, 否则可以绕过用户的通知偏好
+func parseCategory(name string, s string) (string, error) {
+	if b, ok := message_template.Builtin[name]; ok {
+		if s != "" && s != b.Category {
+			return "", exception.MessageTemplateInvalidCategory
+		}
+		return b.Category, nil
+	}
+
+	if s == "" {
+		return message_template.CategorySystem, nil
+	}
+
+	category, err := preference.ParseCategory(s)
+
+	if err != nil || category == preference.CategorySecurity {
+		return "", exception.MessageTemplateInvalidCategory
+	}
+
+	return string(category), nil
+}
+
+// 渲染模版用于预览
+func preview(tpl message_template.Template, values map[string]interface{}) (data schema.MessageTemplatePreview, err error) {
+	rendered, err := tpl.Render(values)
+
+	if err != nil {
+		return
+	}
+
+	data.Subject = rendered.Subject
+	data.Body = rendered.Body
+	data.HTML = rendered.HTML
+	data.Sms = message_template.Strings(rendered.Values)
+
+	return
+}
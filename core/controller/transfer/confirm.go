@@ -0,0 +1,198 @@
+// Copyright 2019 Axetroy. All rights reserved. MIT license.
+package transfer
+
+import (
+	"errors"
+	"github.com/axetroy/go-server/core/controller"
+	"github.com/axetroy/go-server/core/exception"
+	"github.com/axetroy/go-server/core/helper"
+	"github.com/axetroy/go-server/core/logger"
+	"github.com/axetroy/go-server/core/middleware"
+	"github.com/axetroy/go-server/core/model"
+	"github.com/axetroy/go-server/core/schema"
+	"github.com/axetroy/go-server/core/service/database"
+	"github.com/axetroy/go-server/core/service/wallet"
+	"github.com/axetroy/go-server/core/util"
+	"github.com/gin-gonic/gin"
+	"github.com/jinzhu/gorm"
+	"net/http"
+	"time"
+)
+
+// 锁定一条转账记录, 必须在事务中调用
+func lockTransfer(tx *gorm.DB, transferId string) (log model.TransferLog, err error) {
+	if err = tx.Set("gorm:query_option", "FOR UPDATE").Where("id = ?", transferId).First(&log).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			err = exception.TransferNotExist
+		}
+		return
+	}
+
+	return
+}
+
+// 结束一笔等待确认的转账, 并解冻汇款人的钱
+// 确认则把冻结的钱转给收款人, 拒绝或者过期则退回汇款人的可用余额
+func settle(tx *gorm.DB, log *model.TransferLog, status model.TransferStatus) (err error) {
+	var amount util.Decimal
+
+	if log.Status != model.TransferStatusWaitForConfirm {
+		err = exception.TransferHandled
+		return
+	}
+
+	if amount, err = util.ParseDecimal(log.Amount); err != nil {
+		return
+	}
+
+	if status == model.TransferStatusConfirmed {
+		_, err = wallet.Apply(tx, log.Currency, log.Id, wallet.Mutation{
+			Uid:    log.From,
+			Frozen: amount.Neg(),
+			Type:   model.FinanceTypeTransferOut,
+		}, wallet.Mutation{
+			Uid:     log.To,
+			Balance: amount,
+			Type:    model.FinanceTypeTransferIn,
+		})
+	} else {
+		_, err = wallet.Apply(tx, log.Currency, log.Id, wallet.Mutation{
+			Uid:     log.From,
+			Balance: amount,
+			Frozen:  amount.Neg(),
+			Type:    model.FinanceTypeTransferRefund,
+		})
+	}
+
+	if err != nil {
+		return
+	}
+
+	if err = tx.Model(&model.TransferLog{}).Where("id = ?", log.Id).Update("status", status).Error; err != nil {
+		return
+	}
+
+	log.Status = status
+
+	return
+}
+
+// 收款方处理一笔等待确认的转账
+func handle(c controller.Context, transferId string, status model.TransferStatus) (res schema.Response) {
+	var (
+		err  error
+		tx   *gorm.DB
+		data = schema.TransferLog{}
+	)
+
+	defer func() {
+		if r := recover(); r != nil {
+			switch t := r.(type) {
+			case string:
+				err = errors.New(t)
+			case error:
+				err = t
+			default:
+				err = exception.Unknown
+			}
+		}
+
+		if tx != nil {
+			if err != nil {
+				_ = tx.Rollback().Error
+			} else {
+				logger.Infof("User %s handle transfer %s with status %d", c.Uid, transferId, status)
+				err = tx.Commit().Error
+			}
+		}
+
+		helper.Response(&res, data, err)
+	}()
+
+	tx = database.Db.Begin()
+
+	log := model.TransferLog{}
+
+	if log, err = lockTransfer(tx, transferId); err != nil {
+		return
+	}
+
+	// 只有收款方可以确认或者拒绝
+	if log.To != c.Uid {
+		err = exception.NoPermission
+		return
+	}
+
+	if log.Status != model.TransferStatusWaitForConfirm {
+		err = exception.TransferHandled
+		return
+	}
+
+	// 已经过期的转账等待定时任务退回
+	if log.ExpiredAt != nil && !time.Now().Before(*log.ExpiredAt) {
+		err = exception.TransferExpired
+		return
+	}
+
+	if err = settle(tx, &log, status); err != nil {
+		return
+	}
+
+	if status == model.TransferStatusConfirmed {
+		if err = advanceInvite(tx, log); err != nil {
+			return
+		}
+	}
+
+	data, err = mapToSchema(log)
+
+	return
+}
+
+// 确认收款
+func Confirm(c controller.Context, transferId string) (res schema.Response) {
+	return handle(c, transferId, model.TransferStatusConfirmed)
+}
+
+// 拒绝收款, 转账金额退回汇款人
+func Reject(c controller.Context, transferId string) (res schema.Response) {
+	return handle(c, transferId, model.TransferStatusReject)
+}
+
+func ConfirmRouter(c *gin.Context) {
+	var (
+		err error
+		res = schema.Response{}
+	)
+
+	defer func() {
+		if err != nil {
+			res.Data = nil
+			res.Message = err.Error()
+		}
+		c.JSON(http.StatusOK, res)
+	}()
+
+	res = Confirm(controller.Context{
+		Uid: c.GetString(middleware.ContextUidField),
+	}, c.Param("transfer_id"))
+}
+
+func RejectRouter(c *gin.Context) {
+	var (
+		err error
+		res = schema.Response{}
+	)
+
+	defer func() {
+		if err != nil {
+			res.Data = nil
+			res.Message = err.Error()
+		}
+		c.JSON(http.StatusOK, res)
+	}()
+
+	res = Reject(controller.Context{
+		Uid: c.GetString(middleware.ContextUidField),
+	}, c.Param("transfer_id"))
+}
@@ -0,0 +1,288 @@
+// Copyright 2019 Axetroy. All rights reserved. MIT license.
+package transfer_test
+
+import (
+	"encoding/json"
+	"github.com/axetroy/go-server/core/config"
+	"github.com/axetroy/go-server/core/controller"
+	"github.com/axetroy/go-server/core/controller/auth"
+	"github.com/axetroy/go-server/core/controller/transfer"
+	"github.com/axetroy/go-server/core/controller/wallet"
+	"github.com/axetroy/go-server/core/exception"
+	"github.com/axetroy/go-server/core/model"
+	"github.com/axetroy/go-server/core/schema"
+	"github.com/axetroy/go-server/core/service/database"
+	"github.com/axetroy/go-server/core/util"
+	"github.com/axetroy/go-server/tester"
+	"github.com/stretchr/testify/assert"
+	"testing"
+	"time"
+)
+
+// 发起一笔需要确认的转账
+func pendingTransfer(t *testing.T, from string, to string, amount string) schema.TransferLog {
+	input := transfer.ToParams{
+		Currency: "CNY",
+		To:       to,
+		Amount:   amount,
+		Pending:  true,
+	}
+
+	b, _ := json.Marshal(input)
+	signature, _ := util.Signature(string(b))
+
+	res := transfer.To(controller.Context{Uid: from}, input, signature, "")
+
+	assert.Equal(t, "", res.Message)
+	assert.Equal(t, schema.StatusSuccess, res.Status)
+
+	data := schema.TransferLog{}
+
+	assert.Nil(t, tester.Decode(res.Data, &data))
+
+	return data
+}
+
+func getWallet(t *testing.T, uid string) schema.Wallet {
+	res := wallet.GetWallet(controller.Context{Uid: uid}, "CNY")
+	w := schema.Wallet{}
+
+	assert.Equal(t, "", res.Message)
+	assert.Nil(t, tester.Decode(res.Data, &w))
+
+	return w
+}
+
+func getFinanceLogs(t *testing.T, orderId string) (logs []model.FinanceLog) {
+	assert.Nil(t, model.FinanceLogOf(database.Db, model.WalletCNY).Where("order_id = ?", orderId).Order("created_at ASC").Find(&logs).Error)
+	return
+}
+
+func TestConfirm(t *testing.T) {
+	userFrom, _ := tester.CreateUser()
+	userTo, _ := tester.CreateUser()
+
+	defer auth.DeleteUserByUserName(userFrom.Username)
+	defer auth.DeleteUserByUserName(userTo.Username)
+
+	// 给账户充钱
+	assert.Nil(t, model.WalletOf(database.Db, "CNY").Where("id = ?", userFrom.Id).Update(model.Wallet{
+		Balance:  util.NewDecimalFromInt(100),
+		Currency: model.WalletCNY,
+	}).Error)
+
+	log := pendingTransfer(t, userFrom.Id, userTo.Id, "20")
+
+	assert.Equal(t, model.TransferStatusWaitForConfirm, log.Status)
+	assert.NotNil(t, log.ExpiredAt)
+
+	// 转账金额被冻结, 对方还没有收到
+	fromWallet := getWallet(t, userFrom.Id)
+	assert.Equal(t, "80.00000000", fromWallet.Balance)
+	assert.Equal(t, "20.00000000", fromWallet.Frozen)
+	assert.Equal(t, "0.00000000", getWallet(t, userTo.Id).Balance)
+
+	// 汇款人不能确认
+	{
+		res := transfer.Confirm(controller.Context{Uid: userFrom.Id}, log.Id)
+		assert.Equal(t, exception.NoPermission.Error(), res.Message)
+	}
+
+	// 收款人确认
+	{
+		res := transfer.Confirm(controller.Context{Uid: userTo.Id}, log.Id)
+		data := schema.TransferLog{}
+
+		assert.Equal(t, "", res.Message)
+		assert.Nil(t, tester.Decode(res.Data, &data))
+		assert.Equal(t, model.TransferStatusConfirmed, data.Status)
+	}
+
+	fromWallet = getWallet(t, userFrom.Id)
+	assert.Equal(t, "80.00000000", fromWallet.Balance)
+	assert.Equal(t, "0.00000000", fromWallet.Frozen)
+	assert.Equal(t, "20.00000000", getWallet(t, userTo.Id).Balance)
+
+	// 冻结, 解冻并转出, 转入, 一共三条流水
+	logs := getFinanceLogs(t, log.Id)
+	assert.Len(t, logs, 3)
+	assert.Equal(t, model.FinanceTypeTransferFreeze, logs[0].Type)
+	assert.Equal(t, "20", logs[0].FrozenMutation.String())
+	assert.Equal(t, "-20", logs[0].BalanceMutation.String())
+
+	// 不能重复确认
+	{
+		res := transfer.Confirm(controller.Context{Uid: userTo.Id}, log.Id)
+		assert.Equal(t, exception.TransferHandled.Error(), res.Message)
+	}
+
+	// 不存在的转账
+	{
+		res := transfer.Confirm(controller.Context{Uid: userTo.Id}, "123123")
+		assert.Equal(t, exception.TransferNotExist.Error(), res.Message)
+	}
+}
+
+func TestReject(t *testing.T) {
+	userFrom, _ := tester.CreateUser()
+	userTo, _ := tester.CreateUser()
+
+	defer auth.DeleteUserByUserName(userFrom.Username)
+	defer auth.DeleteUserByUserName(userTo.Username)
+
+	// 给账户充钱
+	assert.Nil(t, model.WalletOf(database.Db, "CNY").Where("id = ?", userFrom.Id).Update(model.Wallet{
+		Balance:  util.NewDecimalFromInt(100),
+		Currency: model.WalletCNY,
+	}).Error)
+
+	log := pendingTransfer(t, userFrom.Id, userTo.Id, "20")
+
+	res := transfer.Reject(controller.Context{Uid: userTo.Id}, log.Id)
+	data := schema.TransferLog{}
+
+	assert.Equal(t, "", res.Message)
+	assert.Nil(t, tester.Decode(res.Data, &data))
+	assert.Equal(t, model.TransferStatusReject, data.Status)
+
+	// 钱退回汇款人
+	fromWallet := getWallet(t, userFrom.Id)
+	assert.Equal(t, "100.00000000", fromWallet.Balance)
+	assert.Equal(t, "0.00000000", fromWallet.Frozen)
+	assert.Equal(t, "0.00000000", getWallet(t, userTo.Id).Balance)
+
+	logs := getFinanceLogs(t, log.Id)
+	assert.Len(t, logs, 2)
+	assert.Equal(t, model.FinanceTypeTransferRefund, logs[1].Type)
+	assert.Equal(t, "-20", logs[1].FrozenMutation.String())
+	assert.Equal(t, "20", logs[1].BalanceMutation.String())
+}
+
+func TestExpirePending(t *testing.T) {
+	userFrom, _ := tester.CreateUser()
+	userTo, _ := tester.CreateUser()
+
+	defer auth.DeleteUserByUserName(userFrom.Username)
+	defer auth.DeleteUserByUserName(userTo.Username)
+
+	// 给账户充钱
+	assert.Nil(t, model.WalletOf(database.Db, "CNY").Where("id = ?", userFrom.Id).Update(model.Wallet{
+		Balance:  util.NewDecimalFromInt(100),
+		Currency: model.WalletCNY,
+	}).Error)
+
+	log := pendingTransfer(t, userFrom.Id, userTo.Id, "20")
+
+	// 还没有过期, 不会被退回
+	{
+		_, err := transfer.ExpirePending()
+		assert.Nil(t, err)
+		assert.Equal(t, "20.00000000", getWallet(t, userFrom.Id).Frozen)
+	}
+
+	// 修改为已经过期
+	assert.Nil(t, model.TransferLogOf(database.Db, "CNY").Where("id = ?", log.Id).Update("expired_at", time.Now().Add(-time.Minute)).Error)
+
+	// 过期的转账不能再确认
+	{
+		res := transfer.Confirm(controller.Context{Uid: userTo.Id}, log.Id)
+		assert.Equal(t, exception.TransferExpired.Error(), res.Message)
+	}
+
+	count, err := transfer.ExpirePending()
+
+	assert.Nil(t, err)
+	assert.True(t, count >= 1)
+
+	fromWallet := getWallet(t, userFrom.Id)
+	assert.Equal(t, "100.00000000", fromWallet.Balance)
+	assert.Equal(t, "0.00000000", fromWallet.Frozen)
+
+	res := transfer.GetDetail(controller.Context{Uid: userFrom.Id}, log.Id)
+	data := schema.TransferLog{}
+
+	assert.Equal(t, "", res.Message)
+	assert.Nil(t, tester.Decode(res.Data, &data))
+	assert.Equal(t, model.TransferStatusExpired, data.Status)
+}
+
+func TestConfirmAdvanceInvite(t *testing.T) {
+	inviter, _ := tester.CreateUser()
+	userFrom, _ := tester.CreateUser()
+	userTo, _ := tester.CreateUser()
+
+	defer auth.DeleteUserByUserName(inviter.Username)
+	defer auth.DeleteUserByUserName(userFrom.Username)
+	defer auth.DeleteUserByUserName(userTo.Username)
+
+	history := model.InviteHistory{
+		Inviter: inviter.Id,
+		Invitee: userFrom.Id,
+		Status:  model.StatusInviteRegistered,
+	}
+
+	assert.Nil(t, database.Db.Create(&history).Error)
+
+	defer database.DeleteRowByTable(history.TableName(), "id", history.Id)
+
+	assert.Nil(t, model.WalletOf(database.Db, "CNY").Where("id = ?", userFrom.Id).Update(model.Wallet{
+		Balance:  util.NewDecimalFromInt(100),
+		Currency: model.WalletCNY,
+	}).Error)
+
+	status := func() model.InviteStatus {
+		h := model.InviteHistory{}
+		assert.Nil(t, database.Db.Where("id = ?", history.Id).First(&h).Error)
+		return h.Status
+	}
+
+	payMin := config.Invite.PayMin
+
+	defer func() {
+		config.Invite.PayMin = payMin
+	}()
+
+	// 没有配置最小值的币种不算完成支付
+	{
+		config.Invite.PayMin = map[string]string{"USD": "1"}
+
+		log := pendingTransfer(t, userFrom.Id, userTo.Id, "20")
+
+		res := transfer.Confirm(controller.Context{Uid: userTo.Id}, log.Id)
+		assert.Equal(t, "", res.Message)
+		assert.Equal(t, model.StatusInviteRegistered, status())
+	}
+
+	config.Invite.PayMin = map[string]string{"CNY": "10"}
+
+	// 小于最小值的转账不算完成支付
+	{
+		log := pendingTransfer(t, userFrom.Id, userTo.Id, "5")
+
+		res := transfer.Confirm(controller.Context{Uid: userTo.Id}, log.Id)
+		assert.Equal(t, "", res.Message)
+		assert.Equal(t, model.StatusInviteRegistered, status())
+	}
+
+	// 被拒绝的转账不算完成支付
+	{
+		log := pendingTransfer(t, userFrom.Id, userTo.Id, "20")
+
+		assert.Equal(t, model.StatusInviteRegistered, status())
+
+		res := transfer.Reject(controller.Context{Uid: userTo.Id}, log.Id)
+		assert.Equal(t, "", res.Message)
+		assert.Equal(t, model.StatusInviteRegistered, status())
+	}
+
+	// 收款方确认后才算完成支付
+	{
+		log := pendingTransfer(t, userFrom.Id, userTo.Id, "20")
+
+		assert.Equal(t, model.StatusInviteRegistered, status())
+
+		res := transfer.Confirm(controller.Context{Uid: userTo.Id}, log.Id)
+		assert.Equal(t, "", res.Message)
+		assert.Equal(t, model.StatusInvitePay, status())
+	}
+}
@@ -9,12 +9,11 @@ import (
 	"github.com/axetroy/go-server/core/middleware"
 	"github.com/axetroy/go-server/core/model"
 	"github.com/axetroy/go-server/core/schema"
+	"github.com/axetroy/go-server/core/service/currency"
 	"github.com/axetroy/go-server/core/service/database"
 	"github.com/gin-gonic/gin"
 	"github.com/jinzhu/gorm"
-	"github.com/mitchellh/mapstructure"
 	"net/http"
-	"time"
 )
 
 func GetDetail(c controller.Context, transferId string) (res schema.Response) {
@@ -60,13 +59,15 @@ func GetDetail(c controller.Context, transferId string) (res schema.Response) {
 
 	log := model.TransferLog{}
 
-	// 联表查询
-	// 只能获取自己转给别人的
-	sql := GenerateTransferLogSQL(QueryParams{
-		Id: &transferId,
-	}, 1, false)
+	var codes []string
+
+	if codes, err = currency.Codes(); err != nil {
+		return
+	}
 
-	if err = tx.Raw(sql).Scan(&log).Error; err != nil {
+	if err = TransferLogQuery(tx, codes, QueryParams{
+		Id: &transferId,
+	}).First(&log).Error; err != nil {
 		return
 	}
 
@@ -78,12 +79,7 @@ func GetDetail(c controller.Context, transferId string) (res schema.Response) {
 		}
 	}
 
-	if err = mapstructure.Decode(log, &data.TransferLogPure); err != nil {
-		return
-	}
-
-	data.CreatedAt = log.CreatedAt.Format(time.RFC3339Nano)
-	data.UpdatedAt = log.UpdatedAt.Format(time.RFC3339Nano)
+	data, err = mapToSchema(log)
 	return
 }
 
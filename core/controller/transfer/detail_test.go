@@ -6,7 +6,6 @@ import (
 	"github.com/axetroy/go-server/core/controller"
 	"github.com/axetroy/go-server/core/controller/auth"
 	"github.com/axetroy/go-server/core/controller/transfer"
-	"github.com/axetroy/go-server/core/controller/wallet"
 	"github.com/axetroy/go-server/core/model"
 	"github.com/axetroy/go-server/core/schema"
 	"github.com/axetroy/go-server/core/service/database"
@@ -26,8 +25,8 @@ func TestGetDetail(t *testing.T) {
 
 	// 给账户充钱
 	{
-		assert.Nil(t, database.Db.Table(wallet.GetTableName("CNY")).Where("id = ?", userFrom.Id).Update(model.Wallet{
-			Balance:  100,
+		assert.Nil(t, model.WalletOf(database.Db, "CNY").Where("id = ?", userFrom.Id).Update(model.Wallet{
+			Balance:  util.NewDecimalFromInt(100),
 			Currency: model.WalletCNY,
 		}).Error)
 	}
@@ -50,7 +49,7 @@ func TestGetDetail(t *testing.T) {
 
 		res2 := transfer.To(controller.Context{
 			Uid: userFrom.Id,
-		}, input, signature)
+		}, input, signature, "")
 
 		assert.Equal(t, "", res2.Message)
 		assert.Equal(t, schema.StatusSuccess, res2.Status)
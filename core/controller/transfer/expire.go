@@ -0,0 +1,100 @@
+// Copyright 2019 Axetroy. All rights reserved. MIT license.
+package transfer
+
+import (
+	"github.com/axetroy/go-server/core/config"
+	"github.com/axetroy/go-server/core/logger"
+	"github.com/axetroy/go-server/core/model"
+	"github.com/axetroy/go-server/core/service/currency"
+	"github.com/axetroy/go-server/core/service/database"
+	"log"
+	"time"
+)
+
+// 每次最多处理的过期转账数量
+var expireBatchSize = 100
+
+// 退回一笔过期的转账, 在单独的事务中完成
+func expire(transferId string) (err error) {
+	tx := database.Db.Begin()
+
+	defer func() {
+		if err != nil {
+			_ = tx.Rollback().Error
+		} else {
+			err = tx.Commit().Error
+		}
+	}()
+
+	transferLog, err := lockTransfer(tx, transferId)
+
+	if err != nil {
+		return
+	}
+
+	// 加锁之前已经被收款方处理了
+	if transferLog.Status != model.TransferStatusWaitForConfirm {
+		return
+	}
+
+	return settle(tx, &transferLog, model.TransferStatusExpired)
+}
+
+// 把所有超时未确认的转账退回给汇款人, 返回处理的数量
+// 单笔转账退回失败不会影响其他的转账
+func ExpirePending() (count int, err error) {
+	var codes []string
+
+	if codes, err = currency.Codes(); err != nil {
+		return
+	}
+
+	lastId := ""
+
+	for {
+		var ids []string
+
+		if err = database.Db.Model(&model.TransferLog{}).Where("currency IN (?) AND status = ? AND expired_at <= ? AND id > ?", codes, model.TransferStatusWaitForConfirm, time.Now(), lastId).Order("id ASC").Limit(expireBatchSize).Pluck("id", &ids).Error; err != nil {
+			return
+		}
+
+		for _, id := range ids {
+			if er := expire(id); er != nil {
+				log.Println("退回过期的转账失败:", id, er)
+				continue
+			}
+			count++
+		}
+
+		if len(ids) < expireBatchSize {
+			break
+		}
+
+		lastId = ids[len(ids)-1]
+	}
+
+	return
+}
+
+// 定时退回超时未确认的转账, 进程退出时停止
+func RunExpireWorker(interval time.Duration) {
+	ticker := time.NewTicker(interval)
+
+	defer ticker.Stop()
+
+	for range ticker.C {
+		if config.Common.Exiting {
+			return
+		}
+
+		count, err := ExpirePending()
+
+		if err != nil {
+			log.Println("退回超时的转账失败:", err)
+		}
+
+		if count > 0 {
+			logger.Infof("%d pending transfers expired", count)
+		}
+	}
+}
@@ -9,12 +9,11 @@ import (
 	"github.com/axetroy/go-server/core/middleware"
 	"github.com/axetroy/go-server/core/model"
 	"github.com/axetroy/go-server/core/schema"
+	"github.com/axetroy/go-server/core/service/currency"
 	"github.com/axetroy/go-server/core/service/database"
 	"github.com/gin-gonic/gin"
 	"github.com/jinzhu/gorm"
-	"github.com/mitchellh/mapstructure"
 	"net/http"
-	"time"
 )
 
 type Query struct {
@@ -73,28 +72,28 @@ func GetHistory(c controller.Context, input Query) (res schema.List) {
 		From: &c.Uid,
 	}
 
-	// 联表查询
-	countSQL := GenerateTransferLogSQL(condition, query.Limit, true)
-	listSQL := GenerateTransferLogSQL(condition, query.Limit, false)
+	var codes []string
+
+	if codes, err = currency.Codes(); err != nil {
+		return
+	}
 
 	var total int64
 
-	if err = tx.Raw(countSQL).Count(&total).Error; err != nil {
+	if err = TransferLogQuery(tx, codes, condition).Count(&total).Error; err != nil {
 		return
 	}
 
-	if err = tx.Raw(listSQL).Scan(&list).Error; err != nil {
+	if err = TransferLogQuery(tx, codes, condition).Limit(query.Limit).Find(&list).Error; err != nil {
 		return
 	}
 
 	for _, v := range list {
-		d := schema.TransferLog{}
-		if er := mapstructure.Decode(v, &d.TransferLogPure); er != nil {
+		d, er := mapToSchema(v)
+		if er != nil {
 			err = er
 			return
 		}
-		d.CreatedAt = v.CreatedAt.Format(time.RFC3339Nano)
-		d.UpdatedAt = v.UpdatedAt.Format(time.RFC3339Nano)
 		data = append(data, d)
 	}
 
@@ -0,0 +1,432 @@
+// Copyright 2019 Axetroy. All rights reserved. MIT license.
+package transfer
+
+import (
+	"errors"
+	"github.com/axetroy/go-server/core/controller"
+	"github.com/axetroy/go-server/core/exception"
+	"github.com/axetroy/go-server/core/helper"
+	"github.com/axetroy/go-server/core/middleware"
+	"github.com/axetroy/go-server/core/model"
+	"github.com/axetroy/go-server/core/schema"
+	"github.com/axetroy/go-server/core/service/currency"
+	"github.com/axetroy/go-server/core/service/database"
+	"github.com/axetroy/go-server/core/util"
+	"github.com/axetroy/go-server/core/validator"
+	"github.com/gin-gonic/gin"
+	"github.com/jinzhu/gorm"
+	"net/http"
+	"time"
+)
+
+type CreateLimitParams struct {
+	Currency      string  `json:"currency" valid:"required~请选择币种"` // 币种
+	Level         int32   `json:"level"`                           // 用户等级, 0 则为默认限额
+	Min           *string `json:"min"`                             // 单笔转账的最小金额
+	Max           *string `json:"max"`                             // 单笔转账的最大金额
+	DailyAmount   *string `json:"daily_amount"`                    // 每天累计转账的最大金额
+	MonthlyAmount *string `json:"monthly_amount"`                  // 每月累计转账的最大金额
+	DailyCount    int     `json:"daily_count"`                     // 每天最多转账的次数
+}
+
+type UpdateLimitParams struct {
+	Min           *string `json:"min"`            // 单笔转账的最小金额
+	Max           *string `json:"max"`            // 单笔转账的最大金额
+	DailyAmount   *string `json:"daily_amount"`   // 每天累计转账的最大金额
+	MonthlyAmount *string `json:"monthly_amount"` // 每月累计转账的最大金额
+	DailyCount    *int    `json:"daily_count"`    // 每天最多转账的次数
+}
+
+func DeleteLimitById(id string) {
+	database.DeleteRowByTable("transfer_limit", "id", id)
+}
+
+func limitToSchema(limit model.TransferLimit, precision int) (d schema.TransferLimit) {
+	d.TransferLimitPure = limitToSchemaPure(limit, precision)
+	d.CreatedAt = limit.CreatedAt.Format(time.RFC3339Nano)
+	d.UpdatedAt = limit.UpdatedAt.Format(time.RFC3339Nano)
+	return
+}
+
+func limitToSchemaPure(limit model.TransferLimit, precision int) (d schema.TransferLimitPure) {
+	d.Id = limit.Id
+	d.Currency = limit.Currency
+	d.Level = limit.Level
+	d.Min = limit.Min.StringFixed(precision)
+	d.Max = limit.Max.StringFixed(precision)
+	d.DailyAmount = limit.DailyAmount.StringFixed(precision)
+	d.MonthlyAmount = limit.MonthlyAmount.StringFixed(precision)
+	d.DailyCount = limit.DailyCount
+	return
+}
+
+// 解析限额, 不能为负数, 并且不能超过币种的小数位数
+func parseLimitAmount(s string, precision int) (d util.Decimal, err error) {
+	if d, err = util.ParseDecimal(s); err != nil {
+		err = exception.InvalidParams
+		return
+	}
+
+	if d.Sign() < 0 || d.Places() > precision {
+		err = exception.InvalidParams
+		return
+	}
+
+	return
+}
+
+// 检验限额的配置是否合法
+func validateLimit(limit model.TransferLimit) error {
+	if limit.Level < 0 || limit.DailyCount < 0 {
+		return exception.InvalidParams
+	}
+
+	// 同时设置了最小和最大限额时, 最小限额不能大于最大限额
+	if !limit.Min.IsZero() && !limit.Max.IsZero() && limit.Min.Cmp(limit.Max) > 0 {
+		return exception.InvalidParams
+	}
+
+	return nil
+}
+
+// 把参数中的金额写入限额, 返回需要更新的字段
+func applyLimitAmounts(limit *model.TransferLimit, precision int, amounts map[string]*string) (updated map[string]interface{}, err error) {
+	updated = map[string]interface{}{}
+
+	fields := map[string]*util.Decimal{
+		"min":            &limit.Min,
+		"max":            &limit.Max,
+		"daily_amount":   &limit.DailyAmount,
+		"monthly_amount": &limit.MonthlyAmount,
+	}
+
+	for field, s := range amounts {
+		if s == nil {
+			continue
+		}
+
+		if *fields[field], err = parseLimitAmount(*s, precision); err != nil {
+			return
+		}
+
+		updated[field] = *fields[field]
+	}
+
+	return
+}
+
+// 添加转账限额
+func CreateLimitByAdmin(c controller.Context, input CreateLimitParams) (res schema.Response) {
+	var (
+		err  error
+		data schema.TransferLimit
+		tx   *gorm.DB
+	)
+
+	defer func() {
+		if r := recover(); r != nil {
+			switch t := r.(type) {
+			case string:
+				err = errors.New(t)
+			case error:
+				err = t
+			default:
+				err = exception.Unknown
+			}
+		}
+
+		if tx != nil {
+			if err != nil {
+				_ = tx.Rollback().Error
+			} else {
+				err = tx.Commit().Error
+			}
+		}
+
+		helper.Response(&res, data, err)
+	}()
+
+	// 参数校验
+	if err = validator.ValidateStruct(input); err != nil {
+		return
+	}
+
+	var currencyInfo model.Currency
+
+	if currencyInfo, err = currency.Get(input.Currency); err != nil {
+		return
+	}
+
+	limit := model.TransferLimit{
+		Currency:   currencyInfo.Code,
+		Level:      input.Level,
+		DailyCount: input.DailyCount,
+	}
+
+	if _, err = applyLimitAmounts(&limit, currencyInfo.Precision, map[string]*string{
+		"min":            input.Min,
+		"max":            input.Max,
+		"daily_amount":   input.DailyAmount,
+		"monthly_amount": input.MonthlyAmount,
+	}); err != nil {
+		return
+	}
+
+	if err = validateLimit(limit); err != nil {
+		return
+	}
+
+	tx = database.Db.Begin()
+
+	adminInfo := model.Admin{Id: c.Uid}
+
+	if err = tx.First(&adminInfo).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			err = exception.AdminNotExist
+		}
+		return
+	}
+
+	var count int
+
+	if err = tx.Model(&model.TransferLimit{}).Where("currency = ?", limit.Currency).Where("level = ?", limit.Level).Count(&count).Error; err != nil {
+		return
+	}
+
+	if count > 0 {
+		err = exception.TransferLimitExist
+		return
+	}
+
+	if err = tx.Create(&limit).Error; err != nil {
+		return
+	}
+
+	data = limitToSchema(limit, currencyInfo.Precision)
+
+	return
+}
+
+// 修改转账限额
+func UpdateLimitByAdmin(c controller.Context, limitId string, input UpdateLimitParams) (res schema.Response) {
+	var (
+		err  error
+		data schema.TransferLimit
+		tx   *gorm.DB
+	)
+
+	defer func() {
+		if r := recover(); r != nil {
+			switch t := r.(type) {
+			case string:
+				err = errors.New(t)
+			case error:
+				err = t
+			default:
+				err = exception.Unknown
+			}
+		}
+
+		if tx != nil {
+			if err != nil {
+				_ = tx.Rollback().Error
+			} else {
+				err = tx.Commit().Error
+			}
+		}
+
+		helper.Response(&res, data, err)
+	}()
+
+	tx = database.Db.Begin()
+
+	adminInfo := model.Admin{Id: c.Uid}
+
+	if err = tx.First(&adminInfo).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			err = exception.AdminNotExist
+		}
+		return
+	}
+
+	limit := model.TransferLimit{}
+
+	if err = tx.Where("id = ?", limitId).First(&limit).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			err = exception.TransferLimitNotExist
+		}
+		return
+	}
+
+	var currencyInfo model.Currency
+
+	if currencyInfo, err = currency.Get(limit.Currency); err != nil {
+		return
+	}
+
+	// 使用 map 更新, 否则 0 会被忽略
+	var updated map[string]interface{}
+
+	if updated, err = applyLimitAmounts(&limit, currencyInfo.Precision, map[string]*string{
+		"min":            input.Min,
+		"max":            input.Max,
+		"daily_amount":   input.DailyAmount,
+		"monthly_amount": input.MonthlyAmount,
+	}); err != nil {
+		return
+	}
+
+	if input.DailyCount != nil {
+		limit.DailyCount = *input.DailyCount
+		updated["daily_count"] = limit.DailyCount
+	}
+
+	if err = validateLimit(limit); err != nil {
+		return
+	}
+
+	if len(updated) > 0 {
+		if err = tx.Model(&limit).Updates(updated).Error; err != nil {
+			return
+		}
+	}
+
+	data = limitToSchema(limit, currencyInfo.Precision)
+
+	return
+}
+
+// 删除转账限额
+func DeleteLimitByAdmin(c controller.Context, limitId string) (res schema.Response) {
+	var (
+		err  error
+		data schema.TransferLimit
+		tx   *gorm.DB
+	)
+
+	defer func() {
+		if r := recover(); r != nil {
+			switch t := r.(type) {
+			case string:
+				err = errors.New(t)
+			case error:
+				err = t
+			default:
+				err = exception.Unknown
+			}
+		}
+
+		if tx != nil {
+			if err != nil {
+				_ = tx.Rollback().Error
+			} else {
+				err = tx.Commit().Error
+			}
+		}
+
+		helper.Response(&res, data, err)
+	}()
+
+	tx = database.Db.Begin()
+
+	adminInfo := model.Admin{Id: c.Uid}
+
+	if err = tx.First(&adminInfo).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			err = exception.AdminNotExist
+		}
+		return
+	}
+
+	limit := model.TransferLimit{}
+
+	if err = tx.Where("id = ?", limitId).First(&limit).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			err = exception.TransferLimitNotExist
+		}
+		return
+	}
+
+	if err = tx.Delete(&limit).Error; err != nil {
+		return
+	}
+
+	precision := model.MaxCurrencyPrecision
+
+	if currencyInfo, er := currency.Get(limit.Currency); er == nil {
+		precision = currencyInfo.Precision
+	}
+
+	data = limitToSchema(limit, precision)
+
+	return
+}
+
+func CreateLimitByAdminRouter(c *gin.Context) {
+	var (
+		input CreateLimitParams
+		err   error
+		res   = schema.Response{}
+	)
+
+	defer func() {
+		if err != nil {
+			res.Data = nil
+			res.Message = err.Error()
+		}
+		c.JSON(http.StatusOK, res)
+	}()
+
+	if err = c.ShouldBindJSON(&input); err != nil {
+		err = exception.InvalidParams
+		return
+	}
+
+	res = CreateLimitByAdmin(controller.Context{
+		Uid: c.GetString(middleware.ContextUidField),
+	}, input)
+}
+
+func UpdateLimitByAdminRouter(c *gin.Context) {
+	var (
+		input UpdateLimitParams
+		err   error
+		res   = schema.Response{}
+	)
+
+	defer func() {
+		if err != nil {
+			res.Data = nil
+			res.Message = err.Error()
+		}
+		c.JSON(http.StatusOK, res)
+	}()
+
+	if err = c.ShouldBindJSON(&input); err != nil {
+		err = exception.InvalidParams
+		return
+	}
+
+	res = UpdateLimitByAdmin(controller.Context{
+		Uid: c.GetString(middleware.ContextUidField),
+	}, c.Param("limit_id"), input)
+}
+
+func DeleteLimitByAdminRouter(c *gin.Context) {
+	var (
+		err error
+		res = schema.Response{}
+	)
+
+	defer func() {
+		if err != nil {
+			res.Data = nil
+			res.Message = err.Error()
+		}
+		c.JSON(http.StatusOK, res)
+	}()
+
+	res = DeleteLimitByAdmin(controller.Context{
+		Uid: c.GetString(middleware.ContextUidField),
+	}, c.Param("limit_id"))
+}
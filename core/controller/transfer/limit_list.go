@@ -0,0 +1,174 @@
+// Copyright 2019 Axetroy. All rights reserved. MIT license.
+package transfer
+
+import (
+	"errors"
+	"github.com/axetroy/go-server/core/controller"
+	"github.com/axetroy/go-server/core/exception"
+	"github.com/axetroy/go-server/core/helper"
+	"github.com/axetroy/go-server/core/middleware"
+	"github.com/axetroy/go-server/core/model"
+	"github.com/axetroy/go-server/core/schema"
+	"github.com/axetroy/go-server/core/service/currency"
+	"github.com/axetroy/go-server/core/service/database"
+	"github.com/gin-gonic/gin"
+	"github.com/jinzhu/gorm"
+	"net/http"
+	"strings"
+)
+
+type LimitQuery struct {
+	schema.Query
+	Currency *string `json:"currency" form:"currency"` // 币种
+	Level    *int32  `json:"level" form:"level"`       // 用户等级
+}
+
+// 获取币种的小数位数, 币种不存在则使用最大的小数位数
+func getPrecision(code string) int {
+	if c, err := currency.Get(code); err == nil {
+		return c.Precision
+	}
+	return model.MaxCurrencyPrecision
+}
+
+// 获取转账限额列表
+func GetLimitsByAdmin(c controller.Context, input LimitQuery) (res schema.List) {
+	var (
+		err  error
+		data = make([]schema.TransferLimit, 0)
+		meta = &schema.Meta{}
+	)
+
+	defer func() {
+		if r := recover(); r != nil {
+			switch t := r.(type) {
+			case string:
+				err = errors.New(t)
+			case error:
+				err = t
+			default:
+				err = exception.Unknown
+			}
+		}
+
+		helper.ResponseList(&res, data, meta, err)
+	}()
+
+	query := input.Query
+
+	query.Normalize()
+
+	list := make([]model.TransferLimit, 0)
+
+	filter := map[string]interface{}{}
+
+	if input.Currency != nil {
+		filter["currency"] = strings.ToUpper(*input.Currency)
+	}
+
+	if input.Level != nil {
+		filter["level"] = *input.Level
+	}
+
+	if err = query.Order(database.Db.Limit(query.Limit).Offset(query.Limit * query.Page)).Where(filter).Find(&list).Error; err != nil {
+		return
+	}
+
+	var total int64
+
+	if err = database.Db.Model(&model.TransferLimit{}).Where(filter).Count(&total).Error; err != nil {
+		return
+	}
+
+	for _, v := range list {
+		data = append(data, limitToSchema(v, getPrecision(v.Currency)))
+	}
+
+	meta.Total = total
+	meta.Num = len(list)
+	meta.Page = query.Page
+	meta.Limit = query.Limit
+	meta.Sort = query.Sort
+
+	return
+}
+
+// 获取单条转账限额
+func GetLimitByAdmin(c controller.Context, limitId string) (res schema.Response) {
+	var (
+		err  error
+		data schema.TransferLimit
+	)
+
+	defer func() {
+		if r := recover(); r != nil {
+			switch t := r.(type) {
+			case string:
+				err = errors.New(t)
+			case error:
+				err = t
+			default:
+				err = exception.Unknown
+			}
+		}
+
+		helper.Response(&res, data, err)
+	}()
+
+	limit := model.TransferLimit{}
+
+	if err = database.Db.Where("id = ?", limitId).First(&limit).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			err = exception.TransferLimitNotExist
+		}
+		return
+	}
+
+	data = limitToSchema(limit, getPrecision(limit.Currency))
+
+	return
+}
+
+func GetLimitsByAdminRouter(c *gin.Context) {
+	var (
+		err   error
+		res   = schema.List{}
+		input LimitQuery
+	)
+
+	defer func() {
+		if err != nil {
+			res.Data = nil
+			res.Message = err.Error()
+		}
+		c.JSON(http.StatusOK, res)
+	}()
+
+	if err = c.ShouldBindQuery(&input); err != nil {
+		err = exception.InvalidParams
+		return
+	}
+
+	res = GetLimitsByAdmin(controller.Context{
+		Uid: c.GetString(middleware.ContextUidField),
+	}, input)
+}
+
+func GetLimitByAdminRouter(c *gin.Context) {
+	var (
+		err error
+		res = schema.Response{}
+	)
+
+	defer func() {
+		if err != nil {
+			res.Data = nil
+			res.Message = err.Error()
+		}
+		c.JSON(http.StatusOK, res)
+	}()
+
+	res = GetLimitByAdmin(controller.Context{
+		Uid: c.GetString(middleware.ContextUidField),
+	}, c.Param("limit_id"))
+}
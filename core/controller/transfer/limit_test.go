@@ -0,0 +1,211 @@
+// Copyright 2019 Axetroy. All rights reserved. MIT license.
+package transfer_test
+
+import (
+	"encoding/json"
+	"github.com/axetroy/go-server/core/controller"
+	"github.com/axetroy/go-server/core/controller/auth"
+	"github.com/axetroy/go-server/core/controller/currency"
+	"github.com/axetroy/go-server/core/controller/transfer"
+	"github.com/axetroy/go-server/core/exception"
+	"github.com/axetroy/go-server/core/model"
+	"github.com/axetroy/go-server/core/schema"
+	"github.com/axetroy/go-server/core/service/database"
+	"github.com/axetroy/go-server/core/util"
+	"github.com/axetroy/go-server/tester"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestLimitByAdmin(t *testing.T) {
+	adminInfo, _ := tester.LoginAdmin()
+
+	c := controller.Context{Uid: adminInfo.Id}
+
+	max := "100"
+
+	r := transfer.CreateLimitByAdmin(c, transfer.CreateLimitParams{
+		Currency:   "cny",
+		Level:      99,
+		Max:        &max,
+		DailyCount: 3,
+	})
+
+	assert.Equal(t, "", r.Message)
+
+	data := schema.TransferLimit{}
+
+	assert.Nil(t, tester.Decode(r.Data, &data))
+
+	defer transfer.DeleteLimitById(data.Id)
+
+	assert.Equal(t, model.WalletCNY, data.Currency)
+	assert.Equal(t, int32(99), data.Level)
+	assert.Equal(t, "100.00", data.Max)
+	assert.Equal(t, "0.00", data.Min)
+	assert.Equal(t, 3, data.DailyCount)
+
+	// 同一个币种和等级只能有一条限额
+	{
+		r := transfer.CreateLimitByAdmin(c, transfer.CreateLimitParams{
+			Currency: "CNY",
+			Level:    99,
+		})
+
+		assert.Equal(t, exception.TransferLimitExist.Error(), r.Message)
+	}
+
+	// 超出币种的小数位数
+	{
+		min := "0.001"
+
+		r := transfer.CreateLimitByAdmin(c, transfer.CreateLimitParams{
+			Currency: "CNY",
+			Level:    98,
+			Min:      &min,
+		})
+
+		assert.Equal(t, exception.InvalidParams.Error(), r.Message)
+	}
+
+	// 修改, 次数改为 0 即不限制
+	{
+		count := 0
+		dailyAmount := "500"
+
+		r := transfer.UpdateLimitByAdmin(c, data.Id, transfer.UpdateLimitParams{
+			DailyCount:  &count,
+			DailyAmount: &dailyAmount,
+		})
+
+		assert.Equal(t, "", r.Message)
+
+		updated := schema.TransferLimit{}
+
+		assert.Nil(t, tester.Decode(r.Data, &updated))
+		assert.Equal(t, 0, updated.DailyCount)
+		assert.Equal(t, "500.00", updated.DailyAmount)
+		assert.Equal(t, "100.00", updated.Max)
+	}
+
+	// 最小金额大于最大金额
+	{
+		min := "200"
+
+		r := transfer.UpdateLimitByAdmin(c, data.Id, transfer.UpdateLimitParams{
+			Min: &min,
+		})
+
+		assert.Equal(t, exception.InvalidParams.Error(), r.Message)
+	}
+
+	// 删除
+	{
+		r := transfer.DeleteLimitByAdmin(c, data.Id)
+
+		assert.Equal(t, "", r.Message)
+		assert.Equal(t, exception.TransferLimitNotExist.Error(), transfer.GetLimitByAdmin(c, data.Id).Message)
+	}
+}
+
+func TestToWithRiskRules(t *testing.T) {
+	adminInfo, _ := tester.LoginAdmin()
+	userFrom, _ := tester.CreateUser()
+	userTo, _ := tester.CreateUser()
+
+	defer auth.DeleteUserByUserName(userFrom.Username)
+	defer auth.DeleteUserByUserName(userTo.Username)
+	defer currency.DeleteCurrencyByCode("TEST")
+
+	admin := controller.Context{Uid: adminInfo.Id}
+
+	assert.Equal(t, "", currency.Create(admin, currency.CreateParams{
+		Code:      "TEST",
+		Name:      "测试币",
+		Precision: 2,
+	}).Message)
+
+	assert.Nil(t, database.Db.Create(&model.Wallet{
+		Id:       userFrom.Id,
+		Currency: "TEST",
+		Balance:  util.NewDecimalFromInt(1000),
+	}).Error)
+
+	defer model.WalletOf(database.Db.Unscoped(), "TEST").Where("id = ?", userFrom.Id).Delete(&model.Wallet{})
+	defer model.WalletOf(database.Db.Unscoped(), "TEST").Where("id = ?", userTo.Id).Delete(&model.Wallet{})
+	defer model.TransferLogOf(database.Db.Unscoped(), "TEST").Where(`"from" = ?`, userFrom.Id).Delete(&model.TransferLog{})
+
+	// 默认限额, 对所有等级生效
+	dailyAmount := "100"
+
+	r := transfer.CreateLimitByAdmin(admin, transfer.CreateLimitParams{
+		Currency:    "TEST",
+		DailyAmount: &dailyAmount,
+		DailyCount:  2,
+	})
+
+	assert.Equal(t, "", r.Message)
+
+	limit := schema.TransferLimit{}
+
+	assert.Nil(t, tester.Decode(r.Data, &limit))
+
+	defer transfer.DeleteLimitById(limit.Id)
+
+	to := func(amount string) schema.Response {
+		input := transfer.ToParams{
+			Currency: "TEST",
+			To:       userTo.Id,
+			Amount:   amount,
+		}
+
+		b, _ := json.Marshal(input)
+		signature, _ := util.Signature(string(b))
+
+		return transfer.To(controller.Context{
+			Uid: userFrom.Id,
+		}, input, signature, "")
+	}
+
+	assert.Equal(t, "", to("60").Message)
+
+	// 超出每天的累计金额
+	res := to("50")
+
+	assert.Equal(t, exception.TransferDailyAmountExceeded.Error(), res.Message)
+	assert.Equal(t, exception.TransferDailyAmountExceeded.Code(), res.Status)
+
+	assert.Equal(t, "", to("40").Message)
+
+	// 超出每天的次数
+	assert.Equal(t, exception.TransferDailyCountExceeded.Error(), to("0.01").Message)
+
+	// 已经使用的额度
+	{
+		r := transfer.GetQuota(controller.Context{Uid: userFrom.Id}, "TEST")
+		quota := schema.TransferQuota{}
+
+		assert.Equal(t, "", r.Message)
+		assert.Nil(t, tester.Decode(r.Data, &quota))
+		assert.Equal(t, "100.00", quota.DailyAmount)
+		assert.Equal(t, "100.00", quota.DailyUsedAmount)
+		assert.Equal(t, "100.00", quota.MonthlyUsedAmount)
+		assert.Equal(t, 2, quota.DailyUsedCount)
+	}
+
+	// 收款方被禁用
+	{
+		count := 0
+		unlimited := "0"
+
+		// 取消限额, 只检查收款方
+		assert.Equal(t, "", transfer.UpdateLimitByAdmin(admin, limit.Id, transfer.UpdateLimitParams{
+			DailyCount:  &count,
+			DailyAmount: &unlimited,
+		}).Message)
+
+		assert.Nil(t, database.Db.Model(&model.User{}).Where("id = ?", userTo.Id).Update("status", model.UserStatusBanned).Error)
+
+		assert.Equal(t, exception.TransferRecipientBanned.Error(), to("1").Message)
+	}
+}
@@ -0,0 +1,105 @@
+// Copyright 2019 Axetroy. All rights reserved. MIT license.
+package transfer
+
+import (
+	"errors"
+	"github.com/axetroy/go-server/core/controller"
+	"github.com/axetroy/go-server/core/exception"
+	"github.com/axetroy/go-server/core/helper"
+	"github.com/axetroy/go-server/core/middleware"
+	"github.com/axetroy/go-server/core/model"
+	"github.com/axetroy/go-server/core/schema"
+	"github.com/axetroy/go-server/core/service/currency"
+	"github.com/axetroy/go-server/core/service/database"
+	"github.com/axetroy/go-server/core/service/risk"
+	"github.com/axetroy/go-server/core/util"
+	"github.com/gin-gonic/gin"
+	"github.com/jinzhu/gorm"
+	"net/http"
+	"time"
+)
+
+// 获取我在某个币种的转账限额, 以及今天和本月已经使用的额度
+func GetQuota(c controller.Context, code string) (res schema.Response) {
+	var (
+		err  error
+		data schema.TransferQuota
+	)
+
+	defer func() {
+		if r := recover(); r != nil {
+			switch t := r.(type) {
+			case string:
+				err = errors.New(t)
+			case error:
+				err = t
+			default:
+				err = exception.Unknown
+			}
+		}
+
+		helper.Response(&res, data, err)
+	}()
+
+	userInfo := model.User{Id: c.Uid}
+
+	if err = database.Db.Where(&userInfo).First(&userInfo).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			err = exception.UserNotExist
+		}
+		return
+	}
+
+	var currencyInfo model.Currency
+
+	if currencyInfo, err = currency.Get(code); err != nil {
+		return
+	}
+
+	var limit model.TransferLimit
+
+	if limit, err = risk.GetLimit(database.Db, currencyInfo, userInfo.Level); err != nil {
+		return
+	}
+
+	var (
+		now           = time.Now()
+		dailyAmount   util.Decimal
+		monthlyAmount util.Decimal
+		dailyCount    int
+	)
+
+	if dailyAmount, dailyCount, err = risk.Usage(database.Db, currencyInfo.Code, userInfo.Id, risk.StartOfDay(now)); err != nil {
+		return
+	}
+
+	if monthlyAmount, _, err = risk.Usage(database.Db, currencyInfo.Code, userInfo.Id, risk.StartOfMonth(now)); err != nil {
+		return
+	}
+
+	data.TransferLimitPure = limitToSchemaPure(limit, currencyInfo.Precision)
+	data.DailyUsedAmount = dailyAmount.StringFixed(currencyInfo.Precision)
+	data.MonthlyUsedAmount = monthlyAmount.StringFixed(currencyInfo.Precision)
+	data.DailyUsedCount = dailyCount
+
+	return
+}
+
+func GetQuotaRouter(c *gin.Context) {
+	var (
+		err error
+		res = schema.Response{}
+	)
+
+	defer func() {
+		if err != nil {
+			res.Data = nil
+			res.Message = err.Error()
+		}
+		c.JSON(http.StatusOK, res)
+	}()
+
+	res = GetQuota(controller.Context{
+		Uid: c.GetString(middleware.ContextUidField),
+	}, c.Param("currency"))
+}
@@ -4,24 +4,24 @@ package transfer
 import (
 	"encoding/json"
 	"errors"
+	"github.com/axetroy/go-server/core/config"
 	"github.com/axetroy/go-server/core/controller"
-	"github.com/axetroy/go-server/core/controller/finance"
-	"github.com/axetroy/go-server/core/controller/wallet"
 	"github.com/axetroy/go-server/core/exception"
 	"github.com/axetroy/go-server/core/helper"
 	"github.com/axetroy/go-server/core/logger"
 	"github.com/axetroy/go-server/core/middleware"
 	"github.com/axetroy/go-server/core/model"
 	"github.com/axetroy/go-server/core/schema"
+	"github.com/axetroy/go-server/core/service/currency"
 	"github.com/axetroy/go-server/core/service/database"
+	"github.com/axetroy/go-server/core/service/idempotency"
+	"github.com/axetroy/go-server/core/service/risk"
+	"github.com/axetroy/go-server/core/service/wallet"
 	"github.com/axetroy/go-server/core/util"
 	"github.com/axetroy/go-server/core/validator"
 	"github.com/gin-gonic/gin"
 	"github.com/jinzhu/gorm"
-	"github.com/mitchellh/mapstructure"
 	"net/http"
-	"strconv"
-	"strings"
 	"time"
 )
 
@@ -30,13 +30,18 @@ type ToParams struct {
 	To       string  `json:"to" valid:"required~请输入转账对象,numeric~请输入正确的接受人ID"`   // 转账给谁
 	Amount   string  `json:"amount" valid:"required~请输入转账数量,float~请输入纯数字的转账数量"` // 转账数量
 	Note     *string `json:"note"`                                              // 转账备注
+	Pending  bool    `json:"pending,omitempty"`                                 // 是否需要收款方确认, 确认前转账金额冻结在汇款人的钱包
 }
 
-func To(c controller.Context, input ToParams, signature string) (res schema.Response) {
+// 转账给某人
+// idempotencyKey 不为空时, 相同的幂等键只会转账一次, 重试时返回第一次转账的结果
+func To(c controller.Context, input ToParams, signature string, idempotencyKey string) (res schema.Response) {
 	var (
-		err  error
-		tx   *gorm.DB
-		data = schema.TransferLog{}
+		err         error
+		tx          *gorm.DB
+		data        = schema.TransferLog{}
+		requestHash string
+		conflict    bool // 并发的相同请求已经先一步完成了转账
 	)
 
 	defer func() {
@@ -60,6 +65,12 @@ func To(c controller.Context, input ToParams, signature string) (res schema.Resp
 			}
 		}
 
+		// 事务已经回滚, 直接返回先完成的那次转账的结果
+		if conflict {
+			data = schema.TransferLog{}
+			_, err = idempotency.Replay(database.Db, idempotency.ScopeTransfer, c.Uid, idempotencyKey, requestHash, &data)
+		}
+
 		helper.Response(&res, data, err)
 	}()
 
@@ -87,6 +98,23 @@ func To(c controller.Context, input ToParams, signature string) (res schema.Resp
 		}
 	}
 
+	if idempotencyKey != "" {
+		if err = idempotency.Valid(idempotencyKey); err != nil {
+			return
+		}
+
+		if requestHash, err = idempotency.Hash(input); err != nil {
+			return
+		}
+
+		var replayed bool
+
+		// 已经处理过的请求, 直接返回当时的结果
+		if replayed, err = idempotency.Replay(database.Db, idempotency.ScopeTransfer, c.Uid, idempotencyKey, requestHash, &data); err != nil || replayed {
+			return
+		}
+	}
+
 	tx = database.Db.Begin()
 
 	fromUserInfo := model.User{Id: c.Uid}
@@ -106,134 +134,90 @@ func To(c controller.Context, input ToParams, signature string) (res schema.Resp
 		return
 	}
 
-	walletTableName := wallet.GetTableName(input.Currency)      // 对应的钱包表名
-	transferTableName := GetTransferTableName(input.Currency)   // 对应的转账记录表名
-	financeLogTableName := finance.GetTableName(input.Currency) // 对应的财务日志表名
-
-	fromUserWallet := model.Wallet{
-		Id: c.Uid,
-	}
-
-	toUserWallet := model.Wallet{
-		Id: input.To,
-	}
-
-	if err = tx.Table(walletTableName).Where("id = ?", fromUserWallet.Id).FirstOrInit(&fromUserWallet).Error; err != nil {
-		return
-	}
-
-	if err = tx.Table(walletTableName).Where("id = ?", toUserWallet.Id).FirstOrInit(&toUserWallet).Error; err != nil {
-		return
-	}
-
-	var amount float64 // 转账数量
-
-	if amount, err = strconv.ParseFloat(input.Amount, 64); err != nil {
-		return
-	}
+	var currencyInfo model.Currency
 
-	if fromUserWallet.Balance < amount {
-		err = exception.NotEnoughBalance
+	// 停用的币种不能转账
+	if currencyInfo, err = currency.GetEnabled(input.Currency); err != nil {
 		return
 	}
 
-	// 变动前的余额/冻结
-	fromUserBeforeBalance := fromUserWallet.Balance
-	fromUserBeforeFrozen := fromUserWallet.Frozen
-	toUserBeforeBalance := toUserWallet.Balance
-	toUserBeforeFrozen := toUserWallet.Frozen
-
-	fromUserWallet.Balance = fromUserWallet.Balance - amount // - 自己的钱包
-	toUserWallet.Balance = toUserWallet.Balance + amount     // + 对方的钱包
+	var amount util.Decimal // 转账数量
 
-	// 变动后的余额/冻结
-	fromUserAfterBalance := fromUserWallet.Balance
-	fromUserAfterFrozen := fromUserWallet.Frozen
-	toUserAfterBalance := toUserWallet.Balance
-	toUserAfterFrozen := toUserWallet.Frozen
-
-	// 余额不能为负数
-	if fromUserWallet.Balance < 0 {
-		err = exception.NotEnoughBalance
+	if amount, err = wallet.ParseAmount(currencyInfo.Code, input.Amount); err != nil {
 		return
 	}
 
-	// 扣除我方的钱
-	if err = tx.Table(walletTableName).Where("id = ?", fromUserWallet.Id).UpdateColumn("balance", fromUserWallet.Balance).Error; err != nil {
+	// 先锁定双方的钱包, 同一个汇款人的转账排队执行, 风控统计的累计金额和次数才是准确的
+	if _, err = wallet.LockAll(tx, currencyInfo.Code, c.Uid, input.To); err != nil {
 		return
 	}
 
-	// 给对方加钱
-	if err = tx.Table(walletTableName).Where("id = ?", toUserWallet.Id).UpdateColumn("balance", toUserWallet.Balance).Error; err != nil {
+	// 风控规则, 包括单笔限额, 每日/每月的累计限额, 收款方的帐号状态等
+	if err = risk.Check(tx, currencyInfo, fromUserInfo, toUserInfo, amount); err != nil {
 		return
 	}
 
-	// 如果转账记录的表不存在的话，那么就生成这个表
-	if tx.HasTable(transferTableName) == false {
-		if err = tx.CreateTable(model.TransferLogMap[strings.ToUpper(input.Currency)]).Error; err != nil {
-			return
-		}
-	}
-
 	transferLog := model.TransferLog{
-		Currency: strings.ToUpper(input.Currency),
+		Currency: currencyInfo.Code,
 		From:     c.Uid,
 		To:       input.To,
 		Status:   model.TransferStatusConfirmed,
-		Amount:   util.FloatToStr(amount), // 保留 8 未小数
+		Amount:   amount.StringFixed(8), // 保留 8 位小数
 		Note:     input.Note,
 	}
 
-	if err = tx.Table(transferTableName).Create(&transferLog).Error; err != nil {
-		return
+	if input.Pending {
+		expiredAt := time.Now().Add(config.Transfer.PendingDuration)
+		transferLog.Status = model.TransferStatusWaitForConfirm
+		transferLog.ExpiredAt = &expiredAt
 	}
 
-	if err = mapstructure.Decode(transferLog, &data.TransferLogPure); err != nil {
+	if err = tx.Create(&transferLog).Error; err != nil {
 		return
 	}
 
-	data.CreatedAt = transferLog.CreatedAt.Format(time.RFC3339Nano)
-	data.UpdatedAt = transferLog.UpdatedAt.Format(time.RFC3339Nano)
-
-	// 如果财务日志表不存在的话, 那么就生成这个表
-	if tx.HasTable(financeLogTableName) == false {
-		if err = tx.CreateTable(model.FinanceLogMap[input.Currency]).Error; err != nil {
-			return
-		}
+	if input.Pending {
+		// 把我方的钱冻结, 等待对方确认, 余额不足时会报错
+		_, err = wallet.Apply(tx, input.Currency, transferLog.Id, wallet.Mutation{
+			Uid:     c.Uid,
+			Balance: amount.Neg(),
+			Frozen:  amount,
+			Type:    model.FinanceTypeTransferFreeze,
+		})
+	} else {
+		// 扣除我方的钱, 给对方加钱, 余额不足时会报错
+		_, err = wallet.Apply(tx, input.Currency, transferLog.Id, wallet.Mutation{
+			Uid:     c.Uid,
+			Balance: amount.Neg(),
+			Type:    model.FinanceTypeTransferOut,
+		}, wallet.Mutation{
+			Uid:     input.To,
+			Balance: amount,
+			Type:    model.FinanceTypeTransferIn,
+		})
 	}
 
-	// 生成我的财务日志
-	fromUserFinanceLog := model.FinanceLog{
-		OrderId:         transferLog.Id, // 可用余额的变动
-		Uid:             c.Uid,
-		BeforeBalance:   fromUserBeforeBalance,
-		BalanceMutation: -amount,
-		AfterBalance:    fromUserAfterBalance,
-		BeforeFrozen:    fromUserBeforeFrozen, // 冻结余额的变动
-		FrozenMutation:  0,
-		AfterFrozen:     fromUserAfterFrozen,
-		Type:            model.FinanceTypeTransferOut,
+	if err != nil {
+		return
 	}
 
-	// 生成对方的财务日志
-	toUserFinanceLog := model.FinanceLog{
-		OrderId:         transferLog.Id,
-		Uid:             input.To,
-		BeforeBalance:   toUserBeforeBalance, // 可用余额的变动
-		BalanceMutation: amount,
-		AfterBalance:    toUserAfterBalance,
-		BeforeFrozen:    toUserBeforeFrozen, // 冻结余额的变动
-		FrozenMutation:  0,
-		AfterFrozen:     toUserAfterFrozen,
-		Type:            model.FinanceTypeTransferIn,
+	// 需要确认的转账等到收款方确认后才算完成支付
+	if !input.Pending {
+		if err = advanceInvite(tx, transferLog); err != nil {
+			return
+		}
 	}
 
-	if err = tx.Table(financeLogTableName).Create(&fromUserFinanceLog).Error; err != nil {
+	if data, err = mapToSchema(transferLog); err != nil {
 		return
 	}
 
-	if err = tx.Table(financeLogTableName).Create(&toUserFinanceLog).Error; err != nil {
-		return
+	// 幂等键和转账结果在同一个事务中保存
+	if idempotencyKey != "" {
+		if err = idempotency.Save(tx, idempotency.ScopeTransfer, c.Uid, idempotencyKey, requestHash, data); err != nil {
+			conflict = idempotency.IsConflict(err)
+			return
+		}
 	}
 
 	return
@@ -261,5 +245,5 @@ func ToRouter(c *gin.Context) {
 	// 获取数据签名
 	signature := c.GetHeader(middleware.SignatureHeader)
 
-	res = To(controller.NewContext(c), input, signature)
+	res = To(controller.NewContext(c), input, signature, c.GetHeader(middleware.IdempotencyKeyHeader))
 }
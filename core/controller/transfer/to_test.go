@@ -5,6 +5,7 @@ import (
 	"encoding/json"
 	"github.com/axetroy/go-server/core/controller"
 	"github.com/axetroy/go-server/core/controller/auth"
+	"github.com/axetroy/go-server/core/controller/currency"
 	"github.com/axetroy/go-server/core/controller/transfer"
 	"github.com/axetroy/go-server/core/controller/user"
 	"github.com/axetroy/go-server/core/controller/wallet"
@@ -32,7 +33,7 @@ func TestTo(t *testing.T) {
 	input1 := transfer.ToParams{
 		Currency: "CNY",
 		To:       userTo.Id,
-		Amount:   "0.0001", // 转账失败，钱包没有余额
+		Amount:   "0.01", // 转账失败，钱包没有余额
 	}
 
 	b1, err := json.Marshal(input1)
@@ -45,14 +46,32 @@ func TestTo(t *testing.T) {
 
 	res1 := transfer.To(controller.Context{
 		Uid: userFrom.Id,
-	}, input1, signature1)
+	}, input1, signature1, "")
 
 	assert.Equal(t, exception.NotEnoughBalance.Error(), res1.Message)
 	assert.Equal(t, exception.NotEnoughBalance.Code(), res1.Status)
 
+	// 超出币种允许的小数位数
+	{
+		input := transfer.ToParams{
+			Currency: "CNY",
+			To:       userTo.Id,
+			Amount:   "0.001",
+		}
+
+		b, _ := json.Marshal(input)
+		signature, _ := util.Signature(string(b))
+
+		res := transfer.To(controller.Context{
+			Uid: userFrom.Id,
+		}, input, signature, "")
+
+		assert.Equal(t, exception.InvalidParams.Error(), res.Message)
+	}
+
 	// 给账户充钱
-	assert.Nil(t, database.Db.Table(wallet.GetTableName("CNY")).Where("id = ?", userFrom.Id).Update(model.Wallet{
-		Balance:  100,
+	assert.Nil(t, model.WalletOf(database.Db, "CNY").Where("id = ?", userFrom.Id).Update(model.Wallet{
+		Balance:  util.NewDecimalFromInt(100),
 		Currency: model.WalletCNY,
 	}).Error)
 
@@ -72,7 +91,7 @@ func TestTo(t *testing.T) {
 
 	res2 := transfer.To(controller.Context{
 		Uid: userFrom.Id,
-	}, input2, signature2)
+	}, input2, signature2, "")
 	data := schema.TransferLog{}
 
 	assert.Equal(t, "", res2.Message)
@@ -112,7 +131,7 @@ func TestTo(t *testing.T) {
 
 		res := transfer.To(controller.Context{
 			Uid: userFrom.Id,
-		}, input, "Invalid signature")
+		}, input, "Invalid signature", "")
 
 		assert.Equal(t, exception.InvalidSignature.Error(), res.Message)
 		assert.Equal(t, exception.InvalidSignature.Code(), res.Status)
@@ -136,8 +155,8 @@ func TestToRouter(t *testing.T) {
 	assert.Equal(t, schema.StatusSuccess, rr.Status)
 
 	// 给账户充钱
-	assert.Nil(t, database.Db.Table(wallet.GetTableName("CNY")).Where("id = ?", userFrom.Id).Update(model.Wallet{
-		Balance:  100,
+	assert.Nil(t, model.WalletOf(database.Db, "CNY").Where("id = ?", userFrom.Id).Update(model.Wallet{
+		Balance:  util.NewDecimalFromInt(100),
 		Currency: model.WalletCNY,
 	}).Error)
 
@@ -189,3 +208,128 @@ func TestToRouter(t *testing.T) {
 		assert.Equal(t, "0.00000000", toUserWallet.Frozen)
 	}
 }
+
+func TestToWithIdempotencyKey(t *testing.T) {
+	userFrom, _ := tester.CreateUser()
+	userTo, _ := tester.CreateUser()
+
+	defer auth.DeleteUserByUserName(userFrom.Username)
+	defer auth.DeleteUserByUserName(userTo.Username)
+
+	// 给账户充钱
+	assert.Nil(t, model.WalletOf(database.Db, "CNY").Where("id = ?", userFrom.Id).Update(model.Wallet{
+		Balance:  util.NewDecimalFromInt(100),
+		Currency: model.WalletCNY,
+	}).Error)
+
+	key := "idempotency-" + userFrom.Id
+
+	input := transfer.ToParams{
+		Currency: "CNY",
+		To:       userTo.Id,
+		Amount:   "20",
+	}
+
+	b, _ := json.Marshal(input)
+	signature, _ := util.Signature(string(b))
+
+	res1 := transfer.To(controller.Context{Uid: userFrom.Id}, input, signature, key)
+
+	assert.Equal(t, "", res1.Message)
+	assert.Equal(t, schema.StatusSuccess, res1.Status)
+
+	data1 := schema.TransferLog{}
+
+	assert.Nil(t, tester.Decode(res1.Data, &data1))
+
+	// 重试的请求返回第一次的结果, 不会重复转账
+	res2 := transfer.To(controller.Context{Uid: userFrom.Id}, input, signature, key)
+
+	assert.Equal(t, "", res2.Message)
+	assert.Equal(t, schema.StatusSuccess, res2.Status)
+
+	data2 := schema.TransferLog{}
+
+	assert.Nil(t, tester.Decode(res2.Data, &data2))
+	assert.Equal(t, data1.Id, data2.Id)
+
+	r := wallet.GetWallet(controller.Context{Uid: userFrom.Id}, "CNY")
+	fromUserWallet := schema.Wallet{}
+
+	assert.Nil(t, tester.Decode(r.Data, &fromUserWallet))
+	assert.Equal(t, "80.00000000", fromUserWallet.Balance)
+
+	// 同一个幂等键用于不同的请求
+	{
+		input := transfer.ToParams{
+			Currency: "CNY",
+			To:       userTo.Id,
+			Amount:   "30",
+		}
+
+		b, _ := json.Marshal(input)
+		signature, _ := util.Signature(string(b))
+
+		res := transfer.To(controller.Context{Uid: userFrom.Id}, input, signature, key)
+
+		assert.Equal(t, exception.IdempotencyKeyReused.Error(), res.Message)
+	}
+}
+
+func TestToWithCurrencyLimit(t *testing.T) {
+	adminInfo, _ := tester.LoginAdmin()
+	userFrom, _ := tester.CreateUser()
+	userTo, _ := tester.CreateUser()
+
+	defer auth.DeleteUserByUserName(userFrom.Username)
+	defer auth.DeleteUserByUserName(userTo.Username)
+	defer currency.DeleteCurrencyByCode("TEST")
+
+	admin := controller.Context{Uid: adminInfo.Id}
+
+	min := "1"
+	max := "10"
+
+	assert.Equal(t, "", currency.Create(admin, currency.CreateParams{
+		Code:        "TEST",
+		Name:        "测试币",
+		Precision:   2,
+		TransferMin: &min,
+		TransferMax: &max,
+	}).Message)
+
+	assert.Nil(t, database.Db.Create(&model.Wallet{
+		Id:       userFrom.Id,
+		Currency: "TEST",
+		Balance:  util.NewDecimalFromInt(100),
+	}).Error)
+
+	defer model.WalletOf(database.Db.Unscoped(), "TEST").Where("id = ?", userFrom.Id).Delete(&model.Wallet{})
+
+	to := func(amount string) schema.Response {
+		input := transfer.ToParams{
+			Currency: "TEST",
+			To:       userTo.Id,
+			Amount:   amount,
+		}
+
+		b, _ := json.Marshal(input)
+		signature, _ := util.Signature(string(b))
+
+		return transfer.To(controller.Context{
+			Uid: userFrom.Id,
+		}, input, signature, "")
+	}
+
+	assert.Equal(t, exception.TransferAmountTooSmall.Error(), to("0.5").Message)
+	assert.Equal(t, exception.TransferAmountTooLarge.Error(), to("10.01").Message)
+
+	// 停用的币种不能转账
+	enabled := false
+
+	assert.Equal(t, "", currency.Update(admin, "TEST", currency.UpdateParams{
+		Enabled: &enabled,
+	}).Message)
+
+	assert.Equal(t, exception.CurrencyDisabled.Error(), to("5").Message)
+}
@@ -3,15 +3,61 @@ package transfer
 
 import (
 	"fmt"
+	"github.com/axetroy/go-server/core/config"
+	"github.com/axetroy/go-server/core/controller/invite"
 	"github.com/axetroy/go-server/core/model"
+	"github.com/axetroy/go-server/core/schema"
 	"github.com/axetroy/go-server/core/util"
+	"github.com/jinzhu/gorm"
+	"github.com/mitchellh/mapstructure"
 	"reflect"
 	"strings"
+	"time"
 )
 
-// 获取转账表名
-func GetTransferTableName(currency string) string {
-	return "transfer_log_" + strings.ToLower(currency)
+// 转账到账后推进被邀请人的状态为已完成支付, 邀请奖励由发放任务异步发放
+// 各币种的价值不同, 转账数量按照 INVITE_PAY_MIN 中对应币种的最小值判断, 避免用小额转账刷邀请奖励
+// 没有配置最小值的币种不算完成支付
+func advanceInvite(tx *gorm.DB, log model.TransferLog) (err error) {
+	var (
+		amount util.Decimal
+		min    util.Decimal
+	)
+
+	payMin, ok := config.Invite.PayMin[strings.ToUpper(log.Currency)]
+
+	if !ok {
+		return
+	}
+
+	if amount, err = util.ParseDecimal(log.Amount); err != nil {
+		return
+	}
+
+	if min, err = util.ParseDecimal(payMin); err != nil {
+		return
+	}
+
+	if amount.Cmp(min) < 0 {
+		return
+	}
+
+	return invite.Advance(tx, log.From, model.StatusInvitePay)
+}
+
+func mapToSchema(log model.TransferLog) (d schema.TransferLog, err error) {
+	if err = mapstructure.Decode(log, &d.TransferLogPure); err != nil {
+		return
+	}
+
+	if log.ExpiredAt != nil {
+		expiredAt := log.ExpiredAt.Format(time.RFC3339Nano)
+		d.ExpiredAt = &expiredAt
+	}
+
+	d.CreatedAt = log.CreatedAt.Format(time.RFC3339Nano)
+	d.UpdatedAt = log.UpdatedAt.Format(time.RFC3339Nano)
+	return
 }
 
 type QueryParams struct {
@@ -23,62 +69,39 @@ type QueryParams struct {
 	Status   *model.TransferStatus `json:"status"`   // 转账状态
 }
 
-func GenerateTransferLogSQL(filter QueryParams, limit int, count bool) string {
-	suffix := `("deleted_at" IS NULL OR "deleted_at"='0001-01-01 00:00:00')`
-
-	filterArray := make([]string, 0)
-
-	{
-		t := reflect.TypeOf(filter)
-		v := reflect.ValueOf(filter)
-
-		for k := 0; k < t.NumField(); k++ {
-			key := t.Field(k).Tag.Get("json")
-			value := v.Field(k).Interface()
+// 生成查询所有币种转账记录的语句
+func TransferLogQuery(db *gorm.DB, currencies []string, filter QueryParams) *gorm.DB {
+	query := db.Model(&model.TransferLog{}).Where("currency IN (?)", currencies)
 
-			if key == "" {
-				continue
-			}
+	t := reflect.TypeOf(filter)
+	v := reflect.ValueOf(filter)
 
-			if !v.Field(k).IsValid() {
-				continue
-			}
+	for k := 0; k < t.NumField(); k++ {
+		key := t.Field(k).Tag.Get("json")
+		value := v.Field(k).Interface()
 
-			if util.IsNil(value) {
-				continue
-			}
-
-			// 如果是指针的话
-			if util.IsPoint(value) {
-				// 获取指针对应的值
-				value = reflect.ValueOf(value).Elem()
-			} else {
-				continue
-			}
-
-			filterArray = append(filterArray, fmt.Sprintf(`"%s"='%v'`, key, value))
+		if key == "" {
+			continue
 		}
-	}
 
-	filterStr := "WHERE " + strings.Join(filterArray[:], " AND ")
-
-	if len(filterArray) != 0 {
-		filterStr = filterStr + " AND"
-	}
-
-	SQLs := make([]string, 0)
+		if !v.Field(k).IsValid() {
+			continue
+		}
 
-	selected := "*"
+		if util.IsNil(value) {
+			continue
+		}
 
-	if count {
-		selected = "COUNT(*)"
-	}
+		// 如果是指针的话
+		if util.IsPoint(value) {
+			// 获取指针对应的值
+			value = reflect.ValueOf(value).Elem().Interface()
+		} else {
+			continue
+		}
 
-	for _, tableName := range model.TransferTableNames {
-		sql := fmt.Sprintf(`SELECT %s FROM "%s" %s %s`, selected, tableName, filterStr, suffix)
-		SQLs = append(SQLs, sql)
+		query = query.Where(fmt.Sprintf(`"%s" = ?`, key), value)
 	}
 
-	sql := fmt.Sprintf("%s LIMIT %d", strings.Join(SQLs[:], " UNION "), limit)
-	return sql
+	return query
 }
@@ -10,12 +10,10 @@ import (
 	"github.com/axetroy/go-server/core/service/captcha"
 	"github.com/axetroy/go-server/core/service/database"
 	"github.com/axetroy/go-server/core/service/email"
-	"github.com/axetroy/go-server/core/service/redis"
 	"github.com/axetroy/go-server/core/service/telephone"
 	"github.com/gin-gonic/gin"
 	"github.com/jinzhu/gorm"
 	"net/http"
-	"time"
 )
 
 func SendAuthEmail(c controller.Context) (res schema.Response) {
@@ -50,9 +48,12 @@ func SendAuthEmail(c controller.Context) (res schema.Response) {
 
 	tx = database.Db.Begin()
 
-	userInfo := model.User{}
+	userInfo := model.User{Id: c.Uid}
 
-	if err = tx.Where(&userInfo).Find(&userInfo).Error; err != nil {
+	if err = tx.Where(&userInfo).Last(&userInfo).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			err = exception.UserNotExist
+		}
 		return
 	}
 
@@ -62,20 +63,9 @@ func SendAuthEmail(c controller.Context) (res schema.Response) {
 		return
 	}
 
-	// 生成验证码
-	activationCode := captcha.GenerateEmailCaptcha()
-
-	// 缓存验证码到 redis
-	if err = redis.ClientAuthEmailCode.Set(activationCode, *userInfo.Email, time.Minute*10).Err(); err != nil {
-		return
-	}
-
-	e := email.NewMailer()
-
-	// send email
-	if err = e.SendAuthEmail(*userInfo.Email, activationCode); err != nil {
-		// 邮件没发出去的话，删除redis的key
-		_ = redis.ClientAuthEmailCode.Del(activationCode).Err()
+	if err = captcha.Send(captcha.ChannelEmail, captcha.PurposeUnbinding, *userInfo.Email, func(code string) error {
+		return email.NewMailer().SendAuthEmail(*userInfo.Email, code)
+	}); err != nil {
 		return
 	}
 
@@ -114,9 +104,12 @@ func SendAuthPhone(c controller.Context) (res schema.Response) {
 
 	tx = database.Db.Begin()
 
-	userInfo := model.User{}
+	userInfo := model.User{Id: c.Uid}
 
-	if err = tx.Where(&userInfo).Find(&userInfo).Error; err != nil {
+	if err = tx.Where(&userInfo).Last(&userInfo).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			err = exception.UserNotExist
+		}
 		return
 	}
 
@@ -126,17 +119,9 @@ func SendAuthPhone(c controller.Context) (res schema.Response) {
 		return
 	}
 
-	// 生成验证码
-	activationCode := captcha.GeneratePhoneCaptcha()
-
-	// 缓存验证码到 redis
-	if err = redis.ClientAuthPhoneCode.Set(activationCode, *userInfo.Phone, time.Minute*10).Err(); err != nil {
-		return
-	}
-
-	if err = telephone.GetClient().SendAuthCode(*userInfo.Phone, activationCode); err != nil {
-		// 如果发送失败，则删除
-		_ = redis.ClientAuthPhoneCode.Del(activationCode).Err()
+	if err = captcha.Send(captcha.ChannelPhone, captcha.PurposeUnbinding, *userInfo.Phone, func(code string) error {
+		return telephone.GetClient().SendAuthCode(*userInfo.Phone, code)
+	}); err != nil {
 		return
 	}
 
@@ -3,12 +3,12 @@ package user
 import (
 	"encoding/json"
 	"errors"
-	"github.com/axetroy/go-server/core/controller/wallet"
 	"github.com/axetroy/go-server/core/exception"
 	"github.com/axetroy/go-server/core/helper"
 	"github.com/axetroy/go-server/core/message_queue"
 	"github.com/axetroy/go-server/core/model"
 	"github.com/axetroy/go-server/core/schema"
+	"github.com/axetroy/go-server/core/service/currency"
 	"github.com/axetroy/go-server/core/service/database"
 	"github.com/axetroy/go-server/core/service/redis"
 	"github.com/axetroy/go-server/core/util"
@@ -161,12 +161,16 @@ func CreateUser(input CreateUserParams) (res schema.Response) {
 	data.UpdatedAt = userInfo.UpdatedAt.Format(time.RFC3339Nano)
 
 	// 创建用户对应的钱包账号
-	for _, walletName := range model.Wallets {
-		if err = tx.Table(wallet.GetTableName(walletName)).Create(&model.Wallet{
+	var codes []string
+
+	if codes, err = currency.Codes(); err != nil {
+		return
+	}
+
+	for _, walletName := range codes {
+		if err = tx.Create(&model.Wallet{
 			Id:       userInfo.Id,
 			Currency: walletName,
-			Balance:  0,
-			Frozen:   0,
 		}).Error; err != nil {
 			return
 		}
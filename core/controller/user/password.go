@@ -76,7 +76,7 @@ func UpdatePassword(c controller.Context, input UpdatePasswordParams) (res schem
 	}
 
 	// 验证密码是否正确
-	if userInfo.Password != util.GeneratePassword(input.OldPassword) {
+	if !util.VerifyPassword(input.OldPassword, userInfo.Password) {
 		err = exception.InvalidPassword
 		return
 	}
@@ -133,7 +133,7 @@ func TestUpdatePasswordRouter(t *testing.T) {
 		user := model.User{Id: userInfo.Id}
 
 		assert.Nil(t, database.Db.First(&user).Error)
-		assert.Equal(t, util.GeneratePassword("321321"), user.Password)
+		assert.True(t, util.VerifyPassword("321321", user.Password))
 	}
 }
 
@@ -180,6 +180,6 @@ func TestUpdatePasswordByAdminRouter(t *testing.T) {
 		user := model.User{Id: userInfo.Id}
 
 		assert.Nil(t, database.Db.First(&user).Error)
-		assert.Equal(t, util.GeneratePassword("321321"), user.Password)
+		assert.True(t, util.VerifyPassword("321321", user.Password))
 	}
 }
@@ -184,10 +184,8 @@ func UpdatePayPassword(c controller.Context, input UpdatePayPasswordParams) (res
 		return
 	}
 
-	oldPwd := util.GeneratePassword(input.OldPassword)
-
 	// 旧密码不匹配
-	if *userInfo.PayPassword != oldPwd {
+	if !util.VerifyPassword(input.OldPassword, *userInfo.PayPassword) {
 		err = exception.InvalidPassword
 		return
 	}
@@ -283,7 +281,7 @@ func SendResetPayPassword(c controller.Context) (res schema.Response) {
 		go func() {
 			if err = telephone.GetClient().SendResetPasswordCode(*userInfo.Phone, resetCode); err != nil {
 				// 如果发送失败，则删除
-				_ = redis.ClientAuthPhoneCode.Del(resetCode).Err()
+				_ = redis.ClientResetCode.Del(resetCode).Err()
 				return
 			}
 		}()
@@ -0,0 +1,245 @@
+// Copyright 2019 Axetroy. All rights reserved. MIT license.
+package user
+
+import (
+	"errors"
+	"github.com/axetroy/go-server/core/controller"
+	"github.com/axetroy/go-server/core/exception"
+	"github.com/axetroy/go-server/core/helper"
+	"github.com/axetroy/go-server/core/middleware"
+	"github.com/axetroy/go-server/core/model"
+	"github.com/axetroy/go-server/core/schema"
+	"github.com/axetroy/go-server/core/service/database"
+	"github.com/axetroy/go-server/core/service/message_template"
+	"github.com/axetroy/go-server/core/service/preference"
+	"github.com/gin-gonic/gin"
+	"github.com/jinzhu/gorm"
+	"net/http"
+)
+
+type UpdatePreferenceParams struct {
+	Channels   map[string]map[string]bool `json:"channels"`    // 开启或者关闭某个分类在某个渠道的通知, 只修改传入的项
+	QuietStart *string                    `json:"quiet_start"` // 免打扰时段的开始时间, 需要和结束时间一起设置, 都为空字符串则关闭免打扰
+	QuietEnd   *string                    `json:"quiet_end"`   // 免打扰时段的结束时间
+	Timezone   *string                    `json:"timezone"`    // 时区, 例如 Asia/Shanghai
+}
+
+func mapPreferenceToSchema(p preference.Preferences) (data schema.NotificationPreference) {
+	data.Channels = map[string]map[string]bool{}
+	data.Timezone = p.Timezone
+
+	for _, category := range preference.Categories {
+		channels := map[string]bool{}
+
+		for _, channel := range message_template.Channels {
+			channels[string(channel)] = p.Enabled(category, channel)
+		}
+
+		data.Channels[string(category)] = channels
+	}
+
+	if p.QuietHours != nil {
+		data.QuietStart = &p.QuietHours.Start
+		data.QuietEnd = &p.QuietHours.End
+	}
+
+	return
+}
+
+// 获取通知偏好
+func GetPreference(c controller.Context) (res schema.Response) {
+	var (
+		err  error
+		data schema.NotificationPreference
+	)
+
+	defer func() {
+		if r := recover(); r != nil {
+			switch t := r.(type) {
+			case string:
+				err = errors.New(t)
+			case error:
+				err = t
+			default:
+				err = exception.Unknown
+			}
+		}
+
+		helper.Response(&res, data, err)
+	}()
+
+	p, err := preference.Load(database.Db, c.Uid)
+
+	if err != nil {
+		return
+	}
+
+	data = mapPreferenceToSchema(p)
+
+	return
+}
+
+// 更新通知偏好, 安全类通知无法关闭
+func UpdatePreference(c controller.Context, input UpdatePreferenceParams) (res schema.Response) {
+	var (
+		err  error
+		data schema.NotificationPreference
+		tx   *gorm.DB
+	)
+
+	defer func() {
+		if r := recover(); r != nil {
+			switch t := r.(type) {
+			case string:
+				err = errors.New(t)
+			case error:
+				err = t
+			default:
+				err = exception.Unknown
+			}
+		}
+
+		if tx != nil {
+			if err != nil {
+				_ = tx.Rollback().Error
+			} else {
+				err = tx.Commit().Error
+			}
+		}
+
+		helper.Response(&res, data, err)
+	}()
+
+	tx = database.Db.Begin()
+
+	userInfo := model.User{}
+
+	if err = tx.Where("id = ?", c.Uid).First(&userInfo).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			err = exception.UserNotExist
+		}
+		return
+	}
+
+	p, err := preference.Load(tx, c.Uid)
+
+	if err != nil {
+		return
+	}
+
+	for c, channels := range input.Channels {
+		var category preference.Category
+
+		if category, err = preference.ParseCategory(c); err != nil {
+			return
+		}
+
+		for name, enabled := range channels {
+			var channel message_template.Channel
+
+			if channel, err = preference.ParseChannel(name); err != nil {
+				return
+			}
+
+			if category == preference.CategorySecurity && !enabled {
+				err = exception.NotificationPreferenceSecurity
+				return
+			}
+
+			p.SetEnabled(category, channel, enabled)
+		}
+	}
+
+	if input.QuietStart != nil || input.QuietEnd != nil {
+		if input.QuietStart == nil || input.QuietEnd == nil {
+			err = exception.NotificationPreferenceInvalid
+			return
+		}
+
+		if *input.QuietStart == "" && *input.QuietEnd == "" {
+			p.QuietHours = nil
+		} else {
+			p.QuietHours = &preference.QuietHours{Start: *input.QuietStart, End: *input.QuietEnd}
+		}
+	}
+
+	if input.Timezone != nil {
+		p.Timezone = *input.Timezone
+	}
+
+	if err = p.Validate(); err != nil {
+		return
+	}
+
+	m := p.ToModel()
+
+	var count int
+
+	if err = tx.Model(&model.NotificationPreference{}).Where("uid = ?", c.Uid).Count(&count).Error; err != nil {
+		return
+	}
+
+	if count == 0 {
+		err = tx.Create(&m).Error
+	} else {
+		// 使用 map 更新, 否则空值会被忽略
+		err = tx.Model(&model.NotificationPreference{}).Where("uid = ?", c.Uid).Updates(map[string]interface{}{
+			"disabled":    m.Disabled,
+			"quiet_start": m.QuietStart,
+			"quiet_end":   m.QuietEnd,
+			"timezone":    m.Timezone,
+		}).Error
+	}
+
+	if err != nil {
+		return
+	}
+
+	data = mapPreferenceToSchema(p)
+
+	return
+}
+
+func GetPreferenceRouter(c *gin.Context) {
+	var (
+		err error
+		res = schema.Response{}
+	)
+
+	defer func() {
+		if err != nil {
+			res.Data = nil
+			res.Message = err.Error()
+		}
+		c.JSON(http.StatusOK, res)
+	}()
+
+	res = GetPreference(controller.Context{
+		Uid: c.GetString(middleware.ContextUidField),
+	})
+}
+
+func UpdatePreferenceRouter(c *gin.Context) {
+	var (
+		input UpdatePreferenceParams
+		err   error
+		res   = schema.Response{}
+	)
+
+	defer func() {
+		if err != nil {
+			res.Data = nil
+			res.Message = err.Error()
+		}
+		c.JSON(http.StatusOK, res)
+	}()
+
+	if err = c.ShouldBindJSON(&input); err != nil {
+		err = exception.InvalidParams
+		return
+	}
+
+	res = UpdatePreference(controller.Context{
+		Uid: c.GetString(middleware.ContextUidField),
+	}, input)
+}
@@ -0,0 +1,121 @@
+// Copyright 2019 Axetroy. All rights reserved. MIT license.
+package user_test
+
+import (
+	"github.com/axetroy/go-server/core/controller"
+	"github.com/axetroy/go-server/core/controller/auth"
+	"github.com/axetroy/go-server/core/controller/user"
+	"github.com/axetroy/go-server/core/exception"
+	"github.com/axetroy/go-server/core/schema"
+	"github.com/axetroy/go-server/core/service/database"
+	"github.com/axetroy/go-server/core/service/preference"
+	"github.com/axetroy/go-server/tester"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestGetPreference(t *testing.T) {
+	userInfo, _ := tester.CreateUser()
+
+	defer auth.DeleteUserByUserName(userInfo.Username)
+
+	r := user.GetPreference(controller.Context{Uid: userInfo.Id})
+
+	assert.Equal(t, schema.StatusSuccess, r.Status)
+	assert.Equal(t, "", r.Message)
+
+	data := schema.NotificationPreference{}
+
+	assert.Nil(t, tester.Decode(r.Data, &data))
+
+	// 默认所有通知都开启, 没有免打扰时段
+	assert.True(t, data.Channels["marketing"]["email"])
+	assert.True(t, data.Channels["security"]["sms"])
+	assert.Nil(t, data.QuietStart)
+	assert.Equal(t, preference.DefaultTimezone, data.Timezone)
+}
+
+func TestUpdatePreference(t *testing.T) {
+	userInfo, _ := tester.CreateUser()
+
+	defer auth.DeleteUserByUserName(userInfo.Username)
+	defer database.DeleteRowByTable("notification_preference", "uid", userInfo.Id)
+
+	context := controller.Context{Uid: userInfo.Id}
+
+	// 安全类通知无法关闭
+	{
+		r := user.UpdatePreference(context, user.UpdatePreferenceParams{
+			Channels: map[string]map[string]bool{"security": {"email": false}},
+		})
+
+		assert.Equal(t, exception.NotificationPreferenceSecurity.Code(), r.Status)
+		assert.Equal(t, exception.NotificationPreferenceSecurity.Error(), r.Message)
+	}
+
+	// 无效的渠道
+	{
+		r := user.UpdatePreference(context, user.UpdatePreferenceParams{
+			Channels: map[string]map[string]bool{"marketing": {"wechat": false}},
+		})
+
+		assert.Equal(t, exception.NotificationPreferenceInvalid.Code(), r.Status)
+	}
+
+	// 无效的时区
+	{
+		timezone := "Mars/Base"
+
+		r := user.UpdatePreference(context, user.UpdatePreferenceParams{Timezone: &timezone})
+
+		assert.Equal(t, exception.NotificationPreferenceInvalid.Code(), r.Status)
+	}
+
+	start := "22:00"
+	end := "08:00"
+	timezone := "UTC"
+
+	{
+		r := user.UpdatePreference(context, user.UpdatePreferenceParams{
+			Channels:   map[string]map[string]bool{"marketing": {"email": false, "sms": false}},
+			QuietStart: &start,
+			QuietEnd:   &end,
+			Timezone:   &timezone,
+		})
+
+		assert.Equal(t, schema.StatusSuccess, r.Status)
+		assert.Equal(t, "", r.Message)
+
+		data := schema.NotificationPreference{}
+
+		assert.Nil(t, tester.Decode(r.Data, &data))
+
+		assert.False(t, data.Channels["marketing"]["email"])
+		assert.False(t, data.Channels["marketing"]["sms"])
+		assert.True(t, data.Channels["marketing"]["message"])
+		assert.Equal(t, start, *data.QuietStart)
+		assert.Equal(t, end, *data.QuietEnd)
+		assert.Equal(t, timezone, data.Timezone)
+	}
+
+	// 只修改传入的项, 关闭免打扰时段
+	{
+		empty := ""
+
+		r := user.UpdatePreference(context, user.UpdatePreferenceParams{
+			Channels:   map[string]map[string]bool{"marketing": {"email": true}},
+			QuietStart: &empty,
+			QuietEnd:   &empty,
+		})
+
+		assert.Equal(t, schema.StatusSuccess, r.Status)
+
+		p, err := preference.Load(database.Db, userInfo.Id)
+
+		assert.Nil(t, err)
+		assert.True(t, p.Enabled(preference.CategoryMarketing, "email"))
+		assert.False(t, p.Enabled(preference.CategoryMarketing, "sms"))
+		assert.Nil(t, p.QuietHours)
+		assert.Equal(t, timezone, p.Timezone)
+	}
+}
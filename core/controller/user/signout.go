@@ -2,15 +2,185 @@
 package user
 
 import (
+	"errors"
+	"github.com/axetroy/go-server/core/controller"
+	"github.com/axetroy/go-server/core/exception"
+	"github.com/axetroy/go-server/core/helper"
+	"github.com/axetroy/go-server/core/middleware"
+	"github.com/axetroy/go-server/core/model"
 	"github.com/axetroy/go-server/core/schema"
+	"github.com/axetroy/go-server/core/service/database"
+	"github.com/axetroy/go-server/core/service/token"
 	"github.com/gin-gonic/gin"
+	"github.com/jinzhu/gorm"
 	"net/http"
 )
 
-func SignOut(c *gin.Context) {
-	c.JSON(http.StatusOK, schema.Response{
-		Status:  schema.StatusSuccess,
-		Message: "您已登出",
-		Data:    true,
-	})
+// 登出当前设备，吊销当前使用的 token 以及它所属的刷新令牌家族
+func SignOut(c controller.Context, claims token.Claims) (res schema.Response) {
+	var (
+		err error
+	)
+
+	defer func() {
+		if r := recover(); r != nil {
+			switch t := r.(type) {
+			case string:
+				err = errors.New(t)
+			case error:
+				err = t
+			default:
+				err = exception.Unknown
+			}
+		}
+
+		helper.Response(&res, true, err)
+	}()
+
+	if claims.Uid != c.Uid {
+		err = exception.InvalidToken
+		return
+	}
+
+	if err = token.Revoke(claims); err != nil {
+		return
+	}
+
+	// 否则客户端仍然可以用刷新令牌换取新的 token
+	if claims.Family != "" {
+		if err = token.RevokeRefreshFamily(claims.Uid, claims.Family, false); err != nil {
+			return
+		}
+	}
+
+	return
+}
+
+// 登出所有设备，吊销该用户已签发的所有 token
+func SignOutAll(c controller.Context) (res schema.Response) {
+	var (
+		err error
+	)
+
+	defer func() {
+		if r := recover(); r != nil {
+			switch t := r.(type) {
+			case string:
+				err = errors.New(t)
+			case error:
+				err = t
+			default:
+				err = exception.Unknown
+			}
+		}
+
+		helper.Response(&res, true, err)
+	}()
+
+	if err = token.RevokeAll(c.Uid, false); err != nil {
+		return
+	}
+
+	return
+}
+
+// 管理员强制用户下线
+func RevokeSessionByAdmin(c controller.Context, userId string) (res schema.Response) {
+	var (
+		err error
+	)
+
+	defer func() {
+		if r := recover(); r != nil {
+			switch t := r.(type) {
+			case string:
+				err = errors.New(t)
+			case error:
+				err = t
+			default:
+				err = exception.Unknown
+			}
+		}
+
+		helper.Response(&res, true, err)
+	}()
+
+	userInfo := model.User{Id: userId}
+
+	if err = database.Db.Where(&userInfo).First(&userInfo).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			err = exception.UserNotExist
+		}
+		return
+	}
+
+	if err = token.RevokeAll(userInfo.Id, false); err != nil {
+		return
+	}
+
+	return
+}
+
+func SignOutRouter(c *gin.Context) {
+	var (
+		err error
+		res = schema.Response{}
+	)
+
+	defer func() {
+		if err != nil {
+			res.Data = nil
+			res.Message = err.Error()
+		}
+		c.JSON(http.StatusOK, res)
+	}()
+
+	value, _ := c.Get(middleware.ContextClaimsField)
+
+	claims, ok := value.(token.Claims)
+
+	if !ok {
+		err = exception.InvalidToken
+		return
+	}
+
+	res = SignOut(controller.NewContext(c), claims)
+}
+
+func SignOutAllRouter(c *gin.Context) {
+	var (
+		err error
+		res = schema.Response{}
+	)
+
+	defer func() {
+		if err != nil {
+			res.Data = nil
+			res.Message = err.Error()
+		}
+		c.JSON(http.StatusOK, res)
+	}()
+
+	res = SignOutAll(controller.NewContext(c))
+}
+
+func RevokeSessionByAdminRouter(c *gin.Context) {
+	var (
+		err error
+		res = schema.Response{}
+	)
+
+	defer func() {
+		if err != nil {
+			res.Data = nil
+			res.Message = err.Error()
+		}
+		c.JSON(http.StatusOK, res)
+	}()
+
+	userId := c.Param("user_id")
+
+	res = RevokeSessionByAdmin(controller.Context{
+		Uid: c.GetString(middleware.ContextUidField),
+	}, userId)
 }
@@ -0,0 +1,141 @@
+// Copyright 2019 Axetroy. All rights reserved. MIT license.
+package user_test
+
+import (
+	"encoding/json"
+	"github.com/axetroy/go-server/core/controller"
+	"github.com/axetroy/go-server/core/controller/auth"
+	"github.com/axetroy/go-server/core/controller/user"
+	"github.com/axetroy/go-server/core/exception"
+	"github.com/axetroy/go-server/core/schema"
+	"github.com/axetroy/go-server/core/service/token"
+	"github.com/axetroy/go-server/tester"
+	"github.com/axetroy/mocker"
+	"github.com/stretchr/testify/assert"
+	"net/http"
+	"testing"
+)
+
+func TestSignOut(t *testing.T) {
+	userInfo, _ := tester.CreateUser()
+
+	defer auth.DeleteUserByUserName(userInfo.Username)
+
+	claims, err := token.Parse(token.JoinPrefixToken(userInfo.Token), false)
+
+	assert.Nil(t, err)
+
+	r := user.SignOut(controller.Context{Uid: userInfo.Id}, claims)
+
+	assert.Equal(t, schema.StatusSuccess, r.Status)
+	assert.Equal(t, "", r.Message)
+
+	revoked, err := token.IsRevoked(claims)
+
+	assert.Nil(t, err)
+	assert.True(t, revoked)
+}
+
+func TestSignOutRevokeRefreshToken(t *testing.T) {
+	userInfo, _ := tester.CreateUser()
+
+	defer auth.DeleteUserByUserName(userInfo.Username)
+
+	accessToken, refreshToken, err := token.GenerateSession(userInfo.Id, false)
+
+	assert.Nil(t, err)
+
+	claims, err := token.Parse(token.JoinPrefixToken(accessToken), false)
+
+	assert.Nil(t, err)
+	assert.NotEmpty(t, claims.Family)
+
+	r := user.SignOut(controller.Context{Uid: userInfo.Id}, claims)
+
+	assert.Equal(t, schema.StatusSuccess, r.Status)
+
+	// 登出之后，刷新令牌也不能再使用了
+	_, _, _, err = token.RotateRefreshToken(refreshToken, false)
+
+	assert.Equal(t, exception.InvalidRefreshToken, err)
+}
+
+func TestSignOutRouter(t *testing.T) {
+	userInfo, _ := tester.CreateUser()
+
+	defer auth.DeleteUserByUserName(userInfo.Username)
+
+	header := mocker.Header{
+		"Authorization": token.JoinPrefixToken(userInfo.Token),
+	}
+
+	r := tester.HttpUser.Get("/v1/user/signout", nil, &header)
+
+	assert.Equal(t, http.StatusOK, r.Code)
+
+	res := schema.Response{}
+
+	assert.Nil(t, json.Unmarshal(r.Body.Bytes(), &res))
+	assert.Equal(t, schema.StatusSuccess, res.Status)
+
+	// 登出之后，token 就不能再使用了
+	r = tester.HttpUser.Get("/v1/user/profile", nil, &header)
+
+	res = schema.Response{}
+
+	assert.Nil(t, json.Unmarshal(r.Body.Bytes(), &res))
+	assert.Equal(t, exception.TokenRevoked.Code(), res.Status)
+	assert.Equal(t, exception.TokenRevoked.Error(), res.Message)
+}
+
+func TestSignOutAll(t *testing.T) {
+	userInfo, _ := tester.CreateUser()
+
+	defer auth.DeleteUserByUserName(userInfo.Username)
+
+	claims, err := token.Parse(token.JoinPrefixToken(userInfo.Token), false)
+
+	assert.Nil(t, err)
+
+	r := user.SignOutAll(controller.Context{Uid: userInfo.Id})
+
+	assert.Equal(t, schema.StatusSuccess, r.Status)
+	assert.Equal(t, "", r.Message)
+
+	revoked, err := token.IsRevoked(claims)
+
+	assert.Nil(t, err)
+	assert.True(t, revoked)
+}
+
+func TestRevokeSessionByAdmin(t *testing.T) {
+	adminInfo, _ := tester.LoginAdmin()
+
+	userInfo, _ := tester.CreateUser()
+
+	defer auth.DeleteUserByUserName(userInfo.Username)
+
+	// 不存在的用户
+	{
+		r := user.RevokeSessionByAdmin(controller.Context{Uid: adminInfo.Id}, "123123")
+
+		assert.Equal(t, exception.UserNotExist.Code(), r.Status)
+		assert.Equal(t, exception.UserNotExist.Error(), r.Message)
+	}
+
+	{
+		claims, err := token.Parse(token.JoinPrefixToken(userInfo.Token), false)
+
+		assert.Nil(t, err)
+
+		r := user.RevokeSessionByAdmin(controller.Context{Uid: adminInfo.Id}, userInfo.Id)
+
+		assert.Equal(t, schema.StatusSuccess, r.Status)
+		assert.Equal(t, "", r.Message)
+
+		revoked, err := token.IsRevoked(claims)
+
+		assert.Nil(t, err)
+		assert.True(t, revoked)
+	}
+}
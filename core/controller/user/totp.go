@@ -0,0 +1,284 @@
+// Copyright 2019 Axetroy. All rights reserved. MIT license.
+package user
+
+import (
+	"encoding/base64"
+	"errors"
+	"github.com/axetroy/go-server/core/controller"
+	"github.com/axetroy/go-server/core/exception"
+	"github.com/axetroy/go-server/core/helper"
+	"github.com/axetroy/go-server/core/model"
+	"github.com/axetroy/go-server/core/schema"
+	"github.com/axetroy/go-server/core/service/database"
+	"github.com/axetroy/go-server/core/util"
+	"github.com/axetroy/go-server/core/validator"
+	"github.com/gin-gonic/gin"
+	"github.com/jinzhu/gorm"
+	"net/http"
+)
+
+type TOTPCodeParams struct {
+	Code string `json:"code" valid:"required~请输入动态验证码,numeric~动态验证码为6位数字,length(6|6)~动态验证码为6位数字"` // 身份验证器 App 上的 6 位动态码
+}
+
+// 生成双重身份认证的绑定信息，每次调用都会重新生成密钥，需要调用确认接口后才会生效
+func EnrollTOTP(c controller.Context) (res schema.Response) {
+	var (
+		err  error
+		data schema.TOTPEnrollment
+		tx   *gorm.DB
+	)
+
+	defer func() {
+		if r := recover(); r != nil {
+			switch t := r.(type) {
+			case string:
+				err = errors.New(t)
+			case error:
+				err = t
+			default:
+				err = exception.Unknown
+			}
+		}
+
+		if tx != nil {
+			if err != nil {
+				_ = tx.Rollback().Error
+			} else {
+				err = tx.Commit().Error
+			}
+		}
+
+		helper.Response(&res, data, err)
+	}()
+
+	tx = database.Db.Begin()
+
+	userInfo := model.User{Id: c.Uid}
+
+	if err = tx.Where(&userInfo).Last(&userInfo).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			err = exception.UserNotExist
+		}
+		return
+	}
+
+	// 已经开启的，需要先关闭才能重新绑定
+	if userInfo.EnableTOTP {
+		err = exception.TOTPEnabled
+		return
+	}
+
+	secret, err := util.Generate2FASecret(userInfo.Id)
+
+	if err != nil {
+		return
+	}
+
+	if err = tx.Model(&userInfo).Update("secret", secret).Error; err != nil {
+		return
+	}
+
+	uri := util.Generate2FAURI(userInfo.Username, secret)
+
+	png, err := util.Generate2FAQRCode(uri)
+
+	if err != nil {
+		return
+	}
+
+	data.Secret = secret
+	data.URI = uri
+	data.QRCode = base64.StdEncoding.EncodeToString(png)
+
+	return
+}
+
+// 确认绑定并开启双重身份认证
+func ConfirmTOTP(c controller.Context, input TOTPCodeParams) (res schema.Response) {
+	var (
+		err error
+		tx  *gorm.DB
+	)
+
+	defer func() {
+		if r := recover(); r != nil {
+			switch t := r.(type) {
+			case string:
+				err = errors.New(t)
+			case error:
+				err = t
+			default:
+				err = exception.Unknown
+			}
+		}
+
+		if tx != nil {
+			if err != nil {
+				_ = tx.Rollback().Error
+			} else {
+				err = tx.Commit().Error
+			}
+		}
+
+		helper.Response(&res, nil, err)
+	}()
+
+	// 参数校验
+	if err = validator.ValidateStruct(input); err != nil {
+		return
+	}
+
+	tx = database.Db.Begin()
+
+	userInfo := model.User{Id: c.Uid}
+
+	if err = tx.Where(&userInfo).Last(&userInfo).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			err = exception.UserNotExist
+		}
+		return
+	}
+
+	if userInfo.EnableTOTP {
+		err = exception.TOTPEnabled
+		return
+	}
+
+	if !util.Verify2FA(userInfo.Secret, input.Code) {
+		err = exception.InvalidTOTPCode
+		return
+	}
+
+	if err = tx.Model(&userInfo).Update("enable_totp", true).Error; err != nil {
+		return
+	}
+
+	return
+}
+
+// 关闭双重身份认证
+func DisableTOTP(c controller.Context, input TOTPCodeParams) (res schema.Response) {
+	var (
+		err error
+		tx  *gorm.DB
+	)
+
+	defer func() {
+		if r := recover(); r != nil {
+			switch t := r.(type) {
+			case string:
+				err = errors.New(t)
+			case error:
+				err = t
+			default:
+				err = exception.Unknown
+			}
+		}
+
+		if tx != nil {
+			if err != nil {
+				_ = tx.Rollback().Error
+			} else {
+				err = tx.Commit().Error
+			}
+		}
+
+		helper.Response(&res, nil, err)
+	}()
+
+	// 参数校验
+	if err = validator.ValidateStruct(input); err != nil {
+		return
+	}
+
+	tx = database.Db.Begin()
+
+	userInfo := model.User{Id: c.Uid}
+
+	if err = tx.Where(&userInfo).Last(&userInfo).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			err = exception.UserNotExist
+		}
+		return
+	}
+
+	if !userInfo.EnableTOTP {
+		err = exception.TOTPNotEnabled
+		return
+	}
+
+	if !util.Verify2FA(userInfo.Secret, input.Code) {
+		err = exception.InvalidTOTPCode
+		return
+	}
+
+	if err = tx.Model(&userInfo).Update("enable_totp", false).Error; err != nil {
+		return
+	}
+
+	return
+}
+
+func EnrollTOTPRouter(c *gin.Context) {
+	var (
+		err error
+		res = schema.Response{}
+	)
+
+	defer func() {
+		if err != nil {
+			res.Data = nil
+			res.Message = err.Error()
+		}
+		c.JSON(http.StatusOK, res)
+	}()
+
+	res = EnrollTOTP(controller.NewContext(c))
+}
+
+func ConfirmTOTPRouter(c *gin.Context) {
+	var (
+		err   error
+		res   = schema.Response{}
+		input TOTPCodeParams
+	)
+
+	defer func() {
+		if err != nil {
+			res.Data = nil
+			res.Message = err.Error()
+		}
+		c.JSON(http.StatusOK, res)
+	}()
+
+	if err = c.ShouldBindJSON(&input); err != nil {
+		err = exception.InvalidParams
+		return
+	}
+
+	res = ConfirmTOTP(controller.NewContext(c), input)
+}
+
+func DisableTOTPRouter(c *gin.Context) {
+	var (
+		err   error
+		res   = schema.Response{}
+		input TOTPCodeParams
+	)
+
+	defer func() {
+		if err != nil {
+			res.Data = nil
+			res.Message = err.Error()
+		}
+		c.JSON(http.StatusOK, res)
+	}()
+
+	if err = c.ShouldBindJSON(&input); err != nil {
+		err = exception.InvalidParams
+		return
+	}
+
+	res = DisableTOTP(controller.NewContext(c), input)
+}
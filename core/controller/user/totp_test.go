@@ -0,0 +1,90 @@
+// Copyright 2019 Axetroy. All rights reserved. MIT license.
+package user_test
+
+import (
+	"github.com/axetroy/go-server/core/controller"
+	"github.com/axetroy/go-server/core/controller/auth"
+	"github.com/axetroy/go-server/core/controller/user"
+	"github.com/axetroy/go-server/core/exception"
+	"github.com/axetroy/go-server/core/schema"
+	"github.com/axetroy/go-server/core/util"
+	"github.com/axetroy/go-server/tester"
+	"github.com/stretchr/testify/assert"
+	"testing"
+	"time"
+)
+
+func TestTOTP(t *testing.T) {
+	userInfo, _ := tester.CreateUser()
+
+	defer auth.DeleteUserByUserName(userInfo.Username)
+
+	context := controller.Context{
+		Uid: userInfo.Id,
+	}
+
+	enrollment := schema.TOTPEnrollment{}
+
+	// 获取绑定信息
+	{
+		r := user.EnrollTOTP(context)
+
+		assert.Equal(t, schema.StatusSuccess, r.Status)
+		assert.Equal(t, "", r.Message)
+
+		assert.Nil(t, tester.Decode(r.Data, &enrollment))
+		assert.NotEmpty(t, enrollment.Secret)
+		assert.NotEmpty(t, enrollment.QRCode)
+		assert.Contains(t, enrollment.URI, "otpauth://totp/")
+	}
+
+	// 输入错误的动态码
+	{
+		r := user.ConfirmTOTP(context, user.TOTPCodeParams{Code: "000000"})
+
+		assert.Equal(t, exception.InvalidTOTPCode.Code(), r.Status)
+		assert.Equal(t, exception.InvalidTOTPCode.Error(), r.Message)
+	}
+
+	// 确认开启
+	{
+		code, err := util.Generate2FACode(enrollment.Secret, time.Now())
+
+		assert.Nil(t, err)
+
+		r := user.ConfirmTOTP(context, user.TOTPCodeParams{Code: code})
+
+		assert.Equal(t, schema.StatusSuccess, r.Status)
+		assert.Equal(t, "", r.Message)
+	}
+
+	// 开启后不能重新生成密钥
+	{
+		r := user.EnrollTOTP(context)
+
+		assert.Equal(t, exception.TOTPEnabled.Code(), r.Status)
+		assert.Equal(t, exception.TOTPEnabled.Error(), r.Message)
+	}
+
+	// 关闭
+	{
+		code, err := util.Generate2FACode(enrollment.Secret, time.Now())
+
+		assert.Nil(t, err)
+
+		r := user.DisableTOTP(context, user.TOTPCodeParams{Code: code})
+
+		assert.Equal(t, schema.StatusSuccess, r.Status)
+		assert.Equal(t, "", r.Message)
+	}
+
+	// 已经关闭了，再次关闭就报错
+	{
+		code, _ := util.Generate2FACode(enrollment.Secret, time.Now())
+
+		r := user.DisableTOTP(context, user.TOTPCodeParams{Code: code})
+
+		assert.Equal(t, exception.TOTPNotEnabled.Code(), r.Status)
+		assert.Equal(t, exception.TOTPNotEnabled.Error(), r.Message)
+	}
+}
@@ -9,6 +9,7 @@ import (
 	"github.com/axetroy/go-server/core/middleware"
 	"github.com/axetroy/go-server/core/model"
 	"github.com/axetroy/go-server/core/schema"
+	"github.com/axetroy/go-server/core/service/currency"
 	"github.com/axetroy/go-server/core/service/database"
 	"github.com/gin-gonic/gin"
 	"github.com/jinzhu/gorm"
@@ -19,7 +20,6 @@ func GetWallets(c controller.Context) (res schema.Response) {
 	var (
 		err  error
 		data []schema.Wallet
-		list []model.Wallet
 		tx   *gorm.DB
 	)
 
@@ -58,17 +58,21 @@ func GetWallets(c controller.Context) (res schema.Response) {
 		return
 	}
 
-	sql := GenerateWalletSQL(QueryParams{
-		Id: &userInfo.Id,
-	}, 100, false)
+	var currencies []model.Currency
 
-	if err = tx.Raw(sql).Scan(&list).Error; err != nil {
+	if currencies, err = currency.List(); err != nil {
 		return
 	}
 
-	for _, v := range list {
+	for _, v := range currencies {
+		var w model.Wallet
+
+		if w, err = findWallet(tx, v.Code, userInfo.Id); err != nil {
+			return
+		}
+
 		wallet := schema.Wallet{}
-		mapToSchema(v, &wallet)
+		mapToSchema(w, &wallet)
 		data = append(data, wallet)
 	}
 
@@ -93,3 +97,29 @@ func GetWalletsRouter(c *gin.Context) {
 		Uid: c.GetString(middleware.ContextUidField),
 	})
 }
+
+// 管理员获取用户的所有钱包
+func GetWalletsByAdmin(c controller.Context, userId string) (res schema.Response) {
+	return GetWallets(controller.Context{
+		Uid: userId,
+	})
+}
+
+func GetWalletsByAdminRouter(c *gin.Context) {
+	var (
+		err error
+		res = schema.Response{}
+	)
+
+	defer func() {
+		if err != nil {
+			res.Data = nil
+			res.Message = err.Error()
+		}
+		c.JSON(http.StatusOK, res)
+	}()
+
+	res = GetWalletsByAdmin(controller.Context{
+		Uid: c.GetString(middleware.ContextUidField),
+	}, c.Param("user_id"))
+}
@@ -6,8 +6,8 @@ import (
 	"github.com/axetroy/go-server/core/controller"
 	"github.com/axetroy/go-server/core/controller/auth"
 	"github.com/axetroy/go-server/core/controller/wallet"
-	"github.com/axetroy/go-server/core/model"
 	"github.com/axetroy/go-server/core/schema"
+	"github.com/axetroy/go-server/core/service/currency"
 	"github.com/axetroy/go-server/core/service/token"
 	"github.com/axetroy/go-server/tester"
 	"github.com/axetroy/mocker"
@@ -25,7 +25,10 @@ func TestGetWallets(t *testing.T) {
 	assert.Equal(t, schema.StatusSuccess, r.Status)
 	assert.Equal(t, "", r.Message)
 
-	assert.Len(t, r.Data, len(model.WalletTableNames))
+	currencies, err := currency.List()
+
+	assert.Nil(t, err)
+	assert.Len(t, r.Data, len(currencies))
 
 	list := make([]schema.Wallet, 0)
 	assert.Nil(t, tester.Decode(r.Data, &list))
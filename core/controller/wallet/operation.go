@@ -0,0 +1,251 @@
+// Copyright 2019 Axetroy. All rights reserved. MIT license.
+package wallet
+
+import (
+	"errors"
+	"github.com/axetroy/go-server/core/controller"
+	"github.com/axetroy/go-server/core/exception"
+	"github.com/axetroy/go-server/core/helper"
+	"github.com/axetroy/go-server/core/logger"
+	"github.com/axetroy/go-server/core/middleware"
+	"github.com/axetroy/go-server/core/model"
+	"github.com/axetroy/go-server/core/schema"
+	"github.com/axetroy/go-server/core/service/database"
+	walletService "github.com/axetroy/go-server/core/service/wallet"
+	"github.com/axetroy/go-server/core/util"
+	"github.com/axetroy/go-server/core/validator"
+	"github.com/gin-gonic/gin"
+	"github.com/jinzhu/gorm"
+	"net/http"
+	"strings"
+	"time"
+)
+
+type OperationParams struct {
+	Uid      string `json:"uid" valid:"required~请输入用户ID"`                                 // 要操作的用户
+	Currency string `json:"currency" valid:"required~请选择币种"`                              // 币种
+	Amount   string `json:"amount" valid:"required~请输入金额"`                                // 操作的金额, 必须为正数
+	Reason   string `json:"reason" valid:"required~请输入操作原因,length(1|128)~操作原因不能超过128个字符"` // 操作的原因
+}
+
+// 根据操作类型生成钱包的变动
+func toMutation(uid string, t model.FinanceType, amount util.Decimal, reason string) walletService.Mutation {
+	m := walletService.Mutation{
+		Uid:  uid,
+		Type: t,
+		Note: &reason,
+	}
+
+	switch t {
+	case model.FinanceTypeAdminCredit:
+		m.Balance = amount
+	case model.FinanceTypeAdminDebit:
+		m.Balance = amount.Neg()
+	case model.FinanceTypeFreeze:
+		m.Balance = amount.Neg()
+		m.Frozen = amount
+	case model.FinanceTypeUnfreeze:
+		m.Balance = amount
+		m.Frozen = amount.Neg()
+	}
+
+	return m
+}
+
+// 管理员冻结的金额, 即管理员冻结的总额减去管理员解冻的总额
+// 钱包的冻结余额还包括等待确认的转账等冻结的金额, 管理员只能解冻自己冻结的部分
+func adminFrozen(tx *gorm.DB, uid string, currency string) (amount util.Decimal, err error) {
+	err = tx.Model(&model.WalletOperation{}).
+		Select("COALESCE(SUM(CASE WHEN type = ? THEN amount ELSE -amount END), 0)", model.FinanceTypeFreeze).
+		Where("uid = ? AND currency = ? AND type IN (?)", uid, strings.ToUpper(currency), []model.FinanceType{model.FinanceTypeFreeze, model.FinanceTypeUnfreeze}).
+		Row().Scan(&amount)
+
+	return
+}
+
+func operationToSchema(operation model.WalletOperation) (d schema.WalletOperation) {
+	d.Id = operation.Id
+	d.AdminId = operation.AdminId
+	d.Uid = operation.Uid
+	d.Currency = operation.Currency
+	d.Type = operation.Type
+	d.Amount = operation.Amount.StringFixed(8)
+	d.Reason = operation.Reason
+	d.CreatedAt = operation.CreatedAt.Format(time.RFC3339Nano)
+	d.UpdatedAt = operation.UpdatedAt.Format(time.RFC3339Nano)
+	return
+}
+
+// 管理员操作用户的钱包
+func operate(c controller.Context, input OperationParams, t model.FinanceType) (res schema.Response) {
+	var (
+		err  error
+		tx   *gorm.DB
+		data schema.WalletOperation
+	)
+
+	defer func() {
+		if r := recover(); r != nil {
+			switch t := r.(type) {
+			case string:
+				err = errors.New(t)
+			case error:
+				err = t
+			default:
+				err = exception.Unknown
+			}
+		}
+
+		if tx != nil {
+			if err != nil {
+				_ = tx.Rollback().Error
+			} else {
+				logger.Infof("Admin %s %s wallet %v", c.Uid, t, input)
+				err = tx.Commit().Error
+			}
+		}
+
+		helper.Response(&res, data, err)
+	}()
+
+	// 参数校验
+	if err = validator.ValidateStruct(input); err != nil {
+		return
+	}
+
+	var amount util.Decimal
+
+	if amount, err = walletService.ParseAmount(input.Currency, input.Amount); err != nil {
+		return
+	}
+
+	tx = database.Db.Begin()
+
+	adminInfo := model.Admin{Id: c.Uid}
+
+	if err = tx.Where(&adminInfo).Last(&adminInfo).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			err = exception.AdminNotExist
+		}
+		return
+	}
+
+	userInfo := model.User{Id: input.Uid}
+
+	if err = tx.Where(&userInfo).Last(&userInfo).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			err = exception.UserNotExist
+		}
+		return
+	}
+
+	// 先锁定钱包, 同一个用户的操作依次执行, 避免并发的解冻都通过检查
+	if _, err = walletService.Lock(tx, input.Currency, input.Uid); err != nil {
+		return
+	}
+
+	if t == model.FinanceTypeUnfreeze {
+		var frozen util.Decimal
+
+		if frozen, err = adminFrozen(tx, input.Uid, input.Currency); err != nil {
+			return
+		}
+
+		if amount.Cmp(frozen) > 0 {
+			err = exception.NotEnoughAdminFrozen
+			return
+		}
+	}
+
+	operation := model.WalletOperation{
+		AdminId:  c.Uid,
+		Uid:      input.Uid,
+		Currency: strings.ToUpper(input.Currency),
+		Type:     t,
+		Amount:   amount,
+		Reason:   input.Reason,
+	}
+
+	if err = tx.Create(&operation).Error; err != nil {
+		return
+	}
+
+	// 扣款或者冻结时余额不足会报错
+	if _, err = walletService.Apply(tx, input.Currency, operation.Id, toMutation(input.Uid, t, amount, input.Reason)); err != nil {
+		return
+	}
+
+	w := model.Wallet{}
+
+	if err = model.WalletOf(tx, input.Currency).Where("id = ?", input.Uid).First(&w).Error; err != nil {
+		return
+	}
+
+	data = operationToSchema(operation)
+	data.Wallet = &schema.Wallet{}
+
+	mapToSchema(w, data.Wallet)
+
+	return
+}
+
+// 给用户的钱包加款
+func CreditByAdmin(c controller.Context, input OperationParams) (res schema.Response) {
+	return operate(c, input, model.FinanceTypeAdminCredit)
+}
+
+// 从用户的钱包扣款
+func DebitByAdmin(c controller.Context, input OperationParams) (res schema.Response) {
+	return operate(c, input, model.FinanceTypeAdminDebit)
+}
+
+// 冻结用户钱包的余额
+func FreezeByAdmin(c controller.Context, input OperationParams) (res schema.Response) {
+	return operate(c, input, model.FinanceTypeFreeze)
+}
+
+// 解冻用户钱包的余额
+func UnfreezeByAdmin(c controller.Context, input OperationParams) (res schema.Response) {
+	return operate(c, input, model.FinanceTypeUnfreeze)
+}
+
+func operateRouter(c *gin.Context, handler func(c controller.Context, input OperationParams) schema.Response) {
+	var (
+		err   error
+		res   = schema.Response{}
+		input OperationParams
+	)
+
+	defer func() {
+		if err != nil {
+			res.Data = nil
+			res.Message = err.Error()
+		}
+		c.JSON(http.StatusOK, res)
+	}()
+
+	if err = c.ShouldBindJSON(&input); err != nil {
+		err = exception.InvalidParams
+		return
+	}
+
+	res = handler(controller.Context{
+		Uid: c.GetString(middleware.ContextUidField),
+	}, input)
+}
+
+func CreditByAdminRouter(c *gin.Context) {
+	operateRouter(c, CreditByAdmin)
+}
+
+func DebitByAdminRouter(c *gin.Context) {
+	operateRouter(c, DebitByAdmin)
+}
+
+func FreezeByAdminRouter(c *gin.Context) {
+	operateRouter(c, FreezeByAdmin)
+}
+
+func UnfreezeByAdminRouter(c *gin.Context) {
+	operateRouter(c, UnfreezeByAdmin)
+}
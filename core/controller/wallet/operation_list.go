@@ -0,0 +1,175 @@
+// Copyright 2019 Axetroy. All rights reserved. MIT license.
+package wallet
+
+import (
+	"errors"
+	"github.com/axetroy/go-server/core/controller"
+	"github.com/axetroy/go-server/core/exception"
+	"github.com/axetroy/go-server/core/helper"
+	"github.com/axetroy/go-server/core/middleware"
+	"github.com/axetroy/go-server/core/model"
+	"github.com/axetroy/go-server/core/schema"
+	"github.com/axetroy/go-server/core/service/database"
+	"github.com/gin-gonic/gin"
+	"github.com/jinzhu/gorm"
+	"net/http"
+	"strings"
+)
+
+type OperationQuery struct {
+	schema.Query
+	Uid      *string            `json:"uid" form:"uid"`           // 被操作的用户
+	AdminId  *string            `json:"admin_id" form:"admin_id"` // 操作的管理员
+	Currency *string            `json:"currency" form:"currency"` // 币种
+	Type     *model.FinanceType `json:"type" form:"type"`         // 操作类型
+}
+
+// 获取管理员对钱包的操作记录
+func GetOperationsByAdmin(c controller.Context, input OperationQuery) (res schema.List) {
+	var (
+		err  error
+		data = make([]schema.WalletOperation, 0)
+		meta = &schema.Meta{}
+	)
+
+	defer func() {
+		if r := recover(); r != nil {
+			switch t := r.(type) {
+			case string:
+				err = errors.New(t)
+			case error:
+				err = t
+			default:
+				err = exception.Unknown
+			}
+		}
+
+		helper.ResponseList(&res, data, meta, err)
+	}()
+
+	query := input.Query
+
+	query.Normalize()
+
+	list := make([]model.WalletOperation, 0)
+
+	filter := map[string]interface{}{}
+
+	if input.Uid != nil {
+		filter["uid"] = *input.Uid
+	}
+
+	if input.AdminId != nil {
+		filter["admin_id"] = *input.AdminId
+	}
+
+	if input.Currency != nil {
+		filter["currency"] = strings.ToUpper(*input.Currency)
+	}
+
+	if input.Type != nil {
+		filter["type"] = *input.Type
+	}
+
+	if err = query.Order(database.Db.Limit(query.Limit).Offset(query.Limit * query.Page)).Where(filter).Find(&list).Error; err != nil {
+		return
+	}
+
+	var total int64
+
+	if err = database.Db.Model(&model.WalletOperation{}).Where(filter).Count(&total).Error; err != nil {
+		return
+	}
+
+	for _, v := range list {
+		data = append(data, operationToSchema(v))
+	}
+
+	meta.Total = total
+	meta.Num = len(list)
+	meta.Page = query.Page
+	meta.Limit = query.Limit
+	meta.Sort = query.Sort
+
+	return
+}
+
+// 获取单条钱包操作记录
+func GetOperationByAdmin(c controller.Context, operationId string) (res schema.Response) {
+	var (
+		err  error
+		data schema.WalletOperation
+	)
+
+	defer func() {
+		if r := recover(); r != nil {
+			switch t := r.(type) {
+			case string:
+				err = errors.New(t)
+			case error:
+				err = t
+			default:
+				err = exception.Unknown
+			}
+		}
+
+		helper.Response(&res, data, err)
+	}()
+
+	operation := model.WalletOperation{Id: operationId}
+
+	if err = database.Db.Where(&operation).First(&operation).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			err = exception.WalletOperationNotExist
+		}
+		return
+	}
+
+	data = operationToSchema(operation)
+
+	return
+}
+
+func GetOperationsByAdminRouter(c *gin.Context) {
+	var (
+		err   error
+		res   = schema.List{}
+		input OperationQuery
+	)
+
+	defer func() {
+		if err != nil {
+			res.Data = nil
+			res.Message = err.Error()
+		}
+		c.JSON(http.StatusOK, res)
+	}()
+
+	if err = c.ShouldBindQuery(&input); err != nil {
+		err = exception.InvalidParams
+		return
+	}
+
+	res = GetOperationsByAdmin(controller.Context{
+		Uid: c.GetString(middleware.ContextUidField),
+	}, input)
+}
+
+func GetOperationByAdminRouter(c *gin.Context) {
+	var (
+		err error
+		res = schema.Response{}
+	)
+
+	defer func() {
+		if err != nil {
+			res.Data = nil
+			res.Message = err.Error()
+		}
+		c.JSON(http.StatusOK, res)
+	}()
+
+	res = GetOperationByAdmin(controller.Context{
+		Uid: c.GetString(middleware.ContextUidField),
+	}, c.Param("operation_id"))
+}
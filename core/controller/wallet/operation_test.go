@@ -0,0 +1,167 @@
+// Copyright 2019 Axetroy. All rights reserved. MIT license.
+package wallet_test
+
+import (
+	"github.com/axetroy/go-server/core/controller"
+	"github.com/axetroy/go-server/core/controller/auth"
+	"github.com/axetroy/go-server/core/controller/wallet"
+	"github.com/axetroy/go-server/core/exception"
+	"github.com/axetroy/go-server/core/model"
+	"github.com/axetroy/go-server/core/schema"
+	"github.com/axetroy/go-server/core/service/database"
+	walletService "github.com/axetroy/go-server/core/service/wallet"
+	"github.com/axetroy/go-server/core/util"
+	"github.com/axetroy/go-server/tester"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestOperateByAdmin(t *testing.T) {
+	adminInfo, _ := tester.LoginAdmin()
+	userInfo, _ := tester.CreateUser()
+
+	defer auth.DeleteUserByUserName(userInfo.Username)
+
+	c := controller.Context{Uid: adminInfo.Id}
+
+	operate := func(handler func(c controller.Context, input wallet.OperationParams) schema.Response, amount string) schema.Response {
+		return handler(c, wallet.OperationParams{
+			Uid:      userInfo.Id,
+			Currency: model.WalletCNY,
+			Amount:   amount,
+			Reason:   "测试",
+		})
+	}
+
+	// 加款
+	{
+		r := operate(wallet.CreditByAdmin, "100")
+
+		assert.Equal(t, "", r.Message)
+		assert.Equal(t, schema.StatusSuccess, r.Status)
+
+		data := schema.WalletOperation{}
+
+		assert.Nil(t, tester.Decode(r.Data, &data))
+		assert.Equal(t, adminInfo.Id, data.AdminId)
+		assert.Equal(t, model.FinanceTypeAdminCredit, data.Type)
+		assert.Equal(t, "测试", data.Reason)
+		assert.Equal(t, "100.00000000", data.Wallet.Balance)
+
+		// 生成了对应的财务日志
+		log := model.FinanceLog{}
+
+		assert.Nil(t, model.FinanceLogOf(database.Db, model.WalletCNY).Where("order_id = ?", data.Id).First(&log).Error)
+		assert.Equal(t, model.FinanceTypeAdminCredit, log.Type)
+		assert.Equal(t, "100", log.BalanceMutation.String())
+		assert.Equal(t, "测试", *log.Note)
+	}
+
+	// 扣款
+	{
+		r := operate(wallet.DebitByAdmin, "10")
+		data := schema.WalletOperation{}
+
+		assert.Equal(t, "", r.Message)
+		assert.Nil(t, tester.Decode(r.Data, &data))
+		assert.Equal(t, "90.00000000", data.Wallet.Balance)
+	}
+
+	// 扣款超过余额
+	{
+		r := operate(wallet.DebitByAdmin, "1000")
+
+		assert.Equal(t, exception.NotEnoughBalance.Error(), r.Message)
+	}
+
+	// 冻结
+	{
+		r := operate(wallet.FreezeByAdmin, "30")
+		data := schema.WalletOperation{}
+
+		assert.Equal(t, "", r.Message)
+		assert.Nil(t, tester.Decode(r.Data, &data))
+		assert.Equal(t, "60.00000000", data.Wallet.Balance)
+		assert.Equal(t, "30.00000000", data.Wallet.Frozen)
+	}
+
+	// 解冻超过冻结的金额
+	{
+		r := operate(wallet.UnfreezeByAdmin, "40")
+
+		assert.Equal(t, exception.NotEnoughAdminFrozen.Error(), r.Message)
+	}
+
+	// 解冻
+	{
+		r := operate(wallet.UnfreezeByAdmin, "20")
+		data := schema.WalletOperation{}
+
+		assert.Equal(t, "", r.Message)
+		assert.Nil(t, tester.Decode(r.Data, &data))
+		assert.Equal(t, "80.00000000", data.Wallet.Balance)
+		assert.Equal(t, "10.00000000", data.Wallet.Frozen)
+	}
+
+	// 等待确认的转账冻结的金额不能被管理员解冻
+	{
+		tx := database.Db.Begin()
+
+		_, err := walletService.Apply(tx, model.WalletCNY, "transfer", walletService.Mutation{
+			Uid:     userInfo.Id,
+			Balance: util.MustParseDecimal("-20"),
+			Frozen:  util.MustParseDecimal("20"),
+			Type:    model.FinanceTypeTransferFreeze,
+		})
+
+		assert.Nil(t, err)
+		assert.Nil(t, tx.Commit().Error)
+
+		r := operate(wallet.UnfreezeByAdmin, "15")
+
+		assert.Equal(t, exception.NotEnoughAdminFrozen.Error(), r.Message)
+
+		r = operate(wallet.UnfreezeByAdmin, "10")
+
+		assert.Equal(t, "", r.Message)
+	}
+
+	// 无效的金额
+	{
+		r := operate(wallet.CreditByAdmin, "-1")
+
+		assert.Equal(t, exception.InvalidParams.Error(), r.Message)
+	}
+
+	// 必须填写原因
+	{
+		r := wallet.CreditByAdmin(c, wallet.OperationParams{
+			Uid:      userInfo.Id,
+			Currency: model.WalletCNY,
+			Amount:   "1",
+		})
+
+		assert.Equal(t, exception.InvalidParams.Code(), r.Status)
+	}
+
+	// 操作记录
+	{
+		r := wallet.GetOperationsByAdmin(c, wallet.OperationQuery{
+			Uid: &userInfo.Id,
+		})
+
+		assert.Equal(t, "", r.Message)
+
+		list := make([]schema.WalletOperation, 0)
+
+		assert.Nil(t, tester.Decode(r.Data, &list))
+
+		// 失败的操作不会被记录
+		assert.Len(t, list, 5)
+		assert.Equal(t, int64(5), r.Meta.Total)
+
+		detail := wallet.GetOperationByAdmin(c, list[0].Id)
+
+		assert.Equal(t, "", detail.Message)
+	}
+}
@@ -8,21 +8,17 @@ import (
 	"github.com/axetroy/go-server/core/helper"
 	"github.com/axetroy/go-server/core/model"
 	"github.com/axetroy/go-server/core/schema"
+	"github.com/axetroy/go-server/core/service/currency"
 	"github.com/axetroy/go-server/core/service/database"
 	"github.com/gin-gonic/gin"
 	"github.com/jinzhu/gorm"
 	"net/http"
-	"strings"
 )
 
 func IsValidWallet(walletName string) bool {
-	for _, validWallet := range model.Wallets {
-		// 有效币种验证忽略大小写
-		if validWallet == strings.ToUpper(walletName) {
-			return true
-		}
-	}
-	return false
+	// 有效币种验证忽略大小写
+	_, err := currency.Get(walletName)
+	return err == nil
 }
 
 func GetWallet(c controller.Context, currencyName string) (res schema.Response) {
@@ -67,17 +63,16 @@ func GetWallet(c controller.Context, currencyName string) (res schema.Response)
 		return
 	}
 
-	walletInfo := model.Wallet{
-		Id: userInfo.Id,
-	}
+	var currencyInfo model.Currency
 
 	// 检查是否是有效的钱包
-	if IsValidWallet(strings.ToUpper(currencyName)) == false {
-		err = exception.InvalidWallet
+	if currencyInfo, err = currency.Get(currencyName); err != nil {
 		return
 	}
 
-	if err = tx.Table("wallet_"+strings.ToLower(currencyName)).Where("id = ?", c.Uid).Scan(&walletInfo).Error; err != nil {
+	var walletInfo model.Wallet
+
+	if walletInfo, err = findWallet(tx, currencyInfo.Code, userInfo.Id); err != nil {
 		return
 	}
 
@@ -2,89 +2,31 @@
 package wallet
 
 import (
-	"fmt"
 	"github.com/axetroy/go-server/core/model"
 	"github.com/axetroy/go-server/core/schema"
-	"github.com/axetroy/go-server/core/util"
-	"reflect"
-	"strings"
+	"github.com/jinzhu/gorm"
 	"time"
 )
 
-func GetTableName(currency string) string {
-	return "wallet_" + strings.ToLower(currency)
-}
-
 func mapToSchema(model model.Wallet, d *schema.Wallet) {
 	d.Id = model.Id
 	d.Currency = model.Currency
-	d.Balance = util.FloatToStr(model.Balance)
-	d.Frozen = util.FloatToStr(model.Frozen)
+	d.Balance = model.Balance.StringFixed(8)
+	d.Frozen = model.Frozen.StringFixed(8)
 	d.CreatedAt = model.CreatedAt.Format(time.RFC3339Nano)
 	d.UpdatedAt = model.UpdatedAt.Format(time.RFC3339Nano)
 }
 
-type QueryParams struct {
-	Id       *string `json:"id"`       // 用户ID
-	Currency *string `json:"currency"` // 钱包币种
-}
-
-func GenerateWalletSQL(filter QueryParams, limit int, count bool) string {
-	suffix := `("deleted_at" IS NULL OR "deleted_at"='0001-01-01 00:00:00')`
-
-	filterArray := make([]string, 0)
-
-	{
-		t := reflect.TypeOf(filter)
-		v := reflect.ValueOf(filter)
-
-		for k := 0; k < t.NumField(); k++ {
-			key := t.Field(k).Tag.Get("json")
-			value := v.Field(k).Interface()
-
-			if key == "" {
-				continue
-			}
-
-			if !v.Field(k).IsValid() {
-				continue
-			}
-
-			if util.IsNil(value) {
-				continue
-			}
-
-			// 如果是指针的话
-			if util.IsPoint(value) {
-				// 获取指针对应的值
-				value = reflect.ValueOf(value).Elem()
-			} else {
-				continue
-			}
-
-			filterArray = append(filterArray, fmt.Sprintf(`"%s"='%v'`, key, value))
+// 获取用户在某个币种的钱包
+// 后来新增的币种不会给已有的用户创建钱包, 钱包不存在则视为余额为 0, 第一次变动余额时才会创建
+func findWallet(db *gorm.DB, code string, uid string) (w model.Wallet, err error) {
+	if err = model.WalletOf(db, code).Where("id = ?", uid).First(&w).Error; err == gorm.ErrRecordNotFound {
+		w = model.Wallet{
+			Id:       uid,
+			Currency: code,
 		}
+		err = nil
 	}
 
-	filterStr := "WHERE " + strings.Join(filterArray[:], " AND ")
-
-	if len(filterArray) != 0 {
-		filterStr = filterStr + " AND"
-	}
-
-	SQLs := make([]string, 0)
-
-	selected := "*"
-
-	if count {
-		selected = "COUNT(*)"
-	}
-
-	for _, tableName := range model.WalletTableNames {
-		sql := fmt.Sprintf(`SELECT %s FROM "%s" %s %s`, selected, tableName, filterStr, suffix)
-		SQLs = append(SQLs, sql)
-	}
-
-	sql := fmt.Sprintf("%s LIMIT %d", strings.Join(SQLs[:], " UNION "), limit)
-	return sql
+	return
 }
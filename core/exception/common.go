@@ -2,21 +2,24 @@
 package exception
 
 var (
-	SystemMaintenance = New("系统维护中", -1)
-	Unknown           = New("未知错误", 0)
-	InvalidParams     = New("参数不正确", 100000)
-	NoData            = New("找不到数据", 100001)
-	NoPermission      = New("没有权限", 100002)
-	InvalidSignature  = New("数据签名不正确", 100003)
-	InvalidFormat     = New("格式不正确", 100004)
-	InvalidInviteCode = New("无效的邀请码", 100005)
-	SendMsgFail       = New("发送短信失败", 101000)
-	SendEmailFail     = New("发送邮件失败", 101001)
-	UserNotLogin      = New("请先登陆", 999999)
-	InvalidAuth       = New("无效的身份认证方式", 999999)
-	InvalidToken      = New("无效的身份令牌", 999999)
-	TokenExpired      = New("身份令牌已过期", 999999)
-	EmptyList         = New("sql: no rows in result set", 0)
+	SystemMaintenance   = New("系统维护中", -1)
+	Unknown             = New("未知错误", 0)
+	InvalidParams       = New("参数不正确", 100000)
+	NoData              = New("找不到数据", 100001)
+	NoPermission        = New("没有权限", 100002)
+	InvalidSignature    = New("数据签名不正确", 100003)
+	InvalidFormat       = New("格式不正确", 100004)
+	InvalidInviteCode   = New("无效的邀请码", 100005)
+	SendMsgFail         = New("发送短信失败", 101000)
+	SendEmailFail       = New("发送邮件失败", 101001)
+	UserNotLogin        = New("请先登陆", 999999)
+	InvalidAuth         = New("无效的身份认证方式", 999999)
+	InvalidToken        = New("无效的身份令牌", 999999)
+	TokenExpired        = New("身份令牌已过期", 999999)
+	TokenRevoked        = New("身份令牌已失效，请重新登陆", 999999)
+	InvalidRefreshToken = New("无效的刷新令牌", 999999)
+	RefreshTokenReused  = New("刷新令牌已被使用，请重新登陆", 999999)
+	EmptyList           = New("sql: no rows in result set", 0)
 
 	// 用户类
 	UserNotExist             = New("用户不存在", 200000)
@@ -36,10 +39,47 @@ var (
 	RequirePayPassword       = New("请输入交易密码", 200014)
 	DuplicateBinding         = New("帐号重复绑定", 200015)
 	RenameUserNameFail       = New("无法重命名用户名", 200016)
+	InvalidTOTPCode          = New("动态验证码错误", 200017)
+	InvalidTOTPChallenge     = New("登陆验证已失效，请重新登陆", 200018)
+	TOTPEnabled              = New("已开启双重身份认证", 200019)
+	TOTPNotEnabled           = New("未开启双重身份认证", 200020)
+	AccountLocked            = New("尝试次数过多，请稍后再试", 200021)
+	InvalidCaptcha           = New("验证码错误或已失效", 200022)
+	CaptchaTooFrequent       = New("验证码发送过于频繁，请稍后再试", 200023)
+	CaptchaQuotaExceeded     = New("今日验证码发送次数已达上限", 200024)
 
 	// 钱包
-	NotEnoughBalance = New("钱包余额不足", 0)
-	InvalidWallet    = New("无效的钱包", 0)
+	NotEnoughBalance        = New("钱包余额不足", 0)
+	IdempotencyKeyReused    = New("幂等键已被用于其他请求", 0)
+	InvalidWallet           = New("无效的钱包", 0)
+	TransferNotExist        = New("转账记录不存在", 0)
+	TransferHandled         = New("转账已被处理", 0)
+	TransferExpired         = New("转账已过期", 0)
+	WalletOperationNotExist = New("钱包操作记录不存在", 0)
+	NotEnoughAdminFrozen    = New("解冻金额超过管理员冻结的金额", 0)
+
+	// 转账风控
+	TransferAmountTooSmall        = New("转账金额低于最小限额", 300000)
+	TransferAmountTooLarge        = New("转账金额超过最大限额", 300001)
+	TransferDailyAmountExceeded   = New("今日转账金额已达上限", 300002)
+	TransferMonthlyAmountExceeded = New("本月转账金额已达上限", 300003)
+	TransferDailyCountExceeded    = New("今日转账次数已达上限", 300004)
+	TransferRecipientBanned       = New("收款方帐号已被禁用", 300005)
+	TransferRecipientInactivated  = New("收款方帐号未激活", 300006)
+	TransferLimitNotExist         = New("转账限额不存在", 0)
+	TransferLimitExist            = New("该币种和等级的转账限额已存在", 0)
+
+	// 币种
+	CurrencyNotExist    = New("币种不存在", 0)
+	CurrencyExist       = New("币种已存在", 0)
+	CurrencyDisabled    = New("币种已停用", 0)
+	CurrencyHadBeenUsed = New("币种已被使用, 无法删除", 0)
+
+	// 对账单
+	StatementInvalidPeriod = New("无效的对账单月份", 0)
+	StatementInvalidFormat = New("无效的对账单格式", 0)
+	StatementRequireEmail  = New("需要先绑定邮箱", 0)
+	StatementTooFrequent   = New("对账单发送过于频繁，请稍后再试", 0)
 
 	// 上传
 	RequireFile    = New("请上传文件", 0)
@@ -54,9 +94,14 @@ var (
 	AddressInvalidAreaCode     = New("无效的地区代码", 0)
 
 	// 管理员
-	AdminExist    = New("管理员已存在", 0)
-	AdminNotExist = New("管理员不存在", 0)
-	AdminNotSuper = New("只有超级管理员才能操作", 0)
+	AdminExist       = New("管理员已存在", 0)
+	AdminNotExist    = New("管理员不存在", 0)
+	AdminNotSuper    = New("只有超级管理员才能操作", 0)
+	AdminIsInActive  = New("管理员帐号未激活", 0)
+	AdminHaveBeenBan = New("管理员帐号已被禁用", 0)
+
+	// 锁定
+	LockNotExist = New("锁定记录不存在", 0)
 
 	// banner
 	BannerInvalidPlatform = New("无效的平台", 0)
@@ -66,19 +111,43 @@ var (
 	HelpParentNotExist = New("父级不存在", 0)
 
 	// 邀请
-	InviteNotExist = New("邀请记录不存在", 0)
+	InviteNotExist           = New("邀请记录不存在", 0)
+	InviteRewardRuleNotExist = New("邀请奖励规则不存在", 0)
+	InviteRewardRuleExist    = New("该状态和币种的邀请奖励规则已存在", 0)
+	InviteInvalidStatus      = New("无效的邀请状态", 0)
 
 	// RBAC 角色
 	RoleNotExist     = New("角色不存在", 0)
 	RoleCannotUpdate = New("无法更新角色", 0)
 	RoleHadBeenUsed  = New("角色正在被使用，无法删除", 0)
+	RoleInheritCycle = New("角色不能循环继承", 0)
 
 	// 系统通知
-	NotificationNotExist = New("系统通知不存在", 0)
+	NotificationNotExist        = New("系统通知不存在", 0)
+	NotificationInvalidAudience = New("无效的通知受众", 0)
+	NotificationInvalidSchedule = New("无效的通知发布时间", 0)
+
+	// 通知偏好
+	NotificationPreferenceInvalid  = New("无效的通知偏好设置", 0)
+	NotificationPreferenceSecurity = New("安全类通知无法关闭", 0)
+	NotificationPreferenceDisabled = New("用户关闭了该类通知", 0)
+	NotificationPreferenceQuiet    = New("用户正处于免打扰时段", 0)
 
 	// 用户消息
 	MessageNotExist = New("用户消息不存在", 0)
 
+	// 消息模版
+	MessageTemplateNotExist        = New("消息模版不存在", 0)
+	MessageTemplateExist           = New("该名称和语言的消息模版已存在", 0)
+	MessageTemplateInvalid         = New("无效的消息模版", 0)
+	MessageTemplateInvalidVariable = New("无效的模版变量", 0)
+	MessageTemplateInvalidChannel  = New("无效的发送渠道", 0)
+	MessageTemplateIncompatible    = New("覆盖内置模版时必须声明相同的模版变量", 0)
+	MessageTemplateInvalidCategory = New("无效的模版分类", 0)
+	MessageTemplateRequireSms      = New("该模版没有设置短信模版ID", 0)
+	MessageTemplateRequireEmail    = New("用户没有绑定邮箱", 0)
+	MessageTemplateRequirePhone    = New("用户没有绑定手机号", 0)
+
 	// 新闻资讯
 	NewsInvalidType = New("错误的文章类型", 0)
 	NewsNotExist    = New("文章不存在", 0)
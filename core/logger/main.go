@@ -8,8 +8,10 @@ import (
 )
 
 var (
-	Info  = log.Info
-	Infof = log.Infof
+	Info   = log.Info
+	Infof  = log.Infof
+	Error  = log.Error
+	Errorf = log.Errorf
 )
 
 func init() {
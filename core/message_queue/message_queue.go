@@ -17,10 +17,15 @@ type Topic string
 type Chanel string
 
 var (
-	TopicSendEmail  Topic       = "send_email"
-	ChanelSendEmail Chanel      = "send_email"
-	Address         string      // 消息队列地址
-	Config          *nsq.Config // 消息队列的配置
+	TopicSendEmail      Topic       = "send_email"
+	ChanelSendEmail     Chanel      = "send_email"
+	TopicSendStatement  Topic       = "send_statement" // 生成对账单并发送到用户的邮箱
+	ChanelSendStatement Chanel      = "send_statement"
+	TopicSendTemplate   Topic       = "send_template" // 延迟发送的模版消息, 用户处于免打扰时段时使用
+	ChanelSendTemplate  Chanel      = "send_template"
+	TopicReconcileAlert Topic       = "reconcile_alert" // 对账发现问题时的告警, 由运维系统订阅
+	Address             string                          // 消息队列地址
+	Config              *nsq.Config                     // 消息队列的配置
 )
 
 type SendActivationEmailBody struct {
@@ -42,11 +47,13 @@ func init() {
 	Config.HeartbeatInterval = time.Second * 10
 }
 
-func RunMessageQueueConsumer() (*nsq.Consumer, error) {
+func RunMessageQueueConsumer() ([]*nsq.Consumer, error) {
 	wg := &sync.WaitGroup{}
 
 	wg.Add(1)
 
+	consumers := make([]*nsq.Consumer, 0)
+
 	c, err := CreateConsumer(TopicSendEmail, ChanelSendEmail, nsq.HandlerFunc(func(message *nsq.Message) error {
 
 		body := SendActivationEmailBody{}
@@ -57,6 +64,7 @@ func RunMessageQueueConsumer() (*nsq.Consumer, error) {
 
 		mailer := email.NewMailer()
 
+		// 激活邮件属于安全类通知, 总是发送, 不受通知偏好的影响
 		// 发送邮件
 		if err := mailer.SendActivationEmail(body.Email, body.Code); err != nil {
 			// 邮件没发出去的话，删除 redis 的 key
@@ -69,10 +77,24 @@ func RunMessageQueueConsumer() (*nsq.Consumer, error) {
 	}))
 
 	if err != nil {
-		return c, err
+		return consumers, err
+	}
+
+	consumers = append(consumers, c)
+
+	if c, err = CreateConsumer(TopicSendStatement, ChanelSendStatement, nsq.HandlerFunc(handleSendStatement)); err != nil {
+		return consumers, err
 	}
 
+	consumers = append(consumers, c)
+
+	if c, err = CreateConsumer(TopicSendTemplate, ChanelSendTemplate, nsq.HandlerFunc(handleSendTemplate)); err != nil {
+		return consumers, err
+	}
+
+	consumers = append(consumers, c)
+
 	wg.Wait()
 
-	return c, nil
+	return consumers, nil
 }
@@ -4,10 +4,13 @@ package message_queue
 import (
 	"errors"
 	"github.com/nsqio/go-nsq"
+	"time"
 )
 
 var (
 	producer *nsq.Producer
+	// 延迟消息的最大延迟时间, nsqd 默认不接受超过 1 小时的延迟
+	MaxDeferDelay = time.Hour
 )
 
 func init() {
@@ -24,24 +27,30 @@ func createProducer() (err error) {
 	return
 }
 
-// 发布消息
-func Publish(topic Topic, message []byte) (err error) {
+// 确保生产者的链接可用
+func ping() error {
 	var (
 		maxConnectTimes = 5
 		connectTimes    = 0
 	)
 
-	// 确保链接可用
 	for {
 		if producer.Ping() == nil {
-			break
+			return nil
 		}
 		if connectTimes >= maxConnectTimes {
-			err = errors.New("publish timeout")
-			return
+			return errors.New("publish timeout")
 		}
 		connectTimes = connectTimes + 1
 	}
+}
+
+// 发布消息
+func Publish(topic Topic, message []byte) (err error) {
+	// 确保链接可用
+	if err = ping(); err != nil {
+		return
+	}
 
 	//不能发布空串，否则会导致 error
 	if len(message) == 0 {
@@ -55,3 +64,25 @@ func Publish(topic Topic, message []byte) (err error) {
 
 	return
 }
+
+// 发布延迟消息, 超过 MaxDeferDelay 的延迟会被截断, 消费者需要自行判断是否再次延迟
+func PublishDeferred(topic Topic, delay time.Duration, message []byte) (err error) {
+	if err = ping(); err != nil {
+		return
+	}
+
+	if len(message) == 0 {
+		err = errors.New("message can not be empty")
+		return
+	}
+
+	if delay > MaxDeferDelay {
+		delay = MaxDeferDelay
+	}
+
+	if err = producer.DeferredPublish(string(topic), delay, message); err != nil {
+		return
+	}
+
+	return
+}
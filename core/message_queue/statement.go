@@ -0,0 +1,85 @@
+// Copyright 2019 Axetroy. All rights reserved. MIT license.
+package message_queue
+
+import (
+	"encoding/json"
+	"log"
+	"time"
+
+	"github.com/axetroy/go-server/core/exception"
+	"github.com/axetroy/go-server/core/model"
+	"github.com/axetroy/go-server/core/service/database"
+	"github.com/axetroy/go-server/core/service/message_template"
+	"github.com/axetroy/go-server/core/service/preference"
+	"github.com/axetroy/go-server/core/service/statement"
+	"github.com/nsqio/go-nsq"
+)
+
+type SendStatementBody struct {
+	Id     string           `json:"id"`     // 月度对账单的记录 ID, 用户主动申请的对账单为空
+	Uid    string           `json:"uid"`    // 用户 ID
+	Period string           `json:"period"` // 对账单的月份, 例如 2019-08
+	Format statement.Format `json:"format"` // 对账单的格式
+}
+
+// 发布生成对账单的消息
+func PublishSendStatement(body SendStatementBody) error {
+	b, err := json.Marshal(body)
+
+	if err != nil {
+		return err
+	}
+
+	return Publish(TopicSendStatement, b)
+}
+
+func handleSendStatement(message *nsq.Message) error {
+	body := SendStatementBody{}
+
+	if err := json.Unmarshal(message.Body, &body); err != nil {
+		return err
+	}
+
+	// 月度对账单需要遵守用户的通知偏好, 用户主动申请的对账单总是发送
+	if body.Id != "" {
+		until, err := preference.Check(database.Db, body.Uid, preference.CategoryTransfers, message_template.ChannelEmail)
+
+		switch err {
+		case nil:
+		case exception.NotificationPreferenceQuiet:
+			// 免打扰时段结束后再发送
+			return PublishDeferred(TopicSendStatement, time.Until(until), message.Body)
+		case exception.NotificationPreferenceDisabled:
+			return database.Db.Model(&model.Statement{Id: body.Id}).Update("status", model.StatementStatusSkipped).Error
+		default:
+			return err
+		}
+	}
+
+	err := statement.Deliver(body.Uid, body.Period, body.Format)
+
+	// 邮件发送失败的话, 交给消息队列重试, 直到达到最大的重试次数
+	if err == exception.SendEmailFail && message.Attempts < Config.MaxAttempts {
+		return err
+	}
+
+	if err != nil {
+		log.Printf("发送 %s 的对账单到用户 %s 失败: %s\n", body.Period, body.Uid, err)
+	}
+
+	if body.Id == "" {
+		return nil
+	}
+
+	updated := map[string]interface{}{
+		"status": model.StatementStatusSent,
+	}
+
+	if err != nil {
+		updated["status"] = model.StatementStatusFailed
+	} else {
+		updated["sent_at"] = time.Now()
+	}
+
+	return database.Db.Model(&model.Statement{Id: body.Id}).Updates(updated).Error
+}
@@ -0,0 +1,101 @@
+// Copyright 2019 Axetroy. All rights reserved. MIT license.
+package message_queue
+
+import (
+	"encoding/json"
+	"log"
+	"time"
+
+	"github.com/axetroy/go-server/core/exception"
+	"github.com/axetroy/go-server/core/model"
+	"github.com/axetroy/go-server/core/service/database"
+	"github.com/axetroy/go-server/core/service/email"
+	"github.com/axetroy/go-server/core/service/message_template"
+	"github.com/axetroy/go-server/core/service/preference"
+	"github.com/axetroy/go-server/core/service/telephone"
+	"github.com/nsqio/go-nsq"
+)
+
+// 使用模版发送的邮件或者短信, 用户处于免打扰时段时延迟到时段结束后发送
+type SendTemplateBody struct {
+	Uid           string                   `json:"uid"`             // 用户 ID
+	Category      preference.Category      `json:"category"`        // 通知的分类
+	Channel       message_template.Channel `json:"channel"`         // 发送渠道, email 或者 sms
+	Subject       string                   `json:"subject"`         // 渲染后的标题
+	Body          string                   `json:"body"`            // 渲染后的内容
+	HTML          bool                     `json:"html"`            // 内容是否为 HTML
+	SmsTemplateId string                   `json:"sms_template_id"` // 短信服务商的模版ID
+	SmsParams     map[string]string        `json:"sms_params"`      // 短信服务商的模版参数
+}
+
+// 发布延迟发送的模版消息
+func PublishSendTemplate(body SendTemplateBody, delay time.Duration) error {
+	b, err := json.Marshal(body)
+
+	if err != nil {
+		return err
+	}
+
+	return PublishDeferred(TopicSendTemplate, delay, b)
+}
+
+func handleSendTemplate(message *nsq.Message) error {
+	body := SendTemplateBody{}
+
+	if err := json.Unmarshal(message.Body, &body); err != nil {
+		return err
+	}
+
+	// 延迟期间用户可能修改了通知偏好, 发送前重新检查
+	until, err := preference.Check(database.Db, body.Uid, body.Category, body.Channel)
+
+	switch err {
+	case nil:
+	case exception.NotificationPreferenceQuiet:
+		// 延迟时间超过了消息队列的上限, 还没有到免打扰时段的结束时间
+		return PublishDeferred(TopicSendTemplate, time.Until(until), message.Body)
+	case exception.NotificationPreferenceDisabled:
+		return nil
+	default:
+		return err
+	}
+
+	userInfo := model.User{}
+
+	if err = database.Db.Where("id = ?", body.Uid).First(&userInfo).Error; err != nil {
+		log.Printf("发送模版消息到用户 %s 失败: %s\n", body.Uid, err)
+		return nil
+	}
+
+	switch body.Channel {
+	case message_template.ChannelEmail:
+		if userInfo.Email == nil {
+			err = exception.MessageTemplateRequireEmail
+			break
+		}
+
+		err = email.NewMailer().SendRendered(*userInfo.Email, message_template.Rendered{
+			Subject: body.Subject,
+			Body:    body.Body,
+			HTML:    body.HTML,
+		})
+	case message_template.ChannelSms:
+		if userInfo.Phone == nil {
+			err = exception.MessageTemplateRequirePhone
+			break
+		}
+
+		err = telephone.GetClient().SendTemplate(*userInfo.Phone, body.SmsTemplateId, body.SmsParams)
+	}
+
+	// 发送失败的话, 交给消息队列重试, 直到达到最大的重试次数
+	if err != nil && message.Attempts < Config.MaxAttempts {
+		return err
+	}
+
+	if err != nil {
+		log.Printf("发送模版消息到用户 %s 失败: %s\n", body.Uid, err)
+	}
+
+	return nil
+}
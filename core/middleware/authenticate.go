@@ -10,7 +10,8 @@ import (
 )
 
 var (
-	ContextUidField = "uid"
+	ContextUidField    = "uid"
+	ContextClaimsField = "claims" // token 解析后的内容
 )
 
 // Token 验证中间件
@@ -34,7 +35,6 @@ func Authenticate(isAdmin bool) gin.HandlerFunc {
 
 		if s, isExist := c.GetQuery(token.AuthField); isExist == true {
 			tokenString = s
-			return
 		} else {
 			tokenString = c.GetHeader(token.AuthField)
 
@@ -54,8 +54,19 @@ func Authenticate(isAdmin bool) gin.HandlerFunc {
 			status = exception.InvalidToken.Code()
 			return
 		} else {
+			// 检查 token 是否已经被吊销, 例如用户已登出
+			if revoked, er := token.IsRevoked(claims); er != nil {
+				err = er
+				return
+			} else if revoked {
+				err = exception.TokenRevoked
+				status = exception.TokenRevoked.Code()
+				return
+			}
+
 			// 把 UID 挂载到上下文中国呢
 			c.Set(ContextUidField, claims.Uid)
+			c.Set(ContextClaimsField, claims)
 		}
 	}
 }
@@ -7,6 +7,7 @@ import (
 	"github.com/axetroy/go-server/core/model"
 	"github.com/axetroy/go-server/core/schema"
 	"github.com/axetroy/go-server/core/service/database"
+	"github.com/axetroy/go-server/core/service/lockout"
 	"github.com/axetroy/go-server/core/util"
 	"github.com/gin-gonic/gin"
 	"github.com/jinzhu/gorm"
@@ -14,8 +15,9 @@ import (
 )
 
 var (
-	PayPasswordHeader = "X-Pay-Password"
-	SignatureHeader   = "X-Signature"
+	PayPasswordHeader    = "X-Pay-Password"
+	SignatureHeader      = "X-Signature"
+	IdempotencyKeyHeader = "Idempotency-Key" // 幂等键, 防止重试的请求被重复处理
 )
 
 // 交易密码的验证中间件
@@ -65,6 +67,11 @@ func AuthPayPassword(c *gin.Context) {
 		return
 	}
 
+	// 失败次数过多，账号或 IP 已被锁定
+	if err = lockout.Check(lockout.ScopePayPassword, uid, c.ClientIP()); err != nil {
+		return
+	}
+
 	userInfo := model.User{Id: uid}
 
 	if err = database.Db.Where(&userInfo).Last(&userInfo).Error; err != nil {
@@ -80,9 +87,32 @@ func AuthPayPassword(c *gin.Context) {
 	}
 
 	// 校验密码是否正确
-	if *userInfo.PayPassword != util.GeneratePassword(payPassword) {
+	if !util.VerifyPassword(payPassword, *userInfo.PayPassword) {
+		_, _ = lockout.Fail(lockout.ScopePayPassword, uid, c.ClientIP())
+
+		// 失败记录只是为了审计，写入失败也不影响接口的返回
+		_ = database.Db.Create(&model.LoginLog{
+			Uid:     uid,
+			Type:    model.LoginLogTypePayPassword,
+			Command: model.LoginLogCommandLoginFail,
+			Client:  c.Request.UserAgent(),
+			LastIp:  c.ClientIP(),
+			Reason:  "交易密码错误",
+		}).Error
+
 		err = exception.InvalidPassword
 		return
 	}
 
+	if err = lockout.Success(lockout.ScopePayPassword, uid); err != nil {
+		return
+	}
+
+	// 旧的密码哈希, 校验成功后自动升级
+	if util.PasswordNeedsRehash(*userInfo.PayPassword) {
+		if err = database.Db.Model(&userInfo).Update("pay_password", util.GeneratePassword(payPassword)).Error; err != nil {
+			return
+		}
+	}
+
 }
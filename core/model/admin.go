@@ -2,6 +2,7 @@
 package model
 
 import (
+	"github.com/axetroy/go-server/core/exception"
 	"github.com/axetroy/go-server/core/util"
 	"github.com/jinzhu/gorm"
 	"github.com/lib/pq"
@@ -20,7 +21,7 @@ type Admin struct {
 	Id        string         `gorm:"primary_key;not null;unique;index" json:"id"`            // 用户ID
 	Username  string         `gorm:"not null;unique;index;type:varchar(36)" json:"username"` // 用户名, 用于登陆
 	Name      string         `gorm:"not null;index;type:varchar(36)" json:"Name"`            // 管理员名
-	Password  string         `gorm:"not null;type:varchar(36)" json:"password"`              // 登陆密码
+	Password  string         `gorm:"not null;type:varchar(255)" json:"password"`             // 登陆密码
 	Accession pq.StringArray `gorm:"not null;type:varchar(64)[]" json:"accession"`           // 管理员的权限, 超级管理员不依赖于这个字段
 	IsSuper   bool           `gorm:"not null;" json:"is_super"`                              // 是否是超级管理员, 超级管理员全站应该只有一个
 	Status    AdminStatus    `gorm:"not null;" json:"status"`                                // 状态
@@ -36,3 +37,16 @@ func (news *Admin) TableName() string {
 func (news *Admin) BeforeCreate(scope *gorm.Scope) error {
 	return scope.SetColumn("id", util.GenerateId())
 }
+
+// 检查管理员状态是否正常
+func (a *Admin) CheckStatusValid() error {
+	switch a.Status {
+	case AdminStatusInit:
+		return nil
+	case AdminStatusBanned:
+		return exception.AdminHaveBeenBan
+	default:
+		// 未激活或者其他非正常的状态
+		return exception.AdminIsInActive
+	}
+}
@@ -0,0 +1,52 @@
+// Copyright 2019 Axetroy. All rights reserved. MIT license.
+package model
+
+import (
+	"github.com/axetroy/go-server/core/util"
+	"github.com/jinzhu/gorm"
+	"strings"
+	"time"
+)
+
+var (
+	MaxCurrencyPrecision = 8 // 币种允许的最大小数位数, 与金额展示的位数一致
+
+	// 默认的币种, 第一次启动时写入币种表
+	DefaultCurrencies = []Currency{
+		{Code: WalletCNY, Name: "人民币", Precision: 2, Enabled: true},
+		{Code: WalletUSD, Name: "美元", Precision: 2, Enabled: true},
+		{Code: WalletCOIN, Name: "积分", Precision: 8, Enabled: true},
+	}
+)
+
+// 币种
+// 所有币种的钱包, 转账记录, 财务日志都保存在同一张表中, 以 currency 字段区分
+type Currency struct {
+	Code        string       `gorm:"primary_key;unique;not null;index;type:varchar(16)" json:"code"` // 币种代码, 大写, 例如 CNY
+	Name        string       `gorm:"not null;type:varchar(32)" json:"name"`                          // 币种名称
+	Precision   int          `gorm:"not null" json:"precision"`                                      // 允许的小数位数
+	Enabled     bool         `gorm:"not null;index" json:"enabled"`                                  // 是否启用, 停用后不能转账, 但是可以查询
+	TransferMin util.Decimal `gorm:"not null;type:numeric" json:"transfer_min"`                      // 单笔转账的最小金额, 0 则不限制
+	TransferMax util.Decimal `gorm:"not null;type:numeric" json:"transfer_max"`                      // 单笔转账的最大金额, 0 则不限制
+	CreatedAt   time.Time
+	UpdatedAt   time.Time
+}
+
+func (news *Currency) TableName() string {
+	return "currency"
+}
+
+// 某个币种的钱包
+func WalletOf(db *gorm.DB, code string) *gorm.DB {
+	return db.Model(&Wallet{}).Where("currency = ?", strings.ToUpper(code))
+}
+
+// 某个币种的转账记录
+func TransferLogOf(db *gorm.DB, code string) *gorm.DB {
+	return db.Model(&TransferLog{}).Where("currency = ?", strings.ToUpper(code))
+}
+
+// 某个币种的财务日志
+func FinanceLogOf(db *gorm.DB, code string) *gorm.DB {
+	return db.Model(&FinanceLog{}).Where("currency = ?", strings.ToUpper(code))
+}
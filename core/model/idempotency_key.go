@@ -0,0 +1,22 @@
+// Copyright 2019 Axetroy. All rights reserved. MIT license.
+package model
+
+import (
+	"time"
+)
+
+// 幂等键, 记录已经处理过的请求以及当时的结果
+// 同一个用户在同一个场景下, 相同的幂等键只会被处理一次
+type IdempotencyKey struct {
+	Id          int64     `gorm:"primary_key;auto_increment" json:"id"`
+	Scope       string    `gorm:"not null;unique_index:idx_idempotency_key;type:varchar(32)" json:"scope"` // 使用的场景, 例如转账
+	Uid         string    `gorm:"not null;unique_index:idx_idempotency_key;type:varchar(32)" json:"uid"`   // 发起请求的用户
+	Key         string    `gorm:"not null;unique_index:idx_idempotency_key;type:varchar(64)" json:"key"`   // 客户端生成的幂等键
+	RequestHash string    `gorm:"not null;type:varchar(64)" json:"request_hash"`                           // 请求参数的哈希, 用于判断幂等键是否被用于不同的请求
+	Response    string    `gorm:"not null;type:text" json:"response"`                                      // 第一次处理的结果
+	CreatedAt   time.Time `json:"created_at"`
+}
+
+func (news *IdempotencyKey) TableName() string {
+	return "idempotency_key"
+}
@@ -0,0 +1,53 @@
+// Copyright 2019 Axetroy. All rights reserved. MIT license.
+package model
+
+import (
+	"github.com/axetroy/go-server/core/util"
+	"github.com/jinzhu/gorm"
+	"time"
+)
+
+// 邀请奖励的规则
+// 被邀请人达到某个状态时, 分别给邀请人和被邀请人发放奖励
+// 同一个状态可以配置多个币种的奖励
+type InviteRewardRule struct {
+	Id            string       `gorm:"primary_key;unique;not null;index;type:varchar(32)" json:"id"`                  // 规则ID
+	Status        InviteStatus `gorm:"not null;unique_index:idx_invite_reward_rule" json:"status"`                    // 被邀请人达到的状态
+	Currency      string       `gorm:"not null;unique_index:idx_invite_reward_rule;type:varchar(16)" json:"currency"` // 奖励的币种
+	InviterAmount util.Decimal `gorm:"not null;type:numeric" json:"inviter_amount"`                                   // 邀请人的奖励, 0 则不发放
+	InviteeAmount util.Decimal `gorm:"not null;type:numeric" json:"invitee_amount"`                                   // 被邀请人的奖励, 0 则不发放
+	CreatedAt     time.Time
+	UpdatedAt     time.Time
+}
+
+func (news *InviteRewardRule) TableName() string {
+	return "invite_reward_rule"
+}
+
+func (news *InviteRewardRule) BeforeCreate(scope *gorm.Scope) error {
+	return scope.SetColumn("id", util.GenerateId())
+}
+
+// 已经发放的邀请奖励
+// 每一条邀请记录的每一个状态, 每一个币种只会发放一次, 由唯一索引保证
+// 发放奖励生成的财务日志的 order_id 即为奖励的 ID
+type InviteReward struct {
+	Id            string       `gorm:"primary_key;unique;not null;index;type:varchar(32)" json:"id"`              // 奖励ID
+	InviteId      string       `gorm:"not null;unique_index:idx_invite_reward;type:varchar(32)" json:"invite_id"` // 对应的邀请记录
+	Status        InviteStatus `gorm:"not null;unique_index:idx_invite_reward" json:"status"`                     // 被邀请人达到的状态
+	Currency      string       `gorm:"not null;unique_index:idx_invite_reward;type:varchar(16)" json:"currency"`  // 奖励的币种
+	Inviter       string       `gorm:"not null;index;type:varchar(32)" json:"inviter"`                            // 邀请人
+	Invitee       string       `gorm:"not null;index;type:varchar(32)" json:"invitee"`                            // 被邀请人
+	InviterAmount util.Decimal `gorm:"not null;type:numeric" json:"inviter_amount"`                               // 邀请人的奖励
+	InviteeAmount util.Decimal `gorm:"not null;type:numeric" json:"invitee_amount"`                               // 被邀请人的奖励
+	CreatedAt     time.Time
+	UpdatedAt     time.Time
+}
+
+func (news *InviteReward) TableName() string {
+	return "invite_reward"
+}
+
+func (news *InviteReward) BeforeCreate(scope *gorm.Scope) error {
+	return scope.SetColumn("id", util.GenerateId())
+}
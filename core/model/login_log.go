@@ -11,15 +11,20 @@ type LoginLogType int
 type LoginLogCommand int
 
 const (
-	LoginLogTypeUserName         LoginLogType    = 0 // 用户名登陆
-	LoginLogTypeTel                                  // 手机登陆
-	LoginLogTypeEmail                                // 邮箱登陆
-	LoginLogTypeThird                                // 第三方登陆
-	LoginLogTypeWechat                               // 微信登陆
-	LoginLogCommandLoginSuccess  LoginLogCommand = 0 // 登陆成功
-	LoginLogCommandLogoutSuccess                     // 登出成功
-	LoginLogCommandLoginFail                         // 登陆失败
-	LoginLogCommandLogoutFail                        // 登出失败
+	LoginLogTypeUserName    LoginLogType = iota // 用户名登陆
+	LoginLogTypeTel                             // 手机登陆
+	LoginLogTypeEmail                           // 邮箱登陆
+	LoginLogTypeThird                           // 第三方登陆
+	LoginLogTypeWechat                          // 微信登陆
+	LoginLogTypeAdmin                           // 管理员登陆, uid 为管理员 ID
+	LoginLogTypePayPassword                     // 交易密码验证
+)
+
+const (
+	LoginLogCommandLoginSuccess  LoginLogCommand = iota // 登陆成功
+	LoginLogCommandLogoutSuccess                        // 登出成功
+	LoginLogCommandLoginFail                            // 登陆失败
+	LoginLogCommandLogoutFail                           // 登出失败
 )
 
 type LoginLog struct {
@@ -30,6 +35,7 @@ type LoginLog struct {
 	Command   LoginLogCommand `gorm:"not null;type:int" json:"command"`                      // 登陆的状态(成功, 失败)
 	LastIp    string          `gorm:"not null;type:varchar(15)" json:"last_ip"`              // 本次登陆IP
 	Client    string          `gorm:"not null;type:varchar(255)" json:"client"`              // 登陆的客户端
+	Reason    string          `gorm:"not null;type:varchar(255)" json:"reason"`              // 登陆失败的原因
 	CreatedAt time.Time
 	UpdatedAt time.Time
 	DeletedAt *time.Time `sql:"index"`
@@ -0,0 +1,33 @@
+// Copyright 2019 Axetroy. All rights reserved. MIT license.
+package model
+
+import (
+	"github.com/axetroy/go-server/core/util"
+	"github.com/jinzhu/gorm"
+	"time"
+)
+
+// 消息模版
+// 同一个名称的模版可以有多个语言版本, 名称和语言联合唯一
+type MessageTemplate struct {
+	Id            string  `gorm:"primary_key;unique;not null;index;type:varchar(32)" json:"id"`              // 模版ID
+	Name          string  `gorm:"not null;unique_index:idx_message_template;type:varchar(64)" json:"name"`   // 模版名称, 例如 activation
+	Locale        string  `gorm:"not null;unique_index:idx_message_template;type:varchar(16)" json:"locale"` // 模版的语言, 例如 zh-CN
+	Subject       string  `gorm:"not null;type:varchar(255)" json:"subject"`                                 // 标题, 使用 text/template 语法
+	Format        string  `gorm:"not null;type:varchar(8)" json:"format"`                                    // 内容的格式, text 使用 text/template 渲染, html 使用 html/template 渲染
+	Body          string  `gorm:"not null;type:text" json:"body"`                                            // 模版内容
+	Variables     string  `gorm:"not null;type:text" json:"variables"`                                       // 模版变量的声明, JSON 数组
+	Category      string  `gorm:"not null;default:'system';type:varchar(16)" json:"category"`                // 通知的分类, 用户可以按分类关闭通知
+	SmsTemplateId *string `gorm:"null;type:varchar(64)" json:"sms_template_id"`                              // 短信服务商的模版ID, 为空则不能通过短信发送
+	Note          *string `gorm:"null;type:varchar(255)" json:"note"`                                        // 备注
+	CreatedAt     time.Time
+	UpdatedAt     time.Time
+}
+
+func (news *MessageTemplate) TableName() string {
+	return "message_template"
+}
+
+func (news *MessageTemplate) BeforeCreate(scope *gorm.Scope) error {
+	return scope.SetColumn("id", util.GenerateId())
+}
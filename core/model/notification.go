@@ -4,6 +4,7 @@ package model
 import (
 	"github.com/axetroy/go-server/core/util"
 	"github.com/jinzhu/gorm"
+	"github.com/lib/pq"
 	"time"
 )
 
@@ -12,25 +13,44 @@ type NotificationStatus int
 const (
 	NotificationStatusInActive NotificationStatus = -1 // 未启用的状态
 	NotificationStatusActive   NotificationStatus = 0  // 启用的状态
+	NotificationStatusPending  NotificationStatus = 1  // 等待发布的状态
+	NotificationStatusExpired  NotificationStatus = 2  // 已过期的状态
 )
 
+type NotificationAudience string
+
+const (
+	NotificationAudienceAll   NotificationAudience = "all"   // 所有用户
+	NotificationAudienceRole  NotificationAudience = "role"  // 拥有指定角色的用户
+	NotificationAudienceLevel NotificationAudience = "level" // 指定等级的用户
+	NotificationAudienceUser  NotificationAudience = "user"  // 指定的用户
+)
+
+var NotificationAudiences = []NotificationAudience{NotificationAudienceAll, NotificationAudienceRole, NotificationAudienceLevel, NotificationAudienceUser}
+
 type Notification struct {
-	Id        string             `gorm:"primary_key;not null;unique;index;type:varchar(32)" json:"id"` // 通知ID
-	Author    string             `gorm:"not null;index;type:varchar(32)" json:"Author"`                // 发布这则公告的作者
-	Title     string             `gorm:"not null;index;type:varchar(32)" json:"title"`                 // 公告标题
-	Content   string             `gorm:"not null;type:text" json:"content"`                            // 公告内容
-	Status    NotificationStatus `gorm:"not null" json:"status"`                                       // 公告状态
-	Note      *string            `gorm:"null;type:varchar(255)" json:"note"`                           // 这条通知的备注
+	Id        string               `gorm:"primary_key;not null;unique;index;type:varchar(32)" json:"id"`  // 通知ID
+	Author    string               `gorm:"not null;index;type:varchar(32)" json:"Author"`                 // 发布这则公告的作者
+	Title     string               `gorm:"not null;index;type:varchar(32)" json:"title"`                  // 公告标题
+	Content   string               `gorm:"not null;type:text" json:"content"`                             // 公告内容
+	Status    NotificationStatus   `gorm:"not null" json:"status"`                                        // 公告状态
+	Note      *string              `gorm:"null;type:varchar(255)" json:"note"`                            // 这条通知的备注
+	Audience  NotificationAudience `gorm:"not null;default:'all';index;type:varchar(16)" json:"audience"` // 通知的受众
+	Roles     pq.StringArray       `gorm:"type:varchar(36)[]" json:"roles"`                               // 受众为 role 时, 拥有其中任一角色的用户可见
+	Levels    pq.Int64Array        `gorm:"type:integer[]" json:"levels"`                                  // 受众为 level 时, 等级在其中的用户可见
+	Uids      pq.StringArray       `gorm:"type:varchar(32)[]" json:"uids"`                                // 受众为 user 时, 可见的用户 ID 列表
+	PublishAt *time.Time           `gorm:"null;index" json:"publish_at"`                                  // 定时发布的时间, 为空则立即发布
+	ExpireAt  *time.Time           `gorm:"null;index" json:"expire_at"`                                   // 过期时间, 为空则永不过期
 	CreatedAt time.Time
 	UpdatedAt time.Time
 	DeletedAt *time.Time `sql:"index"`
 }
 
 type NotificationMark struct {
-	Id           string       `gorm:"primary_key;not null;unique(uid);index;type:varchar(32)" json:"id"` // 通知ID, 通知 ID 和 UID 为联合唯一
-	Uid          string       `gorm:"not null;index;unique(id);type:varchar(32)" json:"uid"`             // 对应的用户ID, 联合通知ID唯一
-	Read         bool         `gorm:"not null" json:"read"`                                              // 是否已读
-	Notification Notification `gorm:"foreign_key:Id;association_foreign_key:Id" json:"notification"`     // 关联外键
+	Id           string       `gorm:"primary_key;not null;index;type:varchar(32)" json:"id"`         // 通知ID, 通知 ID 和 UID 为联合主键
+	Uid          string       `gorm:"primary_key;not null;index;type:varchar(32)" json:"uid"`        // 对应的用户ID
+	Read         bool         `gorm:"not null" json:"read"`                                          // 是否已读
+	Notification Notification `gorm:"foreign_key:Id;association_foreign_key:Id" json:"notification"` // 关联外键
 	CreatedAt    time.Time
 	UpdatedAt    time.Time
 	DeletedAt    *time.Time `sql:"index"`
@@ -44,13 +64,29 @@ func (news *Notification) BeforeCreate(scope *gorm.Scope) error {
 	if err := scope.SetColumn("id", util.GenerateId()); err != nil {
 		return err
 	}
-	// 默认启用通知的状态
-	if err := scope.SetColumn("status", NotificationStatusActive); err != nil {
+	// 根据发布时间和过期时间决定通知的状态
+	if err := scope.SetColumn("status", news.Schedule(time.Now())); err != nil {
 		return err
 	}
+	if news.Audience == "" {
+		if err := scope.SetColumn("audience", NotificationAudienceAll); err != nil {
+			return err
+		}
+	}
 	return nil
 }
 
+// 根据发布时间和过期时间计算通知在 now 时刻应该处于的状态
+func (news *Notification) Schedule(now time.Time) NotificationStatus {
+	if news.ExpireAt != nil && !news.ExpireAt.After(now) {
+		return NotificationStatusExpired
+	}
+	if news.PublishAt != nil && news.PublishAt.After(now) {
+		return NotificationStatusPending
+	}
+	return NotificationStatusActive
+}
+
 func (news *NotificationMark) TableName() string {
 	return "notification_mark"
 }
@@ -0,0 +1,22 @@
+// Copyright 2019 Axetroy. All rights reserved. MIT license.
+package model
+
+import (
+	"github.com/lib/pq"
+	"time"
+)
+
+// 用户的通知偏好, 没有记录的用户使用默认的偏好: 所有通知都开启, 没有免打扰时段
+type NotificationPreference struct {
+	Uid        string         `gorm:"primary_key;unique;not null;index;type:varchar(32)" json:"uid"` // 用户ID
+	Disabled   pq.StringArray `gorm:"not null;type:varchar(32)[]" json:"disabled"`                   // 关闭的通知, 格式为 分类:渠道, 例如 marketing:email
+	QuietStart *string        `gorm:"null;type:varchar(5)" json:"quiet_start"`                       // 免打扰时段的开始时间, 格式为 HH:MM
+	QuietEnd   *string        `gorm:"null;type:varchar(5)" json:"quiet_end"`                         // 免打扰时段的结束时间, 早于开始时间则跨过零点
+	Timezone   string         `gorm:"not null;type:varchar(64)" json:"timezone"`                     // 用户的时区, 例如 Asia/Shanghai
+	CreatedAt  time.Time
+	UpdatedAt  time.Time
+}
+
+func (news *NotificationPreference) TableName() string {
+	return "notification_preference"
+}
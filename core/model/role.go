@@ -23,6 +23,7 @@ type Role struct {
 	Name        string         `gorm:"primary_key;unique;not null;index;type:varchar(64)" json:"name"` // 角色名, 作为主建而且唯一
 	Description string         `gorm:"not null;index;type:varchar(64)" json:"description"`             // 角色描述
 	Accession   pq.StringArray `gorm:"not null;index;type:varchar(64)[]" json:"accession"`             // 改角色拥有的权限
+	Parent      pq.StringArray `gorm:"null;type:varchar(64)[]" json:"parent"`                          // 继承的父级角色, 会拥有父级角色的所有权限
 	BuildIn     bool           `gorm:"not null;index;" json:"build_in"`                                // 是否是内建的角色，该角色通常是不可改的
 	Note        *string        `gorm:"null;index;type:varchar(64)" json:"note"`                        // 备注
 	CreatedAt   time.Time
@@ -0,0 +1,38 @@
+// Copyright 2019 Axetroy. All rights reserved. MIT license.
+package model
+
+import (
+	"github.com/axetroy/go-server/core/util"
+	"github.com/jinzhu/gorm"
+	"time"
+)
+
+type StatementStatus int
+
+const (
+	StatementStatusPending StatementStatus = 0 // 已加入发送队列
+	StatementStatusSent    StatementStatus = 1 // 已发送
+	StatementStatusFailed  StatementStatus = 2 // 发送失败
+	StatementStatusSkipped StatementStatus = 3 // 用户关闭了对账单邮件, 没有发送
+)
+
+// 每月自动发送的对账单记录
+// 每个用户每个月只会自动发送一次, 由唯一索引保证. 用户主动申请的对账单不会记录
+type Statement struct {
+	Id        string          `gorm:"primary_key;unique;not null;index;type:varchar(32)" json:"id"`      // 记录ID
+	Uid       string          `gorm:"not null;unique_index:idx_statement;type:varchar(32)" json:"uid"`   // 用户ID
+	Period    string          `gorm:"not null;unique_index:idx_statement;type:varchar(7)" json:"period"` // 对账单的月份, 例如 2019-08
+	Format    string          `gorm:"not null;type:varchar(8)" json:"format"`                            // 对账单的格式, csv 或者 pdf
+	Status    StatementStatus `gorm:"not null;index" json:"status"`                                      // 发送状态
+	SentAt    *time.Time      `gorm:"null" json:"sent_at"`                                               // 发送成功的时间
+	CreatedAt time.Time
+	UpdatedAt time.Time
+}
+
+func (news *Statement) TableName() string {
+	return "statement"
+}
+
+func (news *Statement) BeforeCreate(scope *gorm.Scope) error {
+	return scope.SetColumn("id", util.GenerateId())
+}
@@ -0,0 +1,32 @@
+// Copyright 2019 Axetroy. All rights reserved. MIT license.
+package model
+
+import (
+	"github.com/axetroy/go-server/core/util"
+	"github.com/jinzhu/gorm"
+	"time"
+)
+
+// 转账限额, 按照币种和用户等级配置
+// 用户等级为 0 的限额作为该币种的默认限额, 用户的等级没有单独配置限额时使用
+// 所有的金额和次数为 0 则表示不限制
+type TransferLimit struct {
+	Id            string       `gorm:"primary_key;unique;not null;index;type:varchar(32)" json:"id"`              // 限额ID
+	Currency      string       `gorm:"not null;unique_index:idx_transfer_limit;type:varchar(16)" json:"currency"` // 币种
+	Level         int32        `gorm:"not null;unique_index:idx_transfer_limit" json:"level"`                     // 用户等级, 0 则为默认限额
+	Min           util.Decimal `gorm:"not null;type:numeric" json:"min"`                                          // 单笔转账的最小金额
+	Max           util.Decimal `gorm:"not null;type:numeric" json:"max"`                                          // 单笔转账的最大金额
+	DailyAmount   util.Decimal `gorm:"not null;type:numeric" json:"daily_amount"`                                 // 每天累计转账的最大金额
+	MonthlyAmount util.Decimal `gorm:"not null;type:numeric" json:"monthly_amount"`                               // 每月累计转账的最大金额
+	DailyCount    int          `gorm:"not null" json:"daily_count"`                                               // 每天最多转账的次数
+	CreatedAt     time.Time
+	UpdatedAt     time.Time
+}
+
+func (news *TransferLimit) TableName() string {
+	return "transfer_limit"
+}
+
+func (news *TransferLimit) BeforeCreate(scope *gorm.Scope) error {
+	return scope.SetColumn("id", util.GenerateId())
+}
@@ -2,61 +2,27 @@
 package model
 
 import (
-	"strings"
+	"github.com/axetroy/go-server/core/util"
 	"time"
 )
 
 var (
-	walletTablePrefix = "wallet_"
-	WalletCNY         = "CNY"
-	WalletUSD         = "USD"
-	WalletCOIN        = "COIN"
-	Wallets           = []string{WalletCNY, WalletUSD, WalletCOIN}
-
-	WalletCnyTableName  = walletTablePrefix + strings.ToLower(WalletCNY)  // 人民币表名
-	WalletUsdTableName  = walletTablePrefix + strings.ToLower(WalletUSD)  // 美元表名
-	WalletCoinTableName = walletTablePrefix + strings.ToLower(WalletCOIN) // 积分表名
-
-	WalletTableNames = []string{ // 所有的表名
-		WalletCnyTableName,
-		WalletUsdTableName,
-		WalletCoinTableName,
-	}
+	WalletCNY  = "CNY"  // 人民币
+	WalletUSD  = "USD"  // 美元
+	WalletCOIN = "COIN" // 我们平台自己的币
 )
 
+// 钱包, 每个用户每个币种一个钱包, 以用户 ID 和币种作为联合主键
 type Wallet struct {
-	Id        string  `gorm:"primary_key;unique;notnull;index;type:varchar(32)" json:"id"` // 用户ID
-	Currency  string  `gorm:"not null;type:varchar(12)" json:"currency"`                   // 钱包币种
-	Balance   float64 `gorm:"not null;type:numeric" json:"balance"`                        // 可用余额
-	Frozen    float64 `gorm:"not null;type:numeric" json:"frozen"`                         // 冻结余额
+	Id        string       `gorm:"primary_key;not null;index;type:varchar(32)" json:"id"` // 用户ID
+	Currency  string       `gorm:"primary_key;not null;type:varchar(16)" json:"currency"` // 钱包币种
+	Balance   util.Decimal `gorm:"not null;type:numeric" json:"balance"`                  // 可用余额
+	Frozen    util.Decimal `gorm:"not null;type:numeric" json:"frozen"`                   // 冻结余额
 	CreatedAt time.Time
 	UpdatedAt time.Time
 	DeletedAt *time.Time `sql:"index"`
 }
 
-// 人民币
-type WalletCny struct {
-	Wallet
-}
-
-// 美元
-type WalletUsd struct {
-	Wallet
-}
-
-// 我们平台自己的币
-type WalletCoin struct {
-	Wallet
-}
-
-func (news *WalletCny) TableName() string {
-	return WalletCnyTableName
-}
-
-func (news *WalletUsd) TableName() string {
-	return WalletUsdTableName
-}
-
-func (news *WalletCoin) TableName() string {
-	return WalletCoinTableName
+func (news *Wallet) TableName() string {
+	return "wallet"
 }
@@ -0,0 +1,31 @@
+// Copyright 2019 Axetroy. All rights reserved. MIT license.
+package model
+
+import (
+	"github.com/axetroy/go-server/core/util"
+	"github.com/jinzhu/gorm"
+	"time"
+)
+
+// 管理员对用户钱包的操作记录, 用于审计
+// 每一条操作记录都会生成对应的财务日志, 财务日志的 order_id 即为操作记录的 ID
+type WalletOperation struct {
+	Id        string       `gorm:"primary_key;unique;not null;index;type:varchar(32)" json:"id"` // 操作ID
+	AdminId   string       `gorm:"not null;index;type:varchar(32)" json:"admin_id"`              // 操作的管理员
+	Uid       string       `gorm:"not null;index;type:varchar(32)" json:"uid"`                   // 被操作的用户
+	Currency  string       `gorm:"not null;index;type:varchar(16)" json:"currency"`              // 币种
+	Type      FinanceType  `gorm:"not null;index;type:varchar(32)" json:"type"`                  // 操作类型
+	Amount    util.Decimal `gorm:"not null;type:numeric" json:"amount"`                          // 操作的金额
+	Reason    string       `gorm:"not null;type:varchar(128)" json:"reason"`                     // 操作的原因
+	CreatedAt time.Time
+	UpdatedAt time.Time
+	DeletedAt *time.Time `sql:"index" json:"-"`
+}
+
+func (news *WalletOperation) TableName() string {
+	return "wallet_operation"
+}
+
+func (news *WalletOperation) BeforeCreate(scope *gorm.Scope) error {
+	return scope.SetColumn("id", util.GenerateId())
+}
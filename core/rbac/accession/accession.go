@@ -1,18 +1,66 @@
 // Copyright 2019 Axetroy. All rights reserved. MIT license.
 package accession
 
+import "strings"
+
 type Accession struct {
 	Name        string `json:"name"`        // 权限标识符
 	Description string `json:"description"` // 权限描述
 }
 
+const (
+	Separator = "::" // 权限的分隔符, 例如 news::get
+	Wildcard  = "*"  // 通配符, 例如 news::* 或者 *::get
+)
+
 // 校验一个权限是否是合法的字符串
+// 通配符的权限至少要匹配到一个已有的权限
 func Valid(s []string) bool {
 	for _, v := range s {
-		if _, ok := Map[v]; ok == false {
+		if _, ok := Map[v]; ok == true {
+			continue
+		}
+
+		if !strings.Contains(v, Wildcard) {
+			return false
+		}
+
+		matched := false
+
+		for _, a := range List {
+			if Match(v, a.Name) {
+				matched = true
+				break
+			}
+		}
+
+		if !matched {
+			return false
+		}
+	}
+	return true
+}
+
+// 判断权限是否匹配
+// 按照 :: 分段比较, 每一段的 * 可以匹配任意内容, 单独的 * 匹配所有权限
+func Match(pattern string, name string) bool {
+	if pattern == name || pattern == Wildcard {
+		return true
+	}
+
+	p := strings.Split(pattern, Separator)
+	n := strings.Split(name, Separator)
+
+	if len(p) != len(n) {
+		return false
+	}
+
+	for i, v := range p {
+		if v != Wildcard && v != n[i] {
 			return false
 		}
 	}
+
 	return true
 }
 
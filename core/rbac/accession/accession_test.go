@@ -0,0 +1,31 @@
+// Copyright 2019 Axetroy. All rights reserved. MIT license.
+package accession_test
+
+import (
+	"github.com/axetroy/go-server/core/rbac/accession"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestMatch(t *testing.T) {
+	assert.True(t, accession.Match("news::get", "news::get"))
+	assert.True(t, accession.Match("news::*", "news::get"))
+	assert.True(t, accession.Match("*::get", "news::get"))
+	assert.True(t, accession.Match("*", "news::get"))
+	assert.True(t, accession.Match("*::*", "news::get"))
+
+	assert.False(t, accession.Match("news::get", "news::update"))
+	assert.False(t, accession.Match("news::*", "banner::get"))
+	assert.False(t, accession.Match("*::get", "news::update"))
+	assert.False(t, accession.Match("news", "news::get"))
+	assert.False(t, accession.Match("news::get::*", "news::get"))
+}
+
+func TestValid(t *testing.T) {
+	assert.True(t, accession.Valid([]string{accession.ProfileUpdate.Name}))
+	assert.True(t, accession.Valid([]string{"password2::*", "*::update", "*"}))
+
+	assert.False(t, accession.Valid([]string{"not-exist"}))
+	assert.False(t, accession.Valid([]string{"not-exist::*"}))
+	assert.False(t, accession.Valid([]string{accession.ProfileUpdate.Name, "not-exist"}))
+}
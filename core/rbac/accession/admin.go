@@ -1,27 +1,43 @@
 // Copyright 2019 Axetroy. All rights reserved. MIT license.
 package accession
 
+// 管理员权限, 每一个管理员接口都由其中一个权限控制
+// 超级管理员拥有全部权限
 var (
-	AdminAdminGet    = New("admin::get", "有权限获取横幅信息")
-	AdminAdminCreate = New("admin::create", "有权限创建新横幅")
-	AdminAdminUpdate = New("admin::update", "有权限修改横幅信息")
-	AdminAdminDelete = New("admin::delete", "有权限删除横幅")
+	AdminAdminGet    = New("admin::get", "有权限获取管理员信息")
+	AdminAdminCreate = New("admin::create", "有权限创建新管理员")
+	AdminAdminUpdate = New("admin::update", "有权限修改管理员信息")
+	AdminAdminDelete = New("admin::delete", "有权限删除管理员")
 
 	AdminNewsGet    = New("news::get", "有权限获取新闻")
 	AdminNewsCreate = New("news::create", "有权限创建新闻")
 	AdminNewsUpdate = New("news::update", "有权限修改新闻")
 	AdminNewsDelete = New("news::delete", "有权限删除新闻")
 
-	adminNotificationGet    = New("notification::get", "有权限获取公告")
-	adminNotificationCreate = New("notification::create", "有权限创建公告")
-	adminNotificationUpdate = New("notification::update", "有权限修改公告")
-	adminNotificationDelete = New("notification::delete", "有权限删除公告")
+	AdminNotificationGet    = New("notification::get", "有权限获取公告")
+	AdminNotificationCreate = New("notification::create", "有权限创建公告")
+	AdminNotificationUpdate = New("notification::update", "有权限修改公告")
+	AdminNotificationDelete = New("notification::delete", "有权限删除公告")
+
+	AdminMessageGet    = New("message::get", "有权限获取个人消息")
+	AdminMessageCreate = New("message::create", "有权限创建个人消息")
+	AdminMessageUpdate = New("message::update", "有权限修改个人消息")
+	AdminMessageDelete = New("message::delete", "有权限删除个人消息")
+
+	AdminTemplateGet    = New("template::get", "有权限获取消息模版")
+	AdminTemplateCreate = New("template::create", "有权限创建消息模版")
+	AdminTemplateUpdate = New("template::update", "有权限修改消息模版")
+	AdminTemplateDelete = New("template::delete", "有权限删除消息模版")
+	AdminTemplateSend   = New("template::send", "有权限使用消息模版给用户发送消息")
 
 	AdminUserGet    = New("user::get", "有权限获取用户信息")
 	AdminUserCreate = New("user::create", "有权限创建新用户")
 	AdminUserUpdate = New("user::update", "有权限修改用户信息")
-	AdminUserDelete = New("user::delete", "有权限删除用户")
-	AdminUserExport = New("user::export", "有权限导出用户到CSV等")
+
+	AdminRoleGet    = New("role::get", "有权限获取用户角色")
+	AdminRoleCreate = New("role::create", "有权限创建用户角色")
+	AdminRoleUpdate = New("role::update", "有权限修改用户角色以及分配角色给用户")
+	AdminRoleDelete = New("role::delete", "有权限删除用户角色")
 
 	AdminMenuGet    = New("menu::get", "有权限获取菜单信息")
 	AdminMenuCreate = New("menu::create", "有权限创建新菜单")
@@ -33,9 +49,43 @@ var (
 	AdminBannerUpdate = New("banner::update", "有权限修改横幅信息")
 	AdminBannerDelete = New("banner::delete", "有权限删除横幅")
 
+	AdminHelpGet    = New("help::get", "有权限获取帮助文章")
+	AdminHelpCreate = New("help::create", "有权限创建帮助文章")
+	AdminHelpUpdate = New("help::update", "有权限修改帮助文章")
+	AdminHelpDelete = New("help::delete", "有权限删除帮助文章")
+
 	AdminReportGet    = New("report::get", "有权限获取反馈信息")
 	AdminReportUpdate = New("report::update", "有权限修改反馈信息")
-	AdminReportDelete = New("report::delete", "有权限删除反馈信息")
+
+	AdminLogGet = New("log::get", "有权限获取登陆日志")
+
+	AdminFinanceGet       = New("finance::get", "有权限获取用户的财务日志")
+	AdminFinanceExport    = New("finance::export", "有权限导出财务日志")
+	AdminFinanceReconcile = New("finance::reconcile", "有权限对账")
+
+	AdminWalletGet      = New("wallet::get", "有权限获取用户的钱包以及钱包的操作记录")
+	AdminWalletCredit   = New("wallet::credit", "有权限给用户的钱包加款")
+	AdminWalletDebit    = New("wallet::debit", "有权限从用户的钱包扣款")
+	AdminWalletFreeze   = New("wallet::freeze", "有权限冻结用户钱包的余额")
+	AdminWalletUnfreeze = New("wallet::unfreeze", "有权限解冻用户钱包的余额")
+
+	AdminTransferLimitGet    = New("transfer_limit::get", "有权限获取转账限额")
+	AdminTransferLimitCreate = New("transfer_limit::create", "有权限添加转账限额")
+	AdminTransferLimitUpdate = New("transfer_limit::update", "有权限修改转账限额")
+	AdminTransferLimitDelete = New("transfer_limit::delete", "有权限删除转账限额")
+
+	AdminCurrencyGet    = New("currency::get", "有权限获取币种")
+	AdminCurrencyCreate = New("currency::create", "有权限添加币种")
+	AdminCurrencyUpdate = New("currency::update", "有权限修改币种")
+	AdminCurrencyDelete = New("currency::delete", "有权限删除币种")
+
+	AdminInviteGet    = New("invite::get", "有权限获取邀请记录, 邀请奖励, 奖励规则以及邀请统计")
+	AdminInviteUpdate = New("invite::update", "有权限修改被邀请人的状态")
+	AdminInviteSettle = New("invite::settle", "有权限发放邀请奖励")
+	AdminInviteRule   = New("invite::rule", "有权限修改邀请奖励规则")
+
+	AdminLockGet    = New("lock::get", "有权限获取被锁定的账号和 IP")
+	AdminLockDelete = New("lock::delete", "有权限解除账号和 IP 的锁定")
 
 	// 管理员的所有权限
 	AdminList = []*Accession{
@@ -49,16 +99,30 @@ var (
 		AdminNewsUpdate,
 		AdminNewsDelete,
 
-		adminNotificationGet,
-		adminNotificationUpdate,
-		adminNotificationDelete,
-		adminNotificationCreate,
+		AdminNotificationGet,
+		AdminNotificationCreate,
+		AdminNotificationUpdate,
+		AdminNotificationDelete,
+
+		AdminMessageGet,
+		AdminMessageCreate,
+		AdminMessageUpdate,
+		AdminMessageDelete,
+
+		AdminTemplateGet,
+		AdminTemplateCreate,
+		AdminTemplateUpdate,
+		AdminTemplateDelete,
+		AdminTemplateSend,
 
 		AdminUserGet,
 		AdminUserCreate,
 		AdminUserUpdate,
-		AdminUserDelete,
-		AdminUserExport,
+
+		AdminRoleGet,
+		AdminRoleCreate,
+		AdminRoleUpdate,
+		AdminRoleDelete,
 
 		AdminMenuGet,
 		AdminMenuCreate,
@@ -70,9 +134,43 @@ var (
 		AdminBannerUpdate,
 		AdminBannerDelete,
 
+		AdminHelpGet,
+		AdminHelpCreate,
+		AdminHelpUpdate,
+		AdminHelpDelete,
+
 		AdminReportGet,
 		AdminReportUpdate,
-		AdminReportDelete,
+
+		AdminLogGet,
+
+		AdminFinanceGet,
+		AdminFinanceExport,
+		AdminFinanceReconcile,
+
+		AdminWalletGet,
+		AdminWalletCredit,
+		AdminWalletDebit,
+		AdminWalletFreeze,
+		AdminWalletUnfreeze,
+
+		AdminTransferLimitGet,
+		AdminTransferLimitCreate,
+		AdminTransferLimitUpdate,
+		AdminTransferLimitDelete,
+
+		AdminCurrencyGet,
+		AdminCurrencyCreate,
+		AdminCurrencyUpdate,
+		AdminCurrencyDelete,
+
+		AdminInviteGet,
+		AdminInviteUpdate,
+		AdminInviteSettle,
+		AdminInviteRule,
+
+		AdminLockGet,
+		AdminLockDelete,
 	}
 
 	AdminMap = map[string]*Accession{}
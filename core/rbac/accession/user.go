@@ -5,8 +5,8 @@ var (
 	// 用户类
 	ProfileUpdate   = New("profile::update", "有权限修改用户资料")
 	PasswordUpdate  = New("password::update", "有权限更改自己的密码")
-	Password2Set    = New("password2.set", "有权限设置二级密码")
-	Password2Reset  = New("password2.reset", "有权限重置二级密码")
+	Password2Set    = New("password2::set", "有权限设置二级密码")
+	Password2Reset  = New("password2::reset", "有权限重置二级密码")
 	Password2Update = New("password2::update", "有权限修改二级密码")
 	DoTransfer      = New("transfer::create", "有权限发起转账交易")
 
@@ -15,6 +15,7 @@ var (
 		ProfileUpdate,
 		PasswordUpdate,
 		Password2Set,
+		Password2Reset,
 		Password2Update,
 		DoTransfer,
 	}
@@ -0,0 +1,105 @@
+// Copyright 2019 Axetroy. All rights reserved. MIT license.
+package rbac
+
+import (
+	"github.com/axetroy/go-server/core/exception"
+	"github.com/axetroy/go-server/core/model"
+	"github.com/axetroy/go-server/core/rbac/accession"
+	"github.com/axetroy/go-server/core/schema"
+	"github.com/axetroy/go-server/core/service/database"
+	"github.com/gin-gonic/gin"
+	"github.com/jinzhu/gorm"
+	"net/http"
+)
+
+type AdminController struct {
+	IsSuper   bool
+	Accession []string
+}
+
+func NewAdmin(uid string) (c *AdminController, err error) {
+	adminInfo := model.Admin{
+		Id: uid,
+	}
+
+	if err = database.Db.First(&adminInfo).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			err = exception.AdminNotExist
+		}
+		return
+	}
+
+	// 被禁用、未激活等非正常状态的管理员没有任何权限
+	if err = adminInfo.CheckStatusValid(); err != nil {
+		return
+	}
+
+	c = &AdminController{
+		IsSuper:   adminInfo.IsSuper,
+		Accession: accession.FilterAdminAccession(adminInfo.Accession), // 已经移除的权限不再生效
+	}
+
+	return
+}
+
+// 验证是否有这些权限中的任意一个
+func (c *AdminController) Require(a []accession.Accession) bool {
+	// 超级管理员拥有全部权限
+	if c.IsSuper {
+		return true
+	}
+	for _, v := range a {
+		if c.Has(v) {
+			return true
+		}
+	}
+	return false
+}
+
+// 检验是否拥有单独的权限
+func (c *AdminController) Has(a accession.Accession) bool {
+	if c.IsSuper {
+		return true
+	}
+	for _, v := range c.Accession {
+		if v == a.Name {
+			return true
+		}
+	}
+	return false
+}
+
+// 根据管理员权限鉴权的中间件
+func RequireAdmin(accesions ...accession.Accession) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var (
+			err error
+			uid = c.GetString("uid") // 这个中间件必须安排在JWT的中间件后面, 所以这里是拿的到 UID 的
+			cc  *AdminController
+		)
+
+		defer func() {
+			if err != nil {
+				c.JSON(http.StatusOK, schema.Response{
+					Status:  exception.GetCodeFromError(err),
+					Message: err.Error(),
+					Data:    nil,
+				})
+				c.Abort()
+			}
+		}()
+
+		if uid == "" {
+			err = exception.NoPermission
+			return
+		}
+
+		if cc, err = NewAdmin(uid); err != nil {
+			return
+		}
+
+		if cc.Require(accesions) == false {
+			err = exception.NoPermission
+		}
+	}
+}
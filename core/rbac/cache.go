@@ -0,0 +1,131 @@
+// Copyright 2019 Axetroy. All rights reserved. MIT license.
+package rbac
+
+import (
+	"log"
+	"sync"
+	"time"
+
+	"github.com/axetroy/go-server/core/exception"
+	"github.com/axetroy/go-server/core/service/redis"
+)
+
+// 用户权限的缓存
+// 解析出来的用户角色缓存在进程内，避免每次请求都查询数据库
+// 角色或者用户的角色发生变化时，通过 Redis 的发布/订阅通知所有服务实例清除缓存
+
+var (
+	CacheDuration      = time.Minute * 5   // 缓存的有效期, 即使没有收到失效通知, 过期后也会重新从数据库加载
+	InvalidateChannel  = "rbac:invalidate" // 缓存失效通知的频道
+	invalidateAllToken = "*"               // 通知所有用户的缓存失效
+	cache              = map[string]cacheItem{}
+	cacheLock          sync.RWMutex
+	subscribeOnce      sync.Once
+	generation         uint64    // 每次失效都会递增, 用于丢弃失效之前开始加载的结果
+	nextSweepAt        time.Time // 下一次清理过期缓存的时间
+)
+
+type cacheItem struct {
+	controller *Controller
+	expiredAt  time.Time
+}
+
+// 获取用户的权限，优先从缓存中读取
+func Get(uid string) (c *Controller, err error) {
+	subscribeOnce.Do(subscribe)
+
+	now := time.Now()
+
+	cacheLock.RLock()
+	item, ok := cache[uid]
+	gen := generation
+	cacheLock.RUnlock()
+
+	if ok && now.Before(item.expiredAt) {
+		return item.controller, nil
+	}
+
+	if c, err = New(uid); err != nil {
+		// 没有分配任何角色的用户也缓存起来, 只是没有任何权限
+		if err != exception.NoPermission {
+			return
+		}
+		c, err = &Controller{}, nil
+	}
+
+	cacheLock.Lock()
+	defer cacheLock.Unlock()
+
+	// 加载期间缓存被失效了, 加载的结果可能是旧的权限, 不写入缓存
+	if gen != generation {
+		return
+	}
+
+	sweep(now)
+
+	cache[uid] = cacheItem{
+		controller: c,
+		expiredAt:  now.Add(CacheDuration),
+	}
+
+	return
+}
+
+// 清理过期的缓存, 避免不再访问的用户一直占用内存
+// 调用前需要持有写锁
+func sweep(now time.Time) {
+	if now.Before(nextSweepAt) {
+		return
+	}
+
+	for uid, item := range cache {
+		if !now.Before(item.expiredAt) {
+			delete(cache, uid)
+		}
+	}
+
+	nextSweepAt = now.Add(CacheDuration)
+}
+
+// 使某个用户的权限缓存失效, 例如修改了用户的角色
+func Invalidate(uid string) error {
+	evict(uid)
+	return redis.Client.Publish(InvalidateChannel, uid).Err()
+}
+
+// 使所有用户的权限缓存失效, 例如修改或删除了角色
+func InvalidateAll() error {
+	evict(invalidateAllToken)
+	return redis.Client.Publish(InvalidateChannel, invalidateAllToken).Err()
+}
+
+func evict(uid string) {
+	cacheLock.Lock()
+	defer cacheLock.Unlock()
+
+	generation++
+
+	if uid == invalidateAllToken {
+		cache = map[string]cacheItem{}
+	} else {
+		delete(cache, uid)
+	}
+}
+
+// 订阅其他服务实例发出的失效通知
+func subscribe() {
+	pubsub := redis.Client.Subscribe(InvalidateChannel)
+
+	go func() {
+		defer func() {
+			if r := recover(); r != nil {
+				log.Println("RBAC 缓存失效通知的订阅已退出", r)
+			}
+		}()
+
+		// 连接断开后会自动重连, 在这期间错过的通知则依赖缓存的有效期兜底
+		for msg := range pubsub.Channel() {
+			evict(msg.Payload)
+		}
+	}()
+}
@@ -0,0 +1,46 @@
+// Copyright 2019 Axetroy. All rights reserved. MIT license.
+package rbac_test
+
+import (
+	"github.com/axetroy/go-server/core/controller/auth"
+	"github.com/axetroy/go-server/core/rbac"
+	"github.com/axetroy/go-server/core/rbac/accession"
+	"github.com/axetroy/go-server/tester"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestGet(t *testing.T) {
+	userInfo, err := tester.CreateUser()
+
+	assert.Nil(t, err)
+
+	defer auth.DeleteUserByUserName(userInfo.Username)
+
+	c1, err := rbac.Get(userInfo.Id)
+
+	assert.Nil(t, err)
+	assert.True(t, c1.Has(*accession.ProfileUpdate))
+
+	// 第二次读取的是缓存
+	c2, err := rbac.Get(userInfo.Id)
+
+	assert.Nil(t, err)
+	assert.True(t, c1 == c2)
+
+	// 缓存失效后重新加载
+	assert.Nil(t, rbac.Invalidate(userInfo.Id))
+
+	c3, err := rbac.Get(userInfo.Id)
+
+	assert.Nil(t, err)
+	assert.False(t, c1 == c3)
+	assert.True(t, c3.Has(*accession.ProfileUpdate))
+
+	assert.Nil(t, rbac.InvalidateAll())
+
+	c4, err := rbac.Get(userInfo.Id)
+
+	assert.Nil(t, err)
+	assert.False(t, c3 == c4)
+}
@@ -15,10 +15,13 @@ import (
 
 type Controller struct {
 	Roles []*role.Role
+	paths map[string][]string // 角色的继承路径, key: 角色名, value: 从用户的角色到该角色经过的角色
 }
 
 func New(uid string) (c *Controller, err error) {
-	c = &Controller{}
+	c = &Controller{
+		paths: map[string][]string{},
+	}
 
 	userInfo := model.User{
 		Id: uid,
@@ -33,13 +36,31 @@ func New(uid string) (c *Controller, err error) {
 		return
 	}
 
+	// 按照继承关系广度优先加载角色, 离用户越近的角色越先加载
+	// 已经加载过的角色会跳过, 所以即使数据中存在循环继承也不会死循环
+	queue := make([][]string, 0)
+
 	for _, roleName := range userInfo.Role {
+		queue = append(queue, []string{roleName})
+	}
+
+	for len(queue) > 0 {
+		path := queue[0]
+		queue = queue[1:]
+
+		roleName := path[len(path)-1]
+
+		if _, ok := c.paths[roleName]; ok {
+			continue
+		}
+
 		roleInfo := model.Role{
 			Name: roleName,
 		}
 
 		if err = database.Db.First(&roleInfo).Error; err != nil {
 			if err == gorm.ErrRecordNotFound {
+				err = nil
 				continue
 			}
 			return
@@ -47,7 +68,14 @@ func New(uid string) (c *Controller, err error) {
 
 		r := role.New(roleInfo.Name, roleInfo.Description, accession.Normalize(roleInfo.Accession))
 
+		r.Parent = roleInfo.Parent
+
 		c.Roles = append(c.Roles, r)
+		c.paths[roleName] = path
+
+		for _, parent := range roleInfo.Parent {
+			queue = append(queue, append(append([]string{}, path...), parent))
+		}
 	}
 
 	return c, nil
@@ -63,11 +91,11 @@ func (c *Controller) Require(a []accession.Accession) bool {
 	return false
 }
 
-// 检验是否拥有单独的权限
+// 检验是否拥有单独的权限, 角色中的权限支持通配符
 func (c *Controller) Has(a accession.Accession) bool {
 	for _, r := range c.Roles {
 		for _, v := range r.Accession {
-			if v.Name == a.Name {
+			if accession.Match(v.Name, a.Name) {
 				return true
 			}
 		}
@@ -75,6 +103,25 @@ func (c *Controller) Has(a accession.Accession) bool {
 	return false
 }
 
+// 列出授予该权限的所有角色, 用于审计
+func (c *Controller) Explain(a accession.Accession) (list []schema.AccessionGrant) {
+	list = make([]schema.AccessionGrant, 0)
+
+	for _, r := range c.Roles {
+		for _, v := range r.Accession {
+			if accession.Match(v.Name, a.Name) {
+				list = append(list, schema.AccessionGrant{
+					Role:      r.Name,
+					Accession: v.Name,
+					Path:      c.paths[r.Name],
+				})
+			}
+		}
+	}
+
+	return
+}
+
 // 根据 RBAC 鉴权的中间件
 func Require(accesions ...accession.Accession) gin.HandlerFunc {
 	return func(c *gin.Context) {
@@ -96,9 +143,10 @@ func Require(accesions ...accession.Accession) gin.HandlerFunc {
 
 		if uid == "" {
 			err = exception.NoPermission
+			return
 		}
 
-		if cc, err = New(uid); err != nil {
+		if cc, err = Get(uid); err != nil {
 			return
 		}
 
@@ -9,6 +9,7 @@ type Role struct {
 	Name        string                `json:"name"`        // 角色名
 	Description string                `json:"description"` // 角色描述
 	Accession   []accession.Accession `json:"accession"`   // 角色拥有的权限
+	Parent      []string              `json:"parent"`      // 继承的父级角色
 }
 
 func New(name string, description string, accessions []accession.Accession) *Role {
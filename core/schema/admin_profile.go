@@ -16,7 +16,8 @@ type AdminProfilePure struct {
 
 type AdminProfileWithToken struct {
 	AdminProfile
-	Token string `json:"token"`
+	Token        string `json:"token"`
+	RefreshToken string `json:"refresh_token"` // 刷新令牌，用于换取新的 token
 }
 
 type AdminProfile struct {
@@ -0,0 +1,17 @@
+// Copyright 2019 Axetroy. All rights reserved. MIT license.
+package schema
+
+type CurrencyPure struct {
+	Code        string `json:"code"`         // 币种代码
+	Name        string `json:"name"`         // 币种名称
+	Precision   int    `json:"precision"`    // 允许的小数位数
+	Enabled     bool   `json:"enabled"`      // 是否启用
+	TransferMin string `json:"transfer_min"` // 单笔转账的最小金额, 0 则不限制
+	TransferMax string `json:"transfer_max"` // 单笔转账的最大金额, 0 则不限制
+}
+
+type Currency struct {
+	CurrencyPure
+	CreatedAt string `json:"created_at"`
+	UpdatedAt string `json:"updated_at"`
+}
@@ -0,0 +1,25 @@
+// Copyright 2019 Axetroy. All rights reserved. MIT license.
+package schema
+
+import "github.com/axetroy/go-server/core/model"
+
+type FinanceLogPure struct {
+	Id              string            `json:"id"`               // 流水ID
+	Currency        string            `json:"currency"`         // 对应的币种流水
+	OrderId         string            `json:"order_id"`         // 对应的订单id, 系统产生的流水可能不会orderId
+	Uid             string            `json:"uid"`              // 对应的用户
+	BeforeBalance   string            `json:"before_balance"`   // 这条流水前的余额
+	BalanceMutation string            `json:"balance_mutation"` // 可用余额的变动，正数则为加，负数为减
+	AfterBalance    string            `json:"after_balance"`    // 这条流水后的余额
+	BeforeFrozen    string            `json:"before_frozen"`    // 这条流水前的冻结余额
+	FrozenMutation  string            `json:"frozen_mutation"`  // 冻结余额的变动,正数则为加，负数为减
+	AfterFrozen     string            `json:"after_frozen"`     // 这条流水后的冻结余额
+	Type            model.FinanceType `json:"type"`             // 流水类型
+	Note            *string           `json:"note"`             // 流水备注
+}
+
+type FinanceLog struct {
+	FinanceLogPure
+	CreatedAt string `json:"created_at"`
+	UpdatedAt string `json:"updated_at"`
+}
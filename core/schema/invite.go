@@ -14,3 +14,37 @@ type Invite struct {
 	CreatedAt string `json:"created_at"`
 	UpdatedAt string `json:"updated_at"`
 }
+
+// 邀请关系树中的一个节点
+type InviteNode struct {
+	Invite
+	Level int `json:"level"` // 第几级邀请, 直接邀请的为 1
+}
+
+// 某一级的邀请人数
+type InviteLevelCount struct {
+	Level int `json:"level"` // 第几级邀请
+	Count int `json:"count"` // 人数
+}
+
+// 达到某个状态的人数
+type InviteFunnel struct {
+	Status int `json:"status"` // 被邀请人的状态
+	Count  int `json:"count"`  // 达到该状态的人数, 包括已经达到更高状态的人
+}
+
+// 邀请关系树的统计
+type InviteStats struct {
+	Depth  int                `json:"depth"`  // 统计的层级深度
+	Total  int                `json:"total"`  // 总人数
+	Levels []InviteLevelCount `json:"levels"` // 每一级的人数
+	Funnel []InviteFunnel     `json:"funnel"` // 转化漏斗
+}
+
+// 邀请排行
+type TopInviter struct {
+	Inviter string `json:"inviter"` // 邀请人
+	Total   int    `json:"total"`   // 直接邀请的人数
+	Auth    int    `json:"auth"`    // 其中已实名认证的人数
+	Pay     int    `json:"pay"`     // 其中已完成支付的人数
+}
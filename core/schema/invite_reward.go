@@ -0,0 +1,33 @@
+// Copyright 2019 Axetroy. All rights reserved. MIT license.
+package schema
+
+type InviteRewardRulePure struct {
+	Id            string `json:"id"`             // 规则ID
+	Status        int    `json:"status"`         // 被邀请人达到的状态
+	Currency      string `json:"currency"`       // 奖励的币种
+	InviterAmount string `json:"inviter_amount"` // 邀请人的奖励
+	InviteeAmount string `json:"invitee_amount"` // 被邀请人的奖励
+}
+
+type InviteRewardRule struct {
+	InviteRewardRulePure
+	CreatedAt string `json:"created_at"`
+	UpdatedAt string `json:"updated_at"`
+}
+
+type InviteRewardPure struct {
+	Id            string `json:"id"`             // 奖励ID
+	InviteId      string `json:"invite_id"`      // 对应的邀请记录
+	Status        int    `json:"status"`         // 被邀请人达到的状态
+	Currency      string `json:"currency"`       // 奖励的币种
+	Inviter       string `json:"inviter"`        // 邀请人
+	Invitee       string `json:"invitee"`        // 被邀请人
+	InviterAmount string `json:"inviter_amount"` // 邀请人的奖励
+	InviteeAmount string `json:"invitee_amount"` // 被邀请人的奖励
+}
+
+type InviteReward struct {
+	InviteRewardPure
+	CreatedAt string `json:"created_at"`
+	UpdatedAt string `json:"updated_at"`
+}
@@ -0,0 +1,11 @@
+// Copyright 2019 Axetroy. All rights reserved. MIT license.
+package schema
+
+type Lock struct {
+	Id        string `json:"id"`         // 锁定 ID
+	Scope     string `json:"scope"`      // 锁定的场景, user: 用户登陆, admin: 管理员登陆, pay: 交易密码
+	Kind      string `json:"kind"`       // 锁定的对象, account: 账号, ip: IP 地址
+	Value     string `json:"value"`      // 被锁定的账号或 IP
+	Level     int64  `json:"level"`      // 第几次被锁定
+	ExpiredAt string `json:"expired_at"` // 解锁的时间
+}
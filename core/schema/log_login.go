@@ -8,6 +8,7 @@ type LogLoginPure struct {
 	Command int    `json:"command"`
 	LastIp  string `json:"last_ip"`
 	Client  string `json:"client"`
+	Reason  string `json:"reason"`
 }
 
 type LogLogin struct {
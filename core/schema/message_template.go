@@ -0,0 +1,44 @@
+// Copyright 2019 Axetroy. All rights reserved. MIT license.
+package schema
+
+type MessageTemplateVariable struct {
+	Name     string `json:"name"`     // 变量名
+	Type     string `json:"type"`     // 变量类型, string/int/float/bool/time
+	Required bool   `json:"required"` // 是否必填
+}
+
+type MessageTemplatePure struct {
+	Id            string                    `json:"id"`
+	Name          string                    `json:"name"`            // 模版名称
+	Locale        string                    `json:"locale"`          // 模版的语言
+	Subject       string                    `json:"subject"`         // 标题
+	Format        string                    `json:"format"`          // 内容的格式, text 或者 html
+	Body          string                    `json:"body"`            // 模版内容
+	Variables     []MessageTemplateVariable `json:"variables"`       // 模版变量的声明
+	Category      string                    `json:"category"`        // 通知的分类
+	SmsTemplateId *string                   `json:"sms_template_id"` // 短信服务商的模版ID
+	Note          *string                   `json:"note"`            // 备注
+}
+
+type MessageTemplate struct {
+	MessageTemplatePure
+	CreatedAt string `json:"created_at"`
+	UpdatedAt string `json:"updated_at"`
+}
+
+// 模版预览的结果
+type MessageTemplatePreview struct {
+	Subject string            `json:"subject"` // 渲染后的标题
+	Body    string            `json:"body"`    // 渲染后的内容
+	HTML    bool              `json:"html"`    // 内容是否为 HTML
+	Sms     map[string]string `json:"sms"`     // 发送短信时传给服务商的模版参数
+}
+
+// 某个用户在某个渠道的发送结果
+type MessageTemplateDelivery struct {
+	Uid      string `json:"uid"`      // 用户ID
+	Channel  string `json:"channel"`  // 发送渠道
+	Success  bool   `json:"success"`  // 是否发送成功
+	Deferred bool   `json:"deferred"` // 用户正处于免打扰时段, 免打扰时段结束后再发送
+	Error    string `json:"error"`    // 失败的原因
+}
@@ -19,11 +19,18 @@ type Notification struct {
 
 // 这是管理员获取的接口
 type NotificationPureAdmin struct {
-	Id      string  `json:"id"`
-	Author  string  `json:"author"`
-	Title   string  `json:"title"`
-	Content string  `json:"content"`
-	Note    *string `json:"note"`
+	Id        string   `json:"id"`
+	Author    string   `json:"author"`
+	Title     string   `json:"title"`
+	Content   string   `json:"content"`
+	Note      *string  `json:"note"`
+	Status    int      `json:"status"`                      // 通知状态, -1 未启用, 0 已发布, 1 等待发布, 2 已过期
+	Audience  string   `json:"audience"`                    // 通知的受众, all/role/level/user
+	Roles     []string `json:"roles"`                       // 受众为 role 时的角色列表
+	Levels    []int64  `json:"levels"`                      // 受众为 level 时的等级列表
+	Uids      []string `json:"uids"`                        // 受众为 user 时的用户 ID 列表
+	PublishAt *string  `json:"publish_at" mapstructure:"-"` // 定时发布的时间
+	ExpireAt  *string  `json:"expire_at" mapstructure:"-"`  // 过期的时间
 }
 
 type NotificationAdmin struct {
@@ -0,0 +1,10 @@
+// Copyright 2019 Axetroy. All rights reserved. MIT license.
+package schema
+
+// 用户的通知偏好
+type NotificationPreference struct {
+	Channels   map[string]map[string]bool `json:"channels"`    // 每个分类在每个渠道是否开启, 例如 {"marketing": {"email": false}}. 安全类总是开启
+	QuietStart *string                    `json:"quiet_start"` // 免打扰时段的开始时间, 格式为 HH:MM, 为空则没有免打扰时段
+	QuietEnd   *string                    `json:"quiet_end"`   // 免打扰时段的结束时间, 早于开始时间则跨过零点
+	Timezone   string                     `json:"timezone"`    // 免打扰时段使用的时区
+}
@@ -15,6 +15,7 @@ type ProfilePure struct {
 	Level                   int32    `json:"level"`
 	InviteCode              string   `json:"invite_code"`
 	UsernameRenameRemaining int      `json:"username_rename_remaining"`
+	EnableTOTP              bool     `json:"enable_totp"`
 }
 
 // 绑定的微信帐号信息
@@ -38,7 +39,22 @@ type ProfilePublic struct {
 
 type ProfileWithToken struct {
 	Profile
-	Token string `json:"token"`
+	Token        string `json:"token"`
+	RefreshToken string `json:"refresh_token"` // 刷新令牌，用于换取新的 token
+}
+
+// 开启了双重身份认证的帐号，登陆时先返回挑战码，再通过 `/v1/auth/signin/totp` 换取 token
+type SignInTOTPChallenge struct {
+	TOTPRequired bool   `json:"totp_required"` // 是否需要进行双重身份认证, 恒为 true
+	Challenge    string `json:"challenge"`     // 挑战码
+	ExpiredAt    string `json:"expired_at"`    // 挑战码过期时间
+}
+
+// 双重身份认证的绑定信息
+type TOTPEnrollment struct {
+	Secret string `json:"secret"` // 密钥，用于无法扫码时手动输入
+	URI    string `json:"uri"`    // otpauth:// 链接
+	QRCode string `json:"qrcode"` // 二维码, base64 编码的 PNG 图片
 }
 
 type Profile struct {
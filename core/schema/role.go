@@ -5,6 +5,7 @@ type RolePure struct {
 	Name        string   `json:"name"`
 	Description string   `json:"description"`
 	Accession   []string `json:"accession"`
+	Parent      []string `json:"parent"`
 	BuildIn     bool     `json:"build_in"`
 	Note        *string  `json:"note"`
 }
@@ -14,3 +15,10 @@ type Role struct {
 	CreatedAt string `json:"created_at"`
 	UpdatedAt string `json:"updated_at"`
 }
+
+// 权限的授予来源
+type AccessionGrant struct {
+	Role      string   `json:"role"`      // 授予权限的角色
+	Accession string   `json:"accession"` // 角色中匹配到的权限, 可能是通配符
+	Path      []string `json:"path"`      // 从用户的角色到授予权限的角色的继承路径
+}
@@ -0,0 +1,8 @@
+// Copyright 2019 Axetroy. All rights reserved. MIT license.
+package schema
+
+// 刷新 token 之后返回的令牌对
+type TokenPair struct {
+	Token        string `json:"token"`         // 新的 token
+	RefreshToken string `json:"refresh_token"` // 新的刷新令牌，旧的刷新令牌已失效
+}
@@ -0,0 +1,27 @@
+// Copyright 2019 Axetroy. All rights reserved. MIT license.
+package schema
+
+type TransferLimitPure struct {
+	Id            string `json:"id"`             // 限额ID
+	Currency      string `json:"currency"`       // 币种
+	Level         int32  `json:"level"`          // 用户等级, 0 则为默认限额
+	Min           string `json:"min"`            // 单笔转账的最小金额, 0 则不限制
+	Max           string `json:"max"`            // 单笔转账的最大金额, 0 则不限制
+	DailyAmount   string `json:"daily_amount"`   // 每天累计转账的最大金额, 0 则不限制
+	MonthlyAmount string `json:"monthly_amount"` // 每月累计转账的最大金额, 0 则不限制
+	DailyCount    int    `json:"daily_count"`    // 每天最多转账的次数, 0 则不限制
+}
+
+type TransferLimit struct {
+	TransferLimitPure
+	CreatedAt string `json:"created_at"`
+	UpdatedAt string `json:"updated_at"`
+}
+
+// 用户当前的转账限额以及已经使用的额度
+type TransferQuota struct {
+	TransferLimitPure
+	DailyUsedAmount   string `json:"daily_used_amount"`   // 今天已经转出的金额
+	MonthlyUsedAmount string `json:"monthly_used_amount"` // 本月已经转出的金额
+	DailyUsedCount    int    `json:"daily_used_count"`    // 今天已经转账的次数
+}
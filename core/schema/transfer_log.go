@@ -15,6 +15,7 @@ type TransferLogPure struct {
 
 type TransferLog struct {
 	TransferLogPure
-	CreatedAt string `json:"created_at"`
-	UpdatedAt string `json:"updated_at"`
+	ExpiredAt *string `json:"expired_at"` // 等待确认的过期时间
+	CreatedAt string  `json:"created_at"`
+	UpdatedAt string  `json:"updated_at"`
 }
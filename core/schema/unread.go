@@ -0,0 +1,8 @@
+// Copyright 2019 Axetroy. All rights reserved. MIT license.
+package schema
+
+// 未读数量
+type Unread struct {
+	Message      int64 `json:"message"`      // 未读的个人消息数量
+	Notification int64 `json:"notification"` // 未读的系统通知数量
+}
@@ -0,0 +1,21 @@
+// Copyright 2019 Axetroy. All rights reserved. MIT license.
+package schema
+
+import "github.com/axetroy/go-server/core/model"
+
+type WalletOperationPure struct {
+	Id       string            `json:"id"`       // 操作ID
+	AdminId  string            `json:"admin_id"` // 操作的管理员
+	Uid      string            `json:"uid"`      // 被操作的用户
+	Currency string            `json:"currency"` // 币种
+	Type     model.FinanceType `json:"type"`     // 操作类型
+	Amount   string            `json:"amount"`   // 操作的金额
+	Reason   string            `json:"reason"`   // 操作的原因
+}
+
+type WalletOperation struct {
+	WalletOperationPure
+	Wallet    *Wallet `json:"wallet,omitempty"` // 操作后的钱包
+	CreatedAt string  `json:"created_at"`
+	UpdatedAt string  `json:"updated_at"`
+}
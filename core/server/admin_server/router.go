@@ -7,8 +7,12 @@ import (
 	"github.com/axetroy/go-server/core/controller/address"
 	"github.com/axetroy/go-server/core/controller/admin"
 	"github.com/axetroy/go-server/core/controller/banner"
+	"github.com/axetroy/go-server/core/controller/currency"
 	"github.com/axetroy/go-server/core/controller/downloader"
+	"github.com/axetroy/go-server/core/controller/finance"
 	"github.com/axetroy/go-server/core/controller/help"
+	"github.com/axetroy/go-server/core/controller/invite"
+	"github.com/axetroy/go-server/core/controller/lock"
 	loginLog "github.com/axetroy/go-server/core/controller/logger/login"
 	"github.com/axetroy/go-server/core/controller/menu"
 	"github.com/axetroy/go-server/core/controller/message"
@@ -18,9 +22,14 @@ import (
 	"github.com/axetroy/go-server/core/controller/resource"
 	"github.com/axetroy/go-server/core/controller/role"
 	"github.com/axetroy/go-server/core/controller/system"
+	"github.com/axetroy/go-server/core/controller/template"
+	"github.com/axetroy/go-server/core/controller/transfer"
 	"github.com/axetroy/go-server/core/controller/uploader"
 	"github.com/axetroy/go-server/core/controller/user"
+	"github.com/axetroy/go-server/core/controller/wallet"
 	"github.com/axetroy/go-server/core/middleware"
+	"github.com/axetroy/go-server/core/rbac"
+	"github.com/axetroy/go-server/core/rbac/accession"
 	"github.com/axetroy/go-server/core/schema"
 	"github.com/axetroy/go-server/core/service/dotenv"
 	"github.com/gin-gonic/gin"
@@ -66,121 +75,204 @@ func init() {
 		adminAuthMiddleware := middleware.Authenticate(true) // 管理员Token的中间件
 
 		// 登陆
-		v1.POST("/login", admin.LoginRouter) // 管理员登陆
+		v1.POST("/login", admin.LoginRouter)                     // 管理员登陆
+		v1.POST("/auth/token/refresh", admin.RefreshTokenRouter) // 使用刷新令牌换取新的 token
 
 		v1.Use(adminAuthMiddleware)
 
+		// 以下接口都需要管理员拥有对应的权限, 超级管理员不受限制
+		// 个人信息以及通用类的接口, 任何管理员都可以访问
 		v1.GET("/profile", adminAuthMiddleware, admin.GetAdminInfoRouter)    // 获取管理员自己的信息
 		v1.PUT("/password", adminAuthMiddleware, admin.UpdatePasswordRouter) // 更改自己的密码
 
 		// 管理员类
 		{
 			adminRouter := v1.Group("admin")
-			adminRouter.POST("", admin.CreateAdminRouter)                   // 创建管理员
-			adminRouter.GET("", admin.GetListRouter)                        // 获取管理员列表
-			adminRouter.GET("/a/:admin_id", admin.GetAdminInfoByIdRouter)   // 获取某个管理员的信息
-			adminRouter.PUT("/a/:admin_id", admin.UpdateRouter)             // 修改某个管理员的信息
-			adminRouter.DELETE("/a/:admin_id", admin.DeleteAdminByIdRouter) // 修改某个管理员的信息
-			adminRouter.GET("/accession", admin.GetAccessionRouter)         // 获取管理员的所有权限列表
+			adminRouter.POST("", rbac.RequireAdmin(*accession.AdminAdminCreate), admin.CreateAdminRouter)                   // 创建管理员
+			adminRouter.GET("", rbac.RequireAdmin(*accession.AdminAdminGet), admin.GetListRouter)                           // 获取管理员列表
+			adminRouter.GET("/a/:admin_id", rbac.RequireAdmin(*accession.AdminAdminGet), admin.GetAdminInfoByIdRouter)      // 获取某个管理员的信息
+			adminRouter.PUT("/a/:admin_id", rbac.RequireAdmin(*accession.AdminAdminUpdate), admin.UpdateRouter)             // 修改某个管理员的信息
+			adminRouter.DELETE("/a/:admin_id", rbac.RequireAdmin(*accession.AdminAdminDelete), admin.DeleteAdminByIdRouter) // 修改某个管理员的信息
+			adminRouter.GET("/accession", rbac.RequireAdmin(*accession.AdminAdminGet), admin.GetAccessionRouter)            // 获取管理员的所有权限列表
 		}
 
 		// 用户类
 		{
 			userRouter := v1.Group("user")
-			userRouter.GET("", user.GetListRouter)                                   // 获取会员列表
-			userRouter.POST("", user.CreateUserRouter)                               // 创建会员
-			userRouter.GET("/u/:user_id", user.GetProfileByAdminRouter)              // 获取单个会员的信息
-			userRouter.PUT("/u/:user_id", user.UpdateProfileByAdminRouter)           // 更新会员信息
-			userRouter.PUT("/u/:user_id/password", user.UpdatePasswordByAdminRouter) // 修改会员密码
+			userRouter.GET("", rbac.RequireAdmin(*accession.AdminUserGet), user.GetListRouter)                                       // 获取会员列表
+			userRouter.POST("", rbac.RequireAdmin(*accession.AdminUserCreate), user.CreateUserRouter)                                // 创建会员
+			userRouter.GET("/u/:user_id", rbac.RequireAdmin(*accession.AdminUserGet), user.GetProfileByAdminRouter)                  // 获取单个会员的信息
+			userRouter.PUT("/u/:user_id", rbac.RequireAdmin(*accession.AdminUserUpdate), user.UpdateProfileByAdminRouter)            // 更新会员信息
+			userRouter.PUT("/u/:user_id/password", rbac.RequireAdmin(*accession.AdminUserUpdate), user.UpdatePasswordByAdminRouter)  // 修改会员密码
+			userRouter.DELETE("/u/:user_id/session", rbac.RequireAdmin(*accession.AdminUserUpdate), user.RevokeSessionByAdminRouter) // 强制会员所有设备下线
 		}
 
 		// 用户角色
 		{
 			roleRouter := v1.Group("role")
-			roleRouter.GET("", role.GetListRouter)                   // 获取角色列表
-			roleRouter.POST("", role.CreateRouter)                   // 创建角色
-			roleRouter.PUT("/r/:name", role.UpdateRouter)            // 修改角色
-			roleRouter.DELETE("/r/:name", role.DeleteRouter)         // 删除角色
-			roleRouter.GET("/r/:name", role.GetRouter)               // 获取角色详情
-			roleRouter.GET("/accession", role.GetAccessionRouter)    // 获取用户的所有的权限列表
-			roleRouter.GET("/u/:user_id", role.UpdateUserRoleRouter) // 用户用户的角色信息
-			roleRouter.PUT("/u/:user_id", role.UpdateUserRoleRouter) // 管理员修改用户的角色
+			roleRouter.GET("", rbac.RequireAdmin(*accession.AdminRoleGet), role.GetListRouter)                           // 获取角色列表
+			roleRouter.POST("", rbac.RequireAdmin(*accession.AdminRoleCreate), role.CreateRouter)                        // 创建角色
+			roleRouter.PUT("/r/:name", rbac.RequireAdmin(*accession.AdminRoleUpdate), role.UpdateRouter)                 // 修改角色
+			roleRouter.DELETE("/r/:name", rbac.RequireAdmin(*accession.AdminRoleDelete), role.DeleteRouter)              // 删除角色
+			roleRouter.GET("/r/:name", rbac.RequireAdmin(*accession.AdminRoleGet), role.GetRouter)                       // 获取角色详情
+			roleRouter.GET("/accession", rbac.RequireAdmin(*accession.AdminRoleGet), role.GetAccessionRouter)            // 获取用户的所有的权限列表
+			roleRouter.GET("/u/:user_id", rbac.RequireAdmin(*accession.AdminRoleUpdate), role.UpdateUserRoleRouter)      // 用户用户的角色信息
+			roleRouter.PUT("/u/:user_id", rbac.RequireAdmin(*accession.AdminRoleUpdate), role.UpdateUserRoleRouter)      // 管理员修改用户的角色
+			roleRouter.GET("/u/:user_id/explain", rbac.RequireAdmin(*accession.AdminRoleGet), role.ExplainByAdminRouter) // 查询用户的权限是由哪些角色授予的
 		}
 
 		// 新闻咨询类
 		{
 			newsRouter := v1.Group("/news")
-			newsRouter.POST("", news.CreateRouter)              // 新建新闻公告
-			newsRouter.GET("", news.GetNewsListByUserRouter)    // 获取新闻列表
-			newsRouter.GET("/n/:news_id", news.GetNewsRouter)   // 获取新闻详情
-			newsRouter.PUT("/n/:news_id", news.UpdateRouter)    // 更新新闻公告
-			newsRouter.DELETE("/n/:news_id", news.DeleteRouter) // 删除新闻
+			newsRouter.POST("", rbac.RequireAdmin(*accession.AdminNewsCreate), news.CreateRouter)              // 新建新闻公告
+			newsRouter.GET("", rbac.RequireAdmin(*accession.AdminNewsGet), news.GetNewsListByUserRouter)       // 获取新闻列表
+			newsRouter.GET("/n/:news_id", rbac.RequireAdmin(*accession.AdminNewsGet), news.GetNewsRouter)      // 获取新闻详情
+			newsRouter.PUT("/n/:news_id", rbac.RequireAdmin(*accession.AdminNewsUpdate), news.UpdateRouter)    // 更新新闻公告
+			newsRouter.DELETE("/n/:news_id", rbac.RequireAdmin(*accession.AdminNewsDelete), news.DeleteRouter) // 删除新闻
 		}
 
 		// 系统通知
 		{
 			notificationRouter := v1.Group("/notification")
-			notificationRouter.POST("", notification.CreateRouter)                    // 创建系统通知
-			notificationRouter.GET("", notification.GetNotificationListByAdminRouter) // 获取系统通知列表
-			notificationRouter.PUT("/n/:id", notification.UpdateRouter)               // 更新系统通知
-			notificationRouter.DELETE("/n/:id", notification.DeleteRouter)            // 删除系统通知
-			notificationRouter.GET("/n/:id", notification.GetRouter)                  // 获取单条系统通知
+			notificationRouter.POST("", rbac.RequireAdmin(*accession.AdminNotificationCreate), notification.CreateRouter)                 // 创建系统通知
+			notificationRouter.GET("", rbac.RequireAdmin(*accession.AdminNotificationGet), notification.GetNotificationListByAdminRouter) // 获取系统通知列表
+			notificationRouter.PUT("/n/:id", rbac.RequireAdmin(*accession.AdminNotificationUpdate), notification.UpdateRouter)            // 更新系统通知
+			notificationRouter.DELETE("/n/:id", rbac.RequireAdmin(*accession.AdminNotificationDelete), notification.DeleteRouter)         // 删除系统通知
+			notificationRouter.DELETE("", rbac.RequireAdmin(*accession.AdminNotificationDelete), notification.DeleteBatchRouter)          // 批量删除系统通知
+			notificationRouter.GET("/n/:id", rbac.RequireAdmin(*accession.AdminNotificationGet), notification.GetRouter)                  // 获取单条系统通知
 		}
 
 		// 个人消息
 		{
 			messageRouter := v1.Group("/message")
-			messageRouter.POST("", message.CreateRouter)                        // 创建个人消息
-			messageRouter.GET("", message.GetMessageListByAdminRouter)          // 获取消息列表
-			messageRouter.GET("/m/:message_id", message.GetAdminRouter)         // 获取个人消息
-			messageRouter.PUT("/m/:message_id", message.UpdateRouter)           // 更新个人消息
-			messageRouter.DELETE("/m/:message_id", message.DeleteByAdminRouter) // 删除个人消息
+			messageRouter.POST("", rbac.RequireAdmin(*accession.AdminMessageCreate), message.CreateRouter)                        // 创建个人消息
+			messageRouter.GET("", rbac.RequireAdmin(*accession.AdminMessageGet), message.GetMessageListByAdminRouter)             // 获取消息列表
+			messageRouter.GET("/m/:message_id", rbac.RequireAdmin(*accession.AdminMessageGet), message.GetAdminRouter)            // 获取个人消息
+			messageRouter.PUT("/m/:message_id", rbac.RequireAdmin(*accession.AdminMessageUpdate), message.UpdateRouter)           // 更新个人消息
+			messageRouter.DELETE("/m/:message_id", rbac.RequireAdmin(*accession.AdminMessageDelete), message.DeleteByAdminRouter) // 删除个人消息
+		}
+
+		// 消息模版
+		{
+			templateRouter := v1.Group("/template")
+			templateRouter.GET("", rbac.RequireAdmin(*accession.AdminTemplateGet), template.GetListRouter)                    // 获取消息模版列表
+			templateRouter.POST("", rbac.RequireAdmin(*accession.AdminTemplateCreate), template.CreateRouter)                 // 创建消息模版
+			templateRouter.POST("/preview", rbac.RequireAdmin(*accession.AdminTemplateGet), template.PreviewRouter)           // 根据名称预览消息模版
+			templateRouter.POST("/send", rbac.RequireAdmin(*accession.AdminTemplateSend), template.SendRouter)                // 使用消息模版发送消息
+			templateRouter.GET("/t/:id", rbac.RequireAdmin(*accession.AdminTemplateGet), template.GetRouter)                  // 获取消息模版详情
+			templateRouter.PUT("/t/:id", rbac.RequireAdmin(*accession.AdminTemplateUpdate), template.UpdateRouter)            // 修改消息模版
+			templateRouter.DELETE("/t/:id", rbac.RequireAdmin(*accession.AdminTemplateDelete), template.DeleteRouter)         // 删除消息模版
+			templateRouter.POST("/t/:id/preview", rbac.RequireAdmin(*accession.AdminTemplateGet), template.PreviewByIdRouter) // 预览消息模版
 		}
 
 		// 用户反馈
 		{
 			reportRouter := v1.Group("/report")
-			reportRouter.Use(adminAuthMiddleware)
-			reportRouter.GET("", report.GetListByAdminRouter)                // 获取我的反馈列表
-			reportRouter.GET("/r/:report_id", report.GetReportByAdminRouter) // 获取反馈详情
-			reportRouter.PUT("/r/:report_id", report.UpdateByAdminRouter)    // 更新用户反馈
+			reportRouter.GET("", rbac.RequireAdmin(*accession.AdminReportGet), report.GetListByAdminRouter)                // 获取我的反馈列表
+			reportRouter.GET("/r/:report_id", rbac.RequireAdmin(*accession.AdminReportGet), report.GetReportByAdminRouter) // 获取反馈详情
+			reportRouter.PUT("/r/:report_id", rbac.RequireAdmin(*accession.AdminReportUpdate), report.UpdateByAdminRouter) // 更新用户反馈
 		}
 
 		// 帮助中心
 		{
 			helpRouter := v1.Group("help")
-			helpRouter.GET("", help.GetHelpListRouter)          // 创建帮助列表
-			helpRouter.POST("", help.CreateRouter)              // 创建帮助
-			helpRouter.PUT("/h/:help_id", help.UpdateRouter)    // 更新帮助
-			helpRouter.GET("/h/:help_id", help.GetHelpRouter)   // 获取帮助详情
-			helpRouter.DELETE("/h/:help_id", help.DeleteRouter) // 删除帮助
+			helpRouter.GET("", rbac.RequireAdmin(*accession.AdminHelpGet), help.GetHelpListRouter)             // 创建帮助列表
+			helpRouter.POST("", rbac.RequireAdmin(*accession.AdminHelpCreate), help.CreateRouter)              // 创建帮助
+			helpRouter.PUT("/h/:help_id", rbac.RequireAdmin(*accession.AdminHelpUpdate), help.UpdateRouter)    // 更新帮助
+			helpRouter.GET("/h/:help_id", rbac.RequireAdmin(*accession.AdminHelpGet), help.GetHelpRouter)      // 获取帮助详情
+			helpRouter.DELETE("/h/:help_id", rbac.RequireAdmin(*accession.AdminHelpDelete), help.DeleteRouter) // 删除帮助
 		}
 
 		// Banner
 		{
 			bannerRouter := v1.Group("banner")
-			bannerRouter.GET("", banner.GetBannerListRouter)          // 获取 banner 列表
-			bannerRouter.POST("", banner.CreateRouter)                // 创建 banner
-			bannerRouter.PUT("/b/:banner_id", banner.UpdateRouter)    // 更新 banner
-			bannerRouter.GET("/b/:banner_id", banner.GetBannerRouter) // 获取 banner 详情
-			bannerRouter.DELETE("/b/:banner_id", banner.DeleteRouter) // 删除 banner
+			bannerRouter.GET("", rbac.RequireAdmin(*accession.AdminBannerGet), banner.GetBannerListRouter)             // 获取 banner 列表
+			bannerRouter.POST("", rbac.RequireAdmin(*accession.AdminBannerCreate), banner.CreateRouter)                // 创建 banner
+			bannerRouter.PUT("/b/:banner_id", rbac.RequireAdmin(*accession.AdminBannerUpdate), banner.UpdateRouter)    // 更新 banner
+			bannerRouter.GET("/b/:banner_id", rbac.RequireAdmin(*accession.AdminBannerGet), banner.GetBannerRouter)    // 获取 banner 详情
+			bannerRouter.DELETE("/b/:banner_id", rbac.RequireAdmin(*accession.AdminBannerDelete), banner.DeleteRouter) // 删除 banner
 		}
 
 		// 后台管理员菜单
 		{
 			menuRouter := v1.Group("menu")
-			menuRouter.GET("", menu.GetListRouter)              // 获取菜单列表
-			menuRouter.POST("", menu.CreateRouter)              // 创建菜单
-			menuRouter.PUT("/m/:menu_id", menu.UpdateRouter)    // 更新菜单
-			menuRouter.GET("/m/:menu_id", menu.GetMenuRouter)   // 获取菜单详情
-			menuRouter.DELETE("/m/:menu_id", menu.DeleteRouter) // 删除菜单
+			menuRouter.GET("", rbac.RequireAdmin(*accession.AdminMenuGet), menu.GetListRouter)                 // 获取菜单列表
+			menuRouter.POST("", rbac.RequireAdmin(*accession.AdminMenuCreate), menu.CreateRouter)              // 创建菜单
+			menuRouter.PUT("/m/:menu_id", rbac.RequireAdmin(*accession.AdminMenuUpdate), menu.UpdateRouter)    // 更新菜单
+			menuRouter.GET("/m/:menu_id", rbac.RequireAdmin(*accession.AdminMenuGet), menu.GetMenuRouter)      // 获取菜单详情
+			menuRouter.DELETE("/m/:menu_id", rbac.RequireAdmin(*accession.AdminMenuDelete), menu.DeleteRouter) // 删除菜单
 		}
 
 		// 日志
 		{
 			logRouter := v1.Group("log")
-			logRouter.GET("/login", loginLog.GetLoginLogsRouter)          // 获取用户的登陆日志列表
-			logRouter.GET("/login/l/:log_id", loginLog.GetLoginLogRouter) // 用户单条登陆记录
+			logRouter.GET("/login", rbac.RequireAdmin(*accession.AdminLogGet), loginLog.GetLoginLogsRouter)          // 获取用户的登陆日志列表
+			logRouter.GET("/login/l/:log_id", rbac.RequireAdmin(*accession.AdminLogGet), loginLog.GetLoginLogRouter) // 用户单条登陆记录
+		}
+
+		// 财务日志
+		{
+			financeRouter := v1.Group("finance")
+			financeRouter.GET("/history", rbac.RequireAdmin(*accession.AdminFinanceGet), finance.GetHistoryByAdminRouter)              // 获取用户的财务日志
+			financeRouter.GET("/history/export", rbac.RequireAdmin(*accession.AdminFinanceExport), finance.ExportHistoryByAdminRouter) // 导出财务日志为 CSV
+			financeRouter.POST("/reconcile", rbac.RequireAdmin(*accession.AdminFinanceReconcile), finance.ReconcileByAdminRouter)      // 对账
+		}
+
+		// 用户钱包
+		{
+			walletRouter := v1.Group("wallet")
+			walletRouter.GET("/u/:user_id", rbac.RequireAdmin(*accession.AdminWalletGet), wallet.GetWalletsByAdminRouter)                // 获取用户的钱包
+			walletRouter.POST("/credit", rbac.RequireAdmin(*accession.AdminWalletCredit), wallet.CreditByAdminRouter)                    // 给用户的钱包加款
+			walletRouter.POST("/debit", rbac.RequireAdmin(*accession.AdminWalletDebit), wallet.DebitByAdminRouter)                       // 从用户的钱包扣款
+			walletRouter.POST("/freeze", rbac.RequireAdmin(*accession.AdminWalletFreeze), wallet.FreezeByAdminRouter)                    // 冻结用户钱包的余额
+			walletRouter.POST("/unfreeze", rbac.RequireAdmin(*accession.AdminWalletUnfreeze), wallet.UnfreezeByAdminRouter)              // 解冻用户钱包的余额
+			walletRouter.GET("/operation", rbac.RequireAdmin(*accession.AdminWalletGet), wallet.GetOperationsByAdminRouter)              // 获取钱包的操作记录
+			walletRouter.GET("/operation/:operation_id", rbac.RequireAdmin(*accession.AdminWalletGet), wallet.GetOperationByAdminRouter) // 获取单条钱包操作记录
+		}
+
+		// 转账限额
+		{
+			transferLimitRouter := v1.Group("transfer/limit")
+			transferLimitRouter.GET("", rbac.RequireAdmin(*accession.AdminTransferLimitGet), transfer.GetLimitsByAdminRouter)                     // 获取转账限额列表
+			transferLimitRouter.POST("", rbac.RequireAdmin(*accession.AdminTransferLimitCreate), transfer.CreateLimitByAdminRouter)               // 添加转账限额
+			transferLimitRouter.GET("/l/:limit_id", rbac.RequireAdmin(*accession.AdminTransferLimitGet), transfer.GetLimitByAdminRouter)          // 获取单条转账限额
+			transferLimitRouter.PUT("/l/:limit_id", rbac.RequireAdmin(*accession.AdminTransferLimitUpdate), transfer.UpdateLimitByAdminRouter)    // 修改转账限额
+			transferLimitRouter.DELETE("/l/:limit_id", rbac.RequireAdmin(*accession.AdminTransferLimitDelete), transfer.DeleteLimitByAdminRouter) // 删除转账限额
+		}
+
+		// 币种
+		{
+			currencyRouter := v1.Group("currency")
+			currencyRouter.GET("", rbac.RequireAdmin(*accession.AdminCurrencyGet), currency.GetListByAdminRouter)       // 获取所有币种
+			currencyRouter.POST("", rbac.RequireAdmin(*accession.AdminCurrencyCreate), currency.CreateRouter)           // 添加币种
+			currencyRouter.GET("/c/:code", rbac.RequireAdmin(*accession.AdminCurrencyGet), currency.GetByAdminRouter)   // 获取单个币种
+			currencyRouter.PUT("/c/:code", rbac.RequireAdmin(*accession.AdminCurrencyUpdate), currency.UpdateRouter)    // 修改币种
+			currencyRouter.DELETE("/c/:code", rbac.RequireAdmin(*accession.AdminCurrencyDelete), currency.DeleteRouter) // 删除币种
+		}
+
+		// 邀请奖励
+		{
+			inviteRouter := v1.Group("invite")
+			inviteRouter.GET("", rbac.RequireAdmin(*accession.AdminInviteGet), invite.GetInvitesByAdminRouter)                          // 获取邀请记录
+			inviteRouter.GET("/i/:invite_id", rbac.RequireAdmin(*accession.AdminInviteGet), invite.GetInviteByAdminRouter)              // 获取单条邀请记录
+			inviteRouter.PUT("/i/:invite_id/status", rbac.RequireAdmin(*accession.AdminInviteUpdate), invite.UpdateStatusByAdminRouter) // 修改被邀请人的状态
+			inviteRouter.POST("/i/:invite_id/settle", rbac.RequireAdmin(*accession.AdminInviteSettle), invite.SettleByAdminRouter)      // 发放单条邀请记录的奖励
+			inviteRouter.POST("/settle", rbac.RequireAdmin(*accession.AdminInviteSettle), invite.SettlePendingByAdminRouter)            // 发放所有未发放的奖励
+			inviteRouter.GET("/reward", rbac.RequireAdmin(*accession.AdminInviteGet), invite.GetRewardsByAdminRouter)                   // 获取已发放的奖励
+			inviteRouter.GET("/tree/:user_id", rbac.RequireAdmin(*accession.AdminInviteGet), invite.GetTreeByAdminRouter)               // 获取用户的邀请关系树
+			inviteRouter.GET("/stats/:user_id", rbac.RequireAdmin(*accession.AdminInviteGet), invite.GetStatsByAdminRouter)             // 获取用户的邀请统计
+			inviteRouter.GET("/top", rbac.RequireAdmin(*accession.AdminInviteGet), invite.GetTopInvitersByAdminRouter)                  // 获取邀请排行
+			inviteRouter.GET("/rule", rbac.RequireAdmin(*accession.AdminInviteGet), invite.GetRulesByAdminRouter)                       // 获取奖励规则
+			inviteRouter.POST("/rule", rbac.RequireAdmin(*accession.AdminInviteRule), invite.CreateRuleByAdminRouter)                   // 添加奖励规则
+			inviteRouter.PUT("/rule/:rule_id", rbac.RequireAdmin(*accession.AdminInviteRule), invite.UpdateRuleByAdminRouter)           // 修改奖励规则
+			inviteRouter.DELETE("/rule/:rule_id", rbac.RequireAdmin(*accession.AdminInviteRule), invite.DeleteRuleByAdminRouter)        // 删除奖励规则
+		}
+
+		// 登陆锁定
+		{
+			lockRouter := v1.Group("lock")
+			lockRouter.GET("", rbac.RequireAdmin(*accession.AdminLockGet), lock.GetLockListByAdminRouter)               // 获取被锁定的账号和 IP
+			lockRouter.DELETE("/:lock_id", rbac.RequireAdmin(*accession.AdminLockDelete), lock.DeleteLockByAdminRouter) // 解除锁定
 		}
 
 		// 通用类
@@ -16,14 +16,14 @@ import (
 
 func Serve() error {
 	var (
-		c *nsq.Consumer
+		consumers []*nsq.Consumer
 	)
 
 	go func() {
-		if ctx, err := message_queue.RunMessageQueueConsumer(); err != nil {
+		if list, err := message_queue.RunMessageQueueConsumer(); err != nil {
 			log.Fatal(err)
 		} else {
-			c = ctx
+			consumers = list
 		}
 	}()
 
@@ -46,7 +46,7 @@ func Serve() error {
 
 	defer cancel()
 
-	if c != nil {
+	for _, c := range consumers {
 		c.Stop()
 
 		_ = c.DisconnectFromNSQD(message_queue.Address)
@@ -7,6 +7,7 @@ import (
 	"github.com/axetroy/go-server/core/controller/address"
 	"github.com/axetroy/go-server/core/controller/auth"
 	"github.com/axetroy/go-server/core/controller/banner"
+	"github.com/axetroy/go-server/core/controller/currency"
 	"github.com/axetroy/go-server/core/controller/downloader"
 	"github.com/axetroy/go-server/core/controller/email"
 	"github.com/axetroy/go-server/core/controller/finance"
@@ -19,6 +20,7 @@ import (
 	"github.com/axetroy/go-server/core/controller/report"
 	"github.com/axetroy/go-server/core/controller/resource"
 	"github.com/axetroy/go-server/core/controller/signature"
+	"github.com/axetroy/go-server/core/controller/stream"
 	"github.com/axetroy/go-server/core/controller/transfer"
 	"github.com/axetroy/go-server/core/controller/uploader"
 	"github.com/axetroy/go-server/core/controller/user"
@@ -81,7 +83,9 @@ func init() {
 			authRouter.POST("/signin/phone", auth.SignInWithPhoneRouter)   // 手机+验证码 登陆
 			authRouter.POST("/signin/wechat", auth.SignInWithWechatRouter) // 微信帐号登陆
 			authRouter.POST("/signin/oauth2", auth.SignInWithOAuthRouter)  // oAuth 码登陆
+			authRouter.POST("/signin/totp", auth.SignInWithTOTPRouter)     // 双重身份认证登陆, 挑战码+动态码 换取 token
 			authRouter.POST("/signin", auth.SignInRouter)                  // 登陆账号
+			authRouter.POST("/token/refresh", auth.RefreshTokenRouter)     // 使用刷新令牌换取新的 token
 			authRouter.PUT("/password/reset", auth.ResetPasswordRouter)    // 密码重置
 			authRouter.POST("/code/email", auth.SendEmailAuthCodeRouter)   // 发送邮箱验证码，验证邮箱是否为用户所有 TODO: 缺少测试用例
 			authRouter.POST("/code/phone", auth.SendPhoneAuthCodeRouter)   // 发送手机验证码，验证手机是否为用户所有 TODO: 缺少测试用例
@@ -98,15 +102,21 @@ func init() {
 		{
 			userRouter := v1.Group("/user")
 			userRouter.Use(userAuthMiddleware)
-			userRouter.GET("/signout", user.SignOut)                                                                      // 用户登出
+			userRouter.GET("/signout", user.SignOutRouter)                                                                // 用户登出
+			userRouter.POST("/signout/all", user.SignOutAllRouter)                                                        // 登出所有设备
 			userRouter.GET("/profile", user.GetProfileRouter)                                                             // 获取用户详细信息
 			userRouter.PUT("/profile", rbac.Require(*accession.ProfileUpdate), user.UpdateProfileRouter)                  // 更新用户资料
+			userRouter.GET("/preference", user.GetPreferenceRouter)                                                       // 获取通知偏好
+			userRouter.PUT("/preference", rbac.Require(*accession.ProfileUpdate), user.UpdatePreferenceRouter)            // 更新通知偏好
 			userRouter.PUT("/password", rbac.Require(*accession.PasswordUpdate), user.UpdatePasswordRouter)               // 更新登陆密码
 			userRouter.POST("/password2", rbac.Require(*accession.Password2Set), user.SetPayPasswordRouter)               // 设置交易密码
 			userRouter.PUT("/password2", rbac.Require(*accession.Password2Update), user.UpdatePayPasswordRouter)          // 更新交易密码
 			userRouter.PUT("/password2/reset", rbac.Require(*accession.Password2Reset), user.ResetPayPasswordRouter)      // 重置交易密码
 			userRouter.POST("/password2/reset", rbac.Require(*accession.Password2Reset), user.SendResetPayPasswordRouter) // 发送重置交易密码的邮件/短信
 			userRouter.POST("/avatar", user.UploadAvatarRouter)                                                           // 上传用户头像
+			userRouter.POST("/totp", user.EnrollTOTPRouter)                                                               // 获取双重身份认证的绑定信息
+			userRouter.PUT("/totp", user.ConfirmTOTPRouter)                                                               // 确认开启双重身份认证
+			userRouter.DELETE("/totp", user.DisableTOTPRouter)                                                            // 关闭双重身份认证
 
 			// 验证码类
 			{
@@ -134,6 +144,8 @@ func init() {
 				inviteRouter := userRouter.Group("/invite")
 				inviteRouter.GET("", invite.GetInviteListByUserRouter) // 获取我已邀请的列表
 				inviteRouter.GET("/i/:invite_id", invite.GetRouter)    // 获取单条邀请记录详情
+				inviteRouter.GET("/tree", invite.GetTreeRouter)        // 获取我的邀请关系树
+				inviteRouter.GET("/stats", invite.GetStatsRouter)      // 获取我的邀请统计
 			}
 			// 收货地址
 			{
@@ -147,6 +159,11 @@ func init() {
 			}
 		}
 
+		// 币种
+		{
+			v1.GET("/currency", currency.GetListRouter) // 获取可用的币种
+		}
+
 		// 钱包类
 		{
 			walletRouter := v1.Group("/wallet")
@@ -160,14 +177,20 @@ func init() {
 			transferRouter.Use(userAuthMiddleware)
 			transferRouter.GET("", transfer.GetHistoryRouter)                                                           // 获取我的转账记录
 			transferRouter.POST("", rbac.Require(*accession.DoTransfer), middleware.AuthPayPassword, transfer.ToRouter) // 转账给某人
+			transferRouter.GET("/limit/:currency", transfer.GetQuotaRouter)                                             // 获取我的转账限额
 			transferRouter.GET("/t/:transfer_id", transfer.GetDetailRouter)                                             // 获取单条转账详情
+			transferRouter.PUT("/t/:transfer_id/confirm", transfer.ConfirmRouter)                                       // 确认收款
+			transferRouter.PUT("/t/:transfer_id/reject", transfer.RejectRouter)                                         // 拒绝收款
 		}
 
 		// 财务日志
 		{
 			financeRouter := v1.Group("/finance")
 			financeRouter.Use(userAuthMiddleware)
-			financeRouter.GET("/history", finance.GetHistory) // TODO: 获取我的财务日志
+			financeRouter.GET("/history", finance.GetHistoryRouter)                   // 获取我的财务日志
+			financeRouter.GET("/statement", finance.GetStatementRouter)               // 预览我的对账单
+			financeRouter.GET("/statement/download", finance.DownloadStatementRouter) // 下载我的对账单
+			financeRouter.POST("/statement", finance.SendStatementRouter)             // 发送对账单到我的邮箱
 		}
 
 		// 新闻咨询类
@@ -181,9 +204,12 @@ func init() {
 		{
 			notificationRouter := v1.Group("/notification")
 			notificationRouter.Use(userAuthMiddleware)
-			notificationRouter.GET("", notification.GetNotificationListByUserRouter) // 获取系统通知列表
-			notificationRouter.GET("/n/:id", notification.GetRouter)                 // 获取某一条系统通知详情
-			notificationRouter.PUT("/n/:id/read", notification.ReadRouter)           // 标记通知为已读
+			notificationRouter.GET("", notification.GetNotificationListByUserRouter)   // 获取系统通知列表
+			notificationRouter.GET("/n/:id", notification.GetByUserRouter)             // 获取某一条系统通知详情
+			notificationRouter.PUT("/n/:id/read", notification.ReadRouter)             // 标记通知为已读
+			notificationRouter.GET("/unread/count", notification.GetUnreadCountRouter) // 获取未读通知的数量
+			notificationRouter.PUT("/read/all", notification.ReadAllRouter)            // 标记所有通知为已读
+			notificationRouter.PUT("/read", notification.ReadBatchRouter)              // 批量标记通知为已读
 		}
 
 		// 用户的个人消息, 个人消息是可以删除的
@@ -194,6 +220,15 @@ func init() {
 			messageRouter.GET("/m/:message_id", message.GetRouter)             // 获取单个消息详情
 			messageRouter.PUT("/m/:message_id/read", message.ReadRouter)       // 标记消息为已读
 			messageRouter.DELETE("/m/:message_id", message.DeleteByUserRouter) // 删除消息
+			messageRouter.GET("/unread/count", message.GetUnreadCountRouter)   // 获取未读消息的数量
+			messageRouter.PUT("/read/all", message.ReadAllRouter)              // 标记所有消息为已读
+			messageRouter.PUT("/read", message.ReadBatchRouter)                // 批量标记消息为已读
+			messageRouter.DELETE("", message.DeleteBatchByUserRouter)          // 批量删除消息
+		}
+
+		// 实时推送
+		{
+			v1.GET("/stream", userAuthMiddleware, stream.StreamRouter) // 推送新的消息, 通知以及未读数量
 		}
 
 		// 用户反馈
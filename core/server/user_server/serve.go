@@ -5,6 +5,10 @@ import (
 	"context"
 	"crypto/tls"
 	"github.com/axetroy/go-server/core/config"
+	"github.com/axetroy/go-server/core/controller/finance"
+	"github.com/axetroy/go-server/core/controller/invite"
+	"github.com/axetroy/go-server/core/controller/notification"
+	"github.com/axetroy/go-server/core/controller/transfer"
 	"github.com/axetroy/go-server/core/service/database"
 	"log"
 	"net/http"
@@ -27,6 +31,12 @@ func Serve() error {
 
 	log.Printf("Listen on:  %s\n", s.Addr)
 
+	// 定时退回超时未确认的转账
+	go transfer.RunExpireWorker(config.Transfer.ExpireInterval)
+	go invite.RunSettleWorker(config.Invite.SettleInterval)
+	go finance.RunStatementWorker(config.Statement.Interval)
+	go notification.RunScheduleWorker(config.Notification.ScheduleInterval)
+
 	go func() {
 		if config.User.TLS != nil {
 			TLSConfig := &tls.Config{
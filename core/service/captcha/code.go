@@ -0,0 +1,160 @@
+// Copyright 2019 Axetroy. All rights reserved. MIT license.
+package captcha
+
+import (
+	"time"
+
+	"github.com/axetroy/go-server/core/exception"
+	"github.com/axetroy/go-server/core/service/redis"
+	goredis "github.com/go-redis/redis"
+)
+
+// 邮箱/短信验证码
+// 验证码按照 发送渠道 + 用途 + 接收方 存储，不同用户、不同用途之间的验证码互不影响
+// 同一个接收方有重发间隔以及每天的发送上限，验证码输错次数过多会失效，验证成功后立即失效
+
+type Channel string
+type Purpose string
+
+const (
+	ChannelEmail Channel = "email" // 邮箱
+	ChannelPhone Channel = "phone" // 短信
+
+	PurposeSignIn    Purpose = "signin"    // 登陆
+	PurposeSignUp    Purpose = "signup"    // 注册
+	PurposeBinding   Purpose = "binding"   // 绑定新的邮箱/手机
+	PurposeUnbinding Purpose = "unbinding" // 验证已绑定的邮箱/手机，例如解除绑定
+)
+
+var (
+	CodeDuration    = time.Minute * 10 // 验证码的有效期
+	CodeCooldown    = time.Minute      // 同一个接收方重新发送的间隔
+	CodeDailyLimit  = int64(10)        // 同一个接收方每天最多发送的次数
+	CodeMaxAttempts = int64(5)         // 验证码允许输错的次数, 超过则验证码失效
+)
+
+// 校验验证码的脚本, 保证验证码只能被使用一次
+// 返回 1: 验证成功, 0: 验证码错误, -1: 验证码不存在或已失效
+var verifyScript = `
+local code = redis.call("HGET", KEYS[1], "code")
+if not code then
+	return -1
+end
+if code == ARGV[1] then
+	redis.call("DEL", KEYS[1])
+	return 1
+end
+local attempts = redis.call("HINCRBY", KEYS[1], "attempts", 1)
+if attempts >= tonumber(ARGV[2]) then
+	redis.call("DEL", KEYS[1])
+end
+return 0
+`
+
+func client(channel Channel) *goredis.Client {
+	if channel == ChannelPhone {
+		return redis.ClientAuthPhoneCode
+	}
+
+	return redis.ClientAuthEmailCode
+}
+
+func codeKey(purpose Purpose, destination string) string {
+	return "code:" + string(purpose) + ":" + destination
+}
+
+func cooldownKey(destination string) string {
+	return "cooldown:" + destination
+}
+
+func quotaKey(destination string, now time.Time) string {
+	return "quota:" + destination + ":" + now.Format("20060102")
+}
+
+func generateCode(channel Channel) string {
+	if channel == ChannelPhone {
+		return GeneratePhoneCaptcha()
+	}
+
+	return GenerateEmailCaptcha()
+}
+
+// 生成验证码并交给 send 发送出去
+// 发送失败的话，验证码会被删除，并且不计入发送次数
+func Send(channel Channel, purpose Purpose, destination string, send func(code string) error) (err error) {
+	var (
+		c   = client(channel)
+		now = time.Now()
+		ok  bool
+	)
+
+	// 重发间隔内不允许再次发送
+	if ok, err = c.SetNX(cooldownKey(destination), 1, CodeCooldown).Result(); err != nil {
+		return
+	} else if !ok {
+		err = exception.CaptchaTooFrequent
+		return
+	}
+
+	defer func() {
+		if err != nil && err != exception.CaptchaQuotaExceeded {
+			_ = c.Del(cooldownKey(destination)).Err()
+		}
+	}()
+
+	times, err := c.Incr(quotaKey(destination, now)).Result()
+
+	if err != nil {
+		return
+	}
+
+	_ = c.Expire(quotaKey(destination, now), time.Hour*24).Err()
+
+	if times > CodeDailyLimit {
+		err = exception.CaptchaQuotaExceeded
+		return
+	}
+
+	code := generateCode(channel)
+
+	// 重新发送会覆盖旧的验证码，输错的次数也重新计算
+	if _, err = c.TxPipelined(func(pipe goredis.Pipeliner) error {
+		pipe.Del(codeKey(purpose, destination))
+		pipe.HMSet(codeKey(purpose, destination), map[string]interface{}{
+			"code":     code,
+			"attempts": 0,
+		})
+		pipe.Expire(codeKey(purpose, destination), CodeDuration)
+		return nil
+	}); err != nil {
+		_ = c.Decr(quotaKey(destination, now)).Err()
+		return
+	}
+
+	if err = send(code); err != nil {
+		_ = c.Del(codeKey(purpose, destination)).Err()
+		_ = c.Decr(quotaKey(destination, now)).Err()
+		return
+	}
+
+	return
+}
+
+// 校验验证码, 验证成功后验证码立即失效
+func Verify(channel Channel, purpose Purpose, destination string, code string) error {
+	if destination == "" || code == "" {
+		return exception.InvalidCaptcha
+	}
+
+	result, err := client(channel).Eval(verifyScript, []string{codeKey(purpose, destination)}, code, CodeMaxAttempts).Int()
+
+	if err != nil {
+		return err
+	}
+
+	if result != 1 {
+		return exception.InvalidCaptcha
+	}
+
+	return nil
+}
@@ -0,0 +1,85 @@
+// Copyright 2019 Axetroy. All rights reserved. MIT license.
+package captcha_test
+
+import (
+	"errors"
+	"github.com/axetroy/go-server/core/exception"
+	"github.com/axetroy/go-server/core/service/captcha"
+	"github.com/axetroy/go-server/core/util"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestSendAndVerify(t *testing.T) {
+	var (
+		destination = "test-" + util.GenerateId() + "@example.com"
+		code        string
+	)
+
+	// 发送成功
+	assert.Nil(t, captcha.Send(captcha.ChannelEmail, captcha.PurposeSignIn, destination, func(c string) error {
+		code = c
+		return nil
+	}))
+
+	assert.Len(t, code, 6)
+
+	// 重发间隔内不能再次发送
+	assert.Equal(t, exception.CaptchaTooFrequent, captcha.Send(captcha.ChannelEmail, captcha.PurposeSignIn, destination, func(c string) error {
+		return nil
+	}))
+
+	// 用途不同，验证码不通用
+	assert.Equal(t, exception.InvalidCaptcha, captcha.Verify(captcha.ChannelEmail, captcha.PurposeBinding, destination, code))
+
+	// 接收方不同，验证码不通用
+	assert.Equal(t, exception.InvalidCaptcha, captcha.Verify(captcha.ChannelEmail, captcha.PurposeSignIn, "other@example.com", code))
+
+	// 验证成功
+	assert.Nil(t, captcha.Verify(captcha.ChannelEmail, captcha.PurposeSignIn, destination, code))
+
+	// 验证码只能使用一次
+	assert.Equal(t, exception.InvalidCaptcha, captcha.Verify(captcha.ChannelEmail, captcha.PurposeSignIn, destination, code))
+}
+
+func TestVerifyMaxAttempts(t *testing.T) {
+	var (
+		destination = "1" + util.RandomNumeric(10)
+		code        string
+	)
+
+	assert.Nil(t, captcha.Send(captcha.ChannelPhone, captcha.PurposeSignUp, destination, func(c string) error {
+		code = c
+		return nil
+	}))
+
+	wrong := "abcdef"
+
+	// 输错次数达到上限后，验证码失效
+	for i := int64(0); i < captcha.CodeMaxAttempts; i++ {
+		assert.Equal(t, exception.InvalidCaptcha, captcha.Verify(captcha.ChannelPhone, captcha.PurposeSignUp, destination, wrong))
+	}
+
+	assert.Equal(t, exception.InvalidCaptcha, captcha.Verify(captcha.ChannelPhone, captcha.PurposeSignUp, destination, code))
+}
+
+func TestSendFail(t *testing.T) {
+	var (
+		destination = "test-" + util.GenerateId() + "@example.com"
+		code        string
+		sendErr     = errors.New("send fail")
+	)
+
+	// 发送失败，验证码无效
+	assert.Equal(t, sendErr, captcha.Send(captcha.ChannelEmail, captcha.PurposeSignIn, destination, func(c string) error {
+		code = c
+		return sendErr
+	}))
+
+	assert.Equal(t, exception.InvalidCaptcha, captcha.Verify(captcha.ChannelEmail, captcha.PurposeSignIn, destination, code))
+
+	// 发送失败不计入重发间隔
+	assert.Nil(t, captcha.Send(captcha.ChannelEmail, captcha.PurposeSignIn, destination, func(c string) error {
+		return nil
+	}))
+}
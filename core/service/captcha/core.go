@@ -1,10 +1,16 @@
 package captcha
 
-import "github.com/axetroy/go-server/core/util"
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"github.com/axetroy/go-server/core/util"
+)
 
 // 该包生成各种码
 // 1. 验证码
 // 2. 重置码
+// 3. 登陆挑战码
+// 4. 邮箱/短信验证码的发送和校验, 见 code.go
 
 // 生成邮箱验证码
 func GenerateEmailCaptcha() string {
@@ -21,3 +27,14 @@ func GenerateResetCode(uid string) string {
 	codeId := util.GenerateId() + uid
 	return util.MD5(codeId)
 }
+
+// 生成双重身份认证的登陆挑战码, 挑战码可以直接换取 token 的一半凭证，所以必须不可预测
+func GenerateTOTPChallenge() (string, error) {
+	b := make([]byte, 32)
+
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+
+	return hex.EncodeToString(b), nil
+}
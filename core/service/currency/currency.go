@@ -0,0 +1,140 @@
+// Copyright 2019 Axetroy. All rights reserved. MIT license.
+package currency
+
+import (
+	"log"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/axetroy/go-server/core/exception"
+	"github.com/axetroy/go-server/core/model"
+	"github.com/axetroy/go-server/core/service/database"
+	"github.com/axetroy/go-server/core/service/redis"
+)
+
+// 币种注册表
+// 币种保存在数据库中, 由管理员在运行时维护
+// 读取的币种缓存在进程内, 修改币种后通过 Redis 的发布/订阅通知所有服务实例清除缓存
+
+var (
+	CacheDuration     = time.Second * 30      // 缓存的有效期, 即使没有收到失效通知, 过期后也会重新从数据库加载
+	InvalidateChannel = "currency:invalidate" // 缓存失效通知的频道
+	cache             []model.Currency
+	cacheExpired      time.Time
+	cacheLock         sync.RWMutex
+	subscribeOnce     sync.Once
+	generation        uint64 // 每次失效都会递增, 用于丢弃失效之前开始加载的结果
+)
+
+// 获取所有币种, 按照币种代码排序
+func List() ([]model.Currency, error) {
+	subscribeOnce.Do(subscribe)
+
+	cacheLock.RLock()
+	list, expiredAt, gen := cache, cacheExpired, generation
+	cacheLock.RUnlock()
+
+	if list != nil && time.Now().Before(expiredAt) {
+		return list, nil
+	}
+
+	list = make([]model.Currency, 0)
+
+	if err := database.Db.Order("code ASC").Find(&list).Error; err != nil {
+		return nil, err
+	}
+
+	cacheLock.Lock()
+	// 加载期间缓存被失效了, 加载的结果可能是旧的币种, 不写入缓存
+	if gen == generation {
+		cache = list
+		cacheExpired = time.Now().Add(CacheDuration)
+	}
+	cacheLock.Unlock()
+
+	return list, nil
+}
+
+// 获取所有币种的代码
+func Codes() ([]string, error) {
+	list, err := List()
+
+	if err != nil {
+		return nil, err
+	}
+
+	codes := make([]string, 0, len(list))
+
+	for _, c := range list {
+		codes = append(codes, c.Code)
+	}
+
+	return codes, nil
+}
+
+// 获取币种, 不存在则返回无效的钱包
+func Get(code string) (c model.Currency, err error) {
+	var list []model.Currency
+
+	if list, err = List(); err != nil {
+		return
+	}
+
+	code = strings.ToUpper(code)
+
+	for _, v := range list {
+		if v.Code == code {
+			return v, nil
+		}
+	}
+
+	err = exception.InvalidWallet
+
+	return
+}
+
+// 获取启用的币种, 停用的币种不能进行新的转账
+func GetEnabled(code string) (c model.Currency, err error) {
+	if c, err = Get(code); err != nil {
+		return
+	}
+
+	if c.Enabled == false {
+		err = exception.CurrencyDisabled
+	}
+
+	return
+}
+
+// 清除所有服务实例的缓存, 修改币种后调用
+func Invalidate() error {
+	evict()
+	return redis.Client.Publish(InvalidateChannel, "*").Err()
+}
+
+func evict() {
+	cacheLock.Lock()
+	defer cacheLock.Unlock()
+
+	generation++
+	cache = nil
+}
+
+// 订阅其他服务实例发出的失效通知
+func subscribe() {
+	pubsub := redis.Client.Subscribe(InvalidateChannel)
+
+	go func() {
+		defer func() {
+			if r := recover(); r != nil {
+				log.Println("币种缓存失效通知的订阅已退出", r)
+			}
+		}()
+
+		// 连接断开后会自动重连, 在这期间错过的通知则依赖缓存的有效期兜底
+		for range pubsub.Channel() {
+			evict()
+		}
+	}()
+}
@@ -0,0 +1,141 @@
+// Copyright 2019 Axetroy. All rights reserved. MIT license.
+package database
+
+import (
+	"fmt"
+	"log"
+	"strings"
+
+	"github.com/axetroy/go-server/core/model"
+	"github.com/jinzhu/gorm"
+)
+
+// 旧版本中每个币种各有一张钱包表, 转账记录表, 财务日志表, 例如 wallet_cny, transfer_log_cny, finance_log_cny
+// 现在所有币种共用 wallet, transfer_log, finance_log 三张表, 启动时会把旧表的数据迁移过来
+type legacyTable struct {
+	prefix  string      // 旧表名的前缀, 加上小写的币种代码即为旧表名
+	value   interface{} // 对应的模型, 迁移前用于补齐旧表缺少的字段
+	marker  string      // 旧表一定有的字段, 用于排除恰好同名的其他表
+	columns []string    // 需要迁移的字段, 不包括币种
+}
+
+var legacyTables = []legacyTable{
+	{
+		prefix:  "wallet_",
+		value:   &model.Wallet{},
+		marker:  "frozen",
+		columns: []string{"id", "balance::numeric", "frozen::numeric", "created_at", "updated_at", "deleted_at"},
+	},
+	{
+		prefix:  "transfer_log_",
+		value:   &model.TransferLog{},
+		marker:  "snapshot_from",
+		columns: []string{"id", `"from"`, `"to"`, "amount::numeric", "status", "note", "snapshot_from", "snapshot_to", "expired_at", "created_at", "updated_at", "deleted_at"},
+	},
+	{
+		prefix:  "finance_log_",
+		value:   &model.FinanceLog{},
+		marker:  "after_frozen",
+		columns: []string{"id", "order_id", "uid", "before_balance::numeric", "balance_mutation::numeric", "after_balance::numeric", "before_frozen::numeric", "frozen_mutation::numeric", "after_frozen::numeric", "type", "note", "created_at", "updated_at", "deleted_at"},
+	},
+}
+
+// 把某个币种的旧表数据复制到共用的表中, 然后把旧表重命名为 <旧表名>_migrated 保留备份
+// 已经迁移过的旧表不存在了, 重复调用不会有影响
+func migrateLegacyTable(db *gorm.DB, t legacyTable, code string) (err error) {
+	legacyTableName := t.prefix + strings.ToLower(code)
+
+	if !db.HasTable(legacyTableName) || !db.Dialect().HasColumn(legacyTableName, t.marker) {
+		return
+	}
+
+	tx := db.Begin()
+
+	defer func() {
+		if err != nil {
+			_ = tx.Rollback().Error
+		} else {
+			err = tx.Commit().Error
+		}
+	}()
+
+	// 旧表可能缺少后来新增的字段
+	if err = tx.Table(legacyTableName).AutoMigrate(t.value).Error; err != nil {
+		return
+	}
+
+	targetTableName := tx.NewScope(t.value).TableName()
+
+	target := make([]string, 0, len(t.columns)+1)
+
+	for _, column := range t.columns {
+		target = append(target, strings.Split(column, "::")[0])
+	}
+
+	target = append(target, "currency")
+
+	sql := fmt.Sprintf(`INSERT INTO "%s" (%s) SELECT %s, ? FROM "%s" ON CONFLICT DO NOTHING`, targetTableName, strings.Join(target, ", "), strings.Join(t.columns, ", "), legacyTableName)
+
+	if err = tx.Exec(sql, strings.ToUpper(code)).Error; err != nil {
+		return
+	}
+
+	if err = tx.Exec(fmt.Sprintf(`ALTER TABLE "%s" RENAME TO "%s_migrated"`, legacyTableName, legacyTableName)).Error; err != nil {
+		return
+	}
+
+	log.Printf("已迁移旧表 %s 的数据到 %s\n", legacyTableName, targetTableName)
+
+	return
+}
+
+// 币种表不存在则创建, 没有任何币种则写入默认的币种
+// 然后把旧版本中每个币种单独的表迁移到共用的表中
+func migrateCurrency(db *gorm.DB, sync bool) (err error) {
+	if db.HasTable(&model.Currency{}) == false {
+		if err = db.CreateTable(&model.Currency{}).Error; err != nil {
+			return
+		}
+	}
+
+	// 同步数据库时更新表结构, 否则只创建缺少的表
+	for _, value := range []interface{}{&model.Wallet{}, &model.TransferLog{}, &model.FinanceLog{}} {
+		if sync || !db.HasTable(value) {
+			if err = db.AutoMigrate(value).Error; err != nil {
+				return
+			}
+		}
+	}
+
+	var count int
+
+	if err = db.Model(&model.Currency{}).Count(&count).Error; err != nil {
+		return
+	}
+
+	if count == 0 {
+		for _, c := range model.DefaultCurrencies {
+			currency := c
+
+			if err = db.Create(&currency).Error; err != nil {
+				return
+			}
+		}
+	}
+
+	list := make([]model.Currency, 0)
+
+	if err = db.Find(&list).Error; err != nil {
+		return
+	}
+
+	for _, c := range list {
+		for _, t := range legacyTables {
+			if err = migrateLegacyTable(db, t, c.Code); err != nil {
+				return
+			}
+		}
+	}
+
+	return
+}
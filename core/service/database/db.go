@@ -36,38 +36,63 @@ func init() {
 
 		// Migrate the schema
 		db.AutoMigrate(
-			new(model.Admin),            // 管理员表
-			new(model.News),             // 新闻公告
-			new(model.User),             // 用户表
-			new(model.Role),             // 角色表 - RBAC
-			new(model.WalletCny),        // 钱包 - CNY
-			new(model.WalletUsd),        // 钱包 - USD
-			new(model.WalletCoin),       // 钱包 - COIN
-			new(model.InviteHistory),    // 邀请表
-			new(model.LoginLog),         // 登陆成功表
-			new(model.TransferLogCny),   // 转账记录 - CNY
-			new(model.TransferLogUsd),   // 转账记录 - USD
-			new(model.TransferLogCoin),  // 转账记录 - COIN
-			new(model.FinanceLogCny),    // 流水列表 - CNY
-			new(model.FinanceLogUsd),    // 流水列表 - USD
-			new(model.FinanceLogCoin),   // 流水列表 - COIN
-			new(model.Notification),     // 系统消息
-			new(model.NotificationMark), // 系统消息的已读记录
-			new(model.Message),          // 个人消息
-			new(model.Address),          // 收货地址
-			new(model.Banner),           // Banner 表
-			new(model.Report),           // 反馈表
-			new(model.Menu),             // 后台管理员菜单
-			new(model.Help),             // 帮助中心
-			new(model.WechatOpenID),     // 微信 open_id 外键表
-			new(model.OAuth),            // oAuth2 表
+			new(model.Admin),                  // 管理员表
+			new(model.News),                   // 新闻公告
+			new(model.User),                   // 用户表
+			new(model.Role),                   // 角色表 - RBAC
+			new(model.Currency),               // 币种
+			new(model.InviteHistory),          // 邀请表
+			new(model.InviteRewardRule),       // 邀请奖励的规则
+			new(model.InviteReward),           // 已发放的邀请奖励
+			new(model.LoginLog),               // 登陆成功表
+			new(model.Notification),           // 系统消息
+			new(model.NotificationMark),       // 系统消息的已读记录
+			new(model.Message),                // 个人消息
+			new(model.Address),                // 收货地址
+			new(model.Banner),                 // Banner 表
+			new(model.Report),                 // 反馈表
+			new(model.Menu),                   // 后台管理员菜单
+			new(model.Help),                   // 帮助中心
+			new(model.WechatOpenID),           // 微信 open_id 外键表
+			new(model.OAuth),                  // oAuth2 表
+			new(model.IdempotencyKey),         // 幂等键
+			new(model.WalletOperation),        // 管理员的钱包操作记录
+			new(model.TransferLimit),          // 转账限额
+			new(model.Statement),              // 每月自动发送的对账单记录
+			new(model.MessageTemplate),        // 消息模版
+			new(model.NotificationPreference), // 用户的通知偏好
 		)
 
+		// 密码哈希的长度变长了, AutoMigrate 不会修改已存在的字段
+		db.Model(new(model.Admin)).ModifyColumn("password", "varchar(255)")
+		db.Model(new(model.User)).ModifyColumn("password", "varchar(255)")
+		db.Model(new(model.User)).ModifyColumn("pay_password", "varchar(255)")
+
+		// 已读记录的主键原来只有通知 ID, 导致同一条通知只能有一个用户标记已读, 改为通知 ID 和用户 ID 的联合主键
+		db.Exec(`DO $$ BEGIN
+			IF (SELECT array_length(conkey, 1) FROM pg_constraint WHERE conname = 'notification_mark_pkey') = 1 THEN
+				ALTER TABLE notification_mark DROP CONSTRAINT notification_mark_pkey;
+				ALTER TABLE notification_mark ADD PRIMARY KEY (id, uid);
+			END IF;
+		END $$`)
+		db.Exec("ALTER TABLE notification_mark DROP CONSTRAINT IF EXISTS notification_mark_id_key")
+
+		// 交易密码的权限名称由 password2.set/password2.reset 改为 password2::set/password2::reset, 已保存的角色和管理员需要同步
+		for _, table := range []string{"role", "admin"} {
+			db.Exec(fmt.Sprintf(`UPDATE %s SET accession = array_replace(array_replace(accession, 'password2.set', 'password2::set'), 'password2.reset', 'password2::reset')
+				WHERE accession && ARRAY['password2.set', 'password2.reset']::varchar[]`, table))
+		}
+
 		log.Println("数据库同步完成.")
 	}
 
 	Db = db
 
+	// 确保币种表以及所有币种共用的钱包, 转账记录, 财务日志表存在, 并迁移旧版本中每个币种单独的表
+	if err := migrateCurrency(db, Config.Sync == "on"); err != nil {
+		panic(err)
+	}
+
 	// 确保超级管理员账号存在
 	if err := db.First(&model.Admin{Username: "admin", IsSuper: true}).Error; err != nil {
 		if err == gorm.ErrRecordNotFound {
@@ -0,0 +1,12 @@
+// Copyright 2019 Axetroy. All rights reserved. MIT license.
+package database
+
+import "github.com/lib/pq"
+
+// 是否是唯一索引冲突导致的错误
+func IsUniqueViolation(err error) bool {
+	if e, ok := err.(*pq.Error); ok {
+		return e.Code == "23505" // unique_violation
+	}
+	return false
+}
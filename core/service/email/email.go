@@ -2,26 +2,22 @@
 package email
 
 import (
+	"bytes"
 	"crypto/tls"
 	"errors"
 	"fmt"
 	"github.com/axetroy/go-server/core/config"
 	"github.com/axetroy/go-server/core/exception"
+	"github.com/axetroy/go-server/core/service/database"
+	"github.com/axetroy/go-server/core/service/message_template"
 	"github.com/jordan-wright/email"
 	"net"
 	"net/smtp"
 	"net/textproto"
 )
 
-// TODO: 重构邮箱模版
-const (
-	prefix                      = "[GOTEST]: "
-	TemplateActivation          = `<a href="javascript: void 0">点击这里激活</a>或使用激活码: %v`
-	TemplateForgotPassword      = `<a href="javascript: void 0">点击连接重置密码</a>或使用重置码: %v`
-	TemplateForgotTradePassword = `<a href="javascript: void 0">点击连接重置交易密码</a>或使用重置码: %v`
-	TemplateAuth                = `正在验证您的身份，你的验证码是 %s`
-	TemplateRegistry            = `<a href="%s" href="target">点击注册您的帐号</a>`
-)
+// 系统邮件标题的前缀
+const prefix = "[GOTEST]: "
 
 var Config = config.SMTP
 
@@ -71,6 +67,13 @@ func (e *Mailer) Send(message *Message) (err error) {
 		Headers: textproto.MIMEHeader{},
 	}
 
+	// 附件的 Content-Type 为空时, 按照二进制文件发送
+	for _, attachment := range message.Attachments {
+		if _, err = msg.Attach(bytes.NewReader(attachment.Content), attachment.Filename, attachment.Header.Get("Content-Type")); err != nil {
+			return
+		}
+	}
+
 	var addr = net.JoinHostPort(Config.Host, Config.Port)
 
 	if err = msg.SendWithTLS(
@@ -86,72 +89,87 @@ func (e *Mailer) Send(message *Message) (err error) {
 	return nil
 }
 
-// 发送激活邮件
-func (e *Mailer) SendActivationEmail(toEmail string, code string) (err error) {
-	if err = e.Send(&Message{
-		To:      []string{toEmail},
-		Subject: prefix + "账号激活",
-		Text:    []byte("请点击连接激活您的账号"),
-		HTML:    []byte(fmt.Sprintf(TemplateActivation, code)),
-	}); err != nil {
+// 使用模版发送邮件
+func (e *Mailer) SendTemplate(toEmail string, tpl message_template.Template, values map[string]interface{}, attachments ...*email.Attachment) (err error) {
+	rendered, err := tpl.Render(values)
+
+	if err != nil {
 		return
 	}
 
-	return nil
+	return e.SendRendered(toEmail, rendered, attachments...)
 }
 
-// 发送认证邮件
-func (e *Mailer) SendAuthEmail(toEmail string, code string) (err error) {
-	if err = e.Send(&Message{
-		To:      []string{toEmail},
-		Subject: prefix + "邮箱认证",
-		Text:    []byte(fmt.Sprintf("您的验证码是: %s", code)),
-		HTML:    []byte(fmt.Sprintf(TemplateAuth, code)),
-	}); err != nil {
+// 使用默认语言的系统模版发送系统邮件
+func (e *Mailer) sendSystem(toEmail string, name string, values map[string]interface{}, attachments ...*email.Attachment) (err error) {
+	tpl, err := message_template.Find(database.Db, name, message_template.DefaultLocale)
+
+	if err != nil {
 		return
 	}
 
-	return nil
-}
+	rendered, err := tpl.Render(values)
 
-// 发送注册邮件
-func (e *Mailer) SendRegisterEmail(toEmail string, redirectURL string) (err error) {
-	if err = e.Send(&Message{
-		To:      []string{toEmail},
-		Subject: prefix + "邮箱认证",
-		Text:    []byte(fmt.Sprintf("打开链接注册帐号: %s", redirectURL)),
-		HTML:    []byte(fmt.Sprintf(TemplateRegistry, redirectURL)),
-	}); err != nil {
+	if err != nil {
 		return
 	}
 
-	return nil
+	rendered.Subject = prefix + rendered.Subject
+
+	return e.SendRendered(toEmail, rendered, attachments...)
 }
 
-// 发送忘记密码邮件
-func (e *Mailer) SendForgotPasswordEmail(toEmail string, code string) (err error) {
-	if err = e.Send(&Message{
-		To:      []string{toEmail},
-		Subject: prefix + "忘记登陆密码",
-		Text:    []byte("请点击重置您的登陆密码"),
-		HTML:    []byte(fmt.Sprintf(TemplateForgotPassword, code)),
-	}); err != nil {
-		return
+// 发送已经渲染好的模版
+func (e *Mailer) SendRendered(toEmail string, rendered message_template.Rendered, attachments ...*email.Attachment) error {
+	message := &Message{
+		To:          []string{toEmail},
+		Subject:     rendered.Subject,
+		Attachments: attachments,
 	}
 
-	return nil
+	if rendered.HTML {
+		message.HTML = []byte(rendered.Body)
+	} else {
+		message.Text = []byte(rendered.Body)
+	}
+
+	return e.Send(message)
+}
+
+// 发送激活邮件
+func (e *Mailer) SendActivationEmail(toEmail string, code string) (err error) {
+	return e.sendSystem(toEmail, message_template.NameActivation, map[string]interface{}{"code": code})
+}
+
+// 发送认证邮件
+func (e *Mailer) SendAuthEmail(toEmail string, code string) (err error) {
+	return e.sendSystem(toEmail, message_template.NameAuth, map[string]interface{}{"code": code})
+}
+
+// 发送注册邮件
+func (e *Mailer) SendRegisterEmail(toEmail string, redirectURL string) (err error) {
+	return e.sendSystem(toEmail, message_template.NameRegister, map[string]interface{}{"url": redirectURL})
+}
+
+// 发送忘记密码邮件
+func (e *Mailer) SendForgotPasswordEmail(toEmail string, code string) (err error) {
+	return e.sendSystem(toEmail, message_template.NameForgotPassword, map[string]interface{}{"code": code})
 }
 
 // 发送忘记交易密码邮件
 func (e *Mailer) SendForgotTradePasswordEmail(toEmail string, code string) (err error) {
-	if err = e.Send(&Message{
-		To:      []string{toEmail},
-		Subject: prefix + "忘记交易密码",
-		Text:    []byte("请点击连接激活您的账号"),
-		HTML:    []byte(fmt.Sprintf(TemplateForgotTradePassword, code)),
-	}); err != nil {
-		return
+	return e.sendSystem(toEmail, message_template.NameForgotTradePassword, map[string]interface{}{"code": code})
+}
+
+// 发送对账单邮件, 对账单作为附件发送
+func (e *Mailer) SendStatementEmail(toEmail string, period string, filename string, contentType string, content []byte) (err error) {
+	attachment := &email.Attachment{
+		Filename: filename,
+		Header:   textproto.MIMEHeader{},
+		Content:  content,
 	}
 
-	return nil
+	attachment.Header.Set("Content-Type", contentType)
+
+	return e.sendSystem(toEmail, message_template.NameStatement, map[string]interface{}{"period": period}, attachment)
 }
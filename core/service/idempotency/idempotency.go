@@ -0,0 +1,98 @@
+// Copyright 2019 Axetroy. All rights reserved. MIT license.
+package idempotency
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+
+	"github.com/axetroy/go-server/core/exception"
+	"github.com/axetroy/go-server/core/model"
+	"github.com/jinzhu/gorm"
+	"github.com/lib/pq"
+)
+
+// 幂等键
+// 客户端在重试请求时带上相同的幂等键, 服务端直接返回第一次处理的结果, 而不会重复处理
+// 幂等键和处理结果在同一个事务中保存, 事务回滚则幂等键也不会保存, 客户端可以用同一个键重试
+
+type Scope string
+
+const (
+	ScopeTransfer Scope = "transfer" // 转账
+
+	MaxKeyLength = 64 // 幂等键的最大长度
+)
+
+// 计算请求参数的哈希
+func Hash(input interface{}) (string, error) {
+	b, err := json.Marshal(input)
+
+	if err != nil {
+		return "", err
+	}
+
+	sum := sha256.Sum256(b)
+
+	return hex.EncodeToString(sum[:]), nil
+}
+
+// 校验幂等键的格式
+func Valid(key string) error {
+	if len(key) == 0 || len(key) > MaxKeyLength {
+		return exception.InvalidParams
+	}
+	return nil
+}
+
+// 查找已经处理过的请求, 如果存在则把当时的结果写入 out
+// 幂等键被用于参数不同的请求时报错
+func Replay(db *gorm.DB, scope Scope, uid string, key string, hash string, out interface{}) (replayed bool, err error) {
+	record := model.IdempotencyKey{}
+
+	if err = db.Where(`scope = ? AND uid = ? AND "key" = ?`, string(scope), uid, key).First(&record).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			err = nil
+		}
+		return
+	}
+
+	if record.RequestHash != hash {
+		err = exception.IdempotencyKeyReused
+		return
+	}
+
+	if err = json.Unmarshal([]byte(record.Response), out); err != nil {
+		return
+	}
+
+	replayed = true
+
+	return
+}
+
+// 在处理请求的事务中保存幂等键以及处理结果
+// 并发的相同请求会在这里因为唯一索引冲突而失败, 事务回滚之后再调用 Replay 即可
+func Save(tx *gorm.DB, scope Scope, uid string, key string, hash string, response interface{}) error {
+	b, err := json.Marshal(response)
+
+	if err != nil {
+		return err
+	}
+
+	return tx.Create(&model.IdempotencyKey{
+		Scope:       string(scope),
+		Uid:         uid,
+		Key:         key,
+		RequestHash: hash,
+		Response:    string(b),
+	}).Error
+}
+
+// 是否是幂等键冲突导致的错误
+func IsConflict(err error) bool {
+	if e, ok := err.(*pq.Error); ok {
+		return e.Code == "23505" // unique_violation
+	}
+	return false
+}
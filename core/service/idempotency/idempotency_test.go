@@ -0,0 +1,48 @@
+// Copyright 2019 Axetroy. All rights reserved. MIT license.
+package idempotency
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/axetroy/go-server/core/exception"
+	"github.com/lib/pq"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestHash(t *testing.T) {
+	type params struct {
+		To     string `json:"to"`
+		Amount string `json:"amount"`
+	}
+
+	h1, err := Hash(params{To: "1", Amount: "10"})
+
+	assert.Nil(t, err)
+	assert.Len(t, h1, 64)
+
+	h2, err := Hash(params{To: "1", Amount: "10"})
+
+	assert.Nil(t, err)
+	assert.Equal(t, h1, h2)
+
+	h3, err := Hash(params{To: "1", Amount: "10.1"})
+
+	assert.Nil(t, err)
+	assert.NotEqual(t, h1, h3)
+}
+
+func TestValid(t *testing.T) {
+	assert.Nil(t, Valid("0c1b0bb8-9b5b-4d0b-8b8e-2f3c1b0a9e11"))
+	assert.Nil(t, Valid(strings.Repeat("a", MaxKeyLength)))
+	assert.Equal(t, exception.InvalidParams, Valid(""))
+	assert.Equal(t, exception.InvalidParams, Valid(strings.Repeat("a", MaxKeyLength+1)))
+}
+
+func TestIsConflict(t *testing.T) {
+	assert.True(t, IsConflict(&pq.Error{Code: "23505"}))
+	assert.False(t, IsConflict(&pq.Error{Code: "23503"}))
+	assert.False(t, IsConflict(errors.New("unique_violation")))
+	assert.False(t, IsConflict(nil))
+}
@@ -0,0 +1,280 @@
+// Copyright 2019 Axetroy. All rights reserved. MIT license.
+package lockout
+
+import (
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/axetroy/go-server/core/config"
+	"github.com/axetroy/go-server/core/exception"
+	"github.com/axetroy/go-server/core/service/redis"
+	"github.com/axetroy/go-server/core/util"
+	goredis "github.com/go-redis/redis"
+)
+
+// 防止暴力破解密码
+// 在滑动窗口内，同一个账号/IP 失败次数达到上限后会被锁定一段时间
+// 每次被锁定，锁定的时长都会翻倍，直到上限
+
+type Scope string
+type Kind string
+
+const (
+	ScopeUser        Scope = "user"  // 用户登陆
+	ScopeAdmin       Scope = "admin" // 管理员登陆
+	ScopePayPassword Scope = "pay"   // 交易密码
+	KindAccount      Kind  = "account"
+	KindIp           Kind  = "ip"
+)
+
+type Lock struct {
+	Id        string    // 锁定 ID, 格式为 <scope>:<kind>:<value>
+	Scope     Scope     // 锁定的场景
+	Kind      Kind      // 锁定的是账号还是 IP
+	Value     string    // 账号或 IP
+	Level     int64     // 第几次被锁定
+	ExpiredAt time.Time // 解锁的时间
+}
+
+func subject(scope Scope, kind Kind, value string) string {
+	return string(scope) + ":" + string(kind) + ":" + value
+}
+
+func failKey(id string) string {
+	return "fail:" + id
+}
+
+func levelKey(id string) string {
+	return "level:" + id
+}
+
+func lockKey(id string) string {
+	return "lock:" + id
+}
+
+// 需要统计的对象, 为空的会被忽略
+func subjects(scope Scope, account string, ip string) []string {
+	list := make([]string, 0)
+
+	if account != "" {
+		list = append(list, subject(scope, KindAccount, account))
+	}
+
+	if ip != "" {
+		list = append(list, subject(scope, KindIp, ip))
+	}
+
+	return list
+}
+
+func limitOf(id string) int64 {
+	if l, ok := parse(id); ok && l.Kind == KindIp {
+		return config.Lockout.IpLimit
+	}
+
+	return config.Lockout.AccountLimit
+}
+
+// 第 level 次锁定的时长
+func durationOf(level int64) time.Duration {
+	duration := config.Lockout.Duration
+
+	for i := int64(1); i < level && duration < config.Lockout.MaxDuration; i++ {
+		duration *= 2
+	}
+
+	if duration > config.Lockout.MaxDuration {
+		duration = config.Lockout.MaxDuration
+	}
+
+	return duration
+}
+
+// 检查账号或 IP 是否被锁定
+func Check(scope Scope, account string, ip string) error {
+	ids := subjects(scope, account, ip)
+
+	if len(ids) == 0 {
+		return nil
+	}
+
+	keys := make([]string, 0, len(ids))
+
+	for _, id := range ids {
+		keys = append(keys, lockKey(id))
+	}
+
+	values, err := redis.ClientLockout.MGet(keys...).Result()
+
+	if err != nil {
+		return err
+	}
+
+	for _, v := range values {
+		if v != nil {
+			return exception.AccountLocked
+		}
+	}
+
+	return nil
+}
+
+// 记录一次失败, 达到上限则锁定
+func Fail(scope Scope, account string, ip string) (locked bool, err error) {
+	now := time.Now()
+
+	for _, id := range subjects(scope, account, ip) {
+		var count *goredis.IntCmd
+
+		if _, err = redis.ClientLockout.TxPipelined(func(pipe goredis.Pipeliner) error {
+			pipe.ZRemRangeByScore(failKey(id), "-inf", strconv.FormatInt(now.Add(-config.Lockout.Window).UnixNano(), 10))
+			pipe.ZAdd(failKey(id), goredis.Z{Score: float64(now.UnixNano()), Member: util.GenerateId()})
+			count = pipe.ZCard(failKey(id))
+			pipe.Expire(failKey(id), config.Lockout.Window)
+			return nil
+		}); err != nil {
+			return
+		}
+
+		if count.Val() < limitOf(id) {
+			continue
+		}
+
+		if err = lock(id, now); err != nil {
+			return
+		}
+
+		locked = true
+	}
+
+	return
+}
+
+func lock(id string, now time.Time) error {
+	level, err := redis.ClientLockout.Incr(levelKey(id)).Result()
+
+	if err != nil {
+		return err
+	}
+
+	duration := durationOf(level)
+
+	_, err = redis.ClientLockout.TxPipelined(func(pipe goredis.Pipeliner) error {
+		pipe.Expire(levelKey(id), config.Lockout.LevelExpiration)
+		pipe.Set(lockKey(id), now.Add(duration).Unix(), duration)
+		// 解锁后重新开始计数
+		pipe.Del(failKey(id))
+		return nil
+	})
+
+	return err
+}
+
+// 登陆成功后清空账号的失败记录, IP 的失败记录保留
+func Success(scope Scope, account string) error {
+	if account == "" {
+		return nil
+	}
+
+	id := subject(scope, KindAccount, account)
+
+	return redis.ClientLockout.Del(failKey(id), levelKey(id)).Err()
+}
+
+func parse(id string) (l Lock, ok bool) {
+	parts := strings.SplitN(id, ":", 3)
+
+	if len(parts) != 3 {
+		return
+	}
+
+	l.Id = id
+	l.Scope = Scope(parts[0])
+	l.Kind = Kind(parts[1])
+	l.Value = parts[2]
+
+	return l, true
+}
+
+// 获取当前被锁定的列表, scope 为空则获取全部
+func List(scope Scope) ([]Lock, error) {
+	var (
+		cursor  uint64
+		pattern = lockKey("*")
+		list    = make([]Lock, 0)
+	)
+
+	if scope != "" {
+		pattern = lockKey(string(scope) + ":*")
+	}
+
+	for {
+		keys, next, err := redis.ClientLockout.Scan(cursor, pattern, 100).Result()
+
+		if err != nil {
+			return nil, err
+		}
+
+		for _, key := range keys {
+			l, err := Get(strings.TrimPrefix(key, lockKey("")))
+
+			if err != nil {
+				if err == exception.LockNotExist {
+					continue
+				}
+				return nil, err
+			}
+
+			list = append(list, l)
+		}
+
+		if next == 0 {
+			break
+		}
+
+		cursor = next
+	}
+
+	return list, nil
+}
+
+// 获取锁定的详情
+func Get(id string) (Lock, error) {
+	l, ok := parse(id)
+
+	if !ok {
+		return l, exception.LockNotExist
+	}
+
+	values, err := redis.ClientLockout.MGet(lockKey(id), levelKey(id)).Result()
+
+	if err != nil {
+		return l, err
+	}
+
+	if values[0] == nil {
+		return l, exception.LockNotExist
+	}
+
+	if s, ok := values[0].(string); ok {
+		if unix, err := strconv.ParseInt(s, 10, 64); err == nil {
+			l.ExpiredAt = time.Unix(unix, 0)
+		}
+	}
+
+	if s, ok := values[1].(string); ok {
+		l.Level, _ = strconv.ParseInt(s, 10, 64)
+	}
+
+	return l, nil
+}
+
+// 解除锁定, 同时清空失败记录以及锁定等级
+func Clear(id string) error {
+	if _, err := Get(id); err != nil {
+		return err
+	}
+
+	return redis.ClientLockout.Del(lockKey(id), failKey(id), levelKey(id)).Err()
+}
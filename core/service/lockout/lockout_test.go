@@ -0,0 +1,37 @@
+// Copyright 2019 Axetroy. All rights reserved. MIT license.
+package lockout
+
+import (
+	"github.com/axetroy/go-server/core/config"
+	"github.com/stretchr/testify/assert"
+	"testing"
+	"time"
+)
+
+func TestDurationOf(t *testing.T) {
+	config.Lockout.Duration = time.Minute
+	config.Lockout.MaxDuration = time.Hour
+
+	// 每次锁定时长翻倍
+	assert.Equal(t, time.Minute, durationOf(1))
+	assert.Equal(t, time.Minute*2, durationOf(2))
+	assert.Equal(t, time.Minute*4, durationOf(3))
+	assert.Equal(t, time.Minute*32, durationOf(6))
+
+	// 不超过上限
+	assert.Equal(t, time.Hour, durationOf(7))
+	assert.Equal(t, time.Hour, durationOf(1000))
+}
+
+func TestParse(t *testing.T) {
+	l, ok := parse("user:ip:::1")
+
+	assert.True(t, ok)
+	assert.Equal(t, ScopeUser, l.Scope)
+	assert.Equal(t, KindIp, l.Kind)
+	assert.Equal(t, "::1", l.Value)
+
+	_, ok = parse("invalid")
+
+	assert.False(t, ok)
+}
@@ -0,0 +1,78 @@
+// Copyright 2019 Axetroy. All rights reserved. MIT license.
+package message_template
+
+// 系统内置的模版名称
+const (
+	NameActivation          = "activation"            // 账号激活
+	NameAuth                = "auth"                  // 身份认证
+	NameRegister            = "register"              // 邮箱注册
+	NameForgotPassword      = "forgot_password"       // 忘记登陆密码
+	NameForgotTradePassword = "forgot_trade_password" // 忘记交易密码
+	NameStatement           = "statement"             // 月度对账单
+)
+
+// 内置模版的通知分类, 与 preference.Category 对应
+const (
+	CategorySecurity  = "security"  // 安全类, 总是发送
+	CategoryTransfers = "transfers" // 转账和资金变动
+	CategorySystem    = "system"    // 系统消息, 自定义模版的默认分类
+)
+
+// 内置的模版, 数据库中没有同名模版时使用
+// 管理员可以创建同名的模版来覆盖它
+var Builtin = map[string]Template{
+	NameActivation: {
+		Name:      NameActivation,
+		Locale:    DefaultLocale,
+		Subject:   "账号激活",
+		Format:    FormatHTML,
+		Body:      `<a href="javascript: void 0">点击这里激活</a>或使用激活码: {{.code}}`,
+		Variables: []Variable{{Name: "code", Type: VariableTypeString, Required: true}},
+		Category:  CategorySecurity,
+	},
+	NameAuth: {
+		Name:      NameAuth,
+		Locale:    DefaultLocale,
+		Subject:   "邮箱认证",
+		Format:    FormatHTML,
+		Body:      `正在验证您的身份，你的验证码是 {{.code}}`,
+		Variables: []Variable{{Name: "code", Type: VariableTypeString, Required: true}},
+		Category:  CategorySecurity,
+	},
+	NameRegister: {
+		Name:      NameRegister,
+		Locale:    DefaultLocale,
+		Subject:   "邮箱认证",
+		Format:    FormatHTML,
+		Body:      `<a href="{{.url}}" href="target">点击注册您的帐号</a>`,
+		Variables: []Variable{{Name: "url", Type: VariableTypeString, Required: true}},
+		Category:  CategorySecurity,
+	},
+	NameForgotPassword: {
+		Name:      NameForgotPassword,
+		Locale:    DefaultLocale,
+		Subject:   "忘记登陆密码",
+		Format:    FormatHTML,
+		Body:      `<a href="javascript: void 0">点击连接重置密码</a>或使用重置码: {{.code}}`,
+		Variables: []Variable{{Name: "code", Type: VariableTypeString, Required: true}},
+		Category:  CategorySecurity,
+	},
+	NameForgotTradePassword: {
+		Name:      NameForgotTradePassword,
+		Locale:    DefaultLocale,
+		Subject:   "忘记交易密码",
+		Format:    FormatHTML,
+		Body:      `<a href="javascript: void 0">点击连接重置交易密码</a>或使用重置码: {{.code}}`,
+		Variables: []Variable{{Name: "code", Type: VariableTypeString, Required: true}},
+		Category:  CategorySecurity,
+	},
+	NameStatement: {
+		Name:      NameStatement,
+		Locale:    DefaultLocale,
+		Subject:   "{{.period}} 账户对账单",
+		Format:    FormatText,
+		Body:      `您 {{.period}} 的账户对账单已生成, 请查看附件`,
+		Variables: []Variable{{Name: "period", Type: VariableTypeString, Required: true}},
+		Category:  CategoryTransfers,
+	},
+}
@@ -0,0 +1,47 @@
+// Copyright 2019 Axetroy. All rights reserved. MIT license.
+package message_template
+
+import "github.com/axetroy/go-server/core/exception"
+
+type Channel string
+
+const (
+	ChannelMessage Channel = "message" // 站内的个人消息
+	ChannelEmail   Channel = "email"   // 邮件
+	ChannelSms     Channel = "sms"     // 短信
+)
+
+var Channels = []Channel{ChannelMessage, ChannelEmail, ChannelSms}
+
+// 校验并去除重复的渠道
+func ParseChannels(channels []string) ([]Channel, error) {
+	result := make([]Channel, 0)
+	exist := map[Channel]bool{}
+
+	for _, c := range channels {
+		channel := Channel(c)
+		valid := false
+
+		for _, v := range Channels {
+			if v == channel {
+				valid = true
+				break
+			}
+		}
+
+		if !valid {
+			return nil, exception.MessageTemplateInvalidChannel
+		}
+
+		if !exist[channel] {
+			exist[channel] = true
+			result = append(result, channel)
+		}
+	}
+
+	if len(result) == 0 {
+		return nil, exception.MessageTemplateInvalidChannel
+	}
+
+	return result, nil
+}
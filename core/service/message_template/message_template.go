@@ -0,0 +1,197 @@
+// Copyright 2019 Axetroy. All rights reserved. MIT license.
+package message_template
+
+import (
+	"bytes"
+	"encoding/json"
+	"github.com/axetroy/go-server/core/exception"
+	"github.com/axetroy/go-server/core/model"
+	"github.com/jinzhu/gorm"
+	htmlTemplate "html/template"
+	textTemplate "text/template"
+)
+
+type Format string
+
+const (
+	FormatText Format = "text" // 纯文本, 使用 text/template 渲染
+	FormatHTML Format = "html" // HTML, 使用 html/template 渲染, 变量会被转义
+)
+
+// 没有指定语言时使用的语言, 找不到对应语言的模版时也会回退到这个语言
+var DefaultLocale = "zh-CN"
+
+type Template struct {
+	Name          string     // 模版名称
+	Locale        string     // 模版的语言
+	Subject       string     // 标题, 使用 text/template 语法
+	Format        Format     // 内容的格式
+	Body          string     // 模版内容
+	Variables     []Variable // 模版变量的声明
+	Category      string     // 通知的分类, 发送时按照这个分类检查用户的通知偏好
+	SmsTemplateId string     // 短信服务商的模版ID, 为空则不能通过短信发送
+}
+
+// 渲染后的消息
+type Rendered struct {
+	Subject string                 // 标题
+	Body    string                 // 内容
+	HTML    bool                   // 内容是否为 HTML
+	Values  map[string]interface{} // 转换类型之后的变量
+}
+
+// 校验模版的语法和变量声明
+func (t Template) Validate() error {
+	if t.Format != FormatText && t.Format != FormatHTML {
+		return exception.MessageTemplateInvalid
+	}
+
+	if err := ValidateVariables(t.Variables); err != nil {
+		return err
+	}
+
+	// 系统发送内置模版时只会传入内置模版声明的变量
+	if b, ok := Builtin[t.Name]; ok {
+		if err := CompatibleVariables(b.Variables, t.Variables); err != nil {
+			return err
+		}
+	}
+
+	if _, err := textTemplate.New("subject").Parse(t.Subject); err != nil {
+		return exception.MessageTemplateInvalid
+	}
+
+	if t.Format == FormatHTML {
+		if _, err := htmlTemplate.New("body").Parse(t.Body); err != nil {
+			return exception.MessageTemplateInvalid
+		}
+	} else {
+		if _, err := textTemplate.New("body").Parse(t.Body); err != nil {
+			return exception.MessageTemplateInvalid
+		}
+	}
+
+	return nil
+}
+
+// 使用传入的变量渲染模版
+func (t Template) Render(values map[string]interface{}) (r Rendered, err error) {
+	if err = t.Validate(); err != nil {
+		return
+	}
+
+	if r.Values, err = Coerce(t.Variables, values); err != nil {
+		return
+	}
+
+	subject := bytes.NewBuffer(nil)
+
+	// 模版引用了未声明的变量时报错, 而不是输出 <no value>
+	if err = textTemplate.Must(textTemplate.New("subject").Option("missingkey=error").Parse(t.Subject)).Execute(subject, r.Values); err != nil {
+		err = exception.MessageTemplateInvalidVariable
+		return
+	}
+
+	body := bytes.NewBuffer(nil)
+
+	if t.Format == FormatHTML {
+		err = htmlTemplate.Must(htmlTemplate.New("body").Option("missingkey=error").Parse(t.Body)).Execute(body, r.Values)
+	} else {
+		err = textTemplate.Must(textTemplate.New("body").Option("missingkey=error").Parse(t.Body)).Execute(body, r.Values)
+	}
+
+	if err != nil {
+		err = exception.MessageTemplateInvalidVariable
+		return
+	}
+
+	r.Subject = subject.String()
+	r.Body = body.String()
+	r.HTML = t.Format == FormatHTML
+
+	return
+}
+
+// 把数据库中的模版转换为 Template
+func FromModel(m model.MessageTemplate) (t Template, err error) {
+	t = Template{
+		Name:      m.Name,
+		Locale:    m.Locale,
+		Subject:   m.Subject,
+		Format:    Format(m.Format),
+		Body:      m.Body,
+		Variables: make([]Variable, 0),
+		Category:  m.Category,
+	}
+
+	if t.Category == "" {
+		t.Category = CategorySystem
+	}
+
+	// 覆盖内置模版时沿用内置模版的分类, 不能通过覆盖改变分类
+	if b, ok := Builtin[m.Name]; ok {
+		t.Category = b.Category
+	}
+
+	if m.SmsTemplateId != nil {
+		t.SmsTemplateId = *m.SmsTemplateId
+	}
+
+	if m.Variables != "" {
+		if err = json.Unmarshal([]byte(m.Variables), &t.Variables); err != nil {
+			err = exception.MessageTemplateInvalid
+			return
+		}
+	}
+
+	return
+}
+
+// 把变量的声明序列化, 用于保存到数据库
+func MarshalVariables(variables []Variable) (string, error) {
+	if variables == nil {
+		variables = make([]Variable, 0)
+	}
+
+	b, err := json.Marshal(variables)
+
+	if err != nil {
+		return "", err
+	}
+
+	return string(b), nil
+}
+
+// 查找模版
+// 依次查找数据库中对应语言的模版, 默认语言的模版, 最后是内置的模版
+func Find(db *gorm.DB, name string, locale string) (t Template, err error) {
+	if locale == "" {
+		locale = DefaultLocale
+	}
+
+	locales := []string{locale}
+
+	if locale != DefaultLocale {
+		locales = append(locales, DefaultLocale)
+	}
+
+	for _, l := range locales {
+		info := model.MessageTemplate{}
+
+		if err = db.Where("name = ? AND locale = ?", name, l).First(&info).Error; err == nil {
+			return FromModel(info)
+		}
+
+		if err != gorm.ErrRecordNotFound {
+			return
+		}
+	}
+
+	if b, ok := Builtin[name]; ok {
+		return b, nil
+	}
+
+	err = exception.MessageTemplateNotExist
+
+	return
+}
@@ -0,0 +1,218 @@
+// Copyright 2019 Axetroy. All rights reserved. MIT license.
+package message_template_test
+
+import (
+	"github.com/axetroy/go-server/core/exception"
+	"github.com/axetroy/go-server/core/model"
+	"github.com/axetroy/go-server/core/service/message_template"
+	"github.com/stretchr/testify/assert"
+	"testing"
+	"time"
+)
+
+func TestCoerce(t *testing.T) {
+	variables := []message_template.Variable{
+		{Name: "name", Type: message_template.VariableTypeString, Required: true},
+		{Name: "count", Type: message_template.VariableTypeInt},
+		{Name: "amount", Type: message_template.VariableTypeFloat},
+		{Name: "vip", Type: message_template.VariableTypeBool},
+		{Name: "at", Type: message_template.VariableTypeTime},
+	}
+
+	// JSON 中的数字为 float64, 时间为字符串
+	values, err := message_template.Coerce(variables, map[string]interface{}{
+		"name":   "axetroy",
+		"count":  float64(3),
+		"amount": "1.5",
+		"vip":    true,
+		"at":     "2019-08-01T10:00:00Z",
+	})
+
+	assert.Nil(t, err)
+	assert.Equal(t, "axetroy", values["name"])
+	assert.Equal(t, int64(3), values["count"])
+	assert.Equal(t, 1.5, values["amount"])
+	assert.Equal(t, true, values["vip"])
+	assert.Equal(t, time.Date(2019, 8, 1, 10, 0, 0, 0, time.UTC), values["at"])
+
+	// 非必填的变量为零值
+	values, err = message_template.Coerce(variables, map[string]interface{}{"name": "axetroy"})
+
+	assert.Nil(t, err)
+	assert.Equal(t, int64(0), values["count"])
+	assert.Equal(t, false, values["vip"])
+
+	// 缺少必填的变量
+	_, err = message_template.Coerce(variables, map[string]interface{}{})
+	assert.Equal(t, exception.MessageTemplateInvalidVariable, err)
+
+	// 类型不匹配
+	_, err = message_template.Coerce(variables, map[string]interface{}{"name": "axetroy", "count": 1.5})
+	assert.Equal(t, exception.MessageTemplateInvalidVariable, err)
+
+	// 未声明的变量
+	_, err = message_template.Coerce(variables, map[string]interface{}{"name": "axetroy", "other": "1"})
+	assert.Equal(t, exception.MessageTemplateInvalidVariable, err)
+
+	assert.Equal(t, map[string]string{
+		"at":    "2019-08-01 10:00:00",
+		"count": "3",
+	}, message_template.Strings(map[string]interface{}{
+		"at":    time.Date(2019, 8, 1, 10, 0, 0, 0, time.UTC),
+		"count": int64(3),
+	}))
+}
+
+func TestValidate(t *testing.T) {
+	tpl := message_template.Template{
+		Subject:   "{{.name}}",
+		Format:    message_template.FormatText,
+		Body:      "hello {{.name}}",
+		Variables: []message_template.Variable{{Name: "name", Type: message_template.VariableTypeString}},
+	}
+
+	assert.Nil(t, tpl.Validate())
+
+	invalid := tpl
+	invalid.Format = "markdown"
+	assert.Equal(t, exception.MessageTemplateInvalid, invalid.Validate())
+
+	invalid = tpl
+	invalid.Body = "hello {{.name"
+	assert.Equal(t, exception.MessageTemplateInvalid, invalid.Validate())
+
+	invalid = tpl
+	invalid.Variables = []message_template.Variable{{Name: "first-name", Type: message_template.VariableTypeString}}
+	assert.Equal(t, exception.MessageTemplateInvalidVariable, invalid.Validate())
+
+	invalid = tpl
+	invalid.Variables = []message_template.Variable{{Name: "name", Type: "decimal"}}
+	assert.Equal(t, exception.MessageTemplateInvalidVariable, invalid.Validate())
+
+	invalid = tpl
+	invalid.Variables = append(tpl.Variables, tpl.Variables...)
+	assert.Equal(t, exception.MessageTemplateInvalidVariable, invalid.Validate())
+
+	// 内置的模版都是合法的
+	for _, b := range message_template.Builtin {
+		assert.Nil(t, b.Validate())
+	}
+}
+
+func TestValidateOverride(t *testing.T) {
+	tpl := message_template.Template{
+		Name:      message_template.NameAuth,
+		Subject:   "验证码",
+		Format:    message_template.FormatText,
+		Body:      "{{.name}} 你的验证码是 {{.code}}",
+		Variables: []message_template.Variable{{Name: "code", Type: message_template.VariableTypeString}, {Name: "name", Type: message_template.VariableTypeString}},
+	}
+
+	// 可以新增非必填的变量, 内置的变量也可以改为非必填
+	assert.Nil(t, tpl.Validate())
+
+	invalid := tpl
+	invalid.Variables = []message_template.Variable{{Name: "name", Type: message_template.VariableTypeString}}
+	assert.Equal(t, exception.MessageTemplateIncompatible, invalid.Validate())
+
+	invalid = tpl
+	invalid.Variables = []message_template.Variable{{Name: "code", Type: message_template.VariableTypeInt, Required: true}}
+	assert.Equal(t, exception.MessageTemplateIncompatible, invalid.Validate())
+
+	invalid = tpl
+	invalid.Variables = []message_template.Variable{{Name: "code", Type: message_template.VariableTypeString, Required: true}, {Name: "name", Type: message_template.VariableTypeString, Required: true}}
+	assert.Equal(t, exception.MessageTemplateIncompatible, invalid.Validate())
+}
+
+func TestRender(t *testing.T) {
+	tpl := message_template.Template{
+		Subject: "{{.name}} 的订单",
+		Format:  message_template.FormatHTML,
+		Body:    `<p>{{.name}} 共 {{.count}} 件</p>`,
+		Variables: []message_template.Variable{
+			{Name: "name", Type: message_template.VariableTypeString, Required: true},
+			{Name: "count", Type: message_template.VariableTypeInt},
+		},
+	}
+
+	r, err := tpl.Render(map[string]interface{}{"name": "<b>axetroy</b>", "count": 2})
+
+	assert.Nil(t, err)
+	assert.True(t, r.HTML)
+	// 标题使用 text/template, 不转义
+	assert.Equal(t, "<b>axetroy</b> 的订单", r.Subject)
+	// HTML 内容中的变量被转义
+	assert.Equal(t, "<p>&lt;b&gt;axetroy&lt;/b&gt; 共 2 件</p>", r.Body)
+
+	tpl.Format = message_template.FormatText
+
+	r, err = tpl.Render(map[string]interface{}{"name": "<b>axetroy</b>"})
+
+	assert.Nil(t, err)
+	assert.False(t, r.HTML)
+	assert.Equal(t, "<p><b>axetroy</b> 共 0 件</p>", r.Body)
+
+	// 引用了未声明的变量
+	tpl.Body = "{{.other}}"
+
+	_, err = tpl.Render(map[string]interface{}{"name": "axetroy"})
+
+	assert.Equal(t, exception.MessageTemplateInvalidVariable, err)
+}
+
+func TestFromModel(t *testing.T) {
+	smsTemplateId := "SMS_001"
+
+	tpl, err := message_template.FromModel(model.MessageTemplate{
+		Name:          "order",
+		Locale:        "en-US",
+		Subject:       "order",
+		Format:        "text",
+		Body:          "{{.name}}",
+		Variables:     `[{"name":"name","type":"string","required":true}]`,
+		SmsTemplateId: &smsTemplateId,
+	})
+
+	assert.Nil(t, err)
+	assert.Equal(t, "en-US", tpl.Locale)
+	assert.Equal(t, smsTemplateId, tpl.SmsTemplateId)
+	assert.Equal(t, []message_template.Variable{{Name: "name", Type: message_template.VariableTypeString, Required: true}}, tpl.Variables)
+
+	s, err := message_template.MarshalVariables(tpl.Variables)
+
+	assert.Nil(t, err)
+	assert.Equal(t, `[{"name":"name","type":"string","required":true}]`, s)
+
+	// 没有分类的模版默认为系统消息
+	assert.Equal(t, message_template.CategorySystem, tpl.Category)
+
+	// 覆盖内置模版时沿用内置模版的分类
+	tpl, err = message_template.FromModel(model.MessageTemplate{
+		Name:      message_template.NameStatement,
+		Subject:   "statement",
+		Format:    "text",
+		Body:      "{{.period}}",
+		Variables: `[{"name":"period","type":"string","required":true}]`,
+		Category:  message_template.CategorySecurity,
+	})
+
+	assert.Nil(t, err)
+	assert.Equal(t, message_template.CategoryTransfers, tpl.Category)
+
+	_, err = message_template.FromModel(model.MessageTemplate{Variables: "{"})
+
+	assert.Equal(t, exception.MessageTemplateInvalid, err)
+}
+
+func TestParseChannels(t *testing.T) {
+	channels, err := message_template.ParseChannels([]string{"email", "message", "email"})
+
+	assert.Nil(t, err)
+	assert.Equal(t, []message_template.Channel{message_template.ChannelEmail, message_template.ChannelMessage}, channels)
+
+	_, err = message_template.ParseChannels([]string{"email", "wechat"})
+	assert.Equal(t, exception.MessageTemplateInvalidChannel, err)
+
+	_, err = message_template.ParseChannels(nil)
+	assert.Equal(t, exception.MessageTemplateInvalidChannel, err)
+}
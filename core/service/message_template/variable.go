@@ -0,0 +1,230 @@
+// Copyright 2019 Axetroy. All rights reserved. MIT license.
+package message_template
+
+import (
+	"encoding/json"
+	"fmt"
+	"github.com/axetroy/go-server/core/exception"
+	"math"
+	"regexp"
+	"strconv"
+	"time"
+)
+
+type VariableType string
+
+const (
+	VariableTypeString VariableType = "string" // 字符串
+	VariableTypeInt    VariableType = "int"    // 整数
+	VariableTypeFloat  VariableType = "float"  // 浮点数
+	VariableTypeBool   VariableType = "bool"   // 布尔值
+	VariableTypeTime   VariableType = "time"   // 时间, 传入 RFC3339 格式的字符串
+)
+
+// 短信参数中时间的格式
+var TimeLayout = "2006-01-02 15:04:05"
+
+// 变量名只能是模版中可以直接引用的标识符, 例如 {{.code}}
+var variableNameReg = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+
+// 模版变量的声明
+type Variable struct {
+	Name     string       `json:"name"`     // 变量名
+	Type     VariableType `json:"type"`     // 变量类型
+	Required bool         `json:"required"` // 是否必填, 非必填的变量没有传入时为该类型的零值
+}
+
+func (v Variable) validate() error {
+	if !variableNameReg.MatchString(v.Name) {
+		return exception.MessageTemplateInvalidVariable
+	}
+
+	switch v.Type {
+	case VariableTypeString, VariableTypeInt, VariableTypeFloat, VariableTypeBool, VariableTypeTime:
+		return nil
+	default:
+		return exception.MessageTemplateInvalidVariable
+	}
+}
+
+// 该类型的零值
+func (v Variable) zero() interface{} {
+	switch v.Type {
+	case VariableTypeInt:
+		return int64(0)
+	case VariableTypeFloat:
+		return float64(0)
+	case VariableTypeBool:
+		return false
+	case VariableTypeTime:
+		return time.Time{}
+	default:
+		return ""
+	}
+}
+
+// 把传入的值转换为声明的类型
+// 值一般来自 JSON, 数字都是 float64, 时间是字符串
+func (v Variable) convert(value interface{}) (interface{}, error) {
+	switch v.Type {
+	case VariableTypeString:
+		if s, ok := value.(string); ok {
+			return s, nil
+		}
+	case VariableTypeInt:
+		switch t := value.(type) {
+		case int:
+			return int64(t), nil
+		case int64:
+			return t, nil
+		case float64:
+			if t == math.Trunc(t) {
+				return int64(t), nil
+			}
+		case json.Number:
+			if i, err := t.Int64(); err == nil {
+				return i, nil
+			}
+		case string:
+			if i, err := strconv.ParseInt(t, 10, 64); err == nil {
+				return i, nil
+			}
+		}
+	case VariableTypeFloat:
+		switch t := value.(type) {
+		case int:
+			return float64(t), nil
+		case int64:
+			return float64(t), nil
+		case float64:
+			return t, nil
+		case json.Number:
+			if f, err := t.Float64(); err == nil {
+				return f, nil
+			}
+		case string:
+			if f, err := strconv.ParseFloat(t, 64); err == nil {
+				return f, nil
+			}
+		}
+	case VariableTypeBool:
+		switch t := value.(type) {
+		case bool:
+			return t, nil
+		case string:
+			if b, err := strconv.ParseBool(t); err == nil {
+				return b, nil
+			}
+		}
+	case VariableTypeTime:
+		switch t := value.(type) {
+		case time.Time:
+			return t, nil
+		case string:
+			if d, err := time.Parse(time.RFC3339, t); err == nil {
+				return d, nil
+			}
+		}
+	}
+
+	return nil, exception.MessageTemplateInvalidVariable
+}
+
+// 校验变量的声明, 变量名不能重复
+func ValidateVariables(variables []Variable) error {
+	names := map[string]bool{}
+
+	for _, v := range variables {
+		if err := v.validate(); err != nil {
+			return err
+		}
+
+		if names[v.Name] {
+			return exception.MessageTemplateInvalidVariable
+		}
+
+		names[v.Name] = true
+	}
+
+	return nil
+}
+
+// 校验覆盖内置模版的变量声明
+// 必须声明内置模版的所有变量并且类型相同, 新增的变量不能是必填的
+func CompatibleVariables(builtin []Variable, variables []Variable) error {
+	declared := map[string]Variable{}
+
+	for _, v := range variables {
+		declared[v.Name] = v
+	}
+
+	for _, b := range builtin {
+		v, ok := declared[b.Name]
+
+		if !ok || v.Type != b.Type {
+			return exception.MessageTemplateIncompatible
+		}
+
+		delete(declared, b.Name)
+	}
+
+	for _, v := range declared {
+		if v.Required {
+			return exception.MessageTemplateIncompatible
+		}
+	}
+
+	return nil
+}
+
+// 按照变量的声明转换传入的值
+// 缺少必填的变量, 传入未声明的变量或者类型不匹配时返回错误
+func Coerce(variables []Variable, values map[string]interface{}) (map[string]interface{}, error) {
+	result := map[string]interface{}{}
+	declared := map[string]bool{}
+
+	for _, v := range variables {
+		declared[v.Name] = true
+
+		value, ok := values[v.Name]
+
+		if !ok || value == nil {
+			if v.Required {
+				return nil, exception.MessageTemplateInvalidVariable
+			}
+			result[v.Name] = v.zero()
+			continue
+		}
+
+		converted, err := v.convert(value)
+
+		if err != nil {
+			return nil, err
+		}
+
+		result[v.Name] = converted
+	}
+
+	for name := range values {
+		if !declared[name] {
+			return nil, exception.MessageTemplateInvalidVariable
+		}
+	}
+
+	return result, nil
+}
+
+// 把转换后的变量格式化为字符串, 用于短信服务商的模版参数
+func Strings(values map[string]interface{}) map[string]string {
+	result := map[string]string{}
+
+	for name, value := range values {
+		if t, ok := value.(time.Time); ok {
+			result[name] = t.Format(TimeLayout)
+		} else {
+			result[name] = fmt.Sprint(value)
+		}
+	}
+
+	return result
+}
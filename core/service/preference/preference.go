@@ -0,0 +1,296 @@
+// Copyright 2019 Axetroy. All rights reserved. MIT license.
+package preference
+
+import (
+	"fmt"
+	"github.com/axetroy/go-server/core/exception"
+	"github.com/axetroy/go-server/core/model"
+	"github.com/axetroy/go-server/core/service/message_template"
+	"github.com/jinzhu/gorm"
+	"strings"
+	"time"
+)
+
+type Category string
+
+const (
+	CategorySecurity  Category = message_template.CategorySecurity  // 安全类, 例如验证码, 重置密码. 总是发送, 无法关闭
+	CategoryTransfers Category = message_template.CategoryTransfers // 转账和资金变动, 例如月度对账单
+	CategoryMarketing Category = "marketing"                        // 营销推广
+	CategorySystem    Category = message_template.CategorySystem    // 系统消息
+)
+
+var (
+	Categories      = []Category{CategorySecurity, CategoryTransfers, CategoryMarketing, CategorySystem}
+	DefaultTimezone = "Asia/Shanghai" // 没有设置时区的用户使用的时区
+	clockLayout     = "15:04"
+)
+
+// 免打扰时段, 只对邮件和短信生效
+type QuietHours struct {
+	Start string // 开始时间, 格式为 HH:MM
+	End   string // 结束时间, 早于开始时间则跨过零点
+}
+
+type Preferences struct {
+	Uid        string
+	Disabled   map[Category]map[message_template.Channel]bool // 关闭的通知
+	QuietHours *QuietHours                                    // 免打扰时段, 为空则没有
+	Timezone   string                                         // 用户的时区
+}
+
+// 默认的偏好, 所有通知都开启, 没有免打扰时段
+func Default(uid string) Preferences {
+	return Preferences{
+		Uid:      uid,
+		Disabled: map[Category]map[message_template.Channel]bool{},
+		Timezone: DefaultTimezone,
+	}
+}
+
+func ParseCategory(s string) (Category, error) {
+	for _, c := range Categories {
+		if string(c) == s {
+			return c, nil
+		}
+	}
+
+	return "", exception.NotificationPreferenceInvalid
+}
+
+func ParseChannel(s string) (message_template.Channel, error) {
+	for _, c := range message_template.Channels {
+		if string(c) == s {
+			return c, nil
+		}
+	}
+
+	return "", exception.NotificationPreferenceInvalid
+}
+
+// 解析 HH:MM 格式的时间, 返回从零点开始的分钟数
+func parseClock(s string) (int, error) {
+	t, err := time.Parse(clockLayout, s)
+
+	if err != nil {
+		return 0, exception.NotificationPreferenceInvalid
+	}
+
+	return t.Hour()*60 + t.Minute(), nil
+}
+
+func FromModel(m model.NotificationPreference) (p Preferences, err error) {
+	p = Default(m.Uid)
+
+	if m.Timezone != "" {
+		p.Timezone = m.Timezone
+	}
+
+	for _, item := range m.Disabled {
+		parts := strings.SplitN(item, ":", 2)
+
+		if len(parts) != 2 {
+			err = exception.NotificationPreferenceInvalid
+			return
+		}
+
+		var (
+			category Category
+			channel  message_template.Channel
+		)
+
+		if category, err = ParseCategory(parts[0]); err != nil {
+			return
+		}
+
+		if channel, err = ParseChannel(parts[1]); err != nil {
+			return
+		}
+
+		p.SetEnabled(category, channel, false)
+	}
+
+	if m.QuietStart != nil && m.QuietEnd != nil {
+		p.QuietHours = &QuietHours{Start: *m.QuietStart, End: *m.QuietEnd}
+	}
+
+	return
+}
+
+func (p Preferences) ToModel() model.NotificationPreference {
+	m := model.NotificationPreference{
+		Uid:      p.Uid,
+		Disabled: make([]string, 0),
+		Timezone: p.Timezone,
+	}
+
+	// 按照固定的顺序保存
+	for _, category := range Categories {
+		for _, channel := range message_template.Channels {
+			if p.Disabled[category][channel] {
+				m.Disabled = append(m.Disabled, fmt.Sprintf("%s:%s", category, channel))
+			}
+		}
+	}
+
+	if p.QuietHours != nil {
+		m.QuietStart = &p.QuietHours.Start
+		m.QuietEnd = &p.QuietHours.End
+	}
+
+	return m
+}
+
+// 校验时区和免打扰时段
+func (p Preferences) Validate() error {
+	if _, err := time.LoadLocation(p.Timezone); err != nil || p.Timezone == "" {
+		return exception.NotificationPreferenceInvalid
+	}
+
+	if p.QuietHours != nil {
+		start, err := parseClock(p.QuietHours.Start)
+
+		if err != nil {
+			return err
+		}
+
+		end, err := parseClock(p.QuietHours.End)
+
+		if err != nil {
+			return err
+		}
+
+		if start == end {
+			return exception.NotificationPreferenceInvalid
+		}
+	}
+
+	return nil
+}
+
+// 开启或者关闭某个分类在某个渠道的通知
+func (p *Preferences) SetEnabled(category Category, channel message_template.Channel, enabled bool) {
+	if p.Disabled == nil {
+		p.Disabled = map[Category]map[message_template.Channel]bool{}
+	}
+
+	if p.Disabled[category] == nil {
+		p.Disabled[category] = map[message_template.Channel]bool{}
+	}
+
+	if enabled {
+		delete(p.Disabled[category], channel)
+	} else {
+		p.Disabled[category][channel] = true
+	}
+}
+
+// 某个分类在某个渠道的通知是否开启, 安全类通知总是开启
+func (p Preferences) Enabled(category Category, channel message_template.Channel) bool {
+	if category == CategorySecurity {
+		return true
+	}
+
+	return !p.Disabled[category][channel]
+}
+
+// 判断 now 是否处于免打扰时段, 如果是, 返回免打扰时段结束的时间
+func (p Preferences) QuietUntil(now time.Time) (until time.Time, quiet bool) {
+	if p.QuietHours == nil {
+		return
+	}
+
+	loc, err := time.LoadLocation(p.Timezone)
+
+	if err != nil {
+		loc = time.UTC
+	}
+
+	start, err := parseClock(p.QuietHours.Start)
+
+	if err != nil {
+		return
+	}
+
+	end, err := parseClock(p.QuietHours.End)
+
+	if err != nil {
+		return
+	}
+
+	local := now.In(loc)
+	minutes := local.Hour()*60 + local.Minute()
+	day := 0
+
+	if start < end {
+		quiet = minutes >= start && minutes < end
+	} else {
+		// 跨过零点, 例如 22:00 - 08:00
+		quiet = minutes >= start || minutes < end
+
+		if minutes >= start {
+			day = 1
+		}
+	}
+
+	if quiet {
+		until = time.Date(local.Year(), local.Month(), local.Day()+day, end/60, end%60, 0, 0, loc)
+	}
+
+	return
+}
+
+// 检查在 now 时刻能否通过某个渠道发送某个分类的通知
+// 通知被关闭时返回 NotificationPreferenceDisabled, 处于免打扰时段时返回 NotificationPreferenceQuiet 以及可以发送的时间
+func (p Preferences) Check(category Category, channel message_template.Channel, now time.Time) (until time.Time, err error) {
+	// 安全类通知总是发送
+	if category == CategorySecurity {
+		return
+	}
+
+	if !p.Enabled(category, channel) {
+		err = exception.NotificationPreferenceDisabled
+		return
+	}
+
+	// 站内消息不会打扰用户, 免打扰时段只对邮件和短信生效
+	if channel == message_template.ChannelMessage {
+		return
+	}
+
+	if t, quiet := p.QuietUntil(now); quiet {
+		until = t
+		err = exception.NotificationPreferenceQuiet
+	}
+
+	return
+}
+
+// 读取用户的通知偏好, 没有设置过的用户返回默认的偏好
+func Load(db *gorm.DB, uid string) (p Preferences, err error) {
+	m := model.NotificationPreference{}
+
+	if err = db.Where("uid = ?", uid).First(&m).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return Default(uid), nil
+		}
+		return
+	}
+
+	return FromModel(m)
+}
+
+// 读取用户的通知偏好并检查能否现在发送
+func Check(db *gorm.DB, uid string, category Category, channel message_template.Channel) (until time.Time, err error) {
+	if category == CategorySecurity {
+		return
+	}
+
+	p, err := Load(db, uid)
+
+	if err != nil {
+		return
+	}
+
+	return p.Check(category, channel, time.Now())
+}
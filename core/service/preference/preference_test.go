@@ -0,0 +1,119 @@
+// Copyright 2019 Axetroy. All rights reserved. MIT license.
+package preference_test
+
+import (
+	"github.com/axetroy/go-server/core/exception"
+	"github.com/axetroy/go-server/core/model"
+	"github.com/axetroy/go-server/core/service/message_template"
+	"github.com/axetroy/go-server/core/service/preference"
+	"github.com/stretchr/testify/assert"
+	"testing"
+	"time"
+)
+
+func TestModel(t *testing.T) {
+	p := preference.Default("uid")
+
+	p.SetEnabled(preference.CategoryMarketing, message_template.ChannelSms, false)
+	p.SetEnabled(preference.CategoryMarketing, message_template.ChannelEmail, false)
+	p.SetEnabled(preference.CategoryMarketing, message_template.ChannelEmail, true)
+	p.QuietHours = &preference.QuietHours{Start: "22:00", End: "08:00"}
+
+	m := p.ToModel()
+
+	assert.Equal(t, []string{"marketing:sms"}, []string(m.Disabled))
+	assert.Equal(t, "22:00", *m.QuietStart)
+	assert.Equal(t, preference.DefaultTimezone, m.Timezone)
+
+	p2, err := preference.FromModel(m)
+
+	assert.Nil(t, err)
+	assert.Equal(t, p.QuietHours, p2.QuietHours)
+	assert.False(t, p2.Enabled(preference.CategoryMarketing, message_template.ChannelSms))
+	assert.True(t, p2.Enabled(preference.CategoryMarketing, message_template.ChannelEmail))
+
+	_, err = preference.FromModel(model.NotificationPreference{Disabled: []string{"unknown:sms"}})
+	assert.Equal(t, exception.NotificationPreferenceInvalid, err)
+}
+
+func TestValidate(t *testing.T) {
+	p := preference.Default("uid")
+
+	assert.Nil(t, p.Validate())
+
+	p.Timezone = "Mars/Base"
+	assert.Equal(t, exception.NotificationPreferenceInvalid, p.Validate())
+
+	p.Timezone = "UTC"
+	p.QuietHours = &preference.QuietHours{Start: "25:00", End: "08:00"}
+	assert.Equal(t, exception.NotificationPreferenceInvalid, p.Validate())
+
+	p.QuietHours = &preference.QuietHours{Start: "08:00", End: "08:00"}
+	assert.Equal(t, exception.NotificationPreferenceInvalid, p.Validate())
+}
+
+func TestQuietUntil(t *testing.T) {
+	p := preference.Default("uid")
+	p.Timezone = "Asia/Shanghai"
+	p.QuietHours = &preference.QuietHours{Start: "22:00", End: "08:00"}
+
+	loc, _ := time.LoadLocation("Asia/Shanghai")
+
+	// 上海时间 23:30, 免打扰到第二天 08:00
+	until, quiet := p.QuietUntil(time.Date(2019, 8, 1, 15, 30, 0, 0, time.UTC))
+
+	assert.True(t, quiet)
+	assert.Equal(t, time.Date(2019, 8, 2, 8, 0, 0, 0, loc).Unix(), until.Unix())
+
+	// 上海时间 07:00, 免打扰到当天 08:00
+	until, quiet = p.QuietUntil(time.Date(2019, 8, 1, 23, 0, 0, 0, time.UTC))
+
+	assert.True(t, quiet)
+	assert.Equal(t, time.Date(2019, 8, 2, 8, 0, 0, 0, loc).Unix(), until.Unix())
+
+	// 上海时间 12:00
+	_, quiet = p.QuietUntil(time.Date(2019, 8, 1, 4, 0, 0, 0, time.UTC))
+
+	assert.False(t, quiet)
+
+	// 不跨零点的时段
+	p.QuietHours = &preference.QuietHours{Start: "12:00", End: "14:00"}
+
+	until, quiet = p.QuietUntil(time.Date(2019, 8, 1, 4, 30, 0, 0, time.UTC))
+
+	assert.True(t, quiet)
+	assert.Equal(t, time.Date(2019, 8, 1, 14, 0, 0, 0, loc).Unix(), until.Unix())
+
+	_, quiet = p.QuietUntil(time.Date(2019, 8, 1, 6, 0, 0, 0, time.UTC))
+
+	assert.False(t, quiet)
+}
+
+func TestCheck(t *testing.T) {
+	p := preference.Default("uid")
+	p.Timezone = "UTC"
+	p.QuietHours = &preference.QuietHours{Start: "22:00", End: "08:00"}
+	p.SetEnabled(preference.CategoryMarketing, message_template.ChannelEmail, false)
+	p.SetEnabled(preference.CategorySecurity, message_template.ChannelEmail, false)
+
+	day := time.Date(2019, 8, 1, 12, 0, 0, 0, time.UTC)
+	night := time.Date(2019, 8, 1, 23, 0, 0, 0, time.UTC)
+
+	_, err := p.Check(preference.CategoryMarketing, message_template.ChannelEmail, day)
+	assert.Equal(t, exception.NotificationPreferenceDisabled, err)
+
+	_, err = p.Check(preference.CategoryMarketing, message_template.ChannelSms, day)
+	assert.Nil(t, err)
+
+	until, err := p.Check(preference.CategoryMarketing, message_template.ChannelSms, night)
+	assert.Equal(t, exception.NotificationPreferenceQuiet, err)
+	assert.Equal(t, time.Date(2019, 8, 2, 8, 0, 0, 0, time.UTC), until)
+
+	// 站内消息不受免打扰时段影响
+	_, err = p.Check(preference.CategorySystem, message_template.ChannelMessage, night)
+	assert.Nil(t, err)
+
+	// 安全类通知总是发送
+	_, err = p.Check(preference.CategorySecurity, message_template.ChannelEmail, night)
+	assert.Nil(t, err)
+}
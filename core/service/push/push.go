@@ -0,0 +1,194 @@
+// Copyright 2019 Axetroy. All rights reserved. MIT license.
+package push
+
+import (
+	"encoding/json"
+	"log"
+	"sync"
+
+	"github.com/axetroy/go-server/core/service/redis"
+)
+
+// 实时推送
+// 事件通过 Redis 的发布/订阅广播给所有的用户服务实例, 每个实例再分发给连接在本实例的用户
+// 所以在管理员服务中创建的消息, 也能推送给连接在任意一个用户服务实例上的用户
+
+type EventType string
+
+var (
+	EventMessage      EventType = "message"      // 新的个人消息
+	EventNotification EventType = "notification" // 新的系统通知
+	EventUnread       EventType = "unread"       // 未读数量发生变化
+)
+
+// Redis 中发布事件的频道
+var Channel = "push"
+
+// 每个连接缓存的事件数量, 超出的事件会被丢弃
+var bufferSize = 16
+
+type Event struct {
+	Type EventType       `json:"type"`           // 事件类型
+	Uid  string          `json:"uid,omitempty"`  // 接收事件的用户, 为空则发送给所有用户
+	Data json.RawMessage `json:"data,omitempty"` // 事件的内容
+}
+
+// 一个用户的连接
+type Subscriber struct {
+	Uid string     // 用户 ID
+	C   chan Event // 接收事件的通道, 取消订阅后关闭
+}
+
+// 本实例的所有连接
+type Hub struct {
+	lock        sync.RWMutex
+	subscribers map[string]map[*Subscriber]bool
+}
+
+func NewHub() *Hub {
+	return &Hub{
+		subscribers: map[string]map[*Subscriber]bool{},
+	}
+}
+
+// 订阅某个用户的事件
+func (h *Hub) Subscribe(uid string) *Subscriber {
+	s := &Subscriber{
+		Uid: uid,
+		C:   make(chan Event, bufferSize),
+	}
+
+	h.lock.Lock()
+	defer h.lock.Unlock()
+
+	if _, ok := h.subscribers[uid]; !ok {
+		h.subscribers[uid] = map[*Subscriber]bool{}
+	}
+
+	h.subscribers[uid][s] = true
+
+	return s
+}
+
+// 取消订阅, 并关闭事件通道
+func (h *Hub) Unsubscribe(s *Subscriber) {
+	h.lock.Lock()
+	defer h.lock.Unlock()
+
+	list, ok := h.subscribers[s.Uid]
+
+	if !ok || !list[s] {
+		return
+	}
+
+	delete(list, s)
+
+	if len(list) == 0 {
+		delete(h.subscribers, s.Uid)
+	}
+
+	close(s.C)
+}
+
+// 把事件分发给对应的连接, 连接处理不过来的话丢弃事件, 不阻塞其他连接
+func (h *Hub) Dispatch(event Event) {
+	h.lock.RLock()
+	defer h.lock.RUnlock()
+
+	send := func(list map[*Subscriber]bool) {
+		for s := range list {
+			select {
+			case s.C <- event:
+			default:
+			}
+		}
+	}
+
+	if event.Uid != "" {
+		send(h.subscribers[event.Uid])
+		return
+	}
+
+	for _, list := range h.subscribers {
+		send(list)
+	}
+}
+
+// 当前的连接数量
+func (h *Hub) Count() int {
+	h.lock.RLock()
+	defer h.lock.RUnlock()
+
+	count := 0
+
+	for _, list := range h.subscribers {
+		count = count + len(list)
+	}
+
+	return count
+}
+
+var (
+	hub        = NewHub()
+	listenOnce sync.Once
+)
+
+// 发布事件, uid 为空则发送给所有用户
+func Publish(eventType EventType, uid string, data interface{}) error {
+	event := Event{
+		Type: eventType,
+		Uid:  uid,
+	}
+
+	if data != nil {
+		b, err := json.Marshal(data)
+
+		if err != nil {
+			return err
+		}
+
+		event.Data = b
+	}
+
+	b, err := json.Marshal(event)
+
+	if err != nil {
+		return err
+	}
+
+	return redis.Client.Publish(Channel, string(b)).Err()
+}
+
+// 订阅某个用户的事件, 第一次订阅时开始监听 Redis
+func Subscribe(uid string) *Subscriber {
+	listenOnce.Do(func() {
+		go listen()
+	})
+
+	return hub.Subscribe(uid)
+}
+
+// 取消订阅
+func Unsubscribe(s *Subscriber) {
+	hub.Unsubscribe(s)
+}
+
+// 监听 Redis 中发布的事件, 断线后 Redis 客户端会自动重连
+func listen() {
+	pubSub := redis.Client.Subscribe(Channel)
+
+	defer func() {
+		_ = pubSub.Close()
+	}()
+
+	for msg := range pubSub.Channel() {
+		event := Event{}
+
+		if err := json.Unmarshal([]byte(msg.Payload), &event); err != nil {
+			log.Println("无效的推送事件:", err)
+			continue
+		}
+
+		hub.Dispatch(event)
+	}
+}
@@ -0,0 +1,52 @@
+// Copyright 2019 Axetroy. All rights reserved. MIT license.
+package push_test
+
+import (
+	"testing"
+
+	"github.com/axetroy/go-server/core/service/push"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestHub(t *testing.T) {
+	hub := push.NewHub()
+
+	a1 := hub.Subscribe("a")
+	a2 := hub.Subscribe("a")
+	b := hub.Subscribe("b")
+
+	assert.Equal(t, 3, hub.Count())
+
+	// 发送给指定用户的所有连接
+	hub.Dispatch(push.Event{Type: push.EventMessage, Uid: "a"})
+
+	assert.Equal(t, push.EventMessage, (<-a1.C).Type)
+	assert.Equal(t, push.EventMessage, (<-a2.C).Type)
+	assert.Len(t, b.C, 0)
+
+	// 发送给所有用户
+	hub.Dispatch(push.Event{Type: push.EventNotification})
+
+	assert.Len(t, a1.C, 1)
+	assert.Len(t, a2.C, 1)
+	assert.Len(t, b.C, 1)
+
+	// 取消订阅后关闭通道
+	hub.Unsubscribe(a1)
+	hub.Unsubscribe(a1)
+
+	assert.Equal(t, 2, hub.Count())
+
+	<-a1.C
+
+	_, ok := <-a1.C
+
+	assert.False(t, ok)
+
+	// 连接处理不过来的话丢弃事件, 不会阻塞
+	for i := 0; i < 100; i++ {
+		hub.Dispatch(push.Event{Type: push.EventUnread, Uid: "b"})
+	}
+
+	assert.Equal(t, cap(b.C), len(b.C))
+}
@@ -0,0 +1,287 @@
+// Copyright 2019 Axetroy. All rights reserved. MIT license.
+package reconcile
+
+import (
+	"encoding/json"
+	"strings"
+	"time"
+
+	"github.com/axetroy/go-server/core/message_queue"
+	"github.com/axetroy/go-server/core/model"
+	"github.com/axetroy/go-server/core/service/currency"
+	"github.com/axetroy/go-server/core/service/database"
+	"github.com/axetroy/go-server/core/util"
+	"github.com/jinzhu/gorm"
+)
+
+// 对账
+// 按照时间顺序重放每个用户的财务日志, 检查:
+// 1. 每条日志的变动前余额是否等于上一条日志的变动后余额, 第一条日志的变动前余额应该为 0
+// 2. 每条日志的变动后余额是否等于变动前余额加上变动
+// 3. 最后一条日志的变动后余额是否等于钱包的余额
+// 4. 有财务日志的用户是否存在对应的钱包
+
+type IssueType string
+
+var (
+	IssueTypeGap           IssueType = "gap"            // 日志不连续, 变动前余额不等于上一条日志的变动后余额
+	IssueTypeMutation      IssueType = "mutation"       // 变动后余额不等于变动前余额加上变动
+	IssueTypeMismatch      IssueType = "mismatch"       // 钱包的余额与日志不一致
+	IssueTypeMissingWallet IssueType = "missing_wallet" // 有财务日志, 但是钱包不存在
+)
+
+var (
+	FieldBalance = "balance" // 可用余额
+	FieldFrozen  = "frozen"  // 冻结余额
+)
+
+// 每次查询的钱包数量
+var batchSize = 100
+
+// 发现的问题
+type Issue struct {
+	Type     IssueType `json:"type"`             // 问题类型
+	Currency string    `json:"currency"`         // 币种
+	Uid      string    `json:"uid"`              // 用户 ID
+	Field    string    `json:"field"`            // 出现问题的字段, balance 或者 frozen
+	LogId    string    `json:"log_id,omitempty"` // 出现问题的财务日志 ID, 钱包的问题则为空
+	Expected string    `json:"expected"`         // 期望的值
+	Actual   string    `json:"actual"`           // 实际的值
+}
+
+// 一个币种的对账报告
+type Report struct {
+	Currency   string    `json:"currency"`    // 币种
+	Wallets    int       `json:"wallets"`     // 检查的钱包数量
+	Logs       int       `json:"logs"`        // 检查的财务日志数量
+	Issues     []Issue   `json:"issues"`      // 发现的问题
+	StartedAt  time.Time `json:"started_at"`  // 开始时间
+	FinishedAt time.Time `json:"finished_at"` // 结束时间
+}
+
+type Options struct {
+	Currencies []string // 要对账的币种, 为空则对账所有币种
+	Uid        string   // 只对账指定的用户, 为空则对账所有用户
+	Alert      bool     // 发现问题时, 是否通过消息队列发送告警
+}
+
+// 对账告警的消息体
+type AlertBody struct {
+	Report Report `json:"report"`
+}
+
+func (r Report) OK() bool {
+	return len(r.Issues) == 0
+}
+
+// 重放一个用户的财务日志, 日志必须按照时间顺序排列
+// wallet 为 nil 表示钱包不存在
+func Check(code string, uid string, logs []model.FinanceLog, wallet *model.Wallet) (issues []Issue) {
+	var balance, frozen util.Decimal
+
+	issues = make([]Issue, 0)
+
+	newIssue := func(t IssueType, field string, logId string, expected util.Decimal, actual util.Decimal) {
+		issues = append(issues, Issue{
+			Type:     t,
+			Currency: code,
+			Uid:      uid,
+			Field:    field,
+			LogId:    logId,
+			Expected: expected.String(),
+			Actual:   actual.String(),
+		})
+	}
+
+	for _, log := range logs {
+		if log.BeforeBalance.Cmp(balance) != 0 {
+			newIssue(IssueTypeGap, FieldBalance, log.Id, balance, log.BeforeBalance)
+		}
+
+		if log.BeforeFrozen.Cmp(frozen) != 0 {
+			newIssue(IssueTypeGap, FieldFrozen, log.Id, frozen, log.BeforeFrozen)
+		}
+
+		if expected := log.BeforeBalance.Add(log.BalanceMutation); expected.Cmp(log.AfterBalance) != 0 {
+			newIssue(IssueTypeMutation, FieldBalance, log.Id, expected, log.AfterBalance)
+		}
+
+		if expected := log.BeforeFrozen.Add(log.FrozenMutation); expected.Cmp(log.AfterFrozen) != 0 {
+			newIssue(IssueTypeMutation, FieldFrozen, log.Id, expected, log.AfterFrozen)
+		}
+
+		// 以日志记录的值为准继续重放, 一处错误不会导致后面的日志全部报错
+		balance = log.AfterBalance
+		frozen = log.AfterFrozen
+	}
+
+	if wallet == nil {
+		if len(logs) > 0 {
+			newIssue(IssueTypeMissingWallet, FieldBalance, "", balance, util.Decimal{})
+		}
+		return
+	}
+
+	if wallet.Balance.Cmp(balance) != 0 {
+		newIssue(IssueTypeMismatch, FieldBalance, "", balance, wallet.Balance)
+	}
+
+	if wallet.Frozen.Cmp(frozen) != 0 {
+		newIssue(IssueTypeMismatch, FieldFrozen, "", frozen, wallet.Frozen)
+	}
+
+	return
+}
+
+// 对账
+func Run(options Options) (reports []Report, err error) {
+	codes := options.Currencies
+
+	if len(codes) == 0 {
+		if codes, err = currency.Codes(); err != nil {
+			return
+		}
+	}
+
+	reports = make([]Report, 0)
+
+	for _, code := range codes {
+		var (
+			c      model.Currency
+			report Report
+		)
+
+		if c, err = currency.Get(code); err != nil {
+			return
+		}
+
+		if report, err = runCurrency(c.Code, options.Uid); err != nil {
+			return
+		}
+
+		reports = append(reports, report)
+
+		if options.Alert && !report.OK() {
+			if err = alert(report); err != nil {
+				return
+			}
+		}
+	}
+
+	return
+}
+
+// 对账一个币种
+// 在可重复读的只读事务中进行, 看到的是同一时刻的快照, 不会受到对账期间的余额变动的影响, 也不会阻塞余额变动
+func runCurrency(code string, uid string) (report Report, err error) {
+	var tx *gorm.DB
+
+	report = Report{
+		Currency:  code,
+		Issues:    make([]Issue, 0),
+		StartedAt: time.Now(),
+	}
+
+	defer func() {
+		if tx != nil {
+			_ = tx.Rollback().Error
+		}
+
+		report.FinishedAt = time.Now()
+	}()
+
+	tx = database.Db.Begin()
+
+	if err = tx.Exec("SET TRANSACTION ISOLATION LEVEL REPEATABLE READ READ ONLY").Error; err != nil {
+		return
+	}
+
+	check := func(uid string, wallet *model.Wallet) error {
+		logs := make([]model.FinanceLog, 0)
+
+		if err := model.FinanceLogOf(tx, code).Where("uid = ?", uid).Order("created_at ASC").Order("id ASC").Find(&logs).Error; err != nil {
+			return err
+		}
+
+		report.Logs += len(logs)
+		report.Issues = append(report.Issues, Check(code, uid, logs, wallet)...)
+
+		return nil
+	}
+
+	// 按照钱包逐个检查
+	lastId := ""
+
+	for {
+		wallets := make([]model.Wallet, 0)
+
+		query := model.WalletOf(tx, code).Where("id > ?", lastId)
+
+		if uid != "" {
+			query = query.Where("id = ?", uid)
+		}
+
+		if err = query.Order("id ASC").Limit(batchSize).Find(&wallets).Error; err != nil {
+			return
+		}
+
+		for i := range wallets {
+			if err = check(wallets[i].Id, &wallets[i]); err != nil {
+				return
+			}
+		}
+
+		report.Wallets += len(wallets)
+
+		if len(wallets) < batchSize {
+			break
+		}
+
+		lastId = wallets[len(wallets)-1].Id
+	}
+
+	// 有财务日志却没有钱包的用户
+	var uids []string
+
+	query := model.FinanceLogOf(tx, code).Where("uid NOT IN (?)", model.WalletOf(tx.Unscoped(), code).Select("id").QueryExpr())
+
+	if uid != "" {
+		query = query.Where("uid = ?", uid)
+	}
+
+	if err = query.Pluck("DISTINCT uid", &uids).Error; err != nil {
+		return
+	}
+
+	for _, u := range uids {
+		if err = check(u, nil); err != nil {
+			return
+		}
+	}
+
+	return
+}
+
+// 通过消息队列发送告警
+func alert(report Report) (err error) {
+	var body []byte
+
+	if body, err = json.Marshal(AlertBody{Report: report}); err != nil {
+		return
+	}
+
+	return message_queue.Publish(message_queue.TopicReconcileAlert, body)
+}
+
+// 格式化问题, 用于打印
+func (i Issue) String() string {
+	s := []string{string(i.Type), i.Currency, i.Uid, i.Field}
+
+	if i.LogId != "" {
+		s = append(s, "log="+i.LogId)
+	}
+
+	s = append(s, "expected="+i.Expected, "actual="+i.Actual)
+
+	return strings.Join(s, " ")
+}
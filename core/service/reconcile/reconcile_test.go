@@ -0,0 +1,149 @@
+// Copyright 2019 Axetroy. All rights reserved. MIT license.
+package reconcile_test
+
+import (
+	"testing"
+
+	"github.com/axetroy/go-server/core/controller/auth"
+	"github.com/axetroy/go-server/core/model"
+	"github.com/axetroy/go-server/core/service/database"
+	"github.com/axetroy/go-server/core/service/reconcile"
+	"github.com/axetroy/go-server/core/service/wallet"
+	"github.com/axetroy/go-server/core/util"
+	"github.com/axetroy/go-server/tester"
+	"github.com/stretchr/testify/assert"
+)
+
+func newLog(id string, before string, mutation string, after string) model.FinanceLog {
+	return model.FinanceLog{
+		Id:              id,
+		BeforeBalance:   util.MustParseDecimal(before),
+		BalanceMutation: util.MustParseDecimal(mutation),
+		AfterBalance:    util.MustParseDecimal(after),
+	}
+}
+
+func TestCheck(t *testing.T) {
+	// 正常的日志
+	{
+		logs := []model.FinanceLog{
+			newLog("1", "0", "10", "10"),
+			newLog("2", "10", "-2.5", "7.5"),
+		}
+
+		issues := reconcile.Check("CNY", "uid", logs, &model.Wallet{Balance: util.MustParseDecimal("7.5")})
+
+		assert.Len(t, issues, 0)
+	}
+
+	// 日志不连续
+	{
+		logs := []model.FinanceLog{
+			newLog("1", "0", "10", "10"),
+			newLog("2", "12", "-2", "10"),
+		}
+
+		issues := reconcile.Check("CNY", "uid", logs, &model.Wallet{Balance: util.MustParseDecimal("10")})
+
+		assert.Len(t, issues, 1)
+		assert.Equal(t, reconcile.IssueTypeGap, issues[0].Type)
+		assert.Equal(t, reconcile.FieldBalance, issues[0].Field)
+		assert.Equal(t, "2", issues[0].LogId)
+		assert.Equal(t, "10", issues[0].Expected)
+		assert.Equal(t, "12", issues[0].Actual)
+	}
+
+	// 变动后余额计算错误
+	{
+		logs := []model.FinanceLog{
+			newLog("1", "0", "10", "11"),
+		}
+
+		issues := reconcile.Check("CNY", "uid", logs, &model.Wallet{Balance: util.MustParseDecimal("11")})
+
+		assert.Len(t, issues, 1)
+		assert.Equal(t, reconcile.IssueTypeMutation, issues[0].Type)
+	}
+
+	// 钱包余额与日志不一致
+	{
+		logs := []model.FinanceLog{
+			newLog("1", "0", "10", "10"),
+		}
+
+		issues := reconcile.Check("CNY", "uid", logs, &model.Wallet{Balance: util.MustParseDecimal("100")})
+
+		assert.Len(t, issues, 1)
+		assert.Equal(t, reconcile.IssueTypeMismatch, issues[0].Type)
+		assert.Equal(t, "10", issues[0].Expected)
+		assert.Equal(t, "100", issues[0].Actual)
+	}
+
+	// 没有日志, 但是钱包有余额
+	{
+		issues := reconcile.Check("CNY", "uid", nil, &model.Wallet{Balance: util.MustParseDecimal("1")})
+
+		assert.Len(t, issues, 1)
+		assert.Equal(t, reconcile.IssueTypeMismatch, issues[0].Type)
+	}
+
+	// 钱包不存在
+	{
+		logs := []model.FinanceLog{
+			newLog("1", "0", "10", "10"),
+		}
+
+		issues := reconcile.Check("CNY", "uid", logs, nil)
+
+		assert.Len(t, issues, 1)
+		assert.Equal(t, reconcile.IssueTypeMissingWallet, issues[0].Type)
+	}
+}
+
+func TestRun(t *testing.T) {
+	userInfo, _ := tester.CreateUser()
+
+	defer auth.DeleteUserByUserName(userInfo.Username)
+
+	tx := database.Db.Begin()
+
+	_, err := wallet.Apply(tx, model.WalletCNY, util.GenerateId(), wallet.Mutation{
+		Uid:     userInfo.Id,
+		Balance: util.NewDecimalFromInt(100),
+		Type:    model.FinanceTypeAdminCredit,
+	})
+
+	assert.Nil(t, err)
+	assert.Nil(t, tx.Commit().Error)
+
+	defer model.FinanceLogOf(database.Db.Unscoped(), model.WalletCNY).Where("uid = ?", userInfo.Id).Delete(&model.FinanceLog{})
+
+	options := reconcile.Options{
+		Currencies: []string{model.WalletCNY},
+		Uid:        userInfo.Id,
+	}
+
+	// 余额与日志一致
+	{
+		reports, err := reconcile.Run(options)
+
+		assert.Nil(t, err)
+		assert.Len(t, reports, 1)
+		assert.Equal(t, 1, reports[0].Wallets)
+		assert.Equal(t, 1, reports[0].Logs)
+		assert.True(t, reports[0].OK())
+	}
+
+	// 绕过钱包服务直接修改余额
+	assert.Nil(t, model.WalletOf(database.Db, model.WalletCNY).Where("id = ?", userInfo.Id).Update("balance", util.NewDecimalFromInt(200)).Error)
+
+	{
+		reports, err := reconcile.Run(options)
+
+		assert.Nil(t, err)
+		assert.Len(t, reports[0].Issues, 1)
+		assert.Equal(t, reconcile.IssueTypeMismatch, reports[0].Issues[0].Type)
+		assert.Equal(t, "100", reports[0].Issues[0].Expected)
+		assert.Equal(t, "200", reports[0].Issues[0].Actual)
+	}
+}
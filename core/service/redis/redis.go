@@ -9,10 +9,15 @@ import (
 var (
 	Client               *redis.Client // 默认的redis存储
 	ClientActivationCode *redis.Client // 存储帐号激活码的
-	ClientAuthEmailCode  *redis.Client // 存储邮箱验证码，存储结构 key: 验证码, value: 邮箱
-	ClientAuthPhoneCode  *redis.Client // 存储手机验证码，存储结构 key: 验证码, value: 手机号
+	ClientAuthEmailCode  *redis.Client // 存储邮箱验证码，存储结构 key: 用途 + 邮箱, value: 验证码以及输错的次数
+	ClientAuthPhoneCode  *redis.Client // 存储手机验证码，存储结构 key: 用途 + 手机号, value: 验证码以及输错的次数
 	ClientResetCode      *redis.Client // 存储重置密码的
 	ClientOAuthCode      *redis.Client // 存储 oAuth2 对应的激活码
+	ClientTOTPChallenge  *redis.Client // 存储双重身份认证的登陆挑战，存储结构 key: 挑战码, value: 用户ID和登陆账号. 同时记录已经使用过的动态码
+	ClientRevokedToken   *redis.Client // 存储已吊销的 token
+	ClientRefreshToken   *redis.Client // 存储刷新令牌
+	ClientLockout        *redis.Client // 存储登陆失败的次数以及账号锁定
+	ClientUnread         *redis.Client // 存储未读消息/通知的数量, 存储结构 key: 类型 + 版本 + 用户ID, value: 未读数量
 	Config               = config.Redis
 )
 
@@ -59,4 +64,34 @@ func init() {
 		DB:       5,
 	})
 
+	ClientTOTPChallenge = redis.NewClient(&redis.Options{
+		Addr:     addr,
+		Password: password,
+		DB:       6,
+	})
+
+	ClientRevokedToken = redis.NewClient(&redis.Options{
+		Addr:     addr,
+		Password: password,
+		DB:       7,
+	})
+
+	ClientRefreshToken = redis.NewClient(&redis.Options{
+		Addr:     addr,
+		Password: password,
+		DB:       8,
+	})
+
+	ClientLockout = redis.NewClient(&redis.Options{
+		Addr:     addr,
+		Password: password,
+		DB:       9,
+	})
+
+	ClientUnread = redis.NewClient(&redis.Options{
+		Addr:     addr,
+		Password: password,
+		DB:       10,
+	})
+
 }
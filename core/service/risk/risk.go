@@ -0,0 +1,216 @@
+// Copyright 2019 Axetroy. All rights reserved. MIT license.
+package risk
+
+import (
+	"time"
+
+	"github.com/axetroy/go-server/core/exception"
+	"github.com/axetroy/go-server/core/model"
+	"github.com/axetroy/go-server/core/util"
+	"github.com/jinzhu/gorm"
+)
+
+// 转账风控
+// 在转账的事务中, 锁定钱包之后依次执行所有的规则, 任意一条规则不通过则拒绝转账
+// 汇款人的钱包被锁定, 同一个用户并发的转账会排队执行, 累计的金额和次数不会被绕过
+
+// 一次转账的上下文
+type Context struct {
+	Tx       *gorm.DB            // 转账的事务
+	Currency model.Currency      // 转账的币种
+	From     model.User          // 汇款人
+	To       model.User          // 收款人
+	Amount   util.Decimal        // 转账金额
+	Limit    model.TransferLimit // 汇款人生效的限额, 已经合并了币种的限额
+	Now      time.Time           // 转账的时间
+}
+
+// 风控规则
+type Rule interface {
+	Check(c *Context) error
+}
+
+type RuleFunc func(c *Context) error
+
+func (f RuleFunc) Check(c *Context) error {
+	return f(c)
+}
+
+// 所有的规则, 按照顺序执行
+var Rules = []Rule{
+	RuleFunc(RecipientRule),
+	RuleFunc(AmountRule),
+	RuleFunc(DailyCountRule),
+	RuleFunc(DailyAmountRule),
+	RuleFunc(MonthlyAmountRule),
+}
+
+// 检查一次转账, 必须在事务中调用
+func Check(tx *gorm.DB, currency model.Currency, from model.User, to model.User, amount util.Decimal) (err error) {
+	c := &Context{
+		Tx:       tx,
+		Currency: currency,
+		From:     from,
+		To:       to,
+		Amount:   amount,
+		Now:      time.Now(),
+	}
+
+	if c.Limit, err = GetLimit(tx, currency, from.Level); err != nil {
+		return
+	}
+
+	for _, rule := range Rules {
+		if err = rule.Check(c); err != nil {
+			return
+		}
+	}
+
+	return
+}
+
+// 获取用户等级生效的限额
+// 优先使用用户等级的限额, 没有则使用默认限额, 再与币种的单笔限额合并, 取更严格的值
+func GetLimit(db *gorm.DB, currency model.Currency, level int32) (limit model.TransferLimit, err error) {
+	list := make([]model.TransferLimit, 0)
+
+	if err = db.Where("currency = ?", currency.Code).Where("level IN (?)", []int32{0, level}).Order("level DESC").Find(&list).Error; err != nil {
+		return
+	}
+
+	if len(list) > 0 {
+		limit = list[0]
+	} else {
+		limit = model.TransferLimit{Currency: currency.Code, Level: level}
+	}
+
+	// 最小金额取较大的值, 最大金额取较小的值
+	if currency.TransferMin.Cmp(limit.Min) > 0 {
+		limit.Min = currency.TransferMin
+	}
+
+	limit.Max = stricter(limit.Max, currency.TransferMax)
+
+	return
+}
+
+// 取更严格的上限, 0 表示不限制
+func stricter(a util.Decimal, b util.Decimal) util.Decimal {
+	if a.IsZero() {
+		return b
+	}
+
+	if b.IsZero() || a.Cmp(b) < 0 {
+		return a
+	}
+
+	return b
+}
+
+// 统计用户从某一时刻起转出的金额和次数
+// 被拒绝和已过期的转账已经退回, 不计入金额, 但是计入次数
+func Usage(db *gorm.DB, code string, uid string, since time.Time) (amount util.Decimal, count int, err error) {
+	query := model.TransferLogOf(db, code).Where("\"from\" = ?", uid).Where("created_at >= ?", since)
+
+	if err = query.Count(&count).Error; err != nil {
+		return
+	}
+
+	err = query.Where("status NOT IN (?)", []model.TransferStatus{model.TransferStatusReject, model.TransferStatusExpired}).Select("COALESCE(SUM(amount), 0)").Row().Scan(&amount)
+
+	return
+}
+
+// 当天的开始时间
+func StartOfDay(t time.Time) time.Time {
+	y, m, d := t.Date()
+	return time.Date(y, m, d, 0, 0, 0, 0, t.Location())
+}
+
+// 当月的开始时间
+func StartOfMonth(t time.Time) time.Time {
+	y, m, _ := t.Date()
+	return time.Date(y, m, 1, 0, 0, 0, 0, t.Location())
+}
+
+// 收款方的帐号必须正常
+func RecipientRule(c *Context) error {
+	switch c.To.Status {
+	case model.UserStatusBanned:
+		return exception.TransferRecipientBanned
+	case model.UserStatusInactivated:
+		return exception.TransferRecipientInactivated
+	}
+
+	return nil
+}
+
+// 单笔转账的限额
+func AmountRule(c *Context) error {
+	if !c.Limit.Min.IsZero() && c.Amount.Cmp(c.Limit.Min) < 0 {
+		return exception.TransferAmountTooSmall
+	}
+
+	if !c.Limit.Max.IsZero() && c.Amount.Cmp(c.Limit.Max) > 0 {
+		return exception.TransferAmountTooLarge
+	}
+
+	return nil
+}
+
+// 每天的转账次数
+func DailyCountRule(c *Context) error {
+	if c.Limit.DailyCount <= 0 {
+		return nil
+	}
+
+	_, count, err := Usage(c.Tx, c.Currency.Code, c.From.Id, StartOfDay(c.Now))
+
+	if err != nil {
+		return err
+	}
+
+	if count+1 > c.Limit.DailyCount {
+		return exception.TransferDailyCountExceeded
+	}
+
+	return nil
+}
+
+// 每天累计转账的金额
+func DailyAmountRule(c *Context) error {
+	if c.Limit.DailyAmount.IsZero() {
+		return nil
+	}
+
+	amount, _, err := Usage(c.Tx, c.Currency.Code, c.From.Id, StartOfDay(c.Now))
+
+	if err != nil {
+		return err
+	}
+
+	if amount.Add(c.Amount).Cmp(c.Limit.DailyAmount) > 0 {
+		return exception.TransferDailyAmountExceeded
+	}
+
+	return nil
+}
+
+// 每月累计转账的金额
+func MonthlyAmountRule(c *Context) error {
+	if c.Limit.MonthlyAmount.IsZero() {
+		return nil
+	}
+
+	amount, _, err := Usage(c.Tx, c.Currency.Code, c.From.Id, StartOfMonth(c.Now))
+
+	if err != nil {
+		return err
+	}
+
+	if amount.Add(c.Amount).Cmp(c.Limit.MonthlyAmount) > 0 {
+		return exception.TransferMonthlyAmountExceeded
+	}
+
+	return nil
+}
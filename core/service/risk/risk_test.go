@@ -0,0 +1,56 @@
+// Copyright 2019 Axetroy. All rights reserved. MIT license.
+package risk_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/axetroy/go-server/core/exception"
+	"github.com/axetroy/go-server/core/model"
+	"github.com/axetroy/go-server/core/service/risk"
+	"github.com/axetroy/go-server/core/util"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRecipientRule(t *testing.T) {
+	c := &risk.Context{To: model.User{Status: model.UserStatusInit}}
+
+	assert.Nil(t, risk.RecipientRule(c))
+
+	c.To.Status = model.UserStatusBanned
+
+	assert.Equal(t, exception.TransferRecipientBanned, risk.RecipientRule(c))
+
+	c.To.Status = model.UserStatusInactivated
+
+	assert.Equal(t, exception.TransferRecipientInactivated, risk.RecipientRule(c))
+}
+
+func TestAmountRule(t *testing.T) {
+	c := &risk.Context{
+		Amount: util.MustParseDecimal("5"),
+	}
+
+	// 不限制
+	assert.Nil(t, risk.AmountRule(c))
+
+	c.Limit.Min = util.MustParseDecimal("1")
+	c.Limit.Max = util.MustParseDecimal("10")
+
+	assert.Nil(t, risk.AmountRule(c))
+
+	c.Amount = util.MustParseDecimal("0.99")
+
+	assert.Equal(t, exception.TransferAmountTooSmall, risk.AmountRule(c))
+
+	c.Amount = util.MustParseDecimal("10.01")
+
+	assert.Equal(t, exception.TransferAmountTooLarge, risk.AmountRule(c))
+}
+
+func TestStartOfDay(t *testing.T) {
+	now := time.Date(2019, 7, 15, 13, 14, 15, 16, time.Local)
+
+	assert.Equal(t, time.Date(2019, 7, 15, 0, 0, 0, 0, time.Local), risk.StartOfDay(now))
+	assert.Equal(t, time.Date(2019, 7, 1, 0, 0, 0, 0, time.Local), risk.StartOfMonth(now))
+}
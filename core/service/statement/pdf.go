@@ -0,0 +1,104 @@
+// Copyright 2019 Axetroy. All rights reserved. MIT license.
+package statement
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+)
+
+// 一个只包含等宽文本的最简 PDF, 使用 PDF 内置的 Courier 字体, 不需要嵌入字体文件
+// 页面为 A4 大小, 超过一页的内容自动分页
+
+const (
+	pdfPageWidth    = 595 // A4 宽度, 单位 pt
+	pdfPageHeight   = 842 // A4 高度, 单位 pt
+	pdfMargin       = 40  // 页边距
+	pdfFontSize     = 8   // 字体大小
+	pdfLineHeight   = 11  // 行高
+	pdfLinesPerPage = (pdfPageHeight - pdfMargin*2) / pdfLineHeight
+)
+
+// 转义 PDF 字符串中的特殊字符, 非 ASCII 字符替换为 ?
+func pdfEscape(s string) string {
+	b := strings.Builder{}
+
+	for _, r := range s {
+		switch {
+		case r == '\\' || r == '(' || r == ')':
+			b.WriteRune('\\')
+			b.WriteRune(r)
+		case r < 32 || r > 126:
+			b.WriteRune('?')
+		default:
+			b.WriteRune(r)
+		}
+	}
+
+	return b.String()
+}
+
+func renderPDF(lines []string) []byte {
+	pages := make([][]string, 0)
+
+	for len(lines) > pdfLinesPerPage {
+		pages = append(pages, lines[:pdfLinesPerPage])
+		lines = lines[pdfLinesPerPage:]
+	}
+
+	pages = append(pages, lines)
+
+	// 1: Catalog, 2: Pages, 3: Font, 之后每一页占用两个对象: Page 和内容流
+	objects := []string{
+		"<< /Type /Catalog /Pages 2 0 R >>",
+		"",
+		"<< /Type /Font /Subtype /Type1 /BaseFont /Courier >>",
+	}
+
+	kids := make([]string, 0, len(pages))
+
+	for i, page := range pages {
+		pageId := 4 + i*2
+
+		content := strings.Builder{}
+
+		content.WriteString(fmt.Sprintf("BT\n/F1 %d Tf\n%d TL\n%d %d Td\n", pdfFontSize, pdfLineHeight, pdfMargin, pdfPageHeight-pdfMargin))
+
+		for _, line := range page {
+			content.WriteString(fmt.Sprintf("(%s) Tj T*\n", pdfEscape(line)))
+		}
+
+		content.WriteString("ET")
+
+		objects = append(objects,
+			fmt.Sprintf("<< /Type /Page /Parent 2 0 R /MediaBox [0 0 %d %d] /Resources << /Font << /F1 3 0 R >> >> /Contents %d 0 R >>", pdfPageWidth, pdfPageHeight, pageId+1),
+			fmt.Sprintf("<< /Length %d >>\nstream\n%s\nendstream", content.Len(), content.String()),
+		)
+
+		kids = append(kids, fmt.Sprintf("%d 0 R", pageId))
+	}
+
+	objects[1] = fmt.Sprintf("<< /Type /Pages /Kids [%s] /Count %d >>", strings.Join(kids, " "), len(pages))
+
+	buf := &bytes.Buffer{}
+	offsets := make([]int, 0, len(objects))
+
+	buf.WriteString("%PDF-1.4\n")
+
+	for i, object := range objects {
+		offsets = append(offsets, buf.Len())
+		buf.WriteString(fmt.Sprintf("%d 0 obj\n%s\nendobj\n", i+1, object))
+	}
+
+	xref := buf.Len()
+
+	buf.WriteString(fmt.Sprintf("xref\n0 %d\n0000000000 65535 f \n", len(objects)+1))
+
+	for _, offset := range offsets {
+		buf.WriteString(fmt.Sprintf("%010d 00000 n \n", offset))
+	}
+
+	buf.WriteString(fmt.Sprintf("trailer\n<< /Size %d /Root 1 0 R >>\nstartxref\n%d\n%%%%EOF\n", len(objects)+1, xref))
+
+	return buf.Bytes()
+}
@@ -0,0 +1,136 @@
+// Copyright 2019 Axetroy. All rights reserved. MIT license.
+package statement
+
+import (
+	"bytes"
+	"encoding/csv"
+	"fmt"
+	"time"
+
+	"github.com/axetroy/go-server/core/exception"
+	"github.com/axetroy/go-server/core/util"
+)
+
+type Format string
+
+const (
+	FormatCSV Format = "csv"
+	FormatPDF Format = "pdf"
+)
+
+// 渲染好的对账单文件
+type Attachment struct {
+	Filename    string // 文件名
+	ContentType string // 文件类型
+	Content     []byte // 文件内容
+}
+
+// 校验对账单的格式
+func (f Format) Validate() error {
+	switch f {
+	case FormatCSV, FormatPDF:
+		return nil
+	default:
+		return exception.StatementInvalidFormat
+	}
+}
+
+// 按照格式渲染对账单
+func Render(format Format, period string, list []Statement) (*Attachment, error) {
+	var (
+		content     []byte
+		contentType string
+		err         error
+	)
+
+	switch format {
+	case FormatCSV:
+		contentType = "text/csv"
+		content, err = RenderCSV(list)
+	case FormatPDF:
+		contentType = "application/pdf"
+		content = RenderPDF(period, list)
+	default:
+		err = exception.StatementInvalidFormat
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	return &Attachment{
+		Filename:    fmt.Sprintf("statement-%s.%s", period, format),
+		ContentType: contentType,
+		Content:     content,
+	}, nil
+}
+
+// 渲染 CSV 格式的对账单
+// 每个币种以 opening 行开始, closing 行结束, 中间是每一笔变动
+func RenderCSV(list []Statement) ([]byte, error) {
+	buf := &bytes.Buffer{}
+
+	w := csv.NewWriter(buf)
+
+	if err := w.Write([]string{"currency", "time", "type", "order_id", "balance_mutation", "frozen_mutation", "balance", "frozen", "note"}); err != nil {
+		return nil, err
+	}
+
+	for _, s := range list {
+		start, end, err := ParsePeriod(s.Period)
+
+		if err != nil {
+			return nil, err
+		}
+
+		rows := [][]string{{s.Currency, start.Format(time.RFC3339), "opening", "", "", "", s.OpeningBalance.String(), s.OpeningFrozen.String(), ""}}
+
+		// 备注可能是转账的发送方填写的, 需要防止 CSV 注入
+		for _, item := range s.Items {
+			rows = append(rows, []string{s.Currency, item.CreatedAt.Format(time.RFC3339), item.Type, item.OrderId, item.BalanceMutation.String(), item.FrozenMutation.String(), item.Balance.String(), item.Frozen.String(), util.EscapeCSVCell(item.Note)})
+		}
+
+		rows = append(rows, []string{s.Currency, end.Format(time.RFC3339), "closing", "", "", "", s.ClosingBalance.String(), s.ClosingFrozen.String(), ""})
+
+		if err := w.WriteAll(rows); err != nil {
+			return nil, err
+		}
+	}
+
+	w.Flush()
+
+	return buf.Bytes(), w.Error()
+}
+
+// 渲染 PDF 格式的对账单
+// 内置字体不支持中文, 所以 PDF 中只使用英文, 并且不包含备注
+func RenderPDF(period string, list []Statement) []byte {
+	const row = "%-19s  %-15s  %16s  %16s  %16s  %s"
+
+	lines := []string{fmt.Sprintf("Account Statement %s", period), ""}
+
+	if len(list) > 0 {
+		lines = append(lines, fmt.Sprintf("User: %s", list[0].Uid), "")
+	} else {
+		lines = append(lines, "No transactions.")
+	}
+
+	for _, s := range list {
+		lines = append(lines,
+			fmt.Sprintf("Currency: %s", s.Currency),
+			fmt.Sprintf("Opening balance: %s  frozen: %s", s.OpeningBalance, s.OpeningFrozen),
+			fmt.Sprintf("Income: %s  Expense: %s", s.Income, s.Expense),
+			fmt.Sprintf("Closing balance: %s  frozen: %s", s.ClosingBalance, s.ClosingFrozen),
+			"",
+			fmt.Sprintf(row, "Time", "Type", "Amount", "Frozen", "Balance", "Order"),
+		)
+
+		for _, item := range s.Items {
+			lines = append(lines, fmt.Sprintf(row, item.CreatedAt.Format("2006-01-02 15:04:05"), item.Type, item.BalanceMutation, item.FrozenMutation, item.Balance, item.OrderId))
+		}
+
+		lines = append(lines, "")
+	}
+
+	return renderPDF(lines)
+}
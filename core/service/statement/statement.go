@@ -0,0 +1,193 @@
+// Copyright 2019 Axetroy. All rights reserved. MIT license.
+package statement
+
+import (
+	"time"
+
+	"github.com/axetroy/go-server/core/exception"
+	"github.com/axetroy/go-server/core/model"
+	"github.com/axetroy/go-server/core/service/currency"
+	"github.com/axetroy/go-server/core/service/database"
+	"github.com/axetroy/go-server/core/service/email"
+	"github.com/axetroy/go-server/core/util"
+	"github.com/jinzhu/gorm"
+)
+
+// 对账单
+// 按月汇总用户每个币种的财务日志, 包括期初余额, 期间的每一笔变动, 以及期末余额
+// 期初余额为月初之前最后一条财务日志的变动后余额, 没有则为 0
+
+// 对账单月份的格式
+const PeriodLayout = "2006-01"
+
+// 对账单中的一笔变动
+type Item struct {
+	Id              string       `json:"id"`               // 财务日志 ID
+	Type            string       `json:"type"`             // 流水类型
+	OrderId         string       `json:"order_id"`         // 对应的订单 ID
+	BalanceMutation util.Decimal `json:"balance_mutation"` // 可用余额的变动
+	FrozenMutation  util.Decimal `json:"frozen_mutation"`  // 冻结余额的变动
+	Balance         util.Decimal `json:"balance"`          // 变动后的可用余额
+	Frozen          util.Decimal `json:"frozen"`           // 变动后的冻结余额
+	Note            string       `json:"note"`             // 备注
+	CreatedAt       time.Time    `json:"created_at"`       // 时间
+}
+
+// 一个币种的对账单
+type Statement struct {
+	Uid            string       `json:"uid"`             // 用户 ID
+	Currency       string       `json:"currency"`        // 币种
+	Period         string       `json:"period"`          // 月份, 例如 2019-08
+	OpeningBalance util.Decimal `json:"opening_balance"` // 期初可用余额
+	OpeningFrozen  util.Decimal `json:"opening_frozen"`  // 期初冻结余额
+	ClosingBalance util.Decimal `json:"closing_balance"` // 期末可用余额
+	ClosingFrozen  util.Decimal `json:"closing_frozen"`  // 期末冻结余额
+	Income         util.Decimal `json:"income"`          // 可用余额增加的总额
+	Expense        util.Decimal `json:"expense"`         // 可用余额减少的总额, 为正数
+	Items          []Item       `json:"items"`           // 期间的每一笔变动
+}
+
+// 解析对账单的月份, 返回这个月的开始时间和下个月的开始时间
+func ParsePeriod(period string) (start time.Time, end time.Time, err error) {
+	if start, err = time.ParseInLocation(PeriodLayout, period, time.Local); err != nil {
+		err = exception.StatementInvalidPeriod
+		return
+	}
+
+	end = start.AddDate(0, 1, 0)
+
+	return
+}
+
+// 上个月的月份, 月度对账单发送的是上个月的对账单
+func LastPeriod(now time.Time) string {
+	return time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, now.Location()).AddDate(0, -1, 0).Format(PeriodLayout)
+}
+
+// 根据期初的财务日志和期间的财务日志生成对账单, 期间的财务日志需要按照时间顺序排列
+func Summarize(uid string, code string, period string, opening *model.FinanceLog, logs []model.FinanceLog) Statement {
+	s := Statement{
+		Uid:      uid,
+		Currency: code,
+		Period:   period,
+		Items:    make([]Item, 0, len(logs)),
+	}
+
+	if opening != nil {
+		s.OpeningBalance = opening.AfterBalance
+		s.OpeningFrozen = opening.AfterFrozen
+	}
+
+	s.ClosingBalance = s.OpeningBalance
+	s.ClosingFrozen = s.OpeningFrozen
+
+	for _, l := range logs {
+		item := Item{
+			Id:              l.Id,
+			Type:            string(l.Type),
+			OrderId:         l.OrderId,
+			BalanceMutation: l.BalanceMutation,
+			FrozenMutation:  l.FrozenMutation,
+			Balance:         l.AfterBalance,
+			Frozen:          l.AfterFrozen,
+			CreatedAt:       l.CreatedAt,
+		}
+
+		if l.Note != nil {
+			item.Note = *l.Note
+		}
+
+		if l.BalanceMutation.Sign() > 0 {
+			s.Income = s.Income.Add(l.BalanceMutation)
+		} else {
+			s.Expense = s.Expense.Sub(l.BalanceMutation)
+		}
+
+		s.ClosingBalance = l.AfterBalance
+		s.ClosingFrozen = l.AfterFrozen
+
+		s.Items = append(s.Items, item)
+	}
+
+	return s
+}
+
+// 生成用户某个月所有币种的对账单, 期初余额为 0 并且没有变动的币种不会生成
+func Build(db *gorm.DB, uid string, period string) (list []Statement, err error) {
+	var (
+		start time.Time
+		end   time.Time
+		codes []string
+	)
+
+	if start, end, err = ParsePeriod(period); err != nil {
+		return
+	}
+
+	if codes, err = currency.Codes(); err != nil {
+		return
+	}
+
+	list = make([]Statement, 0)
+
+	for _, code := range codes {
+		var (
+			opening *model.FinanceLog
+			logs    = make([]model.FinanceLog, 0)
+		)
+
+		last := make([]model.FinanceLog, 0)
+
+		if err = model.FinanceLogOf(db, code).Where("uid = ? AND created_at < ?", uid, start).Order("created_at DESC").Order("id DESC").Limit(1).Find(&last).Error; err != nil {
+			return
+		}
+
+		if len(last) > 0 {
+			opening = &last[0]
+		}
+
+		if err = model.FinanceLogOf(db, code).Where("uid = ? AND created_at >= ? AND created_at < ?", uid, start, end).Order("created_at ASC").Order("id ASC").Find(&logs).Error; err != nil {
+			return
+		}
+
+		if opening == nil && len(logs) == 0 {
+			continue
+		}
+
+		list = append(list, Summarize(uid, code, period, opening, logs))
+	}
+
+	return
+}
+
+// 生成对账单并发送到用户绑定的邮箱
+func Deliver(uid string, period string, format Format) (err error) {
+	var (
+		list       []Statement
+		attachment *Attachment
+	)
+
+	user := model.User{Id: uid}
+
+	if err = database.Db.First(&user).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			err = exception.UserNotExist
+		}
+		return
+	}
+
+	if user.Email == nil || *user.Email == "" {
+		err = exception.StatementRequireEmail
+		return
+	}
+
+	if list, err = Build(database.Db, uid, period); err != nil {
+		return
+	}
+
+	if attachment, err = Render(format, period, list); err != nil {
+		return
+	}
+
+	return email.NewMailer().SendStatementEmail(*user.Email, period, attachment.Filename, attachment.ContentType, attachment.Content)
+}
@@ -0,0 +1,146 @@
+// Copyright 2019 Axetroy. All rights reserved. MIT license.
+package statement_test
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/axetroy/go-server/core/exception"
+	"github.com/axetroy/go-server/core/model"
+	"github.com/axetroy/go-server/core/service/statement"
+	"github.com/axetroy/go-server/core/util"
+	"github.com/stretchr/testify/assert"
+)
+
+func newLog(id string, t model.FinanceType, mutation string, after string, createdAt time.Time) model.FinanceLog {
+	return model.FinanceLog{
+		Id:              id,
+		Type:            t,
+		BalanceMutation: util.MustParseDecimal(mutation),
+		AfterBalance:    util.MustParseDecimal(after),
+		CreatedAt:       createdAt,
+	}
+}
+
+func TestParsePeriod(t *testing.T) {
+	start, end, err := statement.ParsePeriod("2019-12")
+
+	assert.Nil(t, err)
+	assert.Equal(t, time.Date(2019, 12, 1, 0, 0, 0, 0, time.Local), start)
+	assert.Equal(t, time.Date(2020, 1, 1, 0, 0, 0, 0, time.Local), end)
+
+	_, _, err = statement.ParsePeriod("2019-13")
+
+	assert.Equal(t, exception.StatementInvalidPeriod, err)
+
+	assert.Equal(t, "2019-12", statement.LastPeriod(time.Date(2020, 1, 31, 23, 0, 0, 0, time.Local)))
+	assert.Equal(t, "2020-02", statement.LastPeriod(time.Date(2020, 3, 31, 0, 0, 0, 0, time.Local)))
+}
+
+func TestSummarize(t *testing.T) {
+	now := time.Date(2019, 8, 10, 0, 0, 0, 0, time.Local)
+
+	opening := newLog("1", model.FinanceTypeAdminCredit, "100", "100", now.AddDate(0, -1, 0))
+
+	// 没有变动, 期末余额等于期初余额
+	{
+		s := statement.Summarize("uid", model.WalletCNY, "2019-08", &opening, nil)
+
+		assert.Equal(t, "100", s.OpeningBalance.String())
+		assert.Equal(t, "100", s.ClosingBalance.String())
+		assert.Len(t, s.Items, 0)
+	}
+
+	// 有变动
+	{
+		logs := []model.FinanceLog{
+			newLog("2", model.FinanceTypeTransferIn, "50", "150", now),
+			newLog("3", model.FinanceTypeTransferOut, "-30.5", "119.5", now.Add(time.Hour)),
+		}
+
+		s := statement.Summarize("uid", model.WalletCNY, "2019-08", &opening, logs)
+
+		assert.Equal(t, "100", s.OpeningBalance.String())
+		assert.Equal(t, "119.5", s.ClosingBalance.String())
+		assert.Equal(t, "50", s.Income.String())
+		assert.Equal(t, "30.5", s.Expense.String())
+		assert.Len(t, s.Items, 2)
+	}
+
+	// 没有期初的财务日志
+	{
+		logs := []model.FinanceLog{newLog("2", model.FinanceTypeTransferIn, "50", "50", now)}
+
+		s := statement.Summarize("uid", model.WalletCNY, "2019-08", nil, logs)
+
+		assert.Equal(t, "0", s.OpeningBalance.String())
+		assert.Equal(t, "50", s.ClosingBalance.String())
+	}
+}
+
+func TestRender(t *testing.T) {
+	now := time.Date(2019, 8, 10, 0, 0, 0, 0, time.Local)
+
+	logs := []model.FinanceLog{newLog("2", model.FinanceTypeTransferIn, "50", "50", now)}
+
+	list := []statement.Statement{statement.Summarize("uid", model.WalletCNY, "2019-08", nil, logs)}
+
+	// CSV
+	{
+		attachment, err := statement.Render(statement.FormatCSV, "2019-08", list)
+
+		assert.Nil(t, err)
+		assert.Equal(t, "statement-2019-08.csv", attachment.Filename)
+		assert.Equal(t, "text/csv", attachment.ContentType)
+
+		lines := strings.Split(strings.TrimSpace(string(attachment.Content)), "\n")
+
+		assert.Len(t, lines, 4)
+		assert.True(t, strings.HasPrefix(lines[1], "CNY,2019-08-01T00:00:00"))
+		assert.Contains(t, lines[1], ",opening,")
+		assert.Contains(t, lines[2], ",transfer_in,")
+		assert.Contains(t, lines[3], ",closing,")
+	}
+
+	// 备注中的公式会被转义
+	{
+		l := []statement.Statement{list[0]}
+		l[0].Items = []statement.Item{{Type: string(model.FinanceTypeTransferIn), Note: "=HYPERLINK(1)", CreatedAt: now}}
+
+		content, err := statement.RenderCSV(l)
+
+		assert.Nil(t, err)
+		assert.Contains(t, string(content), ",'=HYPERLINK(1)")
+	}
+
+	// PDF
+	{
+		attachment, err := statement.Render(statement.FormatPDF, "2019-08", list)
+
+		assert.Nil(t, err)
+		assert.Equal(t, "application/pdf", attachment.ContentType)
+		assert.True(t, bytes.HasPrefix(attachment.Content, []byte("%PDF-1.4")))
+		assert.True(t, bytes.HasSuffix(attachment.Content, []byte("%%EOF\n")))
+		assert.Contains(t, string(attachment.Content), "(Currency: CNY)")
+	}
+
+	// 很多变动时自动分页
+	{
+		for i := 0; i < 200; i++ {
+			list[0].Items = append(list[0].Items, list[0].Items[0])
+		}
+
+		content := statement.RenderPDF("2019-08", list)
+
+		assert.Contains(t, string(content), "/Count 4")
+	}
+
+	// 无效的格式
+	{
+		_, err := statement.Render("xls", "2019-08", list)
+
+		assert.Equal(t, exception.StatementInvalidFormat, err)
+	}
+}
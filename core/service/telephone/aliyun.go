@@ -77,3 +77,7 @@ func (c *Aliyun) SendRegisterCode(phone string, code string) error {
 		"code": code,
 	})
 }
+
+func (c *Aliyun) SendTemplate(phone string, templateID string, params map[string]string) error {
+	return c.send(phone, templateID, params)
+}
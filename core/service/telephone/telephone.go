@@ -17,13 +17,14 @@ var (
 
 // 邮箱提供这应提供的对象
 type Telephone interface {
-	getAuthTemplateID() string                                                 // 身份验证的模版 ID
-	getResetPasswordTemplateID() string                                        // 重置密码的模版 ID
-	getRegisterTemplateID() string                                             // 注册帐号的模版 ID
-	send(phone string, templateID string, templateMap map[string]string) error // 发送验证码
-	SendRegisterCode(phone string, code string) error                          // 发送注册验证码
-	SendAuthCode(phone string, code string) error                              // 发送身份验证码
-	SendResetPasswordCode(phone string, code string) error                     // 发送重置密码验证码
+	getAuthTemplateID() string                                                    // 身份验证的模版 ID
+	getResetPasswordTemplateID() string                                           // 重置密码的模版 ID
+	getRegisterTemplateID() string                                                // 注册帐号的模版 ID
+	send(phone string, templateID string, templateMap map[string]string) error    // 发送验证码
+	SendRegisterCode(phone string, code string) error                             // 发送注册验证码
+	SendAuthCode(phone string, code string) error                                 // 发送身份验证码
+	SendResetPasswordCode(phone string, code string) error                        // 发送重置密码验证码
+	SendTemplate(phone string, templateID string, params map[string]string) error // 使用服务商的模版发送短信
 }
 
 func init() {
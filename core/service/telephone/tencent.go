@@ -146,3 +146,7 @@ func (c *Tencent) SendRegisterCode(phone string, code string) error {
 		"code": code,
 	})
 }
+
+func (c *Tencent) SendTemplate(phone string, templateID string, params map[string]string) error {
+	return c.send(phone, templateID, params)
+}
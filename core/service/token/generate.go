@@ -9,29 +9,36 @@ import (
 
 // generate jwt token
 func Generate(userId string, isAdmin bool) (tokenString string, err error) {
+	return GenerateWithFamily(userId, isAdmin, "")
+}
+
+// 生成属于某个刷新令牌家族的 token, 吊销 token 时可以找到对应的家族
+func GenerateWithFamily(userId string, isAdmin bool, family string) (tokenString string, err error) {
 	var (
-		issuer string
+		issuer = getIssuer(isAdmin)
 		key    string
 	)
 
 	if isAdmin {
-		issuer = "admin"
 		key = adminSecreteKey
 	} else {
-		issuer = "user"
 		key = userSecreteKey
 	}
 
+	now := time.Now()
+
 	// 生成token
 	c := ClaimsInternal{
 		util.Base64Encode(userId),
+		unixMilli(now),
+		family,
 		jwt.StandardClaims{
 			Audience:  userId,
-			Id:        userId,
-			ExpiresAt: time.Now().Add(time.Hour * time.Duration(6)).Unix(),
+			Id:        util.GenerateId(), // 每个 token 的唯一 ID, 用于吊销单个 token
+			ExpiresAt: now.Add(getDuration(isAdmin)).Unix(),
 			Issuer:    issuer,
-			IssuedAt:  time.Now().Unix(),
-			NotBefore: time.Now().Unix(),
+			IssuedAt:  now.Unix(),
+			NotBefore: now.Unix(),
 		},
 	}
 
@@ -41,3 +48,19 @@ func Generate(userId string, isAdmin bool) (tokenString string, err error) {
 
 	return
 }
+
+// 登陆时生成 token 和刷新令牌, token 绑定刷新令牌的家族
+func GenerateSession(userId string, isAdmin bool) (tokenString string, refreshToken string, err error) {
+	var family string
+
+	if refreshToken, family, err = GenerateRefreshToken(userId, isAdmin); err != nil {
+		return
+	}
+
+	if tokenString, err = GenerateWithFamily(userId, isAdmin, family); err != nil {
+		refreshToken = ""
+		return
+	}
+
+	return
+}
@@ -18,5 +18,23 @@ func TestGenerate(t *testing.T) {
 
 	assert.Nil(t, err1)
 
-	assert.Equal(t, uid, c.Id)
+	assert.Equal(t, uid, c.Uid)
+	assert.Equal(t, uid, c.Audience)
+	assert.NotEmpty(t, c.Id)
+}
+
+func TestGenerateUniqueId(t *testing.T) {
+	uid := "123123"
+
+	tokenStr1, _ := token.Generate(uid, false)
+	tokenStr2, _ := token.Generate(uid, false)
+
+	c1, err := token.Parse(token.Prefix+" "+tokenStr1, false)
+	assert.Nil(t, err)
+
+	c2, err := token.Parse(token.Prefix+" "+tokenStr2, false)
+	assert.Nil(t, err)
+
+	// 同一个用户的每个 token 都有不同的 ID
+	assert.NotEqual(t, c1.Id, c2.Id)
 }
@@ -61,6 +61,8 @@ func Parse(tokenString string, isAdmin bool) (claims Claims, err error) {
 		claims.ExpiresAt = c.ExpiresAt
 		claims.Issuer = c.Issuer
 		claims.IssuedAt = c.IssuedAt
+		claims.IssuedAtMs = c.IssuedAtMs
+		claims.Family = c.Family
 		claims.Subject = c.Subject
 
 		return
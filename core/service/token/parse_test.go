@@ -18,5 +18,8 @@ func TestParse(t *testing.T) {
 
 	assert.Nil(t, err1)
 
-	assert.Equal(t, uid, c.Id)
+	assert.Equal(t, uid, c.Uid)
+	assert.Equal(t, uid, c.Audience)
+	assert.NotEmpty(t, c.Id)
+	assert.Equal(t, c.IssuedAt, c.IssuedAtMs/1000)
 }
@@ -0,0 +1,194 @@
+// Copyright 2019 Axetroy. All rights reserved. MIT license.
+package token
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"github.com/axetroy/go-server/core/exception"
+	"github.com/axetroy/go-server/core/service/redis"
+	"github.com/axetroy/go-server/core/util"
+	goredis "github.com/go-redis/redis"
+)
+
+// 刷新令牌是一个随机字符串，服务端只保存它的 sha256 摘要
+// 每次登陆都会产生一个新的令牌家族(family), 每次刷新都会轮换出新的刷新令牌，旧的随即失效
+// 如果已经被轮换掉的刷新令牌再次被使用，说明令牌可能已经泄漏，整个家族都会被吊销
+
+// 轮换刷新令牌的脚本, 保证并发刷新时只有一个请求能成功
+// 返回 1: 轮换成功, 0: 检测到令牌被重复使用，家族已吊销, -1: 家族不存在
+var rotateScript = `
+local current = redis.call("HGET", KEYS[1], "current")
+if not current then
+	return -1
+end
+if current ~= ARGV[1] then
+	redis.call("DEL", KEYS[1])
+	return 0
+end
+redis.call("HSET", KEYS[1], "current", ARGV[2])
+redis.call("EXPIRE", KEYS[1], ARGV[3])
+return 1
+`
+
+func refreshTokenKey(hash string) string {
+	return "refresh:" + hash
+}
+
+func refreshFamilyKey(family string) string {
+	return "family:" + family
+}
+
+func refreshUserKey(issuer string, uid string) string {
+	return fmt.Sprintf("%s:families:%s", issuer, uid)
+}
+
+func hashRefreshToken(refreshToken string) string {
+	sum := sha256.Sum256([]byte(refreshToken))
+	return hex.EncodeToString(sum[:])
+}
+
+func randomRefreshToken() (string, error) {
+	b := make([]byte, 32)
+
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+
+	return hex.EncodeToString(b), nil
+}
+
+// 登陆时生成刷新令牌，同时创建一个新的令牌家族
+func GenerateRefreshToken(uid string, isAdmin bool) (refreshToken string, family string, err error) {
+	var (
+		issuer   = getIssuer(isAdmin)
+		duration = getRefreshDuration(isAdmin)
+	)
+
+	family = util.GenerateId()
+
+	if refreshToken, err = randomRefreshToken(); err != nil {
+		return
+	}
+
+	hash := hashRefreshToken(refreshToken)
+
+	_, err = redis.ClientRefreshToken.TxPipelined(func(pipe goredis.Pipeliner) error {
+		pipe.HMSet(refreshFamilyKey(family), map[string]interface{}{
+			"uid":     uid,
+			"issuer":  issuer,
+			"current": hash,
+		})
+		pipe.Expire(refreshFamilyKey(family), duration)
+		pipe.Set(refreshTokenKey(hash), family, duration)
+		pipe.SAdd(refreshUserKey(issuer, uid), family)
+		pipe.Expire(refreshUserKey(issuer, uid), duration)
+		return nil
+	})
+
+	if err != nil {
+		refreshToken = ""
+		family = ""
+	}
+
+	return
+}
+
+// 使用刷新令牌换取新的刷新令牌, 返回令牌所属的用户 ID 和家族
+func RotateRefreshToken(refreshToken string, isAdmin bool) (uid string, family string, newRefreshToken string, err error) {
+	var (
+		issuer   = getIssuer(isAdmin)
+		duration = getRefreshDuration(isAdmin)
+		hash     = hashRefreshToken(refreshToken)
+		info     map[string]string
+	)
+
+	if family, err = redis.ClientRefreshToken.Get(refreshTokenKey(hash)).Result(); err != nil {
+		if err == goredis.Nil {
+			err = exception.InvalidRefreshToken
+		}
+		return
+	}
+
+	if info, err = redis.ClientRefreshToken.HGetAll(refreshFamilyKey(family)).Result(); err != nil {
+		return
+	}
+
+	// 家族已经被吊销或者过期
+	if len(info) == 0 || info["issuer"] != issuer {
+		err = exception.InvalidRefreshToken
+		return
+	}
+
+	uid = info["uid"]
+
+	if newRefreshToken, err = randomRefreshToken(); err != nil {
+		return
+	}
+
+	newHash := hashRefreshToken(newRefreshToken)
+
+	result, err := redis.ClientRefreshToken.Eval(rotateScript, []string{refreshFamilyKey(family)}, hash, newHash, int64(duration.Seconds())).Int()
+
+	if err != nil {
+		return
+	}
+
+	switch result {
+	case 1:
+		// 旧的令牌继续保留到过期为止, 用于检测重复使用
+		err = redis.ClientRefreshToken.Set(refreshTokenKey(newHash), family, duration).Err()
+	case 0:
+		_ = redis.ClientRefreshToken.SRem(refreshUserKey(issuer, uid), family).Err()
+
+		// 令牌可能已经泄漏, 家族下签发的访问令牌也一并失效
+		if err = revokeFamily(family, isAdmin); err == nil {
+			err = exception.RefreshTokenReused
+		}
+	default:
+		err = exception.InvalidRefreshToken
+	}
+
+	if err != nil {
+		uid = ""
+		family = ""
+		newRefreshToken = ""
+	}
+
+	return
+}
+
+// 吊销单个刷新令牌家族, 用于登出当前设备
+func RevokeRefreshFamily(uid string, family string, isAdmin bool) error {
+	_, err := redis.ClientRefreshToken.TxPipelined(func(pipe goredis.Pipeliner) error {
+		pipe.Del(refreshFamilyKey(family))
+		pipe.SRem(refreshUserKey(getIssuer(isAdmin), uid), family)
+		return nil
+	})
+
+	if err != nil {
+		return err
+	}
+
+	return revokeFamily(family, isAdmin)
+}
+
+// 吊销用户所有的刷新令牌
+func revokeRefreshTokens(uid string, isAdmin bool) error {
+	key := refreshUserKey(getIssuer(isAdmin), uid)
+
+	families, err := redis.ClientRefreshToken.SMembers(key).Result()
+
+	if err != nil {
+		return err
+	}
+
+	keys := []string{key}
+
+	for _, family := range families {
+		keys = append(keys, refreshFamilyKey(family))
+	}
+
+	return redis.ClientRefreshToken.Del(keys...).Err()
+}
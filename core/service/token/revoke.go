@@ -0,0 +1,109 @@
+// Copyright 2019 Axetroy. All rights reserved. MIT license.
+package token
+
+import (
+	"fmt"
+	"github.com/axetroy/go-server/core/service/redis"
+	"strconv"
+	"time"
+)
+
+// 单个 token 的吊销记录, 以 token 的 jti 区分
+func revokedTokenKey(issuer string, id string) string {
+	return fmt.Sprintf("%s:jti:%s", issuer, id)
+}
+
+// 用户所有 token 的吊销记录, 值为吊销时的毫秒时间戳，在这之前签发的 token 全部失效
+func revokedUserKey(issuer string, uid string) string {
+	return fmt.Sprintf("%s:uid:%s", issuer, uid)
+}
+
+// 刷新令牌家族的吊销记录, 家族下签发的访问令牌全部失效
+func revokedFamilyKey(issuer string, family string) string {
+	return fmt.Sprintf("%s:fid:%s", issuer, family)
+}
+
+func getIssuer(isAdmin bool) string {
+	if isAdmin {
+		return "admin"
+	}
+	return "user"
+}
+
+// 吊销单个 token
+func Revoke(c Claims) error {
+	// 只需要保存到 token 过期为止
+	duration := time.Until(time.Unix(c.ExpiresAt, 0))
+
+	if duration <= 0 {
+		return nil
+	}
+
+	return redis.ClientRevokedToken.Set(revokedTokenKey(c.Issuer, c.Id), c.Uid, duration).Err()
+}
+
+// 吊销某个用户已签发的所有 token, 包括刷新令牌
+func RevokeAll(uid string, isAdmin bool) error {
+	if err := redis.ClientRevokedToken.Set(revokedUserKey(getIssuer(isAdmin), uid), unixMilli(time.Now()), getDuration(isAdmin)).Err(); err != nil {
+		return err
+	}
+
+	return revokeRefreshTokens(uid, isAdmin)
+}
+
+// 吊销某个刷新令牌家族下签发的访问令牌
+// 访问令牌最多只能存活一个有效期, 吊销记录保存到那时为止即可
+func revokeFamily(family string, isAdmin bool) error {
+	return redis.ClientRevokedToken.Set(revokedFamilyKey(getIssuer(isAdmin), family), unixMilli(time.Now()), getDuration(isAdmin)).Err()
+}
+
+// 检查 token 是否已被吊销
+func IsRevoked(c Claims) (bool, error) {
+	keys := []string{revokedTokenKey(c.Issuer, c.Id), revokedUserKey(c.Issuer, c.Uid)}
+
+	// 旧的 token 不属于任何刷新令牌家族
+	if c.Family != "" {
+		keys = append(keys, revokedFamilyKey(c.Issuer, c.Family))
+	}
+
+	values, err := redis.ClientRevokedToken.MGet(keys...).Result()
+
+	if err != nil {
+		return false, err
+	}
+
+	if values[0] != nil {
+		return true, nil
+	}
+
+	if len(values) > 2 && values[2] != nil {
+		return true, nil
+	}
+
+	if s, ok := values[1].(string); ok {
+		revokedAt, err := strconv.ParseInt(s, 10, 64)
+
+		if err != nil {
+			return false, err
+		}
+
+		// 旧的吊销记录以秒为单位
+		if revokedAt < 1e12 {
+			revokedAt = revokedAt * 1000
+		}
+
+		issuedAt := c.IssuedAtMs
+
+		// 旧的 token 没有毫秒时间戳
+		if issuedAt == 0 {
+			issuedAt = c.IssuedAt * 1000
+		}
+
+		// 比较到毫秒, 吊销之后签发的 token 仍然有效
+		if issuedAt <= revokedAt {
+			return true, nil
+		}
+	}
+
+	return false, nil
+}
@@ -5,6 +5,7 @@ import (
 	"errors"
 	"github.com/axetroy/go-server/core/config"
 	"github.com/dgrijalva/jwt-go"
+	"time"
 )
 
 const (
@@ -18,12 +19,16 @@ var (
 )
 
 type Claims struct {
-	Uid string `json:"uid"`
+	Uid        string `json:"uid"`
+	IssuedAtMs int64  `json:"iat_ms"` // 签发时间的毫秒时间戳, 用于和吊销时间比较
+	Family     string `json:"fid"`    // 所属的刷新令牌家族, 登出时一起吊销
 	jwt.StandardClaims
 }
 
 type ClaimsInternal struct {
-	Uid string `json:"uid"` // base64 encode
+	Uid        string `json:"uid"` // base64 encode
+	IssuedAtMs int64  `json:"iat_ms"`
+	Family     string `json:"fid,omitempty"`
 	jwt.StandardClaims
 }
 
@@ -35,6 +40,27 @@ func init() {
 	}
 }
 
+// token 的有效期
+func getDuration(isAdmin bool) time.Duration {
+	if isAdmin {
+		return config.Admin.TokenDuration
+	}
+	return config.User.TokenDuration
+}
+
+// 刷新令牌的有效期
+func getRefreshDuration(isAdmin bool) time.Duration {
+	if isAdmin {
+		return config.Admin.RefreshTokenDuration
+	}
+	return config.User.RefreshTokenDuration
+}
+
 func JoinPrefixToken(token string) string {
 	return Prefix + " " + token
 }
+
+// 毫秒时间戳
+func unixMilli(t time.Time) int64 {
+	return t.UnixNano() / int64(time.Millisecond)
+}
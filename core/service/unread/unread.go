@@ -0,0 +1,126 @@
+// Copyright 2019 Axetroy. All rights reserved. MIT license.
+package unread
+
+import (
+	"strconv"
+	"time"
+
+	"github.com/axetroy/go-server/core/service/redis"
+	goredis "github.com/go-redis/redis"
+)
+
+// 未读数量的计数器
+// 计数器缓存在 Redis 中, 没有缓存时从数据库加载. 数量变化时直接增减缓存, 没有缓存则忽略, 下次读取时重新加载
+// 影响所有用户的变化(例如删除系统通知)通过递增版本号让所有用户的缓存失效
+// 缓存有过期时间, 即使出现并发导致的误差, 过期之后也会恢复正确
+
+// 只有缓存存在时才增减, 减到负数说明缓存已经不准确, 删除缓存
+var incrScript = goredis.NewScript(`
+if redis.call("EXISTS", KEYS[1]) == 0 then
+	return nil
+end
+local count = redis.call("INCRBY", KEYS[1], ARGV[1])
+if count < 0 then
+	redis.call("DEL", KEYS[1])
+end
+return count
+`)
+
+type Counter struct {
+	Name string                          // 计数器的名称, 作为缓存 key 的前缀
+	TTL  time.Duration                   // 缓存的过期时间
+	Load func(uid string) (int64, error) // 从数据库加载用户的未读数量
+}
+
+func New(name string, ttl time.Duration, load func(uid string) (int64, error)) *Counter {
+	return &Counter{
+		Name: name,
+		TTL:  ttl,
+		Load: load,
+	}
+}
+
+func (c *Counter) versionKey() string {
+	return c.Name + ":version"
+}
+
+func (c *Counter) key(uid string) (string, error) {
+	version, err := redis.ClientUnread.Get(c.versionKey()).Result()
+
+	if err == goredis.Nil {
+		version, err = "0", nil
+	}
+
+	if err != nil {
+		return "", err
+	}
+
+	return c.Name + ":" + version + ":" + uid, nil
+}
+
+// 获取用户的未读数量
+func (c *Counter) Get(uid string) (int64, error) {
+	key, err := c.key(uid)
+
+	if err != nil {
+		return 0, err
+	}
+
+	value, err := redis.ClientUnread.Get(key).Result()
+
+	if err == nil {
+		if count, er := strconv.ParseInt(value, 10, 64); er == nil {
+			return count, nil
+		}
+	} else if err != goredis.Nil {
+		return 0, err
+	}
+
+	count, err := c.Load(uid)
+
+	if err != nil {
+		return 0, err
+	}
+
+	// 只在没有缓存的时候写入, 避免覆盖并发的增减
+	if err = redis.ClientUnread.SetNX(key, count, c.TTL).Err(); err != nil {
+		return 0, err
+	}
+
+	return count, nil
+}
+
+// 增减用户的未读数量, 没有缓存则忽略
+func (c *Counter) Add(uid string, delta int64) error {
+	if delta == 0 {
+		return nil
+	}
+
+	key, err := c.key(uid)
+
+	if err != nil {
+		return err
+	}
+
+	if err = incrScript.Run(redis.ClientUnread, []string{key}, delta).Err(); err != nil && err != goredis.Nil {
+		return err
+	}
+
+	return nil
+}
+
+// 删除用户的缓存, 下次读取时重新加载
+func (c *Counter) Invalidate(uid string) error {
+	key, err := c.key(uid)
+
+	if err != nil {
+		return err
+	}
+
+	return redis.ClientUnread.Del(key).Err()
+}
+
+// 让所有用户的缓存失效, 旧的缓存会自动过期
+func (c *Counter) InvalidateAll() error {
+	return redis.ClientUnread.Incr(c.versionKey()).Err()
+}
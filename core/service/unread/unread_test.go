@@ -0,0 +1,65 @@
+// Copyright 2019 Axetroy. All rights reserved. MIT license.
+package unread_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/axetroy/go-server/core/service/unread"
+	"github.com/axetroy/go-server/core/util"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCounter(t *testing.T) {
+	loaded := 0
+
+	counter := unread.New("test-"+util.RandomString(6), time.Minute, func(uid string) (int64, error) {
+		loaded = loaded + 1
+		return 10, nil
+	})
+
+	uid := util.RandomString(8)
+
+	// 没有缓存时不会增减
+	assert.Nil(t, counter.Add(uid, 1))
+
+	// 第一次从数据库加载
+	count, err := counter.Get(uid)
+
+	assert.Nil(t, err)
+	assert.Equal(t, int64(10), count)
+	assert.Equal(t, 1, loaded)
+
+	// 之后直接增减缓存
+	assert.Nil(t, counter.Add(uid, 2))
+	assert.Nil(t, counter.Add(uid, -5))
+
+	count, err = counter.Get(uid)
+
+	assert.Nil(t, err)
+	assert.Equal(t, int64(7), count)
+	assert.Equal(t, 1, loaded)
+
+	// 减到负数时删除缓存, 重新加载
+	assert.Nil(t, counter.Add(uid, -100))
+
+	count, err = counter.Get(uid)
+
+	assert.Nil(t, err)
+	assert.Equal(t, int64(10), count)
+	assert.Equal(t, 2, loaded)
+
+	// 删除单个用户的缓存
+	assert.Nil(t, counter.Invalidate(uid))
+
+	_, _ = counter.Get(uid)
+
+	assert.Equal(t, 3, loaded)
+
+	// 让所有用户的缓存失效
+	assert.Nil(t, counter.InvalidateAll())
+
+	_, _ = counter.Get(uid)
+
+	assert.Equal(t, 4, loaded)
+}
@@ -0,0 +1,167 @@
+// Copyright 2019 Axetroy. All rights reserved. MIT license.
+package wallet
+
+import (
+	"sort"
+	"strings"
+
+	"github.com/axetroy/go-server/core/exception"
+	"github.com/axetroy/go-server/core/model"
+	"github.com/axetroy/go-server/core/service/currency"
+	"github.com/axetroy/go-server/core/util"
+	"github.com/jinzhu/gorm"
+)
+
+// 钱包的余额变动
+// 所有的余额变动都必须通过这里进行, 保证:
+// 1. 变动的钱包按照用户 ID 排序后依次加行锁 (SELECT ... FOR UPDATE), 并发的变动不会丢失更新, 也不会死锁
+// 2. 使用精确的十进制计算, 不会有浮点数的精度问题
+// 3. 每一笔变动都会生成对应的财务日志
+
+// 一个钱包的变动
+type Mutation struct {
+	Uid     string            // 钱包所属的用户
+	Balance util.Decimal      // 可用余额的变动, 正数则为加, 负数为减
+	Frozen  util.Decimal      // 冻结余额的变动, 正数则为加, 负数为减
+	Type    model.FinanceType // 流水类型
+	Note    *string           // 流水备注
+}
+
+// 解析金额, 金额必须为正数, 并且不能超过币种的小数位数
+func ParseAmount(code string, s string) (amount util.Decimal, err error) {
+	var c model.Currency
+
+	if c, err = currency.Get(code); err != nil {
+		return
+	}
+
+	if amount, err = util.ParseDecimal(s); err != nil {
+		err = exception.InvalidParams
+		return
+	}
+
+	if amount.Sign() <= 0 || amount.Places() > c.Precision {
+		err = exception.InvalidParams
+		return
+	}
+
+	return
+}
+
+// 锁定用户的钱包, 必须在事务中调用
+// 钱包不存在的话会自动创建
+func Lock(tx *gorm.DB, code string, uid string) (wallet model.Wallet, err error) {
+	if err = model.WalletOf(tx, code).Set("gorm:query_option", "FOR UPDATE").Where("id = ?", uid).First(&wallet).Error; err != gorm.ErrRecordNotFound {
+		return
+	}
+
+	wallet = model.Wallet{
+		Id:       uid,
+		Currency: strings.ToUpper(code),
+	}
+
+	if err = tx.Create(&wallet).Error; err != nil {
+		return
+	}
+
+	err = model.WalletOf(tx, code).Set("gorm:query_option", "FOR UPDATE").Where("id = ?", uid).First(&wallet).Error
+
+	return
+}
+
+// 锁定多个用户的钱包, 必须在事务中调用
+// 按照用户 ID 的顺序加锁, 避免两个方向相反的转账互相等待造成死锁
+// 同一个事务中重复加锁不会阻塞, 所以可以先锁定钱包做检查, 再调用 Apply
+func LockAll(tx *gorm.DB, code string, uids ...string) (wallets map[string]*model.Wallet, err error) {
+	sorted := make([]string, 0, len(uids))
+	wallets = map[string]*model.Wallet{}
+
+	for _, uid := range uids {
+		if _, ok := wallets[uid]; !ok {
+			wallets[uid] = nil
+			sorted = append(sorted, uid)
+		}
+	}
+
+	sort.Strings(sorted)
+
+	for _, uid := range sorted {
+		w, er := Lock(tx, code, uid)
+
+		if er != nil {
+			err = er
+			return
+		}
+
+		wallets[uid] = &w
+	}
+
+	return
+}
+
+// 在事务中变动钱包的余额, 并生成对应的财务日志
+// 任意一个钱包的可用余额或者冻结余额变为负数, 则返回余额不足的错误, 调用者应该回滚事务
+func Apply(tx *gorm.DB, code string, orderId string, mutations ...Mutation) (logs []model.FinanceLog, err error) {
+	var c model.Currency
+
+	// 停用的币种仍然可以完成已经发起的操作, 例如确认等待中的转账
+	if c, err = currency.Get(code); err != nil {
+		return
+	}
+
+	uids := make([]string, 0)
+
+	for _, m := range mutations {
+		uids = append(uids, m.Uid)
+	}
+
+	var wallets map[string]*model.Wallet
+
+	if wallets, err = LockAll(tx, c.Code, uids...); err != nil {
+		return
+	}
+
+	for _, m := range mutations {
+		w := wallets[m.Uid]
+
+		log := model.FinanceLog{
+			Currency:        c.Code,
+			OrderId:         orderId,
+			Uid:             m.Uid,
+			BeforeBalance:   w.Balance,
+			BalanceMutation: m.Balance,
+			AfterBalance:    w.Balance.Add(m.Balance),
+			BeforeFrozen:    w.Frozen,
+			FrozenMutation:  m.Frozen,
+			AfterFrozen:     w.Frozen.Add(m.Frozen),
+			Type:            m.Type,
+			Note:            m.Note,
+		}
+
+		// 余额不能为负数
+		if log.AfterBalance.Sign() < 0 || log.AfterFrozen.Sign() < 0 {
+			err = exception.NotEnoughBalance
+			return
+		}
+
+		w.Balance = log.AfterBalance
+		w.Frozen = log.AfterFrozen
+
+		if err = tx.Create(&log).Error; err != nil {
+			return
+		}
+
+		logs = append(logs, log)
+	}
+
+	for uid, w := range wallets {
+		if err = model.WalletOf(tx, c.Code).Where("id = ?", uid).UpdateColumns(map[string]interface{}{
+			"balance": w.Balance,
+			"frozen":  w.Frozen,
+		}).Error; err != nil {
+			return
+		}
+	}
+
+	return
+}
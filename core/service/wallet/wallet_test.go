@@ -0,0 +1,160 @@
+// Copyright 2019 Axetroy. All rights reserved. MIT license.
+package wallet_test
+
+import (
+	"fmt"
+	"math/rand"
+	"sync"
+	"testing"
+
+	"github.com/axetroy/go-server/core/controller/auth"
+	"github.com/axetroy/go-server/core/exception"
+	"github.com/axetroy/go-server/core/model"
+	"github.com/axetroy/go-server/core/service/database"
+	"github.com/axetroy/go-server/core/service/wallet"
+	"github.com/axetroy/go-server/core/util"
+	"github.com/axetroy/go-server/tester"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseAmount(t *testing.T) {
+	amount, err := wallet.ParseAmount("cny", "12.34")
+
+	assert.Nil(t, err)
+	assert.Equal(t, "12.34", amount.String())
+
+	_, err = wallet.ParseAmount("CNY", "0.001")
+	assert.Equal(t, exception.InvalidParams, err)
+
+	_, err = wallet.ParseAmount("COIN", "0.00000001")
+	assert.Nil(t, err)
+
+	_, err = wallet.ParseAmount("CNY", "0")
+	assert.Equal(t, exception.InvalidParams, err)
+
+	_, err = wallet.ParseAmount("CNY", "-1")
+	assert.Equal(t, exception.InvalidParams, err)
+
+	_, err = wallet.ParseAmount("CNY", "abc")
+	assert.Equal(t, exception.InvalidParams, err)
+
+	_, err = wallet.ParseAmount("BTC", "1")
+	assert.Equal(t, exception.InvalidWallet, err)
+}
+
+func TestApplyNotEnoughBalance(t *testing.T) {
+	userInfo, _ := tester.CreateUser()
+
+	defer auth.DeleteUserByUserName(userInfo.Username)
+
+	tx := database.Db.Begin()
+
+	defer tx.Rollback()
+
+	_, err := wallet.Apply(tx, model.WalletCNY, "", wallet.Mutation{
+		Uid:     userInfo.Id,
+		Balance: util.MustParseDecimal("-0.01"),
+		Type:    model.FinanceTypeTransferOut,
+	})
+
+	assert.Equal(t, exception.NotEnoughBalance, err)
+}
+
+// 并发转账, 钱不会凭空产生或者消失
+func TestApplyConcurrent(t *testing.T) {
+	var (
+		users    = make([]string, 0)
+		initial  = util.MustParseDecimal("100")
+		currency = model.WalletCNY
+	)
+
+	for i := 0; i < 4; i++ {
+		userInfo, err := tester.CreateUser()
+
+		assert.Nil(t, err)
+
+		defer auth.DeleteUserByUserName(userInfo.Username)
+
+		users = append(users, userInfo.Id)
+
+		tx := database.Db.Begin()
+
+		_, err = wallet.Apply(tx, currency, "", wallet.Mutation{
+			Uid:     userInfo.Id,
+			Balance: initial,
+			Type:    model.FinanceTypeTransferIn,
+		})
+
+		assert.Nil(t, err)
+		assert.Nil(t, tx.Commit().Error)
+	}
+
+	var wg sync.WaitGroup
+
+	for i := 0; i < 40; i++ {
+		wg.Add(1)
+
+		go func(seed int64) {
+			defer wg.Done()
+
+			r := rand.New(rand.NewSource(seed))
+
+			for j := 0; j < 10; j++ {
+				from := users[r.Intn(len(users))]
+				to := users[r.Intn(len(users))]
+
+				if from == to {
+					continue
+				}
+
+				cents := 1 + r.Intn(3000)
+				amount := util.MustParseDecimal(fmt.Sprintf("%d.%02d", cents/100, cents%100))
+
+				tx := database.Db.Begin()
+
+				if _, err := wallet.Apply(tx, currency, "", wallet.Mutation{
+					Uid:     from,
+					Balance: amount.Neg(),
+					Type:    model.FinanceTypeTransferOut,
+				}, wallet.Mutation{
+					Uid:     to,
+					Balance: amount,
+					Type:    model.FinanceTypeTransferIn,
+				}); err != nil {
+					tx.Rollback()
+					continue
+				}
+
+				assert.Nil(t, tx.Commit().Error)
+			}
+		}(int64(i))
+	}
+
+	wg.Wait()
+
+	total := util.Decimal{}
+
+	for _, uid := range users {
+		w := model.Wallet{}
+
+		assert.Nil(t, model.WalletOf(database.Db, currency).Where("id = ?", uid).First(&w).Error)
+		assert.True(t, w.Balance.Sign() >= 0)
+
+		// 钱包的余额等于所有财务日志的变动之和
+		logs := make([]model.FinanceLog, 0)
+		sum := util.Decimal{}
+
+		assert.Nil(t, model.FinanceLogOf(database.Db, model.WalletCNY).Where("uid = ?", uid).Find(&logs).Error)
+
+		for _, log := range logs {
+			sum = sum.Add(log.BalanceMutation)
+		}
+
+		assert.Equal(t, 0, w.Balance.Cmp(sum))
+
+		total = total.Add(w.Balance)
+	}
+
+	// 总金额不变
+	assert.Equal(t, "400", total.String())
+}
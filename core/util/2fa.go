@@ -3,6 +3,18 @@ package util
 
 import (
 	"crypto"
+	"crypto/hmac"
+	"crypto/sha1"
+	"crypto/subtle"
+	"encoding/base32"
+	"encoding/binary"
+	"fmt"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	qr "github.com/sec51/qrcode"
 	"github.com/sec51/twofactor"
 )
 
@@ -10,6 +22,8 @@ var (
 	issuer     = "go-server" // 签发者
 	encryption = crypto.SHA1 // 加密算法
 	digits     = 6           // 密码位数
+	period     = 30          // 动态码的有效周期，单位秒
+	skew       = 1           // 允许客户端时间前后偏移的周期数
 	prefix     = "prefix"    // 用于UID的前缀, 不能暴露这个字段，否则用户私钥可能泄漏
 	suffix     = "suffix"    // 用户UID的后缀，不能暴露这个字段，否则用户私钥可能泄漏
 )
@@ -30,19 +44,118 @@ func Generate2FASecret(uid string) (secret string, err error) {
 	return otp.Secret(), nil
 }
 
-// 验证用户token是否正确
-func Verify2FA(uid string, token string) bool {
-	otp, err := twofactor.NewTOTP(generateAccount(uid), issuer, encryption, digits)
+// 生成身份验证器 App 可识别的 otpauth:// 链接
+func Generate2FAURI(account string, secret string) string {
+	u := url.URL{
+		Scheme: "otpauth",
+		Host:   "totp",
+		Path:   issuer + ":" + account,
+	}
+
+	v := url.Values{}
+	v.Set("secret", strings.TrimRight(secret, "="))
+	v.Set("issuer", issuer)
+	v.Set("algorithm", "SHA1")
+	v.Set("digits", strconv.Itoa(digits))
+	v.Set("period", strconv.Itoa(period))
+
+	u.RawQuery = v.Encode()
+
+	return u.String()
+}
+
+// 把 otpauth:// 链接生成 PNG 格式的二维码
+func Generate2FAQRCode(uri string) ([]byte, error) {
+	code, err := qr.Encode(uri, qr.Q)
 
 	if err != nil {
-		return false
+		return nil, err
+	}
+
+	return code.PNG(), nil
+}
+
+// 根据密钥生成某一时刻的动态码
+func Generate2FACode(secret string, t time.Time) (string, error) {
+	key, err := decode2FASecret(secret)
+
+	if err != nil {
+		return "", err
+	}
+
+	return generate2FACode(key, uint64(t.Unix())/uint64(period)), nil
+}
+
+// 验证用户输入的动态码是否正确
+func Verify2FA(secret string, code string) bool {
+	_, ok := Verify2FAStep(secret, code)
+	return ok
+}
+
+// 验证用户输入的动态码, 并返回动态码所在的周期
+// 同一个周期的动态码只能使用一次时, 可以用周期记录已经使用过的动态码
+func Verify2FAStep(secret string, code string) (step int64, ok bool) {
+	if len(code) != digits {
+		return
 	}
 
-	err = otp.Validate(token)
+	key, err := decode2FASecret(secret)
 
 	if err != nil {
-		return false
+		return
+	}
+
+	counter := int64(time.Now().Unix()) / int64(period)
+
+	valid := 0
+	matched := 0
+
+	// 逐个周期比较，不提前退出，避免通过耗时推断结果
+	for i := -skew; i <= skew; i++ {
+		equal := subtle.ConstantTimeCompare([]byte(generate2FACode(key, uint64(counter+int64(i)))), []byte(code))
+		matched = subtle.ConstantTimeSelect(equal, i, matched)
+		valid |= equal
+	}
+
+	if valid != 1 {
+		return
+	}
+
+	return counter + int64(matched), true
+}
+
+// 动态码的有效时长, 超过这个时长之后同一个动态码不会再被接受
+func TOTPValidDuration() time.Duration {
+	return time.Duration(period*(2*skew+1)) * time.Second
+}
+
+func decode2FASecret(secret string) ([]byte, error) {
+	secret = strings.ToUpper(strings.TrimSpace(secret))
+
+	// 兼容不带填充符的密钥
+	if m := len(secret) % 8; m != 0 {
+		secret += strings.Repeat("=", 8-m)
+	}
+
+	return base32.StdEncoding.DecodeString(secret)
+}
+
+// RFC 6238
+func generate2FACode(key []byte, counter uint64) string {
+	msg := make([]byte, 8)
+	binary.BigEndian.PutUint64(msg, counter)
+
+	mac := hmac.New(sha1.New, key)
+	mac.Write(msg)
+	sum := mac.Sum(nil)
+
+	offset := sum[len(sum)-1] & 0xf
+	value := binary.BigEndian.Uint32(sum[offset:offset+4]) & 0x7fffffff
+
+	mod := uint32(1)
+	for i := 0; i < digits; i++ {
+		mod *= 10
 	}
 
-	return true
+	return fmt.Sprintf("%0*d", digits, value%mod)
 }
@@ -4,7 +4,9 @@ package util_test
 import (
 	"github.com/axetroy/go-server/core/util"
 	"github.com/stretchr/testify/assert"
+	"strings"
 	"testing"
+	"time"
 )
 
 var (
@@ -18,7 +20,48 @@ func TestGenerate2FASecret(t *testing.T) {
 }
 
 func TestVerify2FA(t *testing.T) {
-	_, err := util.Generate2FASecret("101645075095748608")
+	secret, err := util.Generate2FASecret("101645075095748608")
+	assert.Nil(t, err)
+	assert.False(t, util.Verify2FA(secret, "12345678"))
+	assert.False(t, util.Verify2FA("invalid secret", "123456"))
+
+	code, err := util.Generate2FACode(secret, time.Now())
+	assert.Nil(t, err)
+	assert.Len(t, code, 6)
+	assert.True(t, util.Verify2FA(secret, code))
+
+	step, ok := util.Verify2FAStep(secret, code)
+	assert.True(t, ok)
+	assert.Equal(t, time.Now().Unix()/30, step)
+
+	// 已经过期的动态码
+	expired, err := util.Generate2FACode(secret, time.Now().Add(-time.Minute*5))
+	assert.Nil(t, err)
+	if expired != code {
+		assert.False(t, util.Verify2FA(secret, expired))
+	}
+}
+
+func TestGenerate2FACode(t *testing.T) {
+	// RFC 6238 附录 B 的测试向量
+	rfcSecret := "GEZDGNBVGY3TQOJQGEZDGNBVGY3TQOJQ"
+
+	code, err := util.Generate2FACode(rfcSecret, time.Unix(59, 0))
+	assert.Nil(t, err)
+	assert.Equal(t, "287082", code)
+
+	code, err = util.Generate2FACode(rfcSecret, time.Unix(1111111109, 0))
+	assert.Nil(t, err)
+	assert.Equal(t, "081804", code)
+}
+
+func TestGenerate2FAURI(t *testing.T) {
+	uri := util.Generate2FAURI("axetroy", "GEZDGNBVGY3TQOJQGEZDGNBVGY3TQOJQ")
+
+	assert.True(t, strings.HasPrefix(uri, "otpauth://totp/go-server:axetroy?"))
+	assert.Contains(t, uri, "secret=GEZDGNBVGY3TQOJQGEZDGNBVGY3TQOJQ")
+
+	png, err := util.Generate2FAQRCode(uri)
 	assert.Nil(t, err)
-	assert.False(t, util.Verify2FA("101645075095748608", "12345678"))
+	assert.NotEmpty(t, png)
 }
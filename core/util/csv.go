@@ -0,0 +1,15 @@
+// Copyright 2019 Axetroy. All rights reserved. MIT license.
+package util
+
+import "strings"
+
+// 防止 CSV 注入
+// 以 = + - @ 制表符或回车开头的单元格会被电子表格当作公式执行, 在前面加上单引号使其成为普通文本
+// 只用于用户输入的内容, 负数等系统生成的字段不需要处理
+func EscapeCSVCell(s string) string {
+	if s != "" && strings.ContainsAny(s[:1], "=+-@\t\r") {
+		return "'" + s
+	}
+
+	return s
+}
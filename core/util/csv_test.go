@@ -0,0 +1,20 @@
+// Copyright 2019 Axetroy. All rights reserved. MIT license.
+package util_test
+
+import (
+	"github.com/axetroy/go-server/core/util"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestEscapeCSVCell(t *testing.T) {
+	assert.Equal(t, "", util.EscapeCSVCell(""))
+	assert.Equal(t, "hello", util.EscapeCSVCell("hello"))
+	assert.Equal(t, "a=b", util.EscapeCSVCell("a=b"))
+	assert.Equal(t, "'=HYPERLINK(\"http://example.com\")", util.EscapeCSVCell("=HYPERLINK(\"http://example.com\")"))
+	assert.Equal(t, "'+1", util.EscapeCSVCell("+1"))
+	assert.Equal(t, "'-1", util.EscapeCSVCell("-1"))
+	assert.Equal(t, "'@SUM(A1)", util.EscapeCSVCell("@SUM(A1)"))
+	assert.Equal(t, "'\tx", util.EscapeCSVCell("\tx"))
+	assert.Equal(t, "'\rx", util.EscapeCSVCell("\rx"))
+}
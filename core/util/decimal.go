@@ -0,0 +1,205 @@
+// Copyright 2019 Axetroy. All rights reserved. MIT license.
+package util
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"regexp"
+	"strconv"
+)
+
+// 精确的十进制数, 用于货币金额的计算, 避免浮点数的精度问题
+// 零值即为 0, 所有运算都返回新的值, 不会修改原来的值
+type Decimal struct {
+	value *big.Rat
+}
+
+// 十进制字符串的最大长度, 超出的直接拒绝, 避免超长的输入消耗大量的 CPU
+const DecimalMaxLength = 64
+
+var (
+	ErrInvalidDecimal = errors.New("无效的数字")
+	decimalReg        = regexp.MustCompile(`^[-+]?\d+(\.\d+)?$`)
+)
+
+// 解析十进制字符串, 例如 "12.34"
+func ParseDecimal(s string) (Decimal, error) {
+	if len(s) > DecimalMaxLength || !decimalReg.MatchString(s) {
+		return Decimal{}, ErrInvalidDecimal
+	}
+
+	r, ok := new(big.Rat).SetString(s)
+
+	if !ok || !isDecimal(r) {
+		return Decimal{}, ErrInvalidDecimal
+	}
+
+	return Decimal{value: r}, nil
+}
+
+// 解析十进制字符串, 解析失败则 panic, 用于常量
+func MustParseDecimal(s string) Decimal {
+	d, err := ParseDecimal(s)
+
+	if err != nil {
+		panic(err)
+	}
+
+	return d
+}
+
+func NewDecimalFromInt(i int64) Decimal {
+	return Decimal{value: new(big.Rat).SetInt64(i)}
+}
+
+// 分母只包含 2 和 5 的有理数才能用有限的小数表示
+func isDecimal(r *big.Rat) bool {
+	return places(r) >= 0
+}
+
+// 小数的位数, 无法用有限小数表示则返回 -1
+func places(r *big.Rat) int {
+	var (
+		denom = new(big.Int).Set(r.Denom()) // big.Rat 总是约分后的
+		m     = new(big.Int)
+		twos  = 0
+		fives = 0
+	)
+
+	for {
+		if q, _ := new(big.Int).QuoRem(denom, big.NewInt(2), m); m.Sign() == 0 {
+			denom = q
+			twos++
+			continue
+		}
+		break
+	}
+
+	for {
+		if q, _ := new(big.Int).QuoRem(denom, big.NewInt(5), m); m.Sign() == 0 {
+			denom = q
+			fives++
+			continue
+		}
+		break
+	}
+
+	if denom.Cmp(big.NewInt(1)) != 0 {
+		return -1
+	}
+
+	if twos > fives {
+		return twos
+	}
+
+	return fives
+}
+
+func (d Decimal) rat() *big.Rat {
+	if d.value == nil {
+		return new(big.Rat)
+	}
+	return d.value
+}
+
+func (d Decimal) Add(o Decimal) Decimal {
+	return Decimal{value: new(big.Rat).Add(d.rat(), o.rat())}
+}
+
+func (d Decimal) Sub(o Decimal) Decimal {
+	return Decimal{value: new(big.Rat).Sub(d.rat(), o.rat())}
+}
+
+func (d Decimal) Neg() Decimal {
+	return Decimal{value: new(big.Rat).Neg(d.rat())}
+}
+
+// 比较大小, 小于返回 -1, 等于返回 0, 大于返回 1
+func (d Decimal) Cmp(o Decimal) int {
+	return d.rat().Cmp(o.rat())
+}
+
+// 符号, 负数返回 -1, 0 返回 0, 正数返回 1
+func (d Decimal) Sign() int {
+	return d.rat().Sign()
+}
+
+func (d Decimal) IsZero() bool {
+	return d.Sign() == 0
+}
+
+// 小数的位数, 例如 1.230 的位数为 2
+func (d Decimal) Places() int {
+	return places(d.rat())
+}
+
+// 保留指定的小数位数, 多出的位数四舍五入
+func (d Decimal) StringFixed(n int) string {
+	return d.rat().FloatString(n)
+}
+
+// 最简的十进制表示, 例如 1.230 表示为 1.23
+func (d Decimal) String() string {
+	return d.StringFixed(d.Places())
+}
+
+// 转换为浮点数, 仅用于展示等不要求精度的场景
+func (d Decimal) Float64() float64 {
+	f, _ := d.rat().Float64()
+	return f
+}
+
+// 写入数据库
+func (d Decimal) Value() (driver.Value, error) {
+	return d.String(), nil
+}
+
+// 从数据库读取
+func (d *Decimal) Scan(src interface{}) error {
+	switch v := src.(type) {
+	case nil:
+		*d = Decimal{}
+		return nil
+	case []byte:
+		return d.scanString(string(v))
+	case string:
+		return d.scanString(v)
+	case int64:
+		*d = NewDecimalFromInt(v)
+		return nil
+	case float64:
+		return d.scanString(strconv.FormatFloat(v, 'f', -1, 64))
+	default:
+		return fmt.Errorf("无法把 %T 转换为 Decimal", src)
+	}
+}
+
+func (d *Decimal) scanString(s string) error {
+	v, err := ParseDecimal(s)
+
+	if err != nil {
+		return err
+	}
+
+	*d = v
+
+	return nil
+}
+
+func (d Decimal) MarshalJSON() ([]byte, error) {
+	return json.Marshal(d.String())
+}
+
+func (d *Decimal) UnmarshalJSON(b []byte) error {
+	var s string
+
+	// 同时兼容字符串和数字
+	if err := json.Unmarshal(b, &s); err != nil {
+		s = string(b)
+	}
+
+	return d.scanString(s)
+}
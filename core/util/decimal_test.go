@@ -0,0 +1,103 @@
+// Copyright 2019 Axetroy. All rights reserved. MIT license.
+package util_test
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/axetroy/go-server/core/util"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseDecimal(t *testing.T) {
+	for _, s := range []string{"0", "1", "-1", "+1", "0.1", "12.3400", "100000000000000000000.00000001"} {
+		_, err := util.ParseDecimal(s)
+		assert.Nil(t, err, s)
+	}
+
+	for _, s := range []string{"", "abc", "1/3", "1/2", "1e3", ".5", "1.", "0x10", "1.2.3", "0." + strings.Repeat("1", util.DecimalMaxLength)} {
+		_, err := util.ParseDecimal(s)
+		assert.Equal(t, util.ErrInvalidDecimal, err, s)
+	}
+}
+
+func TestDecimalArithmetic(t *testing.T) {
+	// 浮点数下 0.1 + 0.2 != 0.3
+	a := util.MustParseDecimal("0.1")
+	b := util.MustParseDecimal("0.2")
+
+	assert.Equal(t, 0, a.Add(b).Cmp(util.MustParseDecimal("0.3")))
+	assert.Equal(t, "0.3", a.Add(b).String())
+	assert.Equal(t, "-0.1", a.Sub(b).String())
+	assert.Equal(t, "-0.1", a.Neg().String())
+	assert.Equal(t, -1, a.Sub(b).Sign())
+	assert.Equal(t, "0.1", a.String(), "运算不会修改原来的值")
+
+	var zero util.Decimal
+
+	assert.True(t, zero.IsZero())
+	assert.Equal(t, "0", zero.String())
+	assert.Equal(t, "0.2", zero.Add(b).String())
+
+	total := util.Decimal{}
+
+	for i := 0; i < 1000; i++ {
+		total = total.Add(util.MustParseDecimal("0.01"))
+	}
+
+	assert.Equal(t, "10", total.String())
+}
+
+func TestDecimalPlaces(t *testing.T) {
+	assert.Equal(t, 0, util.MustParseDecimal("100").Places())
+	assert.Equal(t, 2, util.MustParseDecimal("1.230").Places())
+	assert.Equal(t, 8, util.MustParseDecimal("0.00000001").Places())
+}
+
+func TestDecimalStringFixed(t *testing.T) {
+	assert.Equal(t, "80.00000000", util.MustParseDecimal("80").StringFixed(8))
+	assert.Equal(t, "0.12", util.MustParseDecimal("0.123").StringFixed(2))
+	assert.Equal(t, "0.13", util.MustParseDecimal("0.125").StringFixed(2))
+}
+
+func TestDecimalScan(t *testing.T) {
+	var d util.Decimal
+
+	assert.Nil(t, d.Scan([]byte("12.5")))
+	assert.Equal(t, "12.5", d.String())
+
+	assert.Nil(t, d.Scan("3"))
+	assert.Equal(t, "3", d.String())
+
+	assert.Nil(t, d.Scan(int64(7)))
+	assert.Equal(t, "7", d.String())
+
+	assert.Nil(t, d.Scan(0.25))
+	assert.Equal(t, "0.25", d.String())
+
+	assert.Nil(t, d.Scan(nil))
+	assert.True(t, d.IsZero())
+
+	assert.NotNil(t, d.Scan(true))
+
+	v, err := util.MustParseDecimal("1.50").Value()
+
+	assert.Nil(t, err)
+	assert.Equal(t, "1.5", v)
+}
+
+func TestDecimalJSON(t *testing.T) {
+	b, err := json.Marshal(util.MustParseDecimal("1.50"))
+
+	assert.Nil(t, err)
+	assert.Equal(t, `"1.5"`, string(b))
+
+	var d util.Decimal
+
+	assert.Nil(t, json.Unmarshal([]byte(`"2.25"`), &d))
+	assert.Equal(t, "2.25", d.String())
+
+	assert.Nil(t, json.Unmarshal([]byte(`3.5`), &d))
+	assert.Equal(t, "3.5", d.String())
+}
@@ -1,12 +1,211 @@
 // Copyright 2019 Axetroy. All rights reserved. MIT license.
 package util
 
+import (
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/base64"
+	"fmt"
+	"strings"
+
+	"golang.org/x/crypto/argon2"
+)
+
 const (
 	passwordPrefix = "prefix"
 	passwordSuffix = "suffix"
 )
 
+// 密码哈希算法
+// 生成的哈希值需要带上算法标识和参数，这样更换算法或调整参数后，旧的哈希值依旧可以校验
+type PasswordHasher interface {
+	// 算法标识, 即编码后哈希值的第一段 $<id>$...
+	Id() string
+	// 生成编码后的哈希值
+	Hash(password string) string
+	// 校验密码与编码后的哈希值是否匹配
+	Verify(password string, encoded string) bool
+	// 编码后的哈希值是否需要使用当前参数重新生成
+	NeedsRehash(encoded string) bool
+}
+
+// 当前使用的密码哈希算法
+var DefaultPasswordHasher PasswordHasher = &Argon2idHasher{
+	Memory:      19 * 1024,
+	Iterations:  2,
+	Parallelism: 1,
+	SaltLength:  16,
+	KeyLength:   32,
+}
+
+// 已注册的算法, 用于校验不同算法生成的哈希值
+var passwordHashers = map[string]PasswordHasher{}
+
+// 旧版本使用的 MD5 哈希, 只用于校验以及升级
+var legacyPasswordHasher = md5Hasher{}
+
+func init() {
+	RegisterPasswordHasher(DefaultPasswordHasher)
+}
+
+// 注册一个密码哈希算法
+func RegisterPasswordHasher(hasher PasswordHasher) {
+	passwordHashers[hasher.Id()] = hasher
+}
+
+// 根据编码后的哈希值找到对应的算法
+func findPasswordHasher(encoded string) PasswordHasher {
+	if !strings.HasPrefix(encoded, "$") {
+		return legacyPasswordHasher
+	}
+
+	parts := strings.SplitN(encoded, "$", 3)
+
+	if len(parts) < 3 {
+		return nil
+	}
+
+	return passwordHashers[parts[1]]
+}
+
+// 生成密码的哈希值
 func GeneratePassword(text string) string {
-	password := MD5(passwordPrefix + text + passwordSuffix)
-	return password
+	return DefaultPasswordHasher.Hash(text)
+}
+
+// 校验密码是否正确
+func VerifyPassword(text string, encoded string) bool {
+	hasher := findPasswordHasher(encoded)
+
+	if hasher == nil {
+		return false
+	}
+
+	return hasher.Verify(text, encoded)
+}
+
+// 哈希值是否需要升级到当前的算法和参数
+func PasswordNeedsRehash(encoded string) bool {
+	hasher := findPasswordHasher(encoded)
+
+	if hasher == nil || hasher.Id() != DefaultPasswordHasher.Id() {
+		return true
+	}
+
+	return hasher.NeedsRehash(encoded)
+}
+
+// argon2id 哈希
+// 编码格式: $argon2id$v=19$m=19456,t=2,p=1$<salt>$<hash>
+type Argon2idHasher struct {
+	Memory      uint32 // 内存开销，单位 KiB
+	Iterations  uint32 // 迭代次数
+	Parallelism uint8  // 并行度
+	SaltLength  uint32 // 盐的长度
+	KeyLength   uint32 // 生成的哈希长度
+}
+
+func (h *Argon2idHasher) Id() string {
+	return "argon2id"
+}
+
+func (h *Argon2idHasher) Hash(password string) string {
+	salt := make([]byte, h.SaltLength)
+
+	if _, err := rand.Read(salt); err != nil {
+		panic(err)
+	}
+
+	key := argon2.IDKey([]byte(password), salt, h.Iterations, h.Memory, h.Parallelism, h.KeyLength)
+
+	return fmt.Sprintf(
+		"$%s$v=%d$m=%d,t=%d,p=%d$%s$%s",
+		h.Id(),
+		argon2.Version,
+		h.Memory,
+		h.Iterations,
+		h.Parallelism,
+		base64.RawStdEncoding.EncodeToString(salt),
+		base64.RawStdEncoding.EncodeToString(key),
+	)
+}
+
+func (h *Argon2idHasher) decode(encoded string) (params Argon2idHasher, salt []byte, key []byte, err error) {
+	parts := strings.Split(encoded, "$")
+
+	if len(parts) != 6 || parts[1] != h.Id() {
+		err = fmt.Errorf("invalid %s hash", h.Id())
+		return
+	}
+
+	var version int
+
+	if _, err = fmt.Sscanf(parts[2], "v=%d", &version); err != nil {
+		return
+	}
+
+	if version != argon2.Version {
+		err = fmt.Errorf("unsupported %s version %d", h.Id(), version)
+		return
+	}
+
+	if _, err = fmt.Sscanf(parts[3], "m=%d,t=%d,p=%d", &params.Memory, &params.Iterations, &params.Parallelism); err != nil {
+		return
+	}
+
+	if salt, err = base64.RawStdEncoding.DecodeString(parts[4]); err != nil {
+		return
+	}
+
+	if key, err = base64.RawStdEncoding.DecodeString(parts[5]); err != nil {
+		return
+	}
+
+	params.SaltLength = uint32(len(salt))
+	params.KeyLength = uint32(len(key))
+
+	return
+}
+
+func (h *Argon2idHasher) Verify(password string, encoded string) bool {
+	params, salt, key, err := h.decode(encoded)
+
+	if err != nil {
+		return false
+	}
+
+	// 使用哈希值里记录的参数进行计算，而不是当前的参数
+	other := argon2.IDKey([]byte(password), salt, params.Iterations, params.Memory, params.Parallelism, params.KeyLength)
+
+	return subtle.ConstantTimeCompare(key, other) == 1
+}
+
+func (h *Argon2idHasher) NeedsRehash(encoded string) bool {
+	params, _, _, err := h.decode(encoded)
+
+	if err != nil {
+		return true
+	}
+
+	return params != *h
+}
+
+// 旧版本的 MD5 哈希, 固定的前后缀，没有盐
+type md5Hasher struct {
+}
+
+func (h md5Hasher) Id() string {
+	return "md5"
+}
+
+func (h md5Hasher) Hash(password string) string {
+	return MD5(passwordPrefix + password + passwordSuffix)
+}
+
+func (h md5Hasher) Verify(password string, encoded string) bool {
+	return subtle.ConstantTimeCompare([]byte(h.Hash(password)), []byte(encoded)) == 1
+}
+
+func (h md5Hasher) NeedsRehash(encoded string) bool {
+	return true
 }
@@ -4,6 +4,7 @@ package util_test
 import (
 	"github.com/axetroy/go-server/core/util"
 	"github.com/stretchr/testify/assert"
+	"strings"
 	"testing"
 )
 
@@ -11,11 +12,42 @@ func TestGenerate(t *testing.T) {
 	testPassword := "password"
 	s := util.GeneratePassword(testPassword)
 
-	// 生成的密码与预期的一致
-	assert.Equal(t, "c52f65639a16da778bd8839424495012", s)
+	// 哈希值带上了算法和参数
+	assert.True(t, strings.HasPrefix(s, "$argon2id$v=19$m=19456,t=2,p=1$"))
 
-	// 生成两次的密码保持一致
-	assert.Equal(t, util.GeneratePassword(testPassword), util.GeneratePassword(testPassword))
+	// 每次生成的盐不同，所以哈希值也不同
+	assert.NotEqual(t, s, util.GeneratePassword(testPassword))
 
-	assert.Len(t, util.GeneratePassword(testPassword), 32, "密码必须是32位")
+	assert.True(t, util.VerifyPassword(testPassword, s))
+	assert.False(t, util.VerifyPassword("password1", s))
+	assert.False(t, util.PasswordNeedsRehash(s))
+}
+
+func TestVerifyPassword(t *testing.T) {
+	// 旧版本的 MD5 哈希依旧可以校验，但是需要升级
+	legacy := "c52f65639a16da778bd8839424495012"
+
+	assert.True(t, util.VerifyPassword("password", legacy))
+	assert.False(t, util.VerifyPassword("password1", legacy))
+	assert.True(t, util.PasswordNeedsRehash(legacy))
+
+	// 参数变更后，旧参数生成的哈希值依旧可以校验，但是需要升级
+	weak := &util.Argon2idHasher{
+		Memory:      1024,
+		Iterations:  1,
+		Parallelism: 1,
+		SaltLength:  16,
+		KeyLength:   32,
+	}
+
+	s := weak.Hash("password")
+
+	assert.True(t, util.VerifyPassword("password", s))
+	assert.True(t, util.PasswordNeedsRehash(s))
+
+	// 无法识别的哈希值
+	assert.False(t, util.VerifyPassword("password", ""))
+	assert.False(t, util.VerifyPassword("password", "$unknown$xxx"))
+	assert.False(t, util.VerifyPassword("password", "$argon2id$v=19$m=19456,t=2,p=1$invalid"))
+	assert.True(t, util.PasswordNeedsRehash("$unknown$xxx"))
 }
@@ -2,8 +2,8 @@
 package util
 
 import (
-	"math/rand"
-	"time"
+	"crypto/rand"
+	"math/big"
 )
 
 const (
@@ -11,20 +11,27 @@ const (
 	numbers     = "0123456789"
 )
 
-func RandomString(length int) string {
+// 使用 crypto/rand 生成随机字符串, 验证码等需要不可预测
+func randomFrom(chars string, length int) string {
 	b := make([]byte, length)
-	rand.Seed(time.Now().UnixNano()) // 不设置随机种子的话，随机数都是一样的
+	max := big.NewInt(int64(len(chars)))
 	for i := range b {
-		b[i] = letterBytes[rand.Intn(len(letterBytes))]
+		n, err := rand.Int(rand.Reader, max)
+
+		// 系统的随机数源不可用, 无法安全地生成
+		if err != nil {
+			panic(err)
+		}
+
+		b[i] = chars[n.Int64()]
 	}
 	return string(b)
 }
 
+func RandomString(length int) string {
+	return randomFrom(letterBytes, length)
+}
+
 func RandomNumeric(length int) string {
-	b := make([]byte, length)
-	rand.Seed(time.Now().UnixNano()) // 不设置随机种子的话，随机数都是一样的
-	for i := range b {
-		b[i] = numbers[rand.Intn(len(numbers))]
-	}
-	return string(b)
+	return randomFrom(numbers, length)
 }
@@ -50,7 +50,7 @@ func CreateUser() (profile schema.ProfileWithToken, err error) {
 
 // 登陆超级管理员
 func LoginAdmin() (profile schema.AdminProfileWithToken, err error) {
-	r := admin.Login(admin.SignInParams{
+	r := admin.Login(controller.Context{}, admin.SignInParams{
 		Username: "admin",
 		Password: "admin",
 	})